@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"flowforge/internal/database"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	budgetDailyCostUSD float64
+	budgetDailyTokens  int
+	budgetShowJSON     bool
+)
+
+var budgetCmd = &cobra.Command{
+	Use:   "budget",
+	Short: "Manage daily spend budgets of integration workspaces",
+	Long: `A workspace budget caps what every run charged to the workspace may spend in
+one UTC day, combined. Runs are charged with --workspace (or the workspace
+config key); the run that reaches the budget is stopped with BUDGET_EXCEEDED
+under the normal policy rollout.
+
+Example:
+  flowforge budget set my-repo --daily-cost-usd 20 --daily-tokens 2000000
+  flowforge run --workspace my-repo --max-cost-usd 2 -- python3 agent.py
+  flowforge budget show my-repo`,
+}
+
+var budgetSetCmd = &cobra.Command{
+	Use:   "set <workspace_id>",
+	Short: "Set a workspace's daily budget (0 = no limit)",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		withDatabase(func() error {
+			budget := database.WorkspaceBudget{
+				WorkspaceID:     args[0],
+				DailyMaxCostUSD: budgetDailyCostUSD,
+				DailyMaxTokens:  budgetDailyTokens,
+			}
+			if err := database.SetWorkspaceBudget(budget); err != nil {
+				return err
+			}
+			fmt.Printf("Workspace %s daily budget: %s, %s\n", args[0], formatBudgetCost(budget.DailyMaxCostUSD), formatBudgetTokens(budget.DailyMaxTokens))
+			return nil
+		})
+	},
+}
+
+var budgetShowCmd = &cobra.Command{
+	Use:   "show <workspace_id>",
+	Short: "Show a workspace's daily budget and today's spend",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		withDatabase(func() error {
+			budget, err := database.GetWorkspaceBudget(args[0])
+			if err != nil {
+				return err
+			}
+			spend, err := database.GetWorkspaceSpend(args[0], database.BudgetDay(time.Now()))
+			if err != nil {
+				return err
+			}
+			if budgetShowJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(map[string]any{"budget": budget, "today": spend})
+			}
+			fmt.Printf("Workspace: %s\n", budget.WorkspaceID)
+			fmt.Printf("Daily budget: %s, %s\n", formatBudgetCost(budget.DailyMaxCostUSD), formatBudgetTokens(budget.DailyMaxTokens))
+			fmt.Printf("Spent %s (UTC): $%.4f, %d tokens\n", spend.Day, spend.CostUSD, spend.Tokens)
+			return nil
+		})
+	},
+}
+
+var budgetClearCmd = &cobra.Command{
+	Use:   "clear <workspace_id>",
+	Short: "Remove a workspace's daily budget",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		withDatabase(func() error {
+			if err := database.DeleteWorkspaceBudget(args[0]); err != nil {
+				return err
+			}
+			fmt.Printf("Workspace %s has no daily budget.\n", args[0])
+			return nil
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(budgetCmd)
+	budgetCmd.AddCommand(budgetSetCmd)
+	budgetCmd.AddCommand(budgetShowCmd)
+	budgetCmd.AddCommand(budgetClearCmd)
+	budgetSetCmd.Flags().Float64Var(&budgetDailyCostUSD, "daily-cost-usd", 0, "Estimated USD the workspace may spend per UTC day")
+	budgetSetCmd.Flags().IntVar(&budgetDailyTokens, "daily-tokens", 0, "Tokens the workspace may use per UTC day")
+	budgetShowCmd.Flags().BoolVar(&budgetShowJSON, "json", false, "Print the budget and today's spend as JSON")
+}
+
+func withDatabase(fn func() error) {
+	if err := database.InitDB(); err != nil {
+		fmt.Printf("Error: Failed to connect to database: %v\n", err)
+		os.Exit(1)
+	}
+	err := fn()
+	database.CloseDB()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func formatBudgetCost(v float64) string {
+	if v <= 0 {
+		return "no cost limit"
+	}
+	return fmt.Sprintf("$%.4f", v)
+}
+
+func formatBudgetTokens(v int) string {
+	if v <= 0 {
+		return "no token limit"
+	}
+	return fmt.Sprintf("%d tokens", v)
+}
@@ -2,8 +2,11 @@ package cmd
 
 import (
 	"fmt"
+	"path"
 	"strings"
 
+	"flowforge/internal/llmproxy"
+	"flowforge/internal/policy"
 	"github.com/spf13/viper"
 )
 
@@ -28,6 +31,69 @@ func validateConfig() error {
 	if err := validateIntRange("policy-canary-percent", 0, 100); err != nil {
 		return err
 	}
+	if viper.IsSet("decision-engine") {
+		if _, err := policy.ResolveEngine(viper.GetString("decision-engine")); err != nil {
+			return fmt.Errorf("invalid config: decision-engine: %w", err)
+		}
+	}
+	if path := strings.TrimSpace(viper.GetString("policy-file")); path != "" {
+		if _, err := policy.LoadRuleSetFile(path); err != nil {
+			return fmt.Errorf("invalid config: policy-file: %w", err)
+		}
+	}
+	if err := validateIntRange("max-restarts", 0, 100); err != nil {
+		return err
+	}
+	if viper.IsSet("restart-backoff") {
+		switch strings.ToLower(strings.TrimSpace(viper.GetString("restart-backoff"))) {
+		case restartBackoffExp, restartBackoffFixed, restartBackoffNone:
+		default:
+			return fmt.Errorf("invalid config: restart-backoff must be one of exp|fixed|none")
+		}
+	}
+	if viper.IsSet("restart-delay") && viper.GetDuration("restart-delay") < 0 {
+		return fmt.Errorf("invalid config: restart-delay must be >= 0")
+	}
+	if viper.IsSet("pause-timeout") && viper.GetDuration("pause-timeout") < 0 {
+		return fmt.Errorf("invalid config: pause-timeout must be >= 0")
+	}
+
+	if err := validateIntRange("output-capture-max-mb", 0, 10240); err != nil {
+		return err
+	}
+	if err := validateIntRange("output-capture-files", 0, 100); err != nil {
+		return err
+	}
+	if err := validateIntRange("output-capture-keep-runs", 0, 100000); err != nil {
+		return err
+	}
+
+	if err := validateCgroupLimits(""); err != nil {
+		return err
+	}
+	if err := validateProcessLimits(""); err != nil {
+		return err
+	}
+	if err := validateDetectorThresholds(""); err != nil {
+		return err
+	}
+	if err := validateStructuredLog(""); err != nil {
+		return err
+	}
+	if _, err := resolveToolCallConfig().rules(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	if viper.IsSet("llm-proxy-upstream") {
+		if _, err := llmproxy.New(llmproxy.Options{Upstream: viper.GetString("llm-proxy-upstream")}); err != nil {
+			return fmt.Errorf("invalid config: llm-proxy-upstream: %w", err)
+		}
+	}
+	if err := validateIntRange("llm-proxy-repeat-window", 0, 10000); err != nil {
+		return err
+	}
+	if _, err := resolvePricingCatalog(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
 
 	if viper.IsSet("max-memory-mb") {
 		mem := viper.GetFloat64("max-memory-mb")
@@ -62,6 +128,18 @@ func validateProfiles() error {
 		if err := validateIntRange(prefix+".log-window", 2, 10000); err != nil {
 			return err
 		}
+		if err := validateCgroupLimits(prefix + "."); err != nil {
+			return err
+		}
+		if err := validateProcessLimits(prefix + "."); err != nil {
+			return err
+		}
+		if err := validateDetectorThresholds(prefix + "."); err != nil {
+			return err
+		}
+		if err := validateStructuredLog(prefix + "."); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -77,6 +155,77 @@ func validateFloatRange(key string, min, max float64) error {
 	return nil
 }
 
+func validateCgroupLimits(prefix string) error {
+	for _, key := range cgroupProfileKeys {
+		if viper.IsSet(prefix+key) && viper.GetFloat64(prefix+key) < 0 {
+			return fmt.Errorf("invalid config: %s must be >= 0", prefix+key)
+		}
+	}
+	return nil
+}
+
+func validateProcessLimits(prefix string) error {
+	for _, k := range rlimitKeys {
+		key := prefix + "rlimit-" + k.name
+		if viper.IsSet(key) && viper.GetInt64(key) < 0 {
+			return fmt.Errorf("invalid config: %s must be >= 0", key)
+		}
+	}
+	for _, key := range []string{prefix + "env-allow", prefix + "env-deny"} {
+		for _, pattern := range viper.GetStringSlice(key) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid config: %s: bad pattern %q", key, pattern)
+			}
+		}
+	}
+	return nil
+}
+
+// validateDetectorThresholds checks the stall, memory-trend, process tree,
+// stream, tool call, prompt and budget keys, which are all durations, sizes,
+// rates, counts or amounts where 0 means "off".
+func validateDetectorThresholds(prefix string) error {
+	for _, keys := range [][]string{stallProfileKeys, memoryTrendProfileKeys, processTreeProfileKeys, streamProfileKeys, toolCallProfileKeys, llmProxyProfileKeys, budgetProfileKeys} {
+		for _, key := range keys {
+			if viper.IsSet(prefix+key) && viper.GetFloat64(prefix+key) < 0 {
+				return fmt.Errorf("invalid config: %s must be >= 0", prefix+key)
+			}
+		}
+	}
+	return validateToolCallRepetition(prefix)
+}
+
+// validateToolCallRepetition rejects a repetition threshold the histogram
+// can never reach. A profile inherits the top-level window it does not set.
+func validateToolCallRepetition(prefix string) error {
+	key := prefix + "max-tool-call-repetition"
+	repetition := viper.GetInt(key)
+	if repetition <= 0 {
+		return nil
+	}
+	window := defaultToolCallWindow
+	switch {
+	case viper.IsSet(prefix + "tool-call-window"):
+		window = viper.GetInt(prefix + "tool-call-window")
+	case viper.IsSet("tool-call-window"):
+		window = viper.GetInt("tool-call-window")
+	}
+	if window <= 0 {
+		window = defaultToolCallWindow
+	}
+	if repetition >= window {
+		return fmt.Errorf("invalid config: %s (%d) must be less than tool-call-window (%d)", key, repetition, window)
+	}
+	return nil
+}
+
+func validateStructuredLog(prefix string) error {
+	if key := prefix + "log-format"; viper.IsSet(key) && !validLogFormat(viper.GetString(key)) {
+		return fmt.Errorf("invalid config: %s must be one of text|json|logfmt|auto", key)
+	}
+	return validateFloatRange(prefix+"max-error-rate", 0, 1)
+}
+
 func validateIntRange(key string, min, max int) error {
 	if !viper.IsSet(key) {
 		return nil
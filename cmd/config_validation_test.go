@@ -39,3 +39,64 @@ func TestValidateConfigRejectsOutOfRangeCanaryPercent(t *testing.T) {
 		t.Fatal("expected validation error for policy-canary-percent")
 	}
 }
+
+func TestValidateConfigRejectsUnknownDecisionEngine(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	viper.Set("decision-engine", "no-such-engine")
+	if err := validateConfig(); err == nil {
+		t.Fatal("expected validation error for unknown decision-engine")
+	}
+	viper.Set("decision-engine", "weighted-score-decider")
+	if err := validateConfig(); err != nil {
+		t.Fatalf("expected weighted-score-decider to validate, got %v", err)
+	}
+}
+
+func TestValidateConfigRejectsNegativeCgroupLimits(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	viper.Set("profiles.standard.cgroup-pids-max", -1)
+	if err := validateConfig(); err == nil {
+		t.Fatal("expected validation error for negative profile cgroup-pids-max")
+	}
+}
+
+func TestValidateConfigRejectsNegativeDetectorThresholds(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	viper.Set("memory-projection-seconds", -300)
+	if err := validateConfig(); err == nil {
+		t.Fatal("expected validation error for negative memory-projection-seconds")
+	}
+
+	viper.Reset()
+	viper.Set("profiles.heavy.stall-output-seconds", -1)
+	if err := validateConfig(); err == nil {
+		t.Fatal("expected validation error for negative profile stall-output-seconds")
+	}
+}
+
+func TestValidateConfigRejectsUnreachableToolCallRepetition(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	viper.Set("max-tool-call-repetition", 5)
+	viper.Set("tool-call-window", 5)
+	if err := validateConfig(); err == nil {
+		t.Fatal("expected validation error for max-tool-call-repetition >= tool-call-window")
+	}
+	viper.Set("tool-call-window", 6)
+	if err := validateConfig(); err != nil {
+		t.Fatalf("expected a reachable repetition threshold to pass, got %v", err)
+	}
+
+	viper.Reset()
+	viper.Set("profiles.heavy.max-tool-call-repetition", defaultToolCallWindow)
+	if err := validateConfig(); err == nil {
+		t.Fatal("expected validation error for a profile repetition at the default window")
+	}
+}
@@ -11,11 +11,13 @@ import (
 	"os/exec"
 	"os/signal"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"flowforge/internal/api"
 	"flowforge/internal/daemon"
+	"flowforge/internal/database"
 
 	"github.com/spf13/cobra"
 )
@@ -32,6 +34,7 @@ var (
 	daemonLogsLines     int
 	daemonLogsFollow    bool
 	daemonRunHiddenPort string
+	daemonUnixSocket    bool
 )
 
 type daemonStartResult struct {
@@ -51,6 +54,12 @@ type daemonStatusReport struct {
 	StartedAt    string `json:"started_at,omitempty"`
 	StoppedAt    string `json:"stopped_at,omitempty"`
 	LastError    string `json:"last_error,omitempty"`
+	// Socket is the daemon's Unix socket, when it serves one.
+	Socket        string `json:"socket,omitempty"`
+	SocketHealthy bool   `json:"socket_healthy,omitempty"`
+	// Workers counts the managed workers in the state file: running ones,
+	// or the ones the next daemon will try to re-adopt.
+	Workers int `json:"workers"`
 }
 
 var daemonCmd = &cobra.Command{
@@ -63,7 +72,7 @@ var daemonStartCmd = &cobra.Command{
 	Short: "Start the local FlowForge daemon",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		wait := time.Duration(daemonWaitSeconds) * time.Second
-		result, err := ensureDaemonRunning(daemonPort, wait)
+		result, err := ensureDaemonRunning(daemonPort, daemonUnixSocket, wait)
 		if err != nil {
 			return err
 		}
@@ -100,6 +109,9 @@ var daemonStatusCmd = &cobra.Command{
 
 		fmt.Printf("Status: %s\n", strings.ToUpper(report.Status))
 		fmt.Printf("API: http://127.0.0.1:%s (healthy=%v)\n", report.Port, report.APIHealthy)
+		if report.Socket != "" {
+			fmt.Printf("Socket: %s (healthy=%v)\n", report.Socket, report.SocketHealthy)
+		}
 		if report.PID > 0 {
 			fmt.Printf("PID: %d\n", report.PID)
 		}
@@ -115,6 +127,9 @@ var daemonStatusCmd = &cobra.Command{
 		if report.LastError != "" {
 			fmt.Printf("Last error: %s\n", report.LastError)
 		}
+		if report.Workers > 0 {
+			fmt.Printf("Managed workers: %d\n", report.Workers)
+		}
 		return nil
 	},
 }
@@ -146,7 +161,7 @@ var daemonRunCmd = &cobra.Command{
 	Short:  "Run daemon process (internal use)",
 	Hidden: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return runDaemon(daemonRunHiddenPort)
+		return runDaemon(daemonRunHiddenPort, daemonUnixSocket)
 	},
 }
 
@@ -160,6 +175,7 @@ func init() {
 
 	daemonStartCmd.Flags().StringVar(&daemonPort, "port", defaultDaemonPort, "API port to serve from daemon")
 	daemonStartCmd.Flags().IntVar(&daemonWaitSeconds, "wait-seconds", 10, "seconds to wait for health after start")
+	daemonStartCmd.Flags().BoolVar(&daemonUnixSocket, "unix-socket", false, "also serve the API on a Unix socket in the runtime dir")
 
 	daemonStopCmd.Flags().StringVar(&daemonPort, "port", defaultDaemonPort, "API port expected for daemon")
 	daemonStopCmd.Flags().IntVar(&daemonStopTimeout, "timeout-seconds", 10, "seconds to wait for graceful stop before force kill")
@@ -172,9 +188,11 @@ func init() {
 
 	daemonRunCmd.Flags().StringVar(&daemonRunHiddenPort, "port", defaultDaemonPort, "API port to serve from daemon")
 	_ = daemonRunCmd.Flags().MarkHidden("port")
+	daemonRunCmd.Flags().BoolVar(&daemonUnixSocket, "unix-socket", false, "also serve the API on a Unix socket in the runtime dir")
+	_ = daemonRunCmd.Flags().MarkHidden("unix-socket")
 }
 
-func ensureDaemonRunning(port string, wait time.Duration) (daemonStartResult, error) {
+func ensureDaemonRunning(port string, unixSocket bool, wait time.Duration) (daemonStartResult, error) {
 	if wait <= 0 {
 		wait = 5 * time.Second
 	}
@@ -204,7 +222,11 @@ func ensureDaemonRunning(port string, wait time.Duration) (daemonStartResult, er
 	}
 	defer logFile.Close()
 
-	child := exec.Command(exe, "daemon", "run", "--port", port)
+	childArgs := []string{"daemon", "run", "--port", port}
+	if unixSocket {
+		childArgs = append(childArgs, "--unix-socket")
+	}
+	child := exec.Command(exe, childArgs...)
 	child.Stdout = logFile
 	child.Stderr = logFile
 	child.Stdin = nil
@@ -226,7 +248,7 @@ func ensureDaemonRunning(port string, wait time.Duration) (daemonStartResult, er
 	return daemonStartResult{PID: childPID, AlreadyRunning: false}, nil
 }
 
-func runDaemon(port string) error {
+func runDaemon(port string, unixSocket bool) error {
 	paths, err := daemon.EnsureRuntimeDir()
 	if err != nil {
 		return err
@@ -237,16 +259,29 @@ func runDaemon(port string) error {
 		return fmt.Errorf("daemon already running with pid=%d", existingPID)
 	}
 
+	// Workers the previous daemon left running are carried over until they
+	// have been re-adopted or found gone.
+	previous, _ := daemon.ReadState(paths)
+
 	startedAt := time.Now().UTC()
 	if err := daemon.WritePID(paths, pid); err != nil {
 		return fmt.Errorf("write daemon pid file: %w", err)
 	}
-	if err := daemon.WriteState(paths, daemon.State{
+	var stateMu sync.Mutex
+	current := daemon.State{
 		PID:       pid,
 		Port:      port,
 		Status:    "running",
 		StartedAt: startedAt,
-	}); err != nil {
+		Workers:   previous.Workers,
+	}
+	saveState := func(update func(*daemon.State)) error {
+		stateMu.Lock()
+		defer stateMu.Unlock()
+		update(&current)
+		return daemon.WriteState(paths, current)
+	}
+	if err := saveState(func(*daemon.State) {}); err != nil {
 		return fmt.Errorf("write daemon state: %w", err)
 	}
 
@@ -254,16 +289,54 @@ func runDaemon(port string) error {
 	finalErr := ""
 	defer func() {
 		_ = daemon.RemovePID(paths)
-		_ = daemon.WriteState(paths, daemon.State{
-			PID:       0,
-			Port:      port,
-			Status:    finalStatus,
-			StartedAt: startedAt,
-			StoppedAt: time.Now().UTC(),
-			LastError: finalErr,
+		_ = saveState(func(st *daemon.State) {
+			st.PID = 0
+			st.Socket = ""
+			st.Status = finalStatus
+			st.StoppedAt = time.Now().UTC()
+			st.LastError = finalErr
 		})
 	}()
 
+	if err := database.InitDB(); err != nil {
+		fmt.Printf("Warning: Failed to initialize database: %v\n", err)
+	}
+	defer database.CloseDB()
+
+	adopted, reattachErrs := api.ReattachWorkers(previous.Workers)
+	for _, runID := range adopted {
+		fmt.Printf("[daemon] Reattached worker %s\n", runID)
+	}
+	for _, err := range reattachErrs {
+		fmt.Printf("[daemon] Not reattaching %v\n", err)
+	}
+	// Managed workers outlive the daemon; the state file keeps the last set
+	// so the next daemon can adopt them.
+	api.SetManagedWorkersHook(func(records []daemon.WorkerRecord) {
+		_ = saveState(func(st *daemon.State) { st.Workers = records })
+	})
+	defer api.SetManagedWorkersHook(nil)
+
+	launcher, err := daemonRunLauncher()
+	if err != nil {
+		return err
+	}
+	api.SetRunLauncher(launcher)
+	defer api.SetRunLauncher(nil)
+
+	// The socket is up before the TCP port answers, so "daemon start"
+	// returning means both are served.
+	if unixSocket {
+		stopSocket, err := api.ServeUnix(paths.SocketFile)
+		if err != nil {
+			finalStatus = "failed"
+			finalErr = err.Error()
+			return fmt.Errorf("serve unix socket: %w", err)
+		}
+		defer stopSocket()
+		_ = saveState(func(st *daemon.State) { st.Socket = paths.SocketFile })
+	}
+
 	stop := api.Start(port)
 	if err := waitForDaemonReady(port, pid, 5*time.Second); err != nil {
 		finalStatus = "failed"
@@ -308,6 +381,18 @@ func probeDaemonHealth(port string, timeout time.Duration) bool {
 	return resp.StatusCode == http.StatusOK
 }
 
+// probeSocketHealth checks /healthz over the daemon's Unix socket, which
+// answers even when the TCP port is taken by something else.
+func probeSocketHealth(socket string, timeout time.Duration) bool {
+	client := daemon.NewSocketClient(socket, timeout)
+	resp, err := client.Get(daemon.SocketBaseURL + "/healthz")
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
 func collectDaemonStatus(port string) (daemonStatusReport, error) {
 	paths, err := daemon.Paths()
 	if err != nil {
@@ -329,18 +414,6 @@ func collectDaemonStatus(port string) (daemonStatusReport, error) {
 			report.Status = "degraded"
 		}
 	}
-	report.APIHealthy = probeDaemonHealth(port, 900*time.Millisecond)
-
-	if report.PID > 0 && report.APIHealthy {
-		report.Status = "running"
-	}
-	if report.PID == 0 && report.APIHealthy {
-		report.Status = "external"
-	}
-	if report.PID > 0 && !daemon.ProcessAlive(report.PID) {
-		report.Status = "stale_pid"
-	}
-
 	if st, err := daemon.ReadState(paths); err == nil {
 		report.StatePresent = true
 		if !st.StartedAt.IsZero() {
@@ -350,6 +423,23 @@ func collectDaemonStatus(port string) (daemonStatusReport, error) {
 			report.StoppedAt = st.StoppedAt.Format(time.RFC3339)
 		}
 		report.LastError = st.LastError
+		report.Socket = st.Socket
+		report.Workers = len(st.Workers)
+	}
+	report.APIHealthy = probeDaemonHealth(port, 900*time.Millisecond)
+	if report.Socket != "" {
+		report.SocketHealthy = probeSocketHealth(report.Socket, 900*time.Millisecond)
+	}
+	healthy := report.APIHealthy || report.SocketHealthy
+
+	if report.PID > 0 && healthy {
+		report.Status = "running"
+	}
+	if report.PID == 0 && healthy {
+		report.Status = "external"
+	}
+	if report.PID > 0 && !daemon.ProcessAlive(report.PID) {
+		report.Status = "stale_pid"
 	}
 	return report, nil
 }
@@ -393,12 +483,7 @@ func stopDaemon(port string, timeout time.Duration) error {
 	for time.Now().Before(deadline) {
 		if !daemon.ProcessAlive(pid) {
 			_ = daemon.RemovePID(paths)
-			_ = daemon.WriteState(paths, daemon.State{
-				PID:       0,
-				Port:      port,
-				Status:    "stopped",
-				StoppedAt: time.Now().UTC(),
-			})
+			markDaemonStopped(paths, port)
 			fmt.Println("FlowForge daemon stopped.")
 			return nil
 		}
@@ -413,12 +498,7 @@ func stopDaemon(port string, timeout time.Duration) error {
 	for i := 0; i < 20; i++ {
 		if !daemon.ProcessAlive(pid) {
 			_ = daemon.RemovePID(paths)
-			_ = daemon.WriteState(paths, daemon.State{
-				PID:       0,
-				Port:      port,
-				Status:    "stopped",
-				StoppedAt: time.Now().UTC(),
-			})
+			markDaemonStopped(paths, port)
 			fmt.Println("FlowForge daemon force-stopped.")
 			return nil
 		}
@@ -427,6 +507,18 @@ func stopDaemon(port string, timeout time.Duration) error {
 	return errors.New("daemon process is still alive after SIGKILL")
 }
 
+// markDaemonStopped records a stop in the state file. Worker records are
+// kept: the workers outlive the daemon and the next one re-adopts them.
+func markDaemonStopped(paths daemon.RuntimePaths, port string) {
+	st, _ := daemon.ReadState(paths)
+	st.PID = 0
+	st.Port = port
+	st.Socket = ""
+	st.Status = "stopped"
+	st.StoppedAt = time.Now().UTC()
+	_ = daemon.WriteState(paths, st)
+}
+
 func tailFileLines(path string, n int) ([]string, error) {
 	if n <= 0 {
 		n = 1
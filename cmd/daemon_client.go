@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"flowforge/internal/daemon"
+)
+
+// daemonAPI sends requests to the local daemon's API, over its Unix socket
+// when it serves one and over the TCP port otherwise.
+type daemonAPI struct {
+	client  *http.Client
+	baseURL string
+	socket  string
+	apiKey  string
+}
+
+// newDaemonAPI picks the transport: socket if set, else the socket recorded
+// by a running daemon, else http://127.0.0.1:<port>. FLOWFORGE_API_KEY is
+// sent when set; same-user callers on the socket do not need it.
+func newDaemonAPI(port, socket string, timeout time.Duration) *daemonAPI {
+	apiKey := strings.TrimSpace(os.Getenv("FLOWFORGE_API_KEY"))
+	if socket = strings.TrimSpace(socket); socket == "" {
+		socket = runningDaemonSocket()
+	}
+	if socket != "" {
+		return &daemonAPI{
+			client:  daemon.NewSocketClient(socket, timeout),
+			baseURL: daemon.SocketBaseURL,
+			socket:  socket,
+			apiKey:  apiKey,
+		}
+	}
+	return &daemonAPI{
+		client:  &http.Client{Timeout: timeout},
+		baseURL: fmt.Sprintf("http://127.0.0.1:%s", port),
+		apiKey:  apiKey,
+	}
+}
+
+func runningDaemonSocket() string {
+	paths, err := daemon.Paths()
+	if err != nil {
+		return ""
+	}
+	st, err := daemon.ReadState(paths)
+	if err != nil || st.Socket == "" || !daemon.ProcessAlive(st.PID) {
+		return ""
+	}
+	return st.Socket
+}
+
+// target names where requests go, for error messages.
+func (d *daemonAPI) target() string {
+	if d.socket != "" {
+		return "unix:" + d.socket
+	}
+	return d.baseURL
+}
+
+// do sends body as JSON when non-nil and decodes a 2xx response into out.
+// Error responses are returned with the problem detail the API sent.
+func (d *daemonAPI) do(method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		blob, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(blob)
+	}
+	req, err := http.NewRequest(method, d.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if d.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+d.apiKey)
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("daemon API at %s: %w", d.target(), err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var problem struct {
+			Detail string `json:"detail"`
+		}
+		if json.Unmarshal(raw, &problem) == nil && problem.Detail != "" {
+			return fmt.Errorf("%s %s: %d %s", method, path, resp.StatusCode, problem.Detail)
+		}
+		return fmt.Errorf("%s %s: %d %s", method, path, resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(raw, out)
+}
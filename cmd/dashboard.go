@@ -33,7 +33,7 @@ Use --foreground to keep the API server attached to the current terminal.
 		}
 
 		fmt.Println("Starting Dashboard API...")
-		result, err := ensureDaemonRunning(dashboardPort, time.Duration(dashboardDaemonWaitS)*time.Second)
+		result, err := ensureDaemonRunning(dashboardPort, false, time.Duration(dashboardDaemonWaitS)*time.Second)
 		if err != nil {
 			return err
 		}
@@ -4,14 +4,18 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
 	"time"
+
+	"flowforge/internal/daemon"
 )
 
 const (
 	defaultHealthcheckURL = "http://127.0.0.1:8080/healthz"
 	envHealthcheckURL     = "FLOWFORGE_HEALTHCHECK_URL"
+	envHealthcheckSocket  = "FLOWFORGE_HEALTHCHECK_SOCKET"
 )
 
 func resolveHealthcheckURL() string {
@@ -21,6 +25,21 @@ func resolveHealthcheckURL() string {
 	return defaultHealthcheckURL
 }
 
+// resolveHealthcheckClient probes over the daemon's Unix socket when
+// FLOWFORGE_HEALTHCHECK_SOCKET names one; the URL then only supplies the path.
+func resolveHealthcheckClient(timeout time.Duration) (*http.Client, string) {
+	healthURL := resolveHealthcheckURL()
+	socket := strings.TrimSpace(os.Getenv(envHealthcheckSocket))
+	if socket == "" {
+		return &http.Client{Timeout: timeout}, healthURL
+	}
+	path := "/healthz"
+	if u, err := url.Parse(healthURL); err == nil && u.Path != "" {
+		path = u.Path
+	}
+	return daemon.NewSocketClient(socket, timeout), daemon.SocketBaseURL + path
+}
+
 func probeHealth(client *http.Client, healthURL string) error {
 	resp, err := client.Get(healthURL)
 	if err != nil {
@@ -35,8 +54,7 @@ func probeHealth(client *http.Client, healthURL string) error {
 }
 
 func main() {
-	client := &http.Client{Timeout: 2 * time.Second}
-	healthURL := resolveHealthcheckURL()
+	client, healthURL := resolveHealthcheckClient(2 * time.Second)
 	err := probeHealth(client, healthURL)
 	if err != nil {
 		if errors.Is(err, os.ErrDeadlineExceeded) {
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -55,3 +57,28 @@ func TestProbeHealthFailsOnConnectionError(t *testing.T) {
 		t.Fatal("expected probe to fail on connection error")
 	}
 }
+
+func TestResolveHealthcheckClientUsesSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "ff.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/healthz" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	srv.Listener = ln
+	srv.Start()
+	defer srv.Close()
+
+	t.Setenv(envHealthcheckURL, "http://127.0.0.1:1/v1/healthz")
+	t.Setenv(envHealthcheckSocket, socket)
+	client, healthURL := resolveHealthcheckClient(500 * time.Millisecond)
+	if err := probeHealth(client, healthURL); err != nil {
+		t.Fatalf("expected probe over the socket to pass, got error: %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"flowforge/internal/runlog"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var logsTail int
+var logsSince string
+var logsJSON bool
+
+var logsCmd = &cobra.Command{
+	Use:   "logs <run_id>",
+	Short: "Show the captured output of a run",
+	Long: `Prints the redacted stdout/stderr captured for a run. The run ID is the
+Agent ID printed when "flowforge run" starts.
+
+Example:
+  flowforge logs 3f6c1d2e-... --tail 50
+  flowforge logs 3f6c1d2e-... --since 10m
+  flowforge logs 3f6c1d2e-... --since 2026-01-02T15:04:05Z --json
+`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := runlog.ReadOptions{Tail: logsTail}
+		if s := strings.TrimSpace(logsSince); s != "" {
+			since, err := runlog.ParseSince(s, time.Now())
+			if err != nil {
+				fmt.Printf("Error: --%v\n", err)
+				os.Exit(1)
+			}
+			opts.Since = since
+		}
+
+		lines, err := runlog.Read(args[0], opts)
+		if err != nil {
+			if errors.Is(err, runlog.ErrNotFound) {
+				fmt.Printf("No captured output for run %s.\n", args[0])
+			} else {
+				fmt.Printf("Error: %v\n", err)
+			}
+			os.Exit(1)
+		}
+
+		if logsJSON {
+			enc := json.NewEncoder(os.Stdout)
+			for _, l := range lines {
+				_ = enc.Encode(l)
+			}
+			return
+		}
+		for _, l := range lines {
+			fmt.Printf("%s %s | %s\n", l.Time.Local().Format("15:04:05.000"), l.Stream, l.Text)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(logsCmd)
+	logsCmd.Flags().IntVar(&logsTail, "tail", 0, "Only show the last N lines (0 = all)")
+	logsCmd.Flags().StringVar(&logsSince, "since", "", "Only show lines since an RFC3339 time or a duration ago (e.g. 10m)")
+	logsCmd.Flags().BoolVar(&logsJSON, "json", false, "Print one JSON object per line")
+}
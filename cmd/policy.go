@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"flowforge/internal/policy"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	explainPolicyFile    string
+	explainCPUPercent    float64
+	explainCPUOverFor    time.Duration
+	explainMemoryMB      float64
+	explainLogRepetition float64
+	explainLogEntropy    float64
+	explainRawDiversity  float64
+	explainProgressLike  bool
+	explainJSON          bool
+)
+
+var policyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Validate and inspect declarative policy files",
+	Long: `Declarative policies are versioned YAML files whose rules combine telemetry
+fields with boolean expressions and map each rule to an action.
+
+Example:
+  version: 1
+  name: strict
+  thresholds:
+    max_cpu_percent: 80
+    cpu_window: 20s
+  rules:
+    - name: runaway-loop
+      when: cpu_percent > max_cpu_percent && cpu_over_for >= 20s && log_repetition > 0.8
+      action: kill
+
+Run a policy with: flowforge run --policy-file strict.yaml -- <command>`,
+}
+
+var policyValidateCmd = &cobra.Command{
+	Use:   "validate [policy-file]",
+	Short: "Validate a policy file",
+	Long:  "Parses and compiles every rule in a policy file. Defaults to the policy-file config key.",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := ""
+		if len(args) == 1 {
+			path = args[0]
+		}
+		runPolicyValidate(path)
+	},
+}
+
+var policyExplainCmd = &cobra.Command{
+	Use:   "explain",
+	Short: "Show which rule fires for a telemetry sample",
+	Long: `Evaluates every rule of a policy against a telemetry sample and prints which
+rules matched, the field values they saw, and the resulting decision.
+Without --file the built-in rule set is used.
+
+Example:
+  flowforge policy explain --file strict.yaml --cpu-percent 95 --cpu-over-for 30s --log-repetition 0.9`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runPolicyExplain()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(policyCmd)
+	policyCmd.AddCommand(policyValidateCmd)
+	policyCmd.AddCommand(policyExplainCmd)
+
+	policyExplainCmd.Flags().StringVar(&explainPolicyFile, "file", "", "Policy file to explain (default: policy-file config key, then built-in rules)")
+	policyExplainCmd.Flags().Float64Var(&explainCPUPercent, "cpu-percent", 0, "Sample CPU usage percent")
+	policyExplainCmd.Flags().DurationVar(&explainCPUOverFor, "cpu-over-for", 0, "Sample duration CPU stayed above the threshold")
+	policyExplainCmd.Flags().Float64Var(&explainMemoryMB, "memory-mb", 0, "Sample resident memory in MB")
+	policyExplainCmd.Flags().Float64Var(&explainLogRepetition, "log-repetition", 0, "Sample log repetition score (0..1)")
+	policyExplainCmd.Flags().Float64Var(&explainLogEntropy, "log-entropy", 1, "Sample log entropy score (0..1)")
+	policyExplainCmd.Flags().Float64Var(&explainRawDiversity, "raw-diversity", 1, "Sample raw line diversity (0..1)")
+	policyExplainCmd.Flags().BoolVar(&explainProgressLike, "progress-like", false, "Sample output looks like forward progress")
+	policyExplainCmd.Flags().BoolVar(&explainJSON, "json", false, "Print the explanation as JSON")
+}
+
+func loadPolicyRuleSet(path string) (*policy.RuleSet, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		path = strings.TrimSpace(viper.GetString("policy-file"))
+	}
+	if path == "" {
+		return policy.DefaultRuleSet(), nil
+	}
+	return policy.LoadRuleSetFile(path)
+}
+
+func runPolicyValidate(path string) {
+	if strings.TrimSpace(path) == "" && strings.TrimSpace(viper.GetString("policy-file")) == "" {
+		fmt.Println("Error: policy file path is required (argument or policy-file config key)")
+		os.Exit(1)
+	}
+	rs, err := loadPolicyRuleSet(path)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✅ Policy %q (v%d) is valid: %d rules\n", rs.Name, rs.Version, len(rs.Rules))
+	for _, rule := range rs.Rules {
+		fmt.Printf("   - %-20s %-8s %s\n", rule.Name, rule.Action.String(), rule.When.String())
+	}
+}
+
+func runPolicyExplain() {
+	rs, err := loadPolicyRuleSet(explainPolicyFile)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	explanation := rs.Explain(policy.Telemetry{
+		CPUPercent:    explainCPUPercent,
+		CPUOverFor:    explainCPUOverFor,
+		MemoryMB:      explainMemoryMB,
+		LogRepetition: explainLogRepetition,
+		LogEntropy:    explainLogEntropy,
+		RawDiversity:  explainRawDiversity,
+		ProgressLike:  explainProgressLike,
+	}, policyConfigForCLI(rs))
+
+	if explainJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(explanation)
+		return
+	}
+
+	fmt.Printf("Policy: %s\n\n", explanation.Policy)
+	for _, match := range explanation.Rules {
+		marker := "  "
+		if match.Matched {
+			marker = "✔ "
+		}
+		if match.Name == explanation.Fired {
+			marker = "▶ "
+		}
+		fmt.Printf("%s%-20s %-8s %s\n", marker, match.Name, match.Action, match.When)
+		fmt.Printf("    %s\n", formatExplainFields(match.Fields))
+	}
+	fmt.Println()
+	if explanation.Fired != "" {
+		fmt.Printf("Fired:    %s\n", explanation.Fired)
+	} else {
+		fmt.Println("Fired:    (none)")
+	}
+	fmt.Printf("Decision: %s (intended %s)\n", explanation.Action, explanation.Intended)
+	fmt.Printf("Reason:   %s\n", explanation.Reason)
+}
+
+func formatExplainFields(fields map[string]string) string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+fields[k])
+	}
+	return strings.Join(parts, " ")
+}
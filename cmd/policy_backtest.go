@@ -0,0 +1,422 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"flowforge/internal/database"
+	"flowforge/internal/policy"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	backtestPolicyFile     string
+	backtestEngine         string
+	backtestBaselineEngine string
+	backtestLimit          int
+	backtestRunID          string
+	backtestFixtures       []string
+	backtestFixtureCPU     float64
+	backtestFixtureCPUFor  time.Duration
+	backtestLogWindow      int
+	backtestSkipHistory    bool
+	backtestShowSamples    bool
+	backtestJSON           bool
+)
+
+var policyBacktestCmd = &cobra.Command{
+	Use:   "backtest",
+	Short: "Replay recorded decisions and log fixtures through a candidate policy",
+	Long: `Replays recorded decision telemetry from the local database, and optionally raw
+log fixtures, through a candidate engine or policy file. Reports which runs the
+candidate would have killed, alerted or spared and how that differs from what
+was actually recorded.
+
+Decisions recorded before telemetry capture was added are approximated from
+their stored CPU/entropy scores and marked as such.
+
+Fixtures are fed line by line through the same log observer and scoring used
+by 'flowforge run'. They have no recorded decision, so --baseline-engine
+provides the comparison.
+
+Examples:
+  flowforge policy backtest --file strict.yaml
+  flowforge policy backtest --engine weighted-score-decider --run-id <run-id>
+  flowforge policy backtest --file strict.yaml --no-history --fixture 'test/fixtures/*.txt'`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runPolicyBacktest()
+	},
+}
+
+func init() {
+	policyCmd.AddCommand(policyBacktestCmd)
+
+	policyBacktestCmd.Flags().StringVar(&backtestPolicyFile, "file", "", "Candidate policy file (implies the rule-decider engine)")
+	policyBacktestCmd.Flags().StringVar(&backtestEngine, "engine", "", "Candidate decision engine (default: rule-decider with --file, otherwise threshold-decider)")
+	policyBacktestCmd.Flags().StringVar(&backtestBaselineEngine, "baseline-engine", policy.DefaultDecisionEngine, "Engine that supplies the comparison decision for fixtures")
+	policyBacktestCmd.Flags().IntVar(&backtestLimit, "limit", 500, "Maximum recorded decisions to replay")
+	policyBacktestCmd.Flags().StringVar(&backtestRunID, "run-id", "", "Only replay decisions from this run")
+	policyBacktestCmd.Flags().StringArrayVar(&backtestFixtures, "fixture", nil, "Raw log fixture file or glob to replay (repeatable)")
+	policyBacktestCmd.Flags().Float64Var(&backtestFixtureCPU, "fixture-cpu", 95, "CPU percent assumed while replaying fixtures")
+	policyBacktestCmd.Flags().DurationVar(&backtestFixtureCPUFor, "fixture-cpu-over-for", time.Minute, "How long CPU is assumed to have been above the threshold for fixtures")
+	policyBacktestCmd.Flags().IntVar(&backtestLogWindow, "log-window", 0, "Log window used for fixtures (default: log-window config, then 10)")
+	policyBacktestCmd.Flags().BoolVar(&backtestSkipHistory, "no-history", false, "Skip recorded decisions and replay fixtures only")
+	policyBacktestCmd.Flags().BoolVar(&backtestShowSamples, "samples", false, "Print every changed sample, not just per-run outcomes")
+	policyBacktestCmd.Flags().BoolVar(&backtestJSON, "json", false, "Print the report as JSON")
+}
+
+// policyConfigForCLI mirrors the thresholds runProcess would hand the engine.
+func policyConfigForCLI(rs *policy.RuleSet) policy.Policy {
+	maxCPU := viper.GetFloat64("max-cpu")
+	if maxCPU <= 0 {
+		maxCPU = 60.0
+	}
+	pollInterval, logWindow := resolveSampling()
+	p := policy.Policy{
+		MaxCPUPercent:    maxCPU,
+		CPUWindow:        resolveCPUWindow(pollInterval, logWindow),
+		MaxMemoryMB:      viper.GetFloat64("max-memory-mb"),
+		MinLogEntropy:    defaultMinLogEntropy,
+		MaxLogRepetition: defaultMaxLogRepetition,
+		RolloutMode:      policy.RolloutEnforce,
+	}
+	p = resolveStallConfig().applyTo(p)
+	p = resolveMemoryTrendConfig().applyTo(p)
+	p = applyProcessTreeLimits(p)
+	p = applyStreamLimits(p)
+	p = applyToolCallLimits(p)
+	p = applyLLMProxyLimits(p)
+	p = applyBudgetLimits(p)
+	p.MaxErrorRate = viper.GetFloat64("max-error-rate")
+	if rs != nil {
+		p = rs.ApplyThresholds(p)
+	}
+	return p
+}
+
+func resolveBacktestCandidate() (policy.EngineRegistration, policy.Decider, *policy.RuleSet, error) {
+	name := strings.TrimSpace(backtestEngine)
+	path := strings.TrimSpace(backtestPolicyFile)
+	if path != "" && name == "" {
+		name = policy.RuleEngineName
+	}
+	engine, err := policy.ResolveEngine(name)
+	if err != nil {
+		return policy.EngineRegistration{}, nil, nil, err
+	}
+	if path == "" {
+		return engine, engine.New(), nil, nil
+	}
+	if engine.Name != policy.RuleEngineName {
+		return policy.EngineRegistration{}, nil, nil, fmt.Errorf("policy file requires decision engine %q, got %q", policy.RuleEngineName, engine.Name)
+	}
+	rs, err := policy.LoadRuleSetFile(path)
+	if err != nil {
+		return policy.EngineRegistration{}, nil, nil, err
+	}
+	return engine, policy.NewRuleDeciderFor(rs), rs, nil
+}
+
+// backtestSamplesFromHistory converts recorded decisions into samples. When a
+// decision predates telemetry capture, the sample is rebuilt from its scores.
+func backtestSamplesFromHistory(records []database.DecisionSample, p policy.Policy) []policy.BacktestSample {
+	samples := make([]policy.BacktestSample, 0, len(records))
+	for _, rec := range records {
+		sample := policy.BacktestSample{
+			Source:           "history",
+			Ref:              backtestRecordRef(rec),
+			RunID:            withFallback(rec.RunID, "unknown-run"),
+			RecordedDecision: rec.Decision,
+			RecordedReason:   rec.Reason,
+		}
+		if rec.Telemetry != nil {
+			sample.Telemetry = policy.Telemetry{
+				CPUPercent:    rec.Telemetry.CPUPercent,
+				CPUOverFor:    secondsToDuration(rec.Telemetry.CPUOverForSeconds),
+				MemoryMB:      rec.Telemetry.MemoryMB,
+				LogRepetition: rec.Telemetry.LogRepetition,
+				LogEntropy:    rec.Telemetry.LogEntropy,
+				RawDiversity:  rec.Telemetry.RawDiversity,
+				ProgressLike:  rec.Telemetry.ProgressLike,
+				ErrorRate:     rec.Telemetry.ErrorRate,
+				OOMKills:      rec.Telemetry.OOMKills,
+				CPUThrottled:  rec.Telemetry.CPUThrottled,
+				OutputIdleFor: secondsToDuration(rec.Telemetry.OutputIdleSeconds),
+				CPUIdleFor:    secondsToDuration(rec.Telemetry.CPUIdleSeconds),
+				HeartbeatAge:  secondsToDuration(rec.Telemetry.HeartbeatAgeSeconds),
+
+				MemoryGrowthMBPerMin: rec.Telemetry.MemoryGrowthMBPerMin,
+
+				ChildProcesses:  rec.Telemetry.ChildProcesses,
+				ZombieProcesses: rec.Telemetry.ZombieProcesses,
+				OpenFDs:         rec.Telemetry.OpenFDs,
+				Threads:         rec.Telemetry.Threads,
+
+				StderrLinesPerSec:  rec.Telemetry.StderrLinesPerSec,
+				StderrRepetition:   rec.Telemetry.StderrRepetition,
+				RepeatedExceptions: rec.Telemetry.RepeatedExceptions,
+				ExceptionSignature: rec.Telemetry.ExceptionSignature,
+
+				ToolCallRepetition: rec.Telemetry.ToolCallRepetition,
+				ToolCallSignature:  rec.Telemetry.ToolCallSignature,
+				PromptRepetition:   rec.Telemetry.PromptRepetition,
+				PromptSignature:    rec.Telemetry.PromptSignature,
+
+				RunCostUSD:       rec.Telemetry.RunCostUSD,
+				RunTokens:        rec.Telemetry.RunTokens,
+				WorkspaceCostUSD: rec.Telemetry.WorkspaceCostUSD,
+				WorkspaceTokens:  rec.Telemetry.WorkspaceTokens,
+			}
+		} else {
+			entropy := rec.EntropyScore / 100.0
+			sample.Telemetry = policy.Telemetry{
+				CPUPercent:    rec.CPUScore / 100.0 * p.MaxCPUPercent,
+				LogEntropy:    entropy,
+				LogRepetition: 1 - entropy,
+				RawDiversity:  entropy,
+			}
+			if rec.CPUScore >= 100 {
+				// The score saturates at the threshold, so assume the run was
+				// above it for the whole window.
+				sample.Telemetry.CPUPercent = p.MaxCPUPercent + 1
+				sample.Telemetry.CPUOverFor = p.CPUWindow
+			}
+			sample.Approximate = true
+		}
+		sample.Telemetry.RolloutKey = sample.RunID
+		samples = append(samples, sample)
+	}
+	return samples
+}
+
+func backtestRecordRef(rec database.DecisionSample) string {
+	if rec.TraceID > 0 {
+		return "trace:" + strconv.Itoa(rec.TraceID)
+	}
+	return "event:" + rec.EventID
+}
+
+func withFallback(value, fallback string) string {
+	if strings.TrimSpace(value) == "" {
+		return fallback
+	}
+	return value
+}
+
+func expandBacktestFixtures(patterns []string) ([]string, error) {
+	var files []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("fixture pattern %q: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("fixture %q matched no files", pattern)
+		}
+		sort.Strings(matches)
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+// backtestSamplesFromFixture replays a raw log file through LogObserver and
+// the run-time scoring helpers, one sample per full log window. The baseline
+// engine provides the "recorded" decision for comparison.
+func backtestSamplesFromFixture(path string, logWindow int, cpuPercent float64, cpuOverFor time.Duration, baseline policy.Decider, p policy.Policy) ([]policy.BacktestSample, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	baselinePolicy := p
+	baselinePolicy.RolloutMode = policy.RolloutEnforce
+	toolCallCfg := resolveToolCallConfig()
+	toolCallRules, err := toolCallCfg.rules()
+	if err != nil {
+		return nil, err
+	}
+	observer := NewLogObserver(logWindow*2, modelName)
+	observer.TrackToolCalls(newToolCallTracker(toolCallRules, toolCallCfg.Window))
+	logCfg := resolveStructuredLogConfig()
+	samples := make([]policy.BacktestSample, 0)
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		_, _ = observer.Write([]byte(scanner.Text() + "\n"))
+		window := observer.GetLastLines(logWindow)
+		if len(window) < logWindow {
+			continue
+		}
+
+		scores := logCfg.scoreWindow(window, cpuPercent, p.MaxCPUPercent)
+		telemetry := policy.Telemetry{
+			CPUPercent:    cpuPercent,
+			CPUOverFor:    cpuOverFor,
+			LogRepetition: scores.Repetition,
+			LogEntropy:    scores.Entropy / 100.0,
+			RawDiversity:  scores.RawDiversity,
+			ProgressLike:  scores.ProgressLike,
+			ErrorRate:     scores.ErrorRate,
+			RolloutKey:    path,
+		}
+		telemetry.ExceptionSignature, telemetry.RepeatedExceptions = observer.RepeatedException()
+		telemetry.ToolCallSignature, telemetry.ToolCallRepetition = observer.RepeatedToolCall()
+		recorded := baseline.Evaluate(telemetry, baselinePolicy)
+		samples = append(samples, policy.BacktestSample{
+			Source:           "fixture",
+			Ref:              fmt.Sprintf("%s:%d", filepath.Base(path), lineNo),
+			RunID:            path,
+			Telemetry:        telemetry,
+			RecordedDecision: recorded.IntendedAction.String(),
+			RecordedReason:   recorded.Reason,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return samples, nil
+}
+
+func runPolicyBacktest() {
+	engine, candidate, rs, err := resolveBacktestCandidate()
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	p := policyConfigForCLI(rs)
+
+	samples := make([]policy.BacktestSample, 0)
+	if !backtestSkipHistory {
+		if err := database.InitDB(); err != nil {
+			fmt.Printf("❌ Failed to open database: %v\n", err)
+			os.Exit(1)
+		}
+		defer database.CloseDB()
+		records, err := database.GetDecisionSamples(backtestLimit, backtestRunID)
+		if err != nil {
+			fmt.Printf("❌ Failed to load decision history: %v\n", err)
+			os.Exit(1)
+		}
+		samples = append(samples, backtestSamplesFromHistory(records, p)...)
+	}
+
+	if len(backtestFixtures) > 0 {
+		baseline, err := policy.ResolveEngine(backtestBaselineEngine)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		files, err := expandBacktestFixtures(backtestFixtures)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		logWindow := backtestLogWindow
+		if logWindow <= 0 {
+			_, logWindow = resolveSampling()
+		}
+		baselinePolicy := policyConfigForCLI(nil)
+		for _, file := range files {
+			fixtureSamples, err := backtestSamplesFromFixture(file, logWindow, backtestFixtureCPU, backtestFixtureCPUFor, baseline.New(), baselinePolicy)
+			if err != nil {
+				fmt.Printf("❌ Failed to replay fixture %s: %v\n", file, err)
+				os.Exit(1)
+			}
+			samples = append(samples, fixtureSamples...)
+		}
+	}
+
+	report := policy.Backtest(candidate, p, samples)
+	candidateName := engine.Name + "@" + engine.Version
+	if rs != nil {
+		candidateName += " (" + rs.Name + ")"
+	}
+
+	if backtestJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(struct {
+			Candidate string `json:"candidate"`
+			policy.BacktestReport
+		}{Candidate: candidateName, BacktestReport: report})
+		return
+	}
+	printBacktestReport(candidateName, report)
+}
+
+func printBacktestReport(candidate string, report policy.BacktestReport) {
+	fmt.Printf("Candidate: %s\n", candidate)
+	fmt.Printf("Samples:   %d (%d changed)\n", report.Samples, report.ChangedSamples)
+	fmt.Printf("Runs:      %d\n\n", len(report.Runs))
+	if len(report.Runs) == 0 {
+		fmt.Println("Nothing to replay. Run 'flowforge run' to record decisions or pass --fixture.")
+		return
+	}
+
+	fmt.Printf("%-10s %8s %10s\n", "OUTCOME", "RECORDED", "CANDIDATE")
+	for _, outcome := range []string{policy.OutcomeKill, policy.OutcomeAlert, policy.OutcomeSpare} {
+		fmt.Printf("%-10s %8d %10d\n", outcome, report.RecordedRuns[outcome], report.CandidateRuns[outcome])
+	}
+	fmt.Println()
+
+	fmt.Printf("%-8s %-40s %7s %-8s %-9s\n", "SOURCE", "RUN", "SAMPLES", "RECORDED", "CANDIDATE")
+	for _, run := range report.Runs {
+		marker := ""
+		if run.Changed {
+			marker = "  ← changed"
+		}
+		fmt.Printf("%-8s %-40s %7d %-8s %-9s%s\n", run.Source, truncateBacktestField(run.RunID, 40), run.Samples, run.Recorded, run.Candidate, marker)
+	}
+
+	if len(report.Transitions) > 0 {
+		keys := make([]string, 0, len(report.Transitions))
+		for k := range report.Transitions {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		fmt.Println("\nChanged runs:")
+		for _, k := range keys {
+			fmt.Printf("  %-14s %d\n", k, report.Transitions[k])
+		}
+	}
+
+	if backtestShowSamples {
+		fmt.Println("\nChanged samples:")
+		for _, result := range report.Results {
+			if !result.Changed {
+				continue
+			}
+			approx := ""
+			if result.Approximate {
+				approx = " (approx)"
+			}
+			fmt.Printf("  %-24s %s -> %s%s: %s\n", result.Ref, result.Recorded, result.Candidate, approx, result.CandidateReason)
+		}
+	}
+}
+
+func truncateBacktestField(value string, max int) string {
+	if len(value) <= max {
+		return value
+	}
+	return "…" + value[len(value)-max+1:]
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"flowforge/internal/database"
+	"flowforge/internal/policy"
+
+	"github.com/spf13/viper"
+)
+
+func logFixturePath(tb testing.TB, filename string) string {
+	tb.Helper()
+	_, current, _, ok := runtime.Caller(0)
+	if !ok {
+		tb.Fatal("failed to resolve runtime caller")
+	}
+	return filepath.Join(filepath.Dir(current), "..", "test", "fixtures", filename)
+}
+
+func TestBacktestSamplesFromFixtureScoresWindows(t *testing.T) {
+	p := policy.Policy{
+		MaxCPUPercent:    60,
+		MinLogEntropy:    defaultMinLogEntropy,
+		MaxLogRepetition: defaultMaxLogRepetition,
+	}
+	baseline := policy.NewThresholdDecider()
+
+	runaway, err := backtestSamplesFromFixture(logFixturePath(t, "runaway.txt"), 5, 95, time.Minute, baseline, p)
+	if err != nil {
+		t.Fatalf("replay runaway fixture: %v", err)
+	}
+	if len(runaway) != 6 {
+		t.Fatalf("expected 6 windows from 10 lines with window 5, got %d", len(runaway))
+	}
+	for _, sample := range runaway {
+		if sample.RecordedDecision != "KILL" {
+			t.Fatalf("expected baseline KILL for runaway window %s, got %s (%s)", sample.Ref, sample.RecordedDecision, sample.RecordedReason)
+		}
+		if sample.Telemetry.LogRepetition < 0.9 {
+			t.Fatalf("expected high repetition for %s, got %.2f", sample.Ref, sample.Telemetry.LogRepetition)
+		}
+	}
+
+	healthy, err := backtestSamplesFromFixture(logFixturePath(t, "healthy.txt"), 5, 95, time.Minute, baseline, p)
+	if err != nil {
+		t.Fatalf("replay healthy fixture: %v", err)
+	}
+	for _, sample := range healthy {
+		if sample.RecordedDecision == "KILL" {
+			t.Fatalf("expected healthy window %s not to be killed", sample.Ref)
+		}
+	}
+}
+
+func TestBacktestSamplesFromHistoryApproximatesLegacyTraces(t *testing.T) {
+	p := policy.Policy{MaxCPUPercent: 80, CPUWindow: 30 * time.Second}
+	records := []database.DecisionSample{
+		{
+			TraceID:  7,
+			RunID:    "run-1",
+			Decision: "KILL",
+			Telemetry: &database.DecisionTelemetry{
+				CPUPercent:        91,
+				CPUOverForSeconds: 12.5,
+				LogEntropy:        0.2,
+			},
+		},
+		{EventID: "evt-legacy", CPUScore: 100, EntropyScore: 10, Decision: "CONTINUE"},
+	}
+
+	samples := backtestSamplesFromHistory(records, p)
+	if len(samples) != 2 {
+		t.Fatalf("expected 2 samples, got %d", len(samples))
+	}
+	if samples[0].Ref != "trace:7" || samples[0].Approximate {
+		t.Fatalf("unexpected recorded sample %+v", samples[0])
+	}
+	if samples[0].Telemetry.CPUOverFor != 12500*time.Millisecond {
+		t.Fatalf("expected cpu_over_for 12.5s, got %s", samples[0].Telemetry.CPUOverFor)
+	}
+
+	legacy := samples[1]
+	if !legacy.Approximate || legacy.Ref != "event:evt-legacy" || legacy.RunID != "unknown-run" {
+		t.Fatalf("unexpected legacy sample %+v", legacy)
+	}
+	if legacy.Telemetry.CPUPercent <= p.MaxCPUPercent || legacy.Telemetry.CPUOverFor != p.CPUWindow {
+		t.Fatalf("expected saturated cpu score to breach the window, got %+v", legacy.Telemetry)
+	}
+	if legacy.Telemetry.LogEntropy != 0.1 {
+		t.Fatalf("expected entropy 0.1, got %.2f", legacy.Telemetry.LogEntropy)
+	}
+}
+
+func TestPolicyConfigForCLIDefaultsCPUWindowLikeRun(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	if got := policyConfigForCLI(nil).CPUWindow; got != 5*time.Second {
+		t.Fatalf("expected the default CPU window of 10 polls at 500ms, got %s", got)
+	}
+	viper.Set("poll-interval", 300)
+	viper.Set("log-window", 20)
+	if got := policyConfigForCLI(nil).CPUWindow; got != 6*time.Second {
+		t.Fatalf("expected the CPU window to follow poll-interval and log-window, got %s", got)
+	}
+	viper.Set("cpu-window-seconds", 42)
+	if got := policyConfigForCLI(nil).CPUWindow; got != 42*time.Second {
+		t.Fatalf("expected cpu-window-seconds to win, got %s", got)
+	}
+}
@@ -85,3 +85,31 @@ func TestResolvePolicyRolloutConfigFromViper(t *testing.T) {
 		t.Fatalf("expected canary percent 22 from config, got %d", percent)
 	}
 }
+
+func TestResolveDecisionEngineFlagOverridesConfig(t *testing.T) {
+	withDecisionEngineGlobals(t)
+	viper.Set("decision-engine", policy.DecisionEngineName)
+	decisionEngine = policy.WeightedScoreEngineName
+
+	engine, _, err := resolveDecisionEngine()
+	if err != nil {
+		t.Fatalf("resolveDecisionEngine: %v", err)
+	}
+	if engine.Name != policy.WeightedScoreEngineName {
+		t.Fatalf("expected flag engine %q, got %q", policy.WeightedScoreEngineName, engine.Name)
+	}
+
+	decisionEngine = ""
+	engine, _, err = resolveDecisionEngine()
+	if err != nil {
+		t.Fatalf("resolveDecisionEngine from config: %v", err)
+	}
+	if engine.Name != policy.DecisionEngineName {
+		t.Fatalf("expected config engine %q, got %q", policy.DecisionEngineName, engine.Name)
+	}
+
+	decisionEngine = "no-such-engine"
+	if _, _, err := resolveDecisionEngine(); err == nil {
+		t.Fatal("expected error for unknown decision engine")
+	}
+}
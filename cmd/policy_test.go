@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"flowforge/internal/policy"
+	"github.com/spf13/viper"
+)
+
+func writePolicyFileForTest(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatalf("write policy file: %v", err)
+	}
+	return path
+}
+
+func withDecisionEngineGlobals(t *testing.T) {
+	t.Helper()
+	oldEngine := decisionEngine
+	oldPolicyFile := policyFile
+	t.Cleanup(func() {
+		decisionEngine = oldEngine
+		policyFile = oldPolicyFile
+		viper.Reset()
+	})
+	viper.Reset()
+}
+
+func TestResolveDecisionEnginePolicyFileImpliesRuleEngine(t *testing.T) {
+	withDecisionEngineGlobals(t)
+	decisionEngine = ""
+	policyFile = writePolicyFileForTest(t, "version: 1\nname: custom\nrules:\n  - name: hot\n    when: cpu_percent > 50\n    action: alert\n")
+
+	engine, ruleSet, err := resolveDecisionEngine()
+	if err != nil {
+		t.Fatalf("resolveDecisionEngine: %v", err)
+	}
+	if engine.Name != policy.RuleEngineName {
+		t.Fatalf("expected %q, got %q", policy.RuleEngineName, engine.Name)
+	}
+	if ruleSet == nil || ruleSet.Name != "custom" {
+		t.Fatalf("expected custom rule set, got %+v", ruleSet)
+	}
+
+	decisionEngine = policy.WeightedScoreEngineName
+	if _, _, err := resolveDecisionEngine(); err == nil {
+		t.Fatal("expected error when policy file is combined with a non-rule engine")
+	}
+}
+
+func TestValidateConfigRejectsInvalidPolicyFile(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	viper.Set("policy-file", writePolicyFileForTest(t, "version: 1\nrules:\n  - name: bad\n    when: cpu_percent >\n    action: kill\n"))
+	if err := validateConfig(); err == nil {
+		t.Fatal("expected validation error for invalid policy-file")
+	}
+}
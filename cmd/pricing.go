@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"flowforge/internal/tokens"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	pricingListModel string
+	pricingListJSON  bool
+)
+
+var pricingCmd = &cobra.Command{
+	Use:   "pricing",
+	Short: "Inspect and validate the model pricing catalog",
+	Long: `Costs on incidents and budgets come from a pricing catalog: built-in list
+prices plus the entries under the pricing config key, which add models or
+override built-in ones. A model is matched exactly or by its longest catalog
+prefix followed by "-" (gpt-4o-2024-08-06 is priced as gpt-4o). Models with
+no entry are reported as unpriced instead of being guessed.
+
+Example:
+  pricing:
+    - model: gpt-4o
+      input_per_1k: 0.0025
+      output_per_1k: 0.01
+      currency: USD
+      effective_date: "2024-10-01"
+      encoding: o200k_base`,
+}
+
+var pricingListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the prices in effect",
+	Run: func(cmd *cobra.Command, args []string) {
+		runPricingList()
+	},
+}
+
+var pricingValidateCmd = &cobra.Command{
+	Use:   "validate [file]",
+	Short: "Validate pricing entries",
+	Long:  "Checks the pricing entries of a YAML file with a top-level pricing key. Defaults to the loaded config.",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := ""
+		if len(args) == 1 {
+			path = args[0]
+		}
+		runPricingValidate(path)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pricingCmd)
+	pricingCmd.AddCommand(pricingListCmd)
+	pricingCmd.AddCommand(pricingValidateCmd)
+	pricingListCmd.Flags().StringVar(&pricingListModel, "model", "", "Only show the entry a model name resolves to")
+	pricingListCmd.Flags().BoolVar(&pricingListJSON, "json", false, "Print entries as JSON")
+}
+
+// configuredPrices reads the pricing key of v. It goes through JSON rather
+// than mapstructure because YAML hands unquoted dates over as time.Time.
+func configuredPrices(v *viper.Viper) ([]tokens.Price, error) {
+	if !v.IsSet("pricing") {
+		return nil, nil
+	}
+	raw, err := json.Marshal(v.Get("pricing"))
+	if err != nil {
+		return nil, fmt.Errorf("pricing: %w", err)
+	}
+	var prices []tokens.Price
+	if err := json.Unmarshal(raw, &prices); err != nil {
+		return nil, fmt.Errorf("pricing: must be a list of model prices: %w", err)
+	}
+	return prices, nil
+}
+
+func resolvePricingCatalog() (*tokens.Catalog, error) {
+	prices, err := configuredPrices(viper.GetViper())
+	if err != nil {
+		return nil, err
+	}
+	return tokens.WithDefaults(prices)
+}
+
+// installPricingCatalog makes the configured catalog the one costs are
+// computed with. validateConfig has already rejected a bad catalog.
+func installPricingCatalog() {
+	if c, err := resolvePricingCatalog(); err == nil {
+		tokens.SetCatalog(c)
+	}
+}
+
+func runPricingList() {
+	catalog := tokens.ActiveCatalog()
+	prices := catalog.Prices()
+	if m := strings.TrimSpace(pricingListModel); m != "" {
+		p, ok := catalog.Lookup(m, time.Now())
+		if !ok {
+			fmt.Printf("Model %q has no pricing entry; its cost is not estimated.\n", m)
+			os.Exit(1)
+		}
+		prices = []tokens.Price{p}
+	}
+	if pricingListJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(prices)
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MODEL\tINPUT/1K\tOUTPUT/1K\tCURRENCY\tEFFECTIVE\tENCODING")
+	for _, p := range prices {
+		fmt.Fprintf(w, "%s\t%g\t%g\t%s\t%s\t%s\n", p.Model, p.InputPer1K, p.OutputPer1K, p.Currency, withFallback(p.EffectiveDate, "-"), withFallback(p.Encoding, "-"))
+	}
+	_ = w.Flush()
+}
+
+func runPricingValidate(path string) {
+	v := viper.GetViper()
+	source := "config"
+	if path != "" {
+		v = viper.New()
+		v.SetConfigFile(path)
+		if err := v.ReadInConfig(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		source = path
+	}
+	prices, err := configuredPrices(v)
+	if err == nil {
+		_, err = tokens.WithDefaults(prices)
+	}
+	if err != nil {
+		fmt.Printf("Invalid pricing in %s: %v\n", source, err)
+		os.Exit(1)
+	}
+	fmt.Printf("OK: %d pricing entries in %s\n", len(prices), source)
+	for _, p := range prices {
+		if c := strings.ToUpper(strings.TrimSpace(p.Currency)); c != "" && c != tokens.DefaultCurrency {
+			fmt.Printf("Warning: %s is priced in %s; --max-cost-usd and daily cost budgets compare it as USD.\n", p.Model, c)
+		}
+	}
+}
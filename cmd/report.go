@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"flowforge/internal/database"
+	"flowforge/internal/tokens"
 	"fmt"
 	"os"
 	"strings"
@@ -93,15 +94,21 @@ func generateReport(id int) {
 	// ROI / Money Saved Section
 	sb.WriteString("## 💰 ROI — Estimated Savings\n\n")
 
-	rate := 0.03
-	if inc.ModelName == "gpt-3.5-turbo" {
-		rate = 0.002
-	}
-
 	sb.WriteString("| Metric | Value |\n")
 	sb.WriteString("|---|---|\n")
 	sb.WriteString(fmt.Sprintf("| **Model** | `%s` |\n", inc.ModelName))
-	sb.WriteString(fmt.Sprintf("| **Rate** | $%.3f/min |\n", rate))
+	if cost := inc.CostBreakdown; cost != nil && cost.Known {
+		currency := withFallback(cost.Currency, tokens.DefaultCurrency)
+		sb.WriteString(fmt.Sprintf("| **Priced As** | `%s` (effective %s) |\n", cost.PricedAs, withFallback(cost.EffectiveDate, "always")))
+		if p, ok := tokens.ActiveCatalog().Lookup(cost.PricedAs, time.Now()); ok && p.EffectiveDate == cost.EffectiveDate {
+			sb.WriteString(fmt.Sprintf("| **Rate** | %g in / %g out per 1K tokens |\n", p.InputPer1K, p.OutputPer1K))
+		}
+		sb.WriteString(fmt.Sprintf("| **Input** | %d tokens, %.4f %s |\n", cost.InputTokens, cost.InputCost, currency))
+		sb.WriteString(fmt.Sprintf("| **Output** | %d tokens, %.4f %s |\n", cost.OutputTokens, cost.OutputCost, currency))
+		sb.WriteString(fmt.Sprintf("| **Total Cost** | **%.4f %s** |\n", cost.Total, currency))
+	} else if cost != nil || inc.TokenCount > 0 {
+		sb.WriteString("| **Cost** | ⚠️ model not in pricing catalog; cost not estimated (add it under `pricing:`) |\n")
+	}
 	sb.WriteString(fmt.Sprintf("| **Estimated Savings** | **$%.4f** |\n", inc.TokenSavingsEstimate))
 
 	if inc.ExitReason == "LOOP_DETECTED" || inc.ExitReason == "WATCHDOG_ALERT" {
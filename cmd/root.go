@@ -61,6 +61,7 @@ func initConfig() {
 		fmt.Printf("Configuration validation failed: %v\n", err)
 		os.Exit(1)
 	}
+	installPricingCatalog()
 }
 
 // resolveProfile merges the active profile's settings into the top-level Viper keys.
@@ -98,6 +99,14 @@ func resolveProfile() {
 			viper.Set("log-window", logWindow)
 		}
 
+		for _, keys := range [][]string{cgroupProfileKeys, processLimitProfileKeys, stallProfileKeys, memoryTrendProfileKeys, processTreeProfileKeys, streamProfileKeys, structuredLogProfileKeys, toolCallProfileKeys, llmProxyProfileKeys, budgetProfileKeys} {
+			for _, key := range keys {
+				if viper.IsSet(prefix + "." + key) {
+					viper.Set(key, viper.Get(prefix+"."+key))
+				}
+			}
+		}
+
 		if verbose {
 			fmt.Printf("Active profile: %s (max-cpu=%.1f, poll-interval=%dms, log-window=%d)\n",
 				active,
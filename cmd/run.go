@@ -4,11 +4,13 @@ import (
 	"bytes"
 	"context"
 	"flowforge/internal/api"
+	"flowforge/internal/cgroup"
 	"flowforge/internal/database"
 	"flowforge/internal/feedback"
 	"flowforge/internal/patterns"
 	"flowforge/internal/policy"
 	"flowforge/internal/redact"
+	"flowforge/internal/runlog"
 	"flowforge/internal/state"
 	"flowforge/internal/supervisor"
 	"flowforge/internal/sysmon"
@@ -39,10 +41,17 @@ var noKill bool
 var shadowMode bool
 var policyRollout string
 var policyCanaryPercent int
+var decisionEngine string
+var policyFile string
 var injectFeedback string
 var deepWatch bool
 var firstNumberRegex = regexp.MustCompile(`\d+`)
 
+const (
+	defaultMinLogEntropy    = 0.20
+	defaultMaxLogRepetition = 0.80
+)
+
 // runCmd represents the run command
 var runCmd = &cobra.Command{
 	Use:   "run -- <command> [args...]",
@@ -52,6 +61,9 @@ Example:
   flowforge run --model gpt-4 -- python3 script.py
   flowforge run --max-cpu 80.0 -- ./my-binary
   flowforge run --no-kill -- python3 stuck.py   (watchdog mode)
+  flowforge run --decision-engine weighted-score-decider -- python3 agent.py
+  flowforge run --restart-on-breach --max-restarts 3 --restart-backoff exp -- python3 agent.py
+  flowforge run --pause-on-breach --pause-timeout 10m -- python3 agent.py
   flowforge run --inject-feedback agent_feedback.txt -- python3 agent.py`,
 	Args: cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
@@ -76,69 +88,132 @@ func init() {
 	runCmd.Flags().BoolVar(&shadowMode, "shadow-mode", false, "Policy dry-run mode: evaluate actions but log-only for intervention")
 	runCmd.Flags().StringVar(&policyRollout, "policy-rollout", "", "Policy rollout mode: shadow, canary, enforce (default: enforce; shadow-mode remains backward-compatible)")
 	runCmd.Flags().IntVar(&policyCanaryPercent, "policy-canary-percent", -1, "Policy canary enforcement percentage (0-100). In canary mode, unsampled runs are log-only")
+	runCmd.Flags().StringVar(&decisionEngine, "decision-engine", "", "Decision engine used to evaluate telemetry (default: threshold-decider)")
+	runCmd.Flags().StringVar(&policyFile, "policy-file", "", "Declarative YAML policy file evaluated by the rule-decider engine")
+	runCmd.Flags().BoolVar(&restartOnBreach, "restart-on-breach", false, "Relaunch the command in-process when policy decides to restart instead of kill")
+	runCmd.Flags().IntVar(&maxRestarts, "max-restarts", -1, "Maximum relaunches per run with --restart-on-breach (default: 3)")
+	runCmd.Flags().StringVar(&restartBackoff, "restart-backoff", "", "Delay strategy between relaunches: exp, fixed, none (default: exp)")
+	runCmd.Flags().DurationVar(&restartDelay, "restart-delay", 0, "Base delay between relaunches (default: 1s)")
+	runCmd.Flags().BoolVar(&pauseOnBreach, "pause-on-breach", false, "Freeze the process group (SIGSTOP) on a breach instead of killing it; resume with POST /v1/process/resume")
+	runCmd.Flags().DurationVar(&pauseTimeout, "pause-timeout", 0, "Kill a paused run that is not resumed within this long (default: 15m)")
+	runCmd.Flags().BoolVar(&useCgroup, "cgroup", false, "Run the command in its own cgroup v2 group with memory.max/cpu.max/pids.max from the profile")
+	runCmd.Flags().StringVar(&cgroupParent, "cgroup-parent", "", "Delegated cgroup v2 directory to create run groups under (default: FlowForge's own cgroup, if nothing else runs in it)")
+	runCmd.Flags().StringArrayVar(&rlimitFlags, "rlimit", nil, "Resource limit applied before exec: as-mb|nofile|nproc|cpu-seconds|fsize-mb=N (repeatable)")
+	runCmd.Flags().StringSliceVar(&envAllowFlags, "env-allow", nil, "Only pass these environment variables to the command (names or globs like LC_*)")
+	runCmd.Flags().StringSliceVar(&envDenyFlags, "env-deny", nil, "Strip these environment variables from the command (names or globs like AWS_*)")
+	runCmd.Flags().StringArrayVar(&envSetFlags, "env", nil, "Inject KEY=VALUE into the command environment (repeatable)")
+	runCmd.Flags().DurationVar(&stallTimeout, "stall-timeout", 0, "Kill (or restart) the command after this long with no output and no CPU activity")
+	runCmd.Flags().StringVar(&heartbeatFile, "heartbeat-file", "", "File the command touches while healthy; a stale mtime counts as a stall")
+	runCmd.Flags().DurationVar(&heartbeatTimeout, "heartbeat-timeout", 0, "Maximum heartbeat file age before a stall is declared (default: 60s)")
+	runCmd.Flags().StringVar(&logFormat, "log-format", "", "How to read output lines for scoring: text, json, logfmt or auto (default: text)")
+	runCmd.Flags().Float64Var(&maxCostUSDFlag, "max-cost-usd", 0, "Kill the run once its estimated model spend reaches this many USD")
+	runCmd.Flags().IntVar(&maxTokensFlag, "max-tokens", 0, "Kill the run once it has used this many tokens")
+	runCmd.Flags().StringVar(&budgetWorkspace, "workspace", "", "Integration workspace the run is charged to; its daily budget applies")
+	runCmd.Flags().BoolVar(&llmProxyFlag, "llm-proxy", false, "Route the command's OpenAI-compatible API calls through a local metering proxy (sets OPENAI_BASE_URL)")
+	runCmd.Flags().StringVar(&runIDFlag, "run-id", "", "Use this run ID instead of a generated one (set by the daemon for submitted runs)")
+	runCmd.Flags().BoolVar(&noAPI, "no-api", false, "Do not start the local API server on port 8080")
 	runCmd.Flags().StringVar(&injectFeedback, "inject-feedback", "", "Path to feedback file to inject into subprocess stdin")
 	runCmd.Flags().BoolVar(&deepWatch, "deep", false, "Enable Deep Watch (syscall monitoring)")
 }
 
-// LogObserver is a thread-safe bounded ring buffer for log lines.
+// LogObserver is a thread-safe bounded ring buffer for log lines. All streams
+// share one window for the blended loop scores; each stream also keeps its own
+// window, line counter and rate so stderr can be scored separately.
 type LogObserver struct {
 	mu          sync.Mutex
-	lines       []string
-	capacity    int
-	index       int          // Current write index
-	isFull      bool         // Whether the buffer has wrapped
-	buf         bytes.Buffer // Partial line buffer
+	all         *lineRing
+	streams     map[string]*streamState
+	lastStream  string // stream of the newest complete line
+	exceptions  *exceptionHistory
+	toolCalls   *toolCallTracker // nil unless TrackToolCalls was called
 	totalTokens int64
-	modelName   string
+	tokenizer   tokens.Tokenizer
+	lastOutput  time.Time // last Write, including partial lines
 }
 
 func NewLogObserver(capacity int, model string) *LogObserver {
 	if capacity <= 0 {
 		capacity = 10 // Safety floor
 	}
-	return &LogObserver{
-		lines:     make([]string, capacity),
-		capacity:  capacity,
-		modelName: model,
+	l := &LogObserver{
+		all:        newLineRing(capacity),
+		streams:    make(map[string]*streamState, 2),
+		exceptions: newExceptionHistory(),
+		tokenizer:  tokens.ForModel(model),
 	}
+	for _, name := range []string{runlog.StreamStdout, runlog.StreamStderr} {
+		l.streams[name] = newStreamState(capacity)
+	}
+	return l
 }
 
+// Write records output as stdout. Use Stream to tag stderr.
 func (l *LogObserver) Write(p []byte) (n int, err error) {
+	return l.write(runlog.StreamStdout, p)
+}
+
+// Stream returns a writer that records its lines under name.
+func (l *LogObserver) Stream(name string) io.Writer {
+	return observerStream{l: l, name: name}
+}
+
+type observerStream struct {
+	l    *LogObserver
+	name string
+}
+
+func (s observerStream) Write(p []byte) (int, error) { return s.l.write(s.name, p) }
+
+func (l *LogObserver) write(stream string, p []byte) (n int, err error) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	n, _ = l.buf.Write(p)
+	st, ok := l.streams[stream]
+	if !ok {
+		st = newStreamState(l.all.capacity)
+		l.streams[stream] = st
+	}
+	n, _ = st.partial.Write(p)
+	if len(p) > 0 {
+		l.lastOutput = time.Now()
+	}
 
-	// Process lines from buffer
+	// Process lines from this stream's buffer; a partial line on stdout
+	// must not be joined with stderr output.
 	for {
-		i := bytes.IndexByte(l.buf.Bytes(), '\n')
+		i := bytes.IndexByte(st.partial.Bytes(), '\n')
 		if i < 0 {
 			break
 		}
 
-		line := l.buf.String()[:i]
-		l.addLine(line)
+		line := st.partial.String()[:i]
+		l.addLine(stream, st, line)
 
 		// Advance buffer
-		l.buf.Next(i + 1)
+		st.partial.Next(i + 1)
 	}
 
 	return n, nil
 }
 
-func (l *LogObserver) addLine(line string) {
+func (l *LogObserver) addLine(stream string, st *streamState, line string) {
 	// Prevent token/key leakage to state/dashboard surfaces.
 	line = redact.Line(line)
 
-	l.lines[l.index] = line
-	l.index++
-	if l.index >= l.capacity {
-		l.index = 0
-		l.isFull = true
+	now := time.Now()
+	l.all.add(line)
+	st.ring.add(line)
+	st.rate.add(now)
+	l.lastStream = stream
+	if sig, ok := exceptionSignature(line); ok {
+		l.exceptions.add(sig, now)
+	}
+	if l.toolCalls != nil {
+		l.toolCalls.observe(line)
 	}
 
 	// Count tokens
-	count := tokens.Count(line, l.modelName)
+	count := l.tokenizer.Count(line)
 	atomic.AddInt64(&l.totalTokens, int64(count))
 }
 
@@ -146,33 +221,75 @@ func (l *LogObserver) TotalTokens() int64 {
 	return atomic.LoadInt64(&l.totalTokens)
 }
 
+// LastOutputAt returns when the process last wrote any output, or the zero
+// time if it has not written yet.
+func (l *LogObserver) LastOutputAt() time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lastOutput
+}
+
+// GetLastLines returns up to n of the newest lines across all streams.
 func (l *LogObserver) GetLastLines(n int) []string {
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	return l.all.last(n)
+}
 
-	if n > l.capacity {
-		n = l.capacity
+// LastLine returns the newest complete line and the stream it came from.
+func (l *LogObserver) LastLine() (line, stream string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if last := l.all.last(1); len(last) == 1 {
+		return last[0], l.lastStream
 	}
+	return "", ""
+}
 
-	total := l.index
-	if l.isFull {
-		total = l.capacity
+// StreamLines returns up to n of the newest lines written to stream.
+func (l *LogObserver) StreamLines(stream string, n int) []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if st, ok := l.streams[stream]; ok {
+		return st.ring.last(n)
 	}
+	return nil
+}
 
-	if n > total {
-		n = total
+// StreamStats returns the total line count and recent lines/sec of stream.
+func (l *LogObserver) StreamStats(stream string) (lines int64, perSec float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if st, ok := l.streams[stream]; ok {
+		return st.lines(), st.rate.perSecond(time.Now())
 	}
+	return 0, 0
+}
 
-	result := make([]string, 0, n)
-	// Calculate starting point (n lines back from current index)
-	start := (l.index - n + l.capacity) % l.capacity
+// RepeatedException returns the most frequent exception signature seen
+// within the exception window and how many times it occurred.
+func (l *LogObserver) RepeatedException() (signature string, count int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.exceptions.top(time.Now())
+}
 
-	for i := 0; i < n; i++ {
-		idx := (start + i) % l.capacity
-		result = append(result, l.lines[idx])
-	}
+// TrackToolCalls starts extracting agent tool calls from new lines.
+func (l *LogObserver) TrackToolCalls(tracker *toolCallTracker) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.toolCalls = tracker
+}
 
-	return result
+// RepeatedToolCall returns the most frequent tool call signature among the
+// recent calls and how many times it occurred.
+func (l *LogObserver) RepeatedToolCall() (signature string, count int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.toolCalls == nil {
+		return "", 0
+	}
+	return l.toolCalls.top()
 }
 
 func NormalizeLog(line string) string {
@@ -318,6 +435,74 @@ func detectProgressLikeOutput(lines []string) bool {
 	return progressHintRatio >= 0.40 && numericCoverage >= 0.70 && increaseRatio >= 0.70
 }
 
+// decisionTelemetryRecord captures the sample an engine evaluated so the
+// decision can be replayed by `flowforge policy backtest`.
+func decisionTelemetryRecord(t policy.Telemetry, maxCPUPercent float64) *database.DecisionTelemetry {
+	return &database.DecisionTelemetry{
+		CPUPercent:          t.CPUPercent,
+		CPUOverForSeconds:   t.CPUOverFor.Seconds(),
+		MemoryMB:            t.MemoryMB,
+		LogRepetition:       t.LogRepetition,
+		LogEntropy:          t.LogEntropy,
+		RawDiversity:        t.RawDiversity,
+		ProgressLike:        t.ProgressLike,
+		ErrorRate:           t.ErrorRate,
+		MaxCPUPercent:       maxCPUPercent,
+		OOMKills:            t.OOMKills,
+		CPUThrottled:        t.CPUThrottled,
+		OutputIdleSeconds:   t.OutputIdleFor.Seconds(),
+		CPUIdleSeconds:      t.CPUIdleFor.Seconds(),
+		HeartbeatAgeSeconds: t.HeartbeatAge.Seconds(),
+
+		MemoryGrowthMBPerMin:     t.MemoryGrowthMBPerMin,
+		MemoryTimeToLimitSeconds: t.MemoryTimeToLimit.Seconds(),
+
+		ChildProcesses:  t.ChildProcesses,
+		ZombieProcesses: t.ZombieProcesses,
+		OpenFDs:         t.OpenFDs,
+		Threads:         t.Threads,
+
+		StderrLinesPerSec:  t.StderrLinesPerSec,
+		StderrRepetition:   t.StderrRepetition,
+		RepeatedExceptions: t.RepeatedExceptions,
+		ExceptionSignature: t.ExceptionSignature,
+
+		ToolCallRepetition: t.ToolCallRepetition,
+		ToolCallSignature:  t.ToolCallSignature,
+		PromptRepetition:   t.PromptRepetition,
+		PromptSignature:    t.PromptSignature,
+
+		RunCostUSD:       t.RunCostUSD,
+		RunTokens:        t.RunTokens,
+		WorkspaceCostUSD: t.WorkspaceCostUSD,
+		WorkspaceTokens:  t.WorkspaceTokens,
+	}
+}
+
+// resolveSampling returns the poll interval in milliseconds and the number
+// of log lines scored per decision.
+func resolveSampling() (int, int) {
+	pollInterval := viper.GetInt("poll-interval")
+	if pollInterval <= 0 {
+		pollInterval = 500
+	}
+	logWindow := viper.GetInt("log-window")
+	if logWindow <= 0 {
+		logWindow = 10
+	}
+	return pollInterval, logWindow
+}
+
+// resolveCPUWindow is how long CPU must stay above max-cpu to count as
+// sustained: cpu-window-seconds, or else one log window's worth of polls.
+func resolveCPUWindow(pollInterval, logWindow int) time.Duration {
+	cpuWindow := time.Duration(viper.GetInt("cpu-window-seconds")) * time.Second
+	if cpuWindow <= 0 {
+		cpuWindow = time.Duration(pollInterval*logWindow) * time.Millisecond
+	}
+	return cpuWindow
+}
+
 func resolvePolicyRolloutConfig() (policy.RolloutMode, int) {
 	mode := strings.ToLower(strings.TrimSpace(policyRollout))
 	if mode == "" {
@@ -362,16 +547,57 @@ func resolvePolicyRolloutConfig() (policy.RolloutMode, int) {
 	return policy.RolloutMode(mode), canaryPercent
 }
 
+// resolveDecisionEngine picks the engine for a run. A policy file implies the
+// rule-decider engine and is rejected when another engine is requested.
+func resolveDecisionEngine() (policy.EngineRegistration, *policy.RuleSet, error) {
+	name := strings.TrimSpace(decisionEngine)
+	if name == "" {
+		name = strings.TrimSpace(viper.GetString("decision-engine"))
+	}
+	path := strings.TrimSpace(policyFile)
+	if path == "" {
+		path = strings.TrimSpace(viper.GetString("policy-file"))
+	}
+	if path != "" && name == "" {
+		name = policy.RuleEngineName
+	}
+
+	engine, err := policy.ResolveEngine(name)
+	if err != nil {
+		return policy.EngineRegistration{}, nil, err
+	}
+	if path == "" {
+		return engine, nil, nil
+	}
+	if engine.Name != policy.RuleEngineName {
+		return policy.EngineRegistration{}, nil, fmt.Errorf("policy file requires decision engine %q, got %q", policy.RuleEngineName, engine.Name)
+	}
+	ruleSet, err := policy.LoadRuleSetFile(path)
+	if err != nil {
+		return policy.EngineRegistration{}, nil, err
+	}
+	return engine, ruleSet, nil
+}
+
 func runProcess(args []string) {
+	engine, ruleSet, engineErr := resolveDecisionEngine()
+	if engineErr != nil {
+		fmt.Printf("[FlowForge] %v\n", engineErr)
+		os.Exit(1)
+	}
+
 	if err := database.InitDB(); err != nil {
 		fmt.Printf("Warning: Failed to initialize database: %v\n", err)
 	}
 	defer database.CloseDB()
 
-	// Start API server in background
-	fmt.Println("[FlowForge] Starting API server on port 8080...")
-	stopAPI := api.Start("8080")
-	defer stopAPI()
+	// Start API server in background. Runs submitted through the daemon skip
+	// it; the daemon already serves their controls.
+	if !noAPI {
+		fmt.Println("[FlowForge] Starting API server on port 8080...")
+		stopAPI := api.Start("8080")
+		defer stopAPI()
+	}
 
 	// Pull known bad patterns on startup
 	blacklist := patterns.PullPatterns()
@@ -382,18 +608,15 @@ func runProcess(args []string) {
 	startTime := time.Now()
 
 	// Read profile-based config values
-	pollInterval := viper.GetInt("poll-interval")
-	if pollInterval <= 0 {
-		pollInterval = 500
-	}
-	logWindow := viper.GetInt("log-window")
-	if logWindow <= 0 {
-		logWindow = 10
-	}
+	pollInterval, logWindow := resolveSampling()
 	rolloutMode, canaryPercent := resolvePolicyRolloutConfig()
 
 	// Generate transient Agent ID for this run
-	agentID := uuid.New().String()
+	agentID, runIDErr := resolveRunID(uuid.NewString)
+	if runIDErr != nil {
+		fmt.Printf("Error: %v\n", runIDErr)
+		os.Exit(1)
+	}
 	agentVersion := "1.0.0"
 	fmt.Printf("[FlowForge] 🆔 Agent ID: %s (v%s)\n", agentID, agentVersion)
 
@@ -404,45 +627,51 @@ func runProcess(args []string) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	cmd := exec.Command(cmdName, cmdArgs...)
-
-	// Initialize LogObserver with profile-based capacity
-	observer := NewLogObserver(logWindow*2, modelName)
-
-	// MultiWriter to print to stdout and capture in observer
-	stdoutWriter := io.MultiWriter(os.Stdout, observer)
-	stderrWriter := io.MultiWriter(os.Stderr, observer)
-
-	cmd.Stdout = stdoutWriter
-	cmd.Stderr = stderrWriter
-
-	// Handle --inject-feedback: pipe feedback into subprocess stdin
+	// Handle --inject-feedback: read once so every generation gets the same stdin
+	feedbackContent := ""
 	if injectFeedback != "" {
-		feedbackContent, err := feedback.ReadFeedback(injectFeedback)
+		content, err := feedback.ReadFeedback(injectFeedback)
 		if err != nil {
 			fmt.Printf("[FlowForge] Warning: Could not read feedback file: %v\n", err)
 		} else {
 			fmt.Printf("[FlowForge] 💉 Injecting feedback from: %s\n", injectFeedback)
-			cmd.Stdin = strings.NewReader(feedbackContent)
+			feedbackContent = content
 			// Clean up after injection
 			defer feedback.CleanupFeedback(injectFeedback)
 		}
 	}
 
-	procSupervisor := supervisor.New(cmd)
-	if err := procSupervisor.Start(); err != nil {
-		fmt.Printf("Failed to start command: %v\n", err)
+	database.SetRunID(agentID)
+	restartCfg := resolveRestartOnBreachConfig()
+	pauseCfg := resolvePauseOnBreachConfig()
+	cgroupCfg := resolveCgroupConfig()
+	processLimits, limitsErr := resolveProcessLimits()
+	if limitsErr != nil {
+		fmt.Printf("[FlowForge] %v\n", limitsErr)
 		os.Exit(1)
 	}
-
-	pid := procSupervisor.PID()
-	fmt.Printf("Process started with PID: %d\n", pid)
-	database.SetRunID(agentID)
-	startWD, _ := os.Getwd()
-	api.RegisterExternalWorker(fullCommand, args, startWD, procSupervisor)
-	api.SetWorkerSpec(fullCommand, args, startWD)
-	state.UpdateState(0, "", "RUNNING", fullCommand, args, startWD, pid)
-	state.UpdateLifecycle("RUNNING", "RUNNING", pid)
+	stallCfg := resolveStallConfig()
+	memTrendCfg := resolveMemoryTrendConfig()
+	logCfg := resolveStructuredLogConfig()
+	toolCallCfg := resolveToolCallConfig()
+	toolCallRules, rulesErr := toolCallCfg.rules()
+	if rulesErr != nil {
+		fmt.Printf("Error: %v\n", rulesErr)
+		os.Exit(1)
+	}
+	capture := openOutputCapture(resolveOutputCaptureConfig(), agentID)
+	defer capture.Close()
+	llmProxy, proxyErr := startLLMProxy(resolveLLMProxyConfig())
+	if proxyErr != nil {
+		fmt.Printf("Error: %v\n", proxyErr)
+		os.Exit(1)
+	}
+	defer llmProxy.Close()
+	processLimits = llmProxy.limits(processLimits)
+	if noAPI {
+		stopPublishing := publishRunState(agentID, time.Duration(pollInterval)*time.Millisecond)
+		defer stopPublishing()
+	}
 
 	var maxObservedCpu float64 = 0.0
 	var lastWatchdogAlert time.Time
@@ -452,18 +681,16 @@ func runProcess(args []string) {
 	var flowforgeTerminated atomic.Bool
 	var highCPUStart time.Time
 
-	cpuWindow := time.Duration(viper.GetInt("cpu-window-seconds")) * time.Second
-	if cpuWindow <= 0 {
-		cpuWindow = time.Duration(pollInterval*logWindow) * time.Millisecond
-	}
-	policyDecider := policy.NewThresholdDecider()
+	cpuWindow := resolveCPUWindow(pollInterval, logWindow)
+	policyDecider := engine.New()
 	policyConfig := policy.Policy{
 		MaxCPUPercent:     maxCpu,
 		CPUWindow:         cpuWindow,
-		MinLogEntropy:     0.20,
-		MaxLogRepetition:  0.80,
+		MinLogEntropy:     defaultMinLogEntropy,
+		MaxLogRepetition:  defaultMaxLogRepetition,
 		MaxMemoryMB:       viper.GetFloat64("max-memory-mb"),
-		RestartOnBreach:   false,
+		RestartOnBreach:   restartCfg.Enabled,
+		PauseOnBreach:     pauseCfg.Enabled,
 		ShadowMode:        shadowMode,
 		RolloutMode:       rolloutMode,
 		CanaryPercent:     canaryPercent,
@@ -471,15 +698,35 @@ func runProcess(args []string) {
 		DryRunActor:       "system",
 		DryRunEventPrefix: "Policy dry-run",
 	}
-	engineContract := policy.CurrentEngineContract(rolloutMode)
+	policyConfig = stallCfg.applyTo(policyConfig)
+	policyConfig = memTrendCfg.applyTo(policyConfig)
+	policyConfig = applyProcessTreeLimits(policyConfig)
+	policyConfig = applyStreamLimits(policyConfig)
+	policyConfig = applyToolCallLimits(policyConfig)
+	policyConfig = applyLLMProxyLimits(policyConfig)
+	policyConfig.MaxErrorRate = viper.GetFloat64("max-error-rate")
+	policyConfig = applyBudgetLimits(policyConfig)
+	budgetCfg := resolveBudgetConfig()
+	policyConfig, budgetErr := applyWorkspaceBudget(policyConfig, budgetCfg.Workspace)
+	if budgetErr != nil {
+		fmt.Printf("Error: %v\n", budgetErr)
+		os.Exit(1)
+	}
+	if ruleSet != nil {
+		policyDecider = policy.NewRuleDeciderFor(ruleSet)
+		policyConfig = ruleSet.ApplyThresholds(policyConfig)
+		fmt.Printf("[FlowForge] Policy file: %s (v%d, %d rules)\n", ruleSet.Name, ruleSet.Version, len(ruleSet.Rules))
+	}
+	engineContract := engine.Contract(rolloutMode)
 	decisionTraceMeta := database.DecisionTraceMeta{
 		DecisionEngine:    engineContract.EngineName,
 		EngineVersion:     engineContract.EngineVersion,
 		DecisionContract:  engineContract.ContractVersion,
 		PolicyRolloutMode: engineContract.RolloutMode,
 	}
-	buildDecisionMeta := func(decisionValue, reasonText string, cpuScore, entropyScore, confidenceScore float64) database.DecisionTraceMeta {
+	buildDecisionMeta := func(decisionValue, reasonText string, cpuScore, entropyScore, confidenceScore float64, sample policy.Telemetry) database.DecisionTraceMeta {
 		meta := decisionTraceMeta
+		meta.Telemetry = decisionTelemetryRecord(sample, policyConfig.MaxCPUPercent)
 		meta.ReplayContract = policy.DecisionReplayContractVersion
 		meta.ReplayDigest = policy.DecisionReplayDigest(policy.DecisionReplayInput{
 			DecisionEngine:   meta.DecisionEngine,
@@ -500,380 +747,678 @@ func runProcess(args []string) {
 		engineContract.ContractVersion,
 		engineContract.RolloutMode,
 	)
+	if restartCfg.Enabled {
+		fmt.Printf("[FlowForge] Restart on breach: max-restarts=%d backoff=%s delay=%s\n", restartCfg.MaxRestarts, restartCfg.Backoff, restartCfg.Delay)
+	}
+	if pauseCfg.Enabled && !restartCfg.Enabled {
+		fmt.Printf("[FlowForge] Pause on breach: killed unless resumed within %s\n", pauseCfg.Timeout)
+	}
+	if cgroupCfg.Enabled {
+		fmt.Printf("[FlowForge] cgroup v2 mode: %s\n", formatCgroupLimits(cgroupCfg.Limits))
+	}
+	if !processLimits.IsZero() {
+		fmt.Printf("[FlowForge] Process limits: %s\n", formatProcessLimits(processLimits))
+	}
+	if policy.StallConfigured(policyConfig) {
+		fmt.Printf("[FlowForge] Stall detection: %s\n", formatStallPolicy(policyConfig, stallCfg))
+	}
+	if policyConfig.MaxChildProcesses > 0 || policyConfig.MaxZombieProcesses > 0 {
+		fmt.Printf("[FlowForge] Process tree limits: max-child-processes=%d max-zombie-processes=%d\n", policyConfig.MaxChildProcesses, policyConfig.MaxZombieProcesses)
+	}
+	if policyConfig.MaxStderrLinesPerSec > 0 || policyConfig.MaxStderrRepetition > 0 || policyConfig.MaxRepeatedExceptions > 0 {
+		fmt.Printf("[FlowForge] Stream limits: max-stderr-lines-per-sec=%.1f max-stderr-repetition=%.2f max-repeated-exceptions=%d\n",
+			policyConfig.MaxStderrLinesPerSec, policyConfig.MaxStderrRepetition, policyConfig.MaxRepeatedExceptions)
+	}
+	if policy.BudgetConfigured(policyConfig) {
+		fmt.Printf("[FlowForge] Budget limits: %s\n", formatBudgetPolicy(policyConfig, budgetCfg.Workspace))
+	}
+	if !tokens.PriceUsage(modelName, 0, 0).Known {
+		fmt.Printf("[FlowForge] Model %q has no pricing entry; its cost is recorded as 0 (add it under pricing:).\n", modelName)
+	}
+	if policyConfig.MaxPromptRepetition > 0 {
+		fmt.Printf("[FlowForge] Prompt limits: max-prompt-repetition=%d\n", policyConfig.MaxPromptRepetition)
+	}
+	if policyConfig.MaxToolCallRepetition > 0 {
+		fmt.Printf("[FlowForge] Tool call limits: max-tool-call-repetition=%d over the last %d calls (presets: %s)\n",
+			policyConfig.MaxToolCallRepetition, toolCallCfg.Window, strings.Join(toolCallCfg.Presets, ","))
+	}
+	if logCfg.Format != logFormatText {
+		fmt.Printf("[FlowForge] Structured logs: %s max-error-rate=%.2f\n", formatStructuredLogConfig(logCfg), policyConfig.MaxErrorRate)
+	}
+	if policyConfig.MemoryProjectionWindow > 0 {
+		fmt.Printf("[FlowForge] Memory trend: act when projected to reach %.0fMB within %s (window %s)\n",
+			policy.MemoryProjectionLimit(policyConfig), policyConfig.MemoryProjectionWindow, memTrendCfg.Window)
+	}
 
 	// Create Monitor instance
 	monitor := sysmon.NewMonitor()
 
-	// CPU Monitoring Goroutine
-	go func() {
-		ticker := time.NewTicker(time.Duration(pollInterval) * time.Millisecond)
-		defer ticker.Stop()
+	var userTerminated atomic.Bool
+	var observer *LogObserver
+	var procSupervisor *supervisor.Supervisor
+	var pid int
+	var err error
+	var carriedTokens int64
+	// runUsage prices the run so far: metered prompt and completion tokens
+	// when the proxy is on, otherwise output tokens counted from the logs.
+	runUsage := func() tokens.Cost {
+		if u, ok := llmProxy.usage(); ok {
+			return tokens.PriceUsage(withFallback(u.Model, modelName), int(u.PromptTokens), int(u.CompletionTokens))
+		}
+		return tokens.PriceUsage(modelName, 0, int(carriedTokens+observer.TotalTokens()))
+	}
+	budget := newBudgetTracker(budgetCfg, policyConfig)
+
+	// Each pass is one generation of the supervised command. A policy RESTART
+	// with --restart-on-breach ends the generation and loops; anything else
+	// ends the run. All generations share the run ID.
+	restarts := 0
+	for {
+		genCtx, genCancel := context.WithCancel(ctx)
+		var restartPending atomic.Bool
+		// pausePending is set while a policy pause awaits a resume.
+		var pausePending atomic.Bool
+		highCPUStart = time.Time{}
+		initialFDs = 0
+
+		cmd := exec.Command(cmdName, cmdArgs...)
+
+		// Initialize LogObserver with profile-based capacity
+		observer = NewLogObserver(logWindow*2, modelName)
+		observer.TrackToolCalls(newToolCallTracker(toolCallRules, toolCallCfg.Window))
+
+		// MultiWriter to print to stdout and capture in observer
+		stdoutWriter, stderrWriter := capture.writers(
+			io.MultiWriter(os.Stdout, observer.Stream(runlog.StreamStdout)),
+			io.MultiWriter(os.Stderr, observer.Stream(runlog.StreamStderr)),
+		)
 
-		p, err := process.NewProcess(int32(pid))
-		if err != nil {
-			fmt.Printf("[FlowForge] Error attaching monitor to PID %d: %v\n", pid, err)
-			return
+		cmd.Stdout = stdoutWriter
+		cmd.Stderr = stderrWriter
+		if feedbackContent != "" {
+			cmd.Stdin = strings.NewReader(feedbackContent)
+		}
+
+		var runCgroup *cgroup.Group
+		if cgroupCfg.Enabled {
+			g, err := createRunCgroup(cgroupCfg, agentID, restarts)
+			if err != nil {
+				fmt.Printf("[FlowForge] %v\n", err)
+				genCancel()
+				os.Exit(1)
+			}
+			runCgroup = g
+		}
+
+		procSupervisor = supervisor.New(cmd)
+		procSupervisor.SetLimits(processLimits)
+		if runCgroup != nil {
+			procSupervisor.PlaceInCgroup(runCgroup.Path())
+		}
+		if err := procSupervisor.Start(); err != nil {
+			fmt.Printf("Failed to start command: %v\n", err)
+			if runCgroup != nil {
+				_ = runCgroup.Remove()
+			}
+			genCancel()
+			os.Exit(1)
 		}
 
-		for {
-			select {
-			case <-ctx.Done():
+		pid = procSupervisor.PID()
+		if restarts == 0 {
+			fmt.Printf("Process started with PID: %d\n", pid)
+		} else {
+			fmt.Printf("[FlowForge] Process restarted (generation %d) with PID: %d\n", restarts, pid)
+		}
+		startWD, _ := os.Getwd()
+		api.RegisterExternalWorker(fullCommand, args, startWD, procSupervisor)
+		api.SetWorkerSpec(fullCommand, args, startWD)
+		state.UpdateState(0, "", "RUNNING", fullCommand, args, startWD, pid)
+		state.UpdateLifecycle("RUNNING", "RUNNING", pid)
+
+		// The kernel OOM killer acts inside memory.max without FlowForge's
+		// involvement, so each new oom_kill is recorded as its own incident.
+		var oomKillsReported int64
+		reportCgroupOOM := func(stats cgroup.Stats, cpuUsage float64) {
+			if stats.OOMKills <= oomKillsReported {
 				return
-			case <-ticker.C:
-				if procSupervisor.Exited() {
-					return
-				}
-				cpuUsage, err := p.CPUPercent()
-				if err != nil {
-					continue
-				}
+			}
+			killed := stats.OOMKills - oomKillsReported
+			oomKillsReported = stats.OOMKills
+			reason := fmt.Sprintf("cgroup OOM killer terminated %d process(es) at %s", killed, formatCgroupLimits(cgroupCfg.Limits))
+			fmt.Printf("\n[FlowForge] 🛑 CGROUP OOM: %s\n", reason)
+			incidentID := uuid.NewString()
+			usage := runUsage()
+			_ = database.LogIncidentWithCostForIncident(fullCommand, modelName, "CGROUP_OOM_KILLED", cpuUsage, fmt.Sprintf("memory.current=%dB oom_kill=%d", stats.MemoryCurrentBytes, stats.OOMKills), time.Since(startTime).Seconds(), usage, agentID, agentVersion, reason, 0, 0, 0, "oom_killed", restarts, incidentID)
+			_ = database.LogAuditEventWithIncident("kernel", "CGROUP_OOM_KILL", reason, "cgroup", pid, fullCommand, incidentID)
+		}
 
-				if cpuUsage > maxObservedCpu {
-					maxObservedCpu = cpuUsage
-				}
+		// CPU Monitoring Goroutine
+		monitorDone := make(chan struct{})
+		go func() {
+			defer close(monitorDone)
+			ticker := time.NewTicker(time.Duration(pollInterval) * time.Millisecond)
+			defer ticker.Stop()
 
-				// Broadcast Live Stats (with PID)
-				lastLines := observer.GetLastLines(1)
-				lastLine := ""
-				if len(lastLines) > 0 {
-					lastLine = lastLines[0]
-				}
+			p, err := process.NewProcess(int32(pid))
+			if err != nil {
+				fmt.Printf("[FlowForge] Error attaching monitor to PID %d: %v\n", pid, err)
+				return
+			}
+			var lastCgroupStats cgroup.Stats
+			var lastCgroupRead time.Time
+			stall := newStallTracker(stallCfg, time.Now())
+			memTrend := sysmon.NewMemoryTrend(memTrendCfg.Window)
+			// The tree sampler reports CPU per interval, so prime it once here;
+			// the first tick then already has a baseline.
+			tree := sysmon.NewTreeSampler(pid)
+			_, treeErr := tree.Sample()
+			paused := false
+
+			for {
+				select {
+				case <-genCtx.Done():
+					return
+				case <-ticker.C:
+					if procSupervisor.Exited() {
+						return
+					}
+					// A paused group is left alone until it is resumed or killed.
+					if procSupervisor.Paused() {
+						paused = true
+						continue
+					}
+					if paused {
+						// Time spent frozen is neither a stall nor sustained CPU.
+						paused = false
+						pausePending.Store(false)
+						stall = newStallTracker(stallCfg, time.Now())
+						highCPUStart = time.Time{}
+						fmt.Println("[FlowForge] Process group resumed.")
+					}
+					// Aggregate the whole process tree when possible; fall back to
+					// the leader on platforms without a tree reader.
+					var treeStats sysmon.TreeStats
+					if treeErr == nil {
+						treeStats, treeErr = tree.Sample()
+					}
+					var cpuUsage float64
+					if treeErr == nil {
+						cpuUsage = treeStats.CPUPercent
+					} else if cpuUsage, err = p.CPUPercent(); err != nil {
+						continue
+					}
 
-				// Deep Watch (Syscall Monitoring)
-				isProbing := false
-				sysStatsStr := ""
-				if deepWatch {
-					stats, err := monitor.GetStats(pid)
-					if err == nil {
-						// Check for probing (sudden spike)
-						// Use monitor's internal logic
-						probing, details := monitor.DetectProbing(pid, stats)
-						if probing {
-							isProbing = true
-							sysStatsStr = details
-						}
+					if cpuUsage > maxObservedCpu {
+						maxObservedCpu = cpuUsage
+					}
 
-						if initialFDs == 0 {
-							initialFDs = stats.OpenFDs
+					var cgroupStats cgroup.Stats
+					cpuThrottled := 0.0
+					if runCgroup != nil {
+						if stats, err := runCgroup.Stats(); err == nil {
+							now := time.Now()
+							if !lastCgroupRead.IsZero() {
+								cpuThrottled = cgroupThrottleRatio(lastCgroupStats, stats, now.Sub(lastCgroupRead).Microseconds())
+							}
+							lastCgroupStats, lastCgroupRead = stats, now
+							cgroupStats = stats
+							reportCgroupOOM(stats, cpuUsage)
 						}
+					}
 
-						// Also keep local logic? No, monitor does it.
-						// But existing code check:
-						/*
-							if stats.OpenFDs > initialFDs*2 && stats.OpenFDs > 50 {
+					// Broadcast Live Stats (with PID)
+					lastLine, lastStream := observer.LastLine()
+
+					// Deep Watch (Syscall Monitoring)
+					isProbing := false
+					sysStatsStr := ""
+					if deepWatch {
+						stats, err := monitor.GetStats(pid)
+						if err == nil {
+							// Check for probing (sudden spike)
+							// Use monitor's internal logic
+							probing, details := monitor.DetectProbing(pid, stats)
+							if probing {
 								isProbing = true
+								sysStatsStr = details
 							}
-						*/
-						// Let's rely on Monitor's return
-						if !isProbing {
-							// Display stats if not probing for debug?
-							sysStatsStr = fmt.Sprintf("FDs: %d | Sockets: %d", stats.OpenFDs, stats.SocketCount)
-						}
-					}
-				}
 
-				status := "RUNNING"
-				if isProbing {
-					status = "PROBING_DETECTED"
-					fmt.Printf("\n[FlowForge] 🚨 PROBING DETECTED: %s\n", sysStatsStr)
-				}
+							if initialFDs == 0 {
+								initialFDs = stats.OpenFDs
+							}
 
-				wd, _ := os.Getwd()
-				state.UpdateState(
-					cpuUsage,
-					lastLine,
-					status,
-					fullCommand,
-					args,
-					wd,
-					pid,
-				)
-
-				// Early blacklist check (even before high CPU)
-				if len(blacklist) > 0 {
-					recentLines := observer.GetLastLines(3)
-					for _, line := range recentLines {
-						normalized := NormalizeLog(line)
-						if patterns.IsBlacklisted(normalized, blacklist) {
-							fmt.Printf("\n⚡ EARLY WARNING: Output matches a known bad pattern from blacklist!\n")
-							fmt.Println("Pattern:", normalized)
-							break
+							// Also keep local logic? No, monitor does it.
+							// But existing code check:
+							/*
+								if stats.OpenFDs > initialFDs*2 && stats.OpenFDs > 50 {
+									isProbing = true
+								}
+							*/
+							// Let's rely on Monitor's return
+							if !isProbing {
+								// Display stats if not probing for debug?
+								sysStatsStr = fmt.Sprintf("FDs: %d | Sockets: %d", stats.OpenFDs, stats.SocketCount)
+							}
 						}
 					}
-				}
 
-				if cpuUsage > maxCpu {
-					if highCPUStart.IsZero() {
-						highCPUStart = time.Now()
+					status := "RUNNING"
+					if isProbing {
+						status = "PROBING_DETECTED"
+						fmt.Printf("\n[FlowForge] 🚨 PROBING DETECTED: %s\n", sysStatsStr)
 					}
-				} else {
-					highCPUStart = time.Time{}
-				}
 
-				windowLines := observer.GetLastLines(logWindow)
-				if len(windowLines) == logWindow {
-					firstNormalized, repetitionScore := calculateRepetitionScore(windowLines)
+					wd, _ := os.Getwd()
+					state.UpdateState(
+						cpuUsage,
+						lastLine,
+						status,
+						fullCommand,
+						args,
+						wd,
+						pid,
+					)
+					state.SetLastLineStream(lastStream)
+
+					// Early blacklist check (even before high CPU)
+					if len(blacklist) > 0 {
+						recentLines := observer.GetLastLines(3)
+						for _, line := range recentLines {
+							normalized := NormalizeLog(line)
+							if patterns.IsBlacklisted(normalized, blacklist) {
+								fmt.Printf("\n⚡ EARLY WARNING: Output matches a known bad pattern from blacklist!\n")
+								fmt.Println("Pattern:", normalized)
+								break
+							}
+						}
+					}
 
-					cpuScore, entropyScore, confidenceScore := calculateDecisionScores(cpuUsage, maxCpu, windowLines)
-					rawDiversity := rawDiversityScore(windowLines)
-					progressLike := detectProgressLikeOutput(windowLines)
-					cpuOverFor := time.Duration(0)
-					if !highCPUStart.IsZero() {
-						cpuOverFor = time.Since(highCPUStart)
+					if cpuUsage > maxCpu {
+						if highCPUStart.IsZero() {
+							highCPUStart = time.Now()
+						}
+					} else {
+						highCPUStart = time.Time{}
 					}
+
 					memMB := 0.0
-					if memInfo, err := p.MemoryInfo(); err == nil {
+					if cgroupStats.MemoryCurrentBytes > 0 {
+						// memory.current covers the whole group, not just the leader.
+						memMB = float64(cgroupStats.MemoryCurrentBytes) / 1024.0 / 1024.0
+					} else if treeErr == nil {
+						memMB = float64(treeStats.RSSBytes) / 1024.0 / 1024.0
+					} else if memInfo, err := p.MemoryInfo(); err == nil {
 						memMB = float64(memInfo.RSS) / 1024.0 / 1024.0
 					}
-
-					decision := policyDecider.Evaluate(policy.Telemetry{
-						CPUPercent:    cpuUsage,
-						CPUOverFor:    cpuOverFor,
-						MemoryMB:      memMB,
-						LogRepetition: repetitionScore,
-						LogEntropy:    entropyScore / 100.0,
-						RawDiversity:  rawDiversity,
-						ProgressLike:  progressLike,
-						RolloutKey:    agentID,
-					}, policyConfig)
-					reason := decision.Reason
-
-					if time.Since(lastDecisionTrace) > 5*time.Second || decision.Action != policy.ActionContinue {
-						meta := buildDecisionMeta(decision.Action.String(), reason, cpuScore, entropyScore, confidenceScore)
-						_ = database.LogDecisionTraceWithMeta(fullCommand, pid, cpuScore, entropyScore, confidenceScore, decision.Action.String(), reason, meta)
-						lastDecisionTrace = time.Now()
+					if memMB > 0 {
+						memTrend.Add(time.Now(), memMB)
 					}
-					state.UpdateDecision(reason, cpuScore, entropyScore, confidenceScore)
-
-					switch decision.Action {
-					case policy.ActionContinue:
-						// no-op
-					case policy.ActionAlert:
-						// WATCHDOG MODE: Escalation Logic
-						cooldown := 30 * time.Second
-						if watchdogEscalationLevel == 1 {
-							cooldown = 15 * time.Second
-						} else if watchdogEscalationLevel >= 2 {
-							cooldown = 5 * time.Second
+
+					windowLines := observer.GetLastLines(logWindow)
+					windowFull := len(windowLines) == logWindow
+					if windowFull || preWindowChecksConfigured(policyConfig) {
+						var firstNormalized string
+						var repetitionScore, cpuScore, entropyScore, confidenceScore, rawDiversity, errorRate float64
+						progressLike := false
+						if windowFull {
+							scores := logCfg.scoreWindow(windowLines, cpuUsage, maxCpu)
+							firstNormalized, repetitionScore = scores.FirstNormalized, scores.Repetition
+							cpuScore, entropyScore, confidenceScore = scores.CPUScore, scores.Entropy, scores.Confidence
+							rawDiversity, progressLike, errorRate = scores.RawDiversity, scores.ProgressLike, scores.ErrorRate
+						}
+						cpuOverFor := time.Duration(0)
+						if !highCPUStart.IsZero() {
+							cpuOverFor = time.Since(highCPUStart)
+						}
+						memGrowth, _ := memTrend.SlopeMBPerMin()
+
+						sample := policy.Telemetry{
+							CPUPercent:    cpuUsage,
+							CPUOverFor:    cpuOverFor,
+							MemoryMB:      memMB,
+							LogRepetition: repetitionScore,
+							LogEntropy:    entropyScore / 100.0,
+							RawDiversity:  rawDiversity,
+							ProgressLike:  progressLike,
+							ErrorRate:     errorRate,
+							RolloutKey:    agentID,
+							OOMKills:      int(cgroupStats.OOMKills),
+							CPUThrottled:  cpuThrottled,
+
+							MemoryGrowthMBPerMin: memGrowth,
+						}
+						applyTreeStats(&sample, treeStats)
+						applyStreamSignals(&sample, observer, logWindow)
+						sample.ToolCallSignature, sample.ToolCallRepetition = observer.RepeatedToolCall()
+						llmProxy.applySignals(&sample)
+						if ttl, ok := policy.ProjectedTimeToLimit(sample, policyConfig); ok {
+							sample.MemoryTimeToLimit = ttl
+						}
+						stall.observe(&sample, time.Now(), cpuUsage, observer.LastOutputAt())
+						budget.observe(&sample, runUsage())
+						decision := evaluateSample(policyDecider, sample, policyConfig, windowFull)
+						reason := decision.Reason
+
+						if windowFull || decision.Action != policy.ActionContinue {
+							if time.Since(lastDecisionTrace) > 5*time.Second || decision.Action != policy.ActionContinue {
+								meta := buildDecisionMeta(decision.Action.String(), reason, cpuScore, entropyScore, confidenceScore, sample)
+								_ = database.LogDecisionTraceWithMeta(fullCommand, pid, cpuScore, entropyScore, confidenceScore, decision.Action.String(), reason, meta)
+								lastDecisionTrace = time.Now()
+							}
+							state.UpdateDecision(reason, cpuScore, entropyScore, confidenceScore)
 						}
 
-						if time.Since(lastWatchdogAlert) > cooldown {
-							lastWatchdogAlert = time.Now()
-							watchdogEscalationLevel++
-							incidentID := uuid.NewString()
+						switch decision.Action {
+						case policy.ActionContinue:
+							// no-op
+						case policy.ActionAlert:
+							// WATCHDOG MODE: Escalation Logic
+							cooldown := 30 * time.Second
+							if watchdogEscalationLevel == 1 {
+								cooldown = 15 * time.Second
+							} else if watchdogEscalationLevel >= 2 {
+								cooldown = 5 * time.Second
+							}
 
-							alertType := "WATCHDOG_ALERT"
-							if watchdogEscalationLevel == 2 {
-								alertType = "WATCHDOG_WARN"
-							} else if watchdogEscalationLevel > 2 {
-								alertType = "WATCHDOG_CRITICAL"
+							if time.Since(lastWatchdogAlert) > cooldown {
+								lastWatchdogAlert = time.Now()
+								watchdogEscalationLevel++
+								incidentID := uuid.NewString()
+
+								alertType := "WATCHDOG_ALERT"
+								if watchdogEscalationLevel == 2 {
+									alertType = "WATCHDOG_WARN"
+								} else if watchdogEscalationLevel > 2 {
+									alertType = "WATCHDOG_CRITICAL"
+								}
+
+								if repetitionScore >= policyConfig.MaxLogRepetition {
+									patterns.SyncPatterns(firstNormalized)
+								}
+
+								fmt.Printf("\n🔍 WATCHDOG [%s]: Policy alert. Escalation Level %d.\n", alertType, watchdogEscalationLevel)
+								fmt.Println("Pattern (Normalized):", firstNormalized)
+								fmt.Printf("[FlowForge] Decision: CPU=%.1f Entropy=%.1f Confidence=%.1f\n", cpuScore, entropyScore, confidenceScore)
+
+								usage := runUsage()
+
+								meta := buildDecisionMeta(decision.Action.String(), reason, cpuScore, entropyScore, confidenceScore, sample)
+								_ = database.LogDecisionTraceWithIncidentAndMeta(fullCommand, pid, cpuScore, entropyScore, confidenceScore, decision.Action.String(), reason, incidentID, meta)
+								_ = database.LogIncidentWithCostForIncident(
+									fullCommand,
+									modelName,
+									alertType,
+									cpuUsage,
+									firstNormalized,
+									time.Since(startTime).Seconds(),
+									usage,
+									agentID,
+									agentVersion,
+									reason,
+									cpuScore,
+									entropyScore,
+									confidenceScore,
+									"watchdog",
+									0,
+									incidentID,
+								)
+								_ = database.LogAuditEventWithIncident("flowforge", "WATCHDOG_ALERT", reason, "monitor", pid, fullCommand, incidentID)
+
+								wd, _ := os.Getwd()
+								state.UpdateState(
+									cpuUsage,
+									fmt.Sprintf("WATCHDOG: Policy alert (%s)", alertType),
+									alertType,
+									fullCommand,
+									args,
+									wd,
+									pid,
+								)
+							}
+						case policy.ActionLogOnly:
+							fmt.Printf("\n[FlowForge] 🧪 %s\n", reason)
+							incidentID := uuid.NewString()
+							meta := buildDecisionMeta(decision.Action.String(), reason, cpuScore, entropyScore, confidenceScore, sample)
+							_ = database.LogDecisionTraceWithIncidentAndMeta(fullCommand, pid, cpuScore, entropyScore, confidenceScore, decision.Action.String(), reason, incidentID, meta)
+							_ = database.LogPolicyDryRunWithIncident(fullCommand, pid, reason, confidenceScore, incidentID)
+						case policy.ActionKill, policy.ActionRestart, policy.ActionPause:
+							if noKill {
+								// Legacy watchdog mode always suppresses destructive actions.
+								fmt.Printf("\n[FlowForge] WATCHDOG MODE: %s\n", reason)
+								incidentID := uuid.NewString()
+								blockedReason := "watchdog mode blocked destructive action: " + reason
+								meta := buildDecisionMeta("ACTION_BLOCKED", blockedReason, cpuScore, entropyScore, confidenceScore, sample)
+								_ = database.LogDecisionTraceWithIncidentAndMeta(fullCommand, pid, cpuScore, entropyScore, confidenceScore, "ACTION_BLOCKED", blockedReason, incidentID, meta)
+								_ = database.LogPolicyDryRunWithIncident(fullCommand, pid, blockedReason, confidenceScore, incidentID)
+								continue
 							}
+							incidentID := uuid.NewString()
 
-							if repetitionScore >= policyConfig.MaxLogRepetition {
+							exitReason := decision.ExitReason
+							if exitReason == "" {
+								exitReason = policy.ExitReasonLoop
+							}
+							evidence := firstNormalized
+							toolCall, toolCallCount := "", 0
+							switch exitReason {
+							case policy.ExitReasonStall:
+								evidence = formatStallEvidence(sample)
+							case policy.ExitReasonMemoryGrowth:
+								evidence = formatMemoryTrendEvidence(sample)
+							case policy.ExitReasonForkBomb:
+								evidence = formatProcessTreeEvidence(sample)
+							case policy.ExitReasonException:
+								evidence = formatExceptionEvidence(sample)
+							case policy.ExitReasonToolLoop:
+								evidence = formatToolCallEvidence(sample)
+								toolCall, toolCallCount = sample.ToolCallSignature, sample.ToolCallRepetition
+							case policy.ExitReasonPromptLoop:
+								evidence = formatPromptEvidence(sample)
+							case policy.ExitReasonBudget:
+								evidence = formatBudgetEvidence(sample)
+							default:
 								patterns.SyncPatterns(firstNormalized)
 							}
+							feedback.GenerateFeedback(feedback.FeedbackData{
+								Command:    fullCommand,
+								Pattern:    evidence,
+								ExitReason: exitReason,
+								MaxCPU:     cpuUsage,
+								ModelName:  modelName,
+								Savings:    0,
+
+								ToolCall:      toolCall,
+								ToolCallCount: toolCallCount,
+							})
+
+							actionName := "AUTO_KILL"
+							decisionValue := decision.Action.String()
+							recoveryStatus := "terminated"
+							restartCount := restarts
+							restartApproved, pauseApproved := false, false
+							if decision.Action == policy.ActionPause {
+								if pauseErr := api.PauseWorker(pauseCfg.Timeout); pauseErr != nil {
+									reason = fmt.Sprintf("%s (pause failed: %v)", reason, pauseErr)
+									decisionValue = policy.ActionKill.String()
+								} else {
+									pauseApproved = true
+									actionName = "AUTO_PAUSE"
+									recoveryStatus = "paused"
+								}
+							}
+							if decision.Action == policy.ActionRestart {
+								if budgetErr := reserveBreachRestart(restartCfg, restarts); budgetErr != nil {
+									// Out of restarts: fall back to a plain kill.
+									reason = fmt.Sprintf("%s (restart suppressed: %v)", reason, budgetErr)
+									decisionValue = policy.ActionKill.String()
+								} else {
+									restartApproved = true
+									actionName = "AUTO_RESTART"
+									exitReason = "RESTART_TRIGGERED"
+									recoveryStatus = "restarting"
+									restartCount = restarts + 1
+								}
+							}
 
-							fmt.Printf("\n🔍 WATCHDOG [%s]: Policy alert. Escalation Level %d.\n", alertType, watchdogEscalationLevel)
-							fmt.Println("Pattern (Normalized):", firstNormalized)
-							fmt.Printf("[FlowForge] Decision: CPU=%.1f Entropy=%.1f Confidence=%.1f\n", cpuScore, entropyScore, confidenceScore)
-
-							finalTokens := int(observer.TotalTokens())
-							finalCost := tokens.EstimateCost(finalTokens, modelName)
+							fmt.Printf("\n🚨 %s: %s\n", actionName, reason)
+							usage := runUsage()
 
-							meta := buildDecisionMeta(decision.Action.String(), reason, cpuScore, entropyScore, confidenceScore)
-							_ = database.LogDecisionTraceWithIncidentAndMeta(fullCommand, pid, cpuScore, entropyScore, confidenceScore, decision.Action.String(), reason, incidentID, meta)
-							_ = database.LogIncidentWithDecisionForIncident(
+							meta := buildDecisionMeta(decisionValue, reason, cpuScore, entropyScore, confidenceScore, sample)
+							_ = database.LogDecisionTraceWithIncidentAndMeta(fullCommand, pid, cpuScore, entropyScore, confidenceScore, decisionValue, reason, incidentID, meta)
+							_ = database.LogIncidentWithCostForIncident(
 								fullCommand,
 								modelName,
-								alertType,
+								exitReason,
 								cpuUsage,
-								firstNormalized,
+								evidence,
 								time.Since(startTime).Seconds(),
-								finalTokens,
-								finalCost,
+								usage,
 								agentID,
 								agentVersion,
 								reason,
 								cpuScore,
 								entropyScore,
 								confidenceScore,
-								"watchdog",
-								0,
+								recoveryStatus,
+								restartCount,
 								incidentID,
 							)
-							_ = database.LogAuditEventWithIncident("flowforge", "WATCHDOG_ALERT", reason, "monitor", pid, fullCommand, incidentID)
+							_ = database.LogAuditEventWithIncident("flowforge", actionName, reason, "monitor", pid, fullCommand, incidentID)
 
+							stateLine, stateStatus := "POLICY ACTION - Terminating process group...", exitReason
+							if pauseApproved {
+								stateLine, stateStatus = "POLICY ACTION - Process group paused", "PAUSED"
+							}
 							wd, _ := os.Getwd()
 							state.UpdateState(
 								cpuUsage,
-								fmt.Sprintf("WATCHDOG: Policy alert (%s)", alertType),
-								alertType,
+								stateLine,
+								stateStatus,
 								fullCommand,
 								args,
 								wd,
 								pid,
 							)
-						}
-					case policy.ActionLogOnly:
-						fmt.Printf("\n[FlowForge] 🧪 %s\n", reason)
-						incidentID := uuid.NewString()
-						meta := buildDecisionMeta(decision.Action.String(), reason, cpuScore, entropyScore, confidenceScore)
-						_ = database.LogDecisionTraceWithIncidentAndMeta(fullCommand, pid, cpuScore, entropyScore, confidenceScore, decision.Action.String(), reason, incidentID, meta)
-						_ = database.LogPolicyDryRunWithIncident(fullCommand, pid, reason, confidenceScore, incidentID)
-					case policy.ActionKill, policy.ActionRestart:
-						if noKill {
-							// Legacy watchdog mode always suppresses destructive actions.
-							fmt.Printf("\n[FlowForge] WATCHDOG MODE: %s\n", reason)
-							incidentID := uuid.NewString()
-							blockedReason := "watchdog mode blocked destructive action: " + reason
-							meta := buildDecisionMeta("ACTION_BLOCKED", blockedReason, cpuScore, entropyScore, confidenceScore)
-							_ = database.LogDecisionTraceWithIncidentAndMeta(fullCommand, pid, cpuScore, entropyScore, confidenceScore, "ACTION_BLOCKED", blockedReason, incidentID, meta)
-							_ = database.LogPolicyDryRunWithIncident(fullCommand, pid, blockedReason, confidenceScore, incidentID)
-							continue
-						}
-						incidentID := uuid.NewString()
-
-						patterns.SyncPatterns(firstNormalized)
-						feedback.GenerateFeedback(feedback.FeedbackData{
-							Command:    fullCommand,
-							Pattern:    firstNormalized,
-							ExitReason: "LOOP_DETECTED",
-							MaxCPU:     cpuUsage,
-							ModelName:  modelName,
-							Savings:    0,
-						})
-
-						actionName := "AUTO_KILL"
-						exitReason := "LOOP_DETECTED"
-						if decision.Action == policy.ActionRestart {
-							actionName = "AUTO_RESTART"
-							exitReason = "RESTART_TRIGGERED"
-						}
 
-						fmt.Printf("\n🚨 %s: %s\n", actionName, reason)
-						finalTokens := int(observer.TotalTokens())
-						finalCost := tokens.EstimateCost(finalTokens, modelName)
-
-						meta := buildDecisionMeta(decision.Action.String(), reason, cpuScore, entropyScore, confidenceScore)
-						_ = database.LogDecisionTraceWithIncidentAndMeta(fullCommand, pid, cpuScore, entropyScore, confidenceScore, decision.Action.String(), reason, incidentID, meta)
-						_ = database.LogIncidentWithDecisionForIncident(
-							fullCommand,
-							modelName,
-							exitReason,
-							cpuUsage,
-							firstNormalized,
-							time.Since(startTime).Seconds(),
-							finalTokens,
-							finalCost,
-							agentID,
-							agentVersion,
-							reason,
-							cpuScore,
-							entropyScore,
-							confidenceScore,
-							"terminated",
-							0,
-							incidentID,
-						)
-						_ = database.LogAuditEventWithIncident("flowforge", actionName, reason, "monitor", pid, fullCommand, incidentID)
-
-						wd, _ := os.Getwd()
-						state.UpdateState(
-							cpuUsage,
-							"POLICY ACTION - Terminating process group...",
-							exitReason,
-							fullCommand,
-							args,
-							wd,
-							pid,
-						)
-
-						flowforgeTerminated.Store(true)
-						_ = procSupervisor.Stop(2 * time.Second)
-						cancel()
-						fmt.Println("[FlowForge] Process group terminated after policy decision.")
-						return
-					}
-				}
-
-				if cpuUsage > maxCpu {
-					fmt.Printf("[FlowForge] WARNING: High CPU (%.2f%%) detected. %s\n", cpuUsage, sysStatsStr)
-				}
-
-				// --- SAFETY CHOKE POINT ---
-				// 1. Memory Limit
-				maxMemMB := viper.GetFloat64("max-memory-mb")
-				if maxMemMB > 0 {
-					memInfo, err := p.MemoryInfo()
-					if err == nil {
-						memMB := float64(memInfo.RSS) / 1024.0 / 1024.0
-						if memMB > maxMemMB {
-							fmt.Printf("\n[FlowForge] 🛑 SAFETY CHOKE: Memory usage (%.2f MB) exceeded limit (%.2f MB). TERMINATING.\n", memMB, maxMemMB)
-							// ... (rest of logic same)
-							finalTokens := int(observer.TotalTokens())
-							finalCost := tokens.EstimateCost(finalTokens, modelName)
-							database.LogIncident(fullCommand, modelName, "SAFETY_LIMIT_EXCEEDED", cpuUsage, fmt.Sprintf("Memory Limit: %.2fMB", memMB), time.Since(startTime).Seconds(), finalTokens, finalCost, agentID, agentVersion)
+							if pauseApproved {
+								pausePending.Store(true)
+								fmt.Printf("[FlowForge] Process group paused; resume with POST /v1/process/resume within %s or it is killed.\n", pauseCfg.Timeout)
+								continue
+							}
+							if restartApproved {
+								restartPending.Store(true)
+								_ = procSupervisor.Stop(2 * time.Second)
+								genCancel()
+								fmt.Println("[FlowForge] Process group stopped after policy decision; restarting.")
+								return
+							}
 
 							flowforgeTerminated.Store(true)
 							_ = procSupervisor.Stop(2 * time.Second)
 							cancel()
+							fmt.Println("[FlowForge] Process group terminated after policy decision.")
 							return
 						}
 					}
-				}
 
-				// 2. Token Rate Limit (Choke)
-				maxTokensRate := viper.GetFloat64("max-tokens-per-min")
-				if maxTokensRate > 0 {
-					currentTokens := observer.TotalTokens()
-					elapsedMin := time.Since(startTime).Minutes()
-					if elapsedMin > 0.1 { // Warmup 6s
-						rate := float64(currentTokens) / elapsedMin
-						if rate > maxTokensRate {
-							fmt.Printf("\n[FlowForge] 🛑 SAFETY CHOKE: Token generation rate (%.0f/min) exceeded limit (%.0f/min). TERMINATING.\n", rate, maxTokensRate)
+					if cpuUsage > maxCpu {
+						fmt.Printf("[FlowForge] WARNING: High CPU (%.2f%%) detected. %s\n", cpuUsage, sysStatsStr)
+					}
+
+					// --- SAFETY CHOKE POINT ---
+					// 1. Memory Limit, on the same whole-tree figure the policy sees
+					maxMemMB := viper.GetFloat64("max-memory-mb")
+					if maxMemMB > 0 && memMB > maxMemMB {
+						fmt.Printf("\n[FlowForge] 🛑 SAFETY CHOKE: Memory usage (%.2f MB) exceeded limit (%.2f MB). TERMINATING.\n", memMB, maxMemMB)
+						usage := runUsage()
+						database.LogIncidentWithCost(fullCommand, modelName, "SAFETY_LIMIT_EXCEEDED", cpuUsage, fmt.Sprintf("Memory Limit: %.2fMB", memMB), time.Since(startTime).Seconds(), usage, agentID, agentVersion)
 
-							finalTokens := int(currentTokens)
-							finalCost := tokens.EstimateCost(finalTokens, modelName)
-							database.LogIncident(fullCommand, modelName, "SAFETY_LIMIT_EXCEEDED", cpuUsage, fmt.Sprintf("Token Rate: %.0f/min", rate), time.Since(startTime).Seconds(), finalTokens, finalCost, agentID, agentVersion)
+						flowforgeTerminated.Store(true)
+						_ = procSupervisor.Stop(2 * time.Second)
+						cancel()
+						return
+					}
 
-							flowforgeTerminated.Store(true)
-							_ = procSupervisor.Stop(2 * time.Second)
-							cancel()
-							return
+					// 2. Token Rate Limit (Choke)
+					maxTokensRate := viper.GetFloat64("max-tokens-per-min")
+					if maxTokensRate > 0 {
+						usage := runUsage()
+						elapsedMin := time.Since(startTime).Minutes()
+						if elapsedMin > 0.1 { // Warmup 6s
+							rate := float64(usage.Tokens()) / elapsedMin
+							if rate > maxTokensRate {
+								fmt.Printf("\n[FlowForge] 🛑 SAFETY CHOKE: Token generation rate (%.0f/min) exceeded limit (%.0f/min). TERMINATING.\n", rate, maxTokensRate)
+
+								database.LogIncidentWithCost(fullCommand, modelName, "SAFETY_LIMIT_EXCEEDED", cpuUsage, fmt.Sprintf("Token Rate: %.0f/min", rate), time.Since(startTime).Seconds(), usage, agentID, agentVersion)
+
+								flowforgeTerminated.Store(true)
+								_ = procSupervisor.Stop(2 * time.Second)
+								cancel()
+								return
+							}
 						}
 					}
 				}
 			}
+		}()
+
+		untrap := procSupervisor.TrapSignals(3*time.Second, func(sig os.Signal) {
+			fmt.Printf("\n[FlowForge] Received signal: %v. Cleaning up process group...\n", sig)
+
+			userTerminated.Store(true)
+			incidentID := uuid.NewString()
+			usage := runUsage()
+			_ = database.LogIncidentWithCostForIncident(fullCommand, modelName, "USER_TERMINATED", maxObservedCpu, "N/A", time.Since(startTime).Seconds(), usage, agentID, agentVersion, "received OS signal", 0, 0, 0, "terminated", restarts, incidentID)
+			_ = database.LogAuditEventWithIncident("operator", "TERMINATE", "received OS signal", "cli", pid, fullCommand, incidentID)
+
+			wd, _ := os.Getwd()
+			state.UpdateState(
+				0,
+				"",
+				"STOPPED",
+				fullCommand,
+				args,
+				wd,
+				pid,
+			)
+			cancel()
+		}, os.Interrupt, syscall.SIGTERM)
+
+		err = procSupervisor.Wait()
+		genCancel()
+		<-monitorDone
+		untrap()
+		if pausePending.Load() && !userTerminated.Load() {
+			flowforgeTerminated.Store(true)
+			fmt.Println("[FlowForge] Paused process group stopped before it was resumed.")
+		}
+		if runCgroup != nil {
+			if stats, statsErr := runCgroup.Stats(); statsErr == nil {
+				reportCgroupOOM(stats, maxObservedCpu)
+			}
+			// Reap anything that outlived the leader before removing the group.
+			_ = runCgroup.Kill()
+			if removeErr := runCgroup.Remove(); removeErr != nil {
+				fmt.Printf("[FlowForge] Warning: %v\n", removeErr)
+			}
 		}
-	}()
-
-	var userTerminated atomic.Bool
-	untrap := procSupervisor.TrapSignals(3*time.Second, func(sig os.Signal) {
-		fmt.Printf("\n[FlowForge] Received signal: %v. Cleaning up process group...\n", sig)
-
-		userTerminated.Store(true)
-		incidentID := uuid.NewString()
-		finalTokens := int(observer.TotalTokens())
-		finalCost := tokens.EstimateCost(finalTokens, modelName)
-		_ = database.LogIncidentWithDecisionForIncident(fullCommand, modelName, "USER_TERMINATED", maxObservedCpu, "N/A", time.Since(startTime).Seconds(), finalTokens, finalCost, agentID, agentVersion, "received OS signal", 0, 0, 0, "terminated", 0, incidentID)
-		_ = database.LogAuditEventWithIncident("operator", "TERMINATE", "received OS signal", "cli", pid, fullCommand, incidentID)
 
+		if !restartPending.Load() || userTerminated.Load() {
+			break
+		}
+		carriedTokens += observer.TotalTokens()
+		restarts++
+		delay := restartBackoffDelay(restartCfg, restarts)
+		fmt.Printf("[FlowForge] Restart %d/%d in %s\n", restarts, restartCfg.MaxRestarts, delay)
 		wd, _ := os.Getwd()
-		state.UpdateState(
-			0,
-			"",
-			"STOPPED",
-			fullCommand,
-			args,
-			wd,
-			pid,
-		)
-		cancel()
-	}, os.Interrupt, syscall.SIGTERM)
-	defer untrap()
-
-	err := procSupervisor.Wait()
+		state.UpdateState(0, fmt.Sprintf("Restarting (generation %d)", restarts), "RESTARTING", fullCommand, args, wd, pid)
+		if !waitForRestart(ctx, delay) {
+			break
+		}
+	}
 	cancel()
+	budget.settle(runUsage())
 
 	// Write STOPPED state on exit
 	wd, _ := os.Getwd()
@@ -892,9 +1437,8 @@ func runProcess(args []string) {
 			isSignal := strings.Contains(exitErr.String(), "signal: killed") || strings.Contains(exitErr.String(), "signal: interrupt")
 
 			if !userTerminated.Load() && !isSignal {
-				finalTokens := int(observer.TotalTokens())
-				finalCost := tokens.EstimateCost(finalTokens, modelName)
-				_ = database.LogIncident(fullCommand, modelName, "COMMAND_FAILURE", maxObservedCpu, "N/A", time.Since(startTime).Seconds(), finalTokens, finalCost, agentID, agentVersion)
+				usage := runUsage()
+				_ = database.LogIncidentWithCost(fullCommand, modelName, "COMMAND_FAILURE", maxObservedCpu, "N/A", time.Since(startTime).Seconds(), usage, agentID, agentVersion)
 			}
 			if flowforgeTerminated.Load() {
 				os.Exit(1)
@@ -907,9 +1451,8 @@ func runProcess(args []string) {
 		} else {
 			fmt.Printf("Command finished with error: %v\n", err)
 			if !userTerminated.Load() {
-				finalTokens := int(observer.TotalTokens())
-				finalCost := tokens.EstimateCost(finalTokens, modelName)
-				_ = database.LogIncident(fullCommand, modelName, "COMMAND_FAILURE", maxObservedCpu, "N/A", time.Since(startTime).Seconds(), finalTokens, finalCost, agentID, agentVersion)
+				usage := runUsage()
+				_ = database.LogIncidentWithCost(fullCommand, modelName, "COMMAND_FAILURE", maxObservedCpu, "N/A", time.Since(startTime).Seconds(), usage, agentID, agentVersion)
 			}
 			os.Exit(1)
 		}
@@ -919,3 +1462,19 @@ func runProcess(args []string) {
 		os.Exit(1)
 	}
 }
+
+// waitForRestart sleeps for the restart backoff. It returns false when the run
+// is cancelled (for example by a signal) before the delay elapses.
+func waitForRestart(ctx context.Context, delay time.Duration) bool {
+	if delay <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"flowforge/internal/database"
+	"flowforge/internal/policy"
+	"flowforge/internal/tokens"
+
+	"github.com/spf13/viper"
+)
+
+var (
+	maxCostUSDFlag   float64
+	maxTokensFlag    int
+	budgetWorkspace  string
+	budgetWarnLevels = []int{50, 80, 100}
+)
+
+// budgetProfileKeys are copied from the active profile by resolveProfile.
+var budgetProfileKeys = []string{"max-cost-usd", "max-tokens"}
+
+type budgetConfig struct {
+	MaxCostUSD float64
+	MaxTokens  int
+	// Workspace is the integration workspace the run is charged to; its
+	// daily budget is read from the database.
+	Workspace string
+}
+
+func resolveBudgetConfig() budgetConfig {
+	cfg := budgetConfig{
+		MaxCostUSD: maxCostUSDFlag,
+		MaxTokens:  maxTokensFlag,
+		Workspace:  strings.TrimSpace(budgetWorkspace),
+	}
+	if cfg.MaxCostUSD <= 0 {
+		cfg.MaxCostUSD = viper.GetFloat64("max-cost-usd")
+	}
+	if cfg.MaxTokens <= 0 {
+		cfg.MaxTokens = viper.GetInt("max-tokens")
+	}
+	if cfg.Workspace == "" {
+		cfg.Workspace = strings.TrimSpace(viper.GetString("workspace"))
+	}
+	return cfg
+}
+
+func applyBudgetLimits(p policy.Policy) policy.Policy {
+	cfg := resolveBudgetConfig()
+	p.MaxCostUSD = cfg.MaxCostUSD
+	p.MaxTokens = cfg.MaxTokens
+	return p
+}
+
+// applyWorkspaceBudget loads the daily budget of the workspace the run is
+// charged to. Backtests leave it out since it is database state, not config.
+func applyWorkspaceBudget(p policy.Policy, workspace string) (policy.Policy, error) {
+	if workspace == "" {
+		return p, nil
+	}
+	budget, err := database.GetWorkspaceBudget(workspace)
+	if err != nil {
+		return p, fmt.Errorf("workspace budget %s: %w", workspace, err)
+	}
+	p.WorkspaceDailyCostUSD = budget.DailyMaxCostUSD
+	p.WorkspaceDailyTokens = budget.DailyMaxTokens
+	return p, nil
+}
+
+func formatBudgetPolicy(p policy.Policy, workspace string) string {
+	parts := []string{}
+	if p.MaxCostUSD > 0 {
+		parts = append(parts, fmt.Sprintf("max-cost-usd=%.4f", p.MaxCostUSD))
+	}
+	if p.MaxTokens > 0 {
+		parts = append(parts, fmt.Sprintf("max-tokens=%d", p.MaxTokens))
+	}
+	if workspace != "" {
+		parts = append(parts, fmt.Sprintf("workspace=%s daily-cost-usd=%.4f daily-tokens=%d", workspace, p.WorkspaceDailyCostUSD, p.WorkspaceDailyTokens))
+	}
+	return strings.Join(parts, " ")
+}
+
+// formatBudgetEvidence is recorded on BUDGET_EXCEEDED incidents.
+func formatBudgetEvidence(t policy.Telemetry) string {
+	return fmt.Sprintf("run $%.4f / %d tokens; workspace today $%.4f / %d tokens", t.RunCostUSD, t.RunTokens, t.WorkspaceCostUSD, t.WorkspaceTokens)
+}
+
+// budgetTracker fills the spend fields of each sample and charges the
+// workspace for the run's usage as it happens, so concurrent runs in one
+// workspace see each other's spend. Warnings are emitted once per level and
+// scope; workspace levels are recorded in the database so they are shared
+// between runs.
+type budgetTracker struct {
+	cfg    budgetConfig
+	limits policy.Policy
+	now    func() time.Time
+
+	chargedTokens int
+	chargedCost   float64
+	workspace     database.WorkspaceSpend
+	runWarned     int
+}
+
+func newBudgetTracker(cfg budgetConfig, limits policy.Policy) *budgetTracker {
+	return &budgetTracker{cfg: cfg, limits: limits, now: time.Now}
+}
+
+// observe records the run's total usage so far into t.
+func (b *budgetTracker) observe(t *policy.Telemetry, usage tokens.Cost) {
+	runTokens, cost := usage.Tokens(), usage.Total
+	t.RunTokens, t.RunCostUSD = runTokens, cost
+	b.charge(runTokens, cost)
+	t.WorkspaceTokens, t.WorkspaceCostUSD = b.workspace.Tokens, b.workspace.CostUSD
+
+	if level := crossedBudgetLevel(usedPercent(cost, b.limits.MaxCostUSD, runTokens, b.limits.MaxTokens), b.runWarned); level > 0 {
+		b.runWarned = level
+		b.warn("run", level, runTokens, cost, b.limits.MaxTokens, b.limits.MaxCostUSD)
+	}
+	if b.cfg.Workspace == "" {
+		return
+	}
+	percent := usedPercent(b.workspace.CostUSD, b.limits.WorkspaceDailyCostUSD, b.workspace.Tokens, b.limits.WorkspaceDailyTokens)
+	if level := crossedBudgetLevel(percent, b.workspace.WarnedPercent); level > 0 {
+		if first, err := database.MarkWorkspaceBudgetWarning(b.cfg.Workspace, b.workspace.Day, level); err == nil && first {
+			b.workspace.WarnedPercent = level
+			b.warn("workspace", level, b.workspace.Tokens, b.workspace.CostUSD, b.limits.WorkspaceDailyTokens, b.limits.WorkspaceDailyCostUSD)
+		}
+	}
+}
+
+// settle charges whatever the run used after its last sample.
+func (b *budgetTracker) settle(usage tokens.Cost) {
+	b.charge(usage.Tokens(), usage.Total)
+}
+
+func (b *budgetTracker) charge(runTokens int, cost float64) {
+	if b.cfg.Workspace == "" {
+		return
+	}
+	dTokens, dCost := runTokens-b.chargedTokens, cost-b.chargedCost
+	day := database.BudgetDay(b.now())
+	if dTokens <= 0 && dCost <= 0 && day == b.workspace.Day {
+		return
+	}
+	spend, err := database.AddWorkspaceSpend(b.cfg.Workspace, day, dTokens, dCost)
+	if err != nil {
+		return
+	}
+	b.chargedTokens, b.chargedCost = runTokens, cost
+	b.workspace = spend
+}
+
+func (b *budgetTracker) warn(scope string, level, usedTokens int, usedCost float64, maxTokens int, maxCost float64) {
+	subject := "Run"
+	if scope == "workspace" {
+		subject = fmt.Sprintf("Workspace %s", b.cfg.Workspace)
+	}
+	summary := fmt.Sprintf("%s reached %d%% of its budget: $%.4f / %d tokens", subject, level, usedCost, usedTokens)
+	fmt.Printf("[FlowForge] Budget warning: %s\n", summary)
+	_, _ = database.InsertEventWithPayload("budget_warning", "flowforge", fmt.Sprintf("%s budget %d%%", scope, level), database.CurrentRunID(), "", "BUDGET_WARNING", summary, 0, 0, 0, 0, map[string]any{
+		"scope":        scope,
+		"workspace_id": b.cfg.Workspace,
+		"percent":      level,
+		"tokens":       usedTokens,
+		"cost_usd":     usedCost,
+		"max_tokens":   maxTokens,
+		"max_cost_usd": maxCost,
+	})
+}
+
+// usedPercent is the larger of the cost and token shares of their limits.
+func usedPercent(cost, maxCost float64, used, maxTokens int) float64 {
+	percent := 0.0
+	if maxCost > 0 {
+		percent = cost / maxCost * 100
+	}
+	if maxTokens > 0 {
+		percent = max(percent, float64(used)/float64(maxTokens)*100)
+	}
+	return percent
+}
+
+// crossedBudgetLevel returns the highest warning level at or below percent
+// that is above the last one warned about, or 0.
+func crossedBudgetLevel(percent float64, warned int) int {
+	crossed := 0
+	for _, level := range budgetWarnLevels {
+		if level > warned && percent >= float64(level) {
+			crossed = level
+		}
+	}
+	return crossed
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"testing"
+
+	"flowforge/internal/policy"
+	"flowforge/internal/tokens"
+)
+
+func TestCrossedBudgetLevel(t *testing.T) {
+	cases := []struct {
+		percent float64
+		warned  int
+		want    int
+	}{
+		{percent: 10, want: 0},
+		{percent: 50, want: 50},
+		{percent: 79.9, warned: 50, want: 0},
+		{percent: 95, warned: 0, want: 80},
+		{percent: 140, warned: 80, want: 100},
+		{percent: 140, warned: 100, want: 0},
+	}
+	for _, tc := range cases {
+		if got := crossedBudgetLevel(tc.percent, tc.warned); got != tc.want {
+			t.Errorf("crossedBudgetLevel(%.1f, %d) = %d, want %d", tc.percent, tc.warned, got, tc.want)
+		}
+	}
+	if got := usedPercent(0.5, 1, 900, 1000); got != 90 {
+		t.Fatalf("expected the larger of cost and token shares, got %.1f", got)
+	}
+}
+
+func TestBudgetTrackerRunScope(t *testing.T) {
+	limits := policy.Policy{MaxTokens: 1000}
+	tracker := newBudgetTracker(budgetConfig{MaxTokens: 1000}, limits)
+	var sample policy.Telemetry
+	tracker.observe(&sample, tokens.PriceUsage("gpt-4", 0, 600))
+	if sample.RunTokens != 600 || sample.RunCostUSD <= 0 || tracker.runWarned != 50 {
+		t.Fatalf("unexpected sample %+v warned=%d", sample, tracker.runWarned)
+	}
+	tracker.observe(&sample, tokens.PriceUsage("gpt-4", 0, 1000))
+	if tracker.runWarned != 100 {
+		t.Fatalf("expected the 100%% warning, got %d", tracker.runWarned)
+	}
+	if d := evaluateSample(policy.NewThresholdDecider(), sample, limits, false); d.Action != policy.ActionKill || d.ExitReason != policy.ExitReasonBudget {
+		t.Fatalf("expected a budget kill, got %+v", d)
+	}
+}
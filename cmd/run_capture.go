@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"flowforge/internal/runlog"
+
+	"github.com/spf13/viper"
+)
+
+type outputCaptureConfig struct {
+	Enabled  bool
+	MaxBytes int64
+	MaxFiles int
+	KeepRuns int
+}
+
+// resolveOutputCaptureConfig reads the capture keys. Capture is on unless
+// output-capture is explicitly false.
+func resolveOutputCaptureConfig() outputCaptureConfig {
+	cfg := outputCaptureConfig{
+		Enabled:  true,
+		MaxBytes: runlog.DefaultMaxBytes,
+		MaxFiles: runlog.DefaultMaxFiles,
+		KeepRuns: runlog.DefaultKeepRuns,
+	}
+	if viper.IsSet("output-capture") {
+		cfg.Enabled = viper.GetBool("output-capture")
+	}
+	if mb := viper.GetInt("output-capture-max-mb"); mb > 0 {
+		cfg.MaxBytes = int64(mb) << 20
+	}
+	if n := viper.GetInt("output-capture-files"); n > 0 {
+		cfg.MaxFiles = n
+	}
+	if n := viper.GetInt("output-capture-keep-runs"); n > 0 {
+		cfg.KeepRuns = n
+	}
+	return cfg
+}
+
+// outputCapture tees the child's stdout/stderr into the run's log. A nil
+// *outputCapture passes writers through unchanged.
+type outputCapture struct {
+	w      *runlog.Writer
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// openOutputCapture starts capturing for runID. Failure only disables
+// capture; supervision continues without it.
+func openOutputCapture(cfg outputCaptureConfig, runID string) *outputCapture {
+	if !cfg.Enabled {
+		return nil
+	}
+	w, err := runlog.Open(runID, runlog.Options{MaxBytes: cfg.MaxBytes, MaxFiles: cfg.MaxFiles, KeepRuns: cfg.KeepRuns})
+	if err != nil {
+		fmt.Printf("[FlowForge] Warning: output capture disabled: %v\n", err)
+		return nil
+	}
+	fmt.Printf("[FlowForge] Output capture: %s (flowforge logs %s)\n", w.Dir(), runID)
+	return &outputCapture{w: w, stdout: w.Stream(runlog.StreamStdout), stderr: w.Stream(runlog.StreamStderr)}
+}
+
+func (c *outputCapture) writers(stdout, stderr io.Writer) (io.Writer, io.Writer) {
+	if c == nil {
+		return stdout, stderr
+	}
+	return io.MultiWriter(stdout, c.stdout), io.MultiWriter(stderr, c.stderr)
+}
+
+func (c *outputCapture) Close() {
+	if c != nil {
+		_ = c.w.Close()
+	}
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"flowforge/internal/cgroup"
+
+	"github.com/spf13/viper"
+)
+
+var useCgroup bool
+var cgroupParent string
+
+// cgroupProfileKeys are copied from the active profile by resolveProfile.
+var cgroupProfileKeys = []string{"cgroup-memory-max-mb", "cgroup-cpu-max-percent", "cgroup-pids-max"}
+
+type cgroupRunConfig struct {
+	Enabled bool
+	Parent  string
+	Limits  cgroup.Limits
+}
+
+// resolveCgroupConfig reads cgroup mode settings. memory.max falls back to
+// max-memory-mb so the polling choke and the kernel limit agree by default.
+func resolveCgroupConfig() cgroupRunConfig {
+	cfg := cgroupRunConfig{
+		Enabled: useCgroup || viper.GetBool("cgroup"),
+		Parent:  strings.TrimSpace(cgroupParent),
+	}
+	if cfg.Parent == "" {
+		cfg.Parent = strings.TrimSpace(viper.GetString("cgroup-parent"))
+	}
+	memMB := viper.GetFloat64("cgroup-memory-max-mb")
+	if memMB <= 0 {
+		memMB = viper.GetFloat64("max-memory-mb")
+	}
+	if memMB > 0 {
+		cfg.Limits.MemoryMaxBytes = int64(memMB * 1024 * 1024)
+	}
+	cfg.Limits.CPUMaxPercent = viper.GetFloat64("cgroup-cpu-max-percent")
+	cfg.Limits.PidsMax = viper.GetInt64("cgroup-pids-max")
+	return cfg
+}
+
+// createRunCgroup makes the leaf group for one generation of a run. Without
+// --cgroup-parent the group goes under FlowForge's own cgroup, which works
+// only when FlowForge is alone there: it moves itself to a supervisor leaf so
+// controllers can be enabled for the run groups beside it.
+func createRunCgroup(cfg cgroupRunConfig, runID string, generation int) (*cgroup.Group, error) {
+	parent := cfg.Parent
+	if parent == "" {
+		root, err := cgroup.DelegatedRoot()
+		if err != nil {
+			return nil, fmt.Errorf("%w; pass --cgroup-parent with a delegated cgroup v2 directory", err)
+		}
+		if err := cgroup.EnableControllers(root); err != nil {
+			return nil, fmt.Errorf("%w; pass --cgroup-parent with a delegated cgroup that has memory, cpu and pids in cgroup.subtree_control", err)
+		}
+		parent = root
+	}
+	return cgroup.Create(parent, fmt.Sprintf("flowforge-%s-%d", runID, generation), cfg.Limits)
+}
+
+func formatCgroupLimits(l cgroup.Limits) string {
+	mem := "max"
+	if l.MemoryMaxBytes > 0 {
+		mem = fmt.Sprintf("%.0fMB", float64(l.MemoryMaxBytes)/1024/1024)
+	}
+	cpu := "max"
+	if l.CPUMaxPercent > 0 {
+		cpu = fmt.Sprintf("%.0f%%", l.CPUMaxPercent)
+	}
+	pids := "max"
+	if l.PidsMax > 0 {
+		pids = fmt.Sprintf("%d", l.PidsMax)
+	}
+	return fmt.Sprintf("memory.max=%s cpu.max=%s pids.max=%s", mem, cpu, pids)
+}
+
+// cgroupThrottleRatio is the share of wall time between two snapshots that
+// the group spent CPU-throttled.
+func cgroupThrottleRatio(prev, cur cgroup.Stats, elapsedUsec int64) float64 {
+	if elapsedUsec <= 0 {
+		return 0
+	}
+	delta := cur.CPUThrottledUsec - prev.CPUThrottledUsec
+	if delta <= 0 {
+		return 0
+	}
+	ratio := float64(delta) / float64(elapsedUsec)
+	if ratio > 1 {
+		ratio = 1
+	}
+	return ratio
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"testing"
+
+	"flowforge/internal/cgroup"
+
+	"github.com/spf13/viper"
+)
+
+func TestResolveCgroupConfigFallsBackToMaxMemory(t *testing.T) {
+	oldUse, oldParent := useCgroup, cgroupParent
+	t.Cleanup(func() {
+		useCgroup, cgroupParent = oldUse, oldParent
+		viper.Reset()
+	})
+	viper.Reset()
+	useCgroup = false
+	cgroupParent = ""
+	viper.Set("cgroup", true)
+	viper.Set("cgroup-parent", "/sys/fs/cgroup/flowforge")
+	viper.Set("max-memory-mb", 512)
+	viper.Set("cgroup-pids-max", 128)
+
+	cfg := resolveCgroupConfig()
+	if !cfg.Enabled || cfg.Parent != "/sys/fs/cgroup/flowforge" {
+		t.Fatalf("unexpected cgroup config: %+v", cfg)
+	}
+	if cfg.Limits.MemoryMaxBytes != 512<<20 {
+		t.Fatalf("expected memory.max from max-memory-mb, got %d", cfg.Limits.MemoryMaxBytes)
+	}
+	if cfg.Limits.PidsMax != 128 || cfg.Limits.CPUMaxPercent != 0 {
+		t.Fatalf("unexpected limits: %+v", cfg.Limits)
+	}
+
+	viper.Set("cgroup-memory-max-mb", 1024)
+	if got := resolveCgroupConfig().Limits.MemoryMaxBytes; got != 1024<<20 {
+		t.Fatalf("expected cgroup-memory-max-mb to win, got %d", got)
+	}
+}
+
+func TestResolveProfileCopiesCgroupLimits(t *testing.T) {
+	oldProfile := profileName
+	t.Cleanup(func() {
+		profileName = oldProfile
+		viper.Reset()
+	})
+	viper.Reset()
+	profileName = "heavy"
+	viper.Set("profiles.heavy.max-cpu", 45.0)
+	viper.Set("profiles.heavy.cgroup-cpu-max-percent", 200)
+
+	resolveProfile()
+	if got := viper.GetFloat64("cgroup-cpu-max-percent"); got != 200 {
+		t.Fatalf("expected profile cgroup-cpu-max-percent 200, got %v", got)
+	}
+}
+
+func TestCgroupThrottleRatio(t *testing.T) {
+	prev := cgroup.Stats{CPUThrottledUsec: 1000}
+	cur := cgroup.Stats{CPUThrottledUsec: 251000}
+	if got := cgroupThrottleRatio(prev, cur, 500000); got != 0.5 {
+		t.Fatalf("expected 0.5, got %v", got)
+	}
+	if got := cgroupThrottleRatio(cur, prev, 500000); got != 0 {
+		t.Fatalf("expected 0 for a counter reset, got %v", got)
+	}
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"flowforge/internal/supervisor"
+
+	"github.com/spf13/viper"
+)
+
+var rlimitFlags []string
+var envAllowFlags []string
+var envDenyFlags []string
+var envSetFlags []string
+
+type rlimitKey struct {
+	name     string // config key suffix and --rlimit name
+	resource string
+	scale    uint64
+}
+
+var rlimitKeys = []rlimitKey{
+	{name: "as-mb", resource: supervisor.RLimitAS, scale: 1024 * 1024},
+	{name: "nofile", resource: supervisor.RLimitNOFILE, scale: 1},
+	{name: "nproc", resource: supervisor.RLimitNPROC, scale: 1},
+	{name: "cpu-seconds", resource: supervisor.RLimitCPU, scale: 1},
+	{name: "fsize-mb", resource: supervisor.RLimitFSIZE, scale: 1024 * 1024},
+}
+
+// processLimitProfileKeys are copied from the active profile by resolveProfile.
+var processLimitProfileKeys = []string{
+	"rlimit-as-mb", "rlimit-nofile", "rlimit-nproc", "rlimit-cpu-seconds", "rlimit-fsize-mb",
+	"env-allow", "env-deny", "env-set",
+}
+
+// resolveProcessLimits merges rlimit/env config with the --rlimit and --env*
+// flags. Flags win over config; a zero rlimit means "inherit".
+func resolveProcessLimits() (supervisor.Limits, error) {
+	values := make(map[string]uint64, len(rlimitKeys))
+	for _, k := range rlimitKeys {
+		if v := viper.GetInt64("rlimit-" + k.name); v > 0 {
+			values[k.name] = uint64(v)
+		}
+	}
+	for _, raw := range rlimitFlags {
+		name, value, ok := strings.Cut(raw, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !ok || lookupRLimitKey(name) == nil {
+			return supervisor.Limits{}, fmt.Errorf("invalid --rlimit %q (expected as-mb|nofile|nproc|cpu-seconds|fsize-mb=N)", raw)
+		}
+		n, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return supervisor.Limits{}, fmt.Errorf("invalid --rlimit %q: %w", raw, err)
+		}
+		values[name] = n
+	}
+
+	var limits supervisor.Limits
+	for _, k := range rlimitKeys {
+		v, ok := values[k.name]
+		if !ok || v == 0 {
+			continue
+		}
+		limits.RLimits = append(limits.RLimits, supervisor.RLimit{Resource: k.resource, Soft: v * k.scale, Hard: v * k.scale})
+	}
+
+	limits.Env.Allow = envAllowFlags
+	if len(limits.Env.Allow) == 0 {
+		limits.Env.Allow = viper.GetStringSlice("env-allow")
+	}
+	limits.Env.Deny = envDenyFlags
+	if len(limits.Env.Deny) == 0 {
+		limits.Env.Deny = viper.GetStringSlice("env-deny")
+	}
+	set := make(map[string]string)
+	for key, value := range viper.GetStringMapString("env-set") {
+		// Viper lower-cases map keys; environment names are conventionally upper case.
+		set[strings.ToUpper(key)] = value
+	}
+	for _, raw := range envSetFlags {
+		key, value, ok := strings.Cut(raw, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return supervisor.Limits{}, fmt.Errorf("invalid --env %q (expected KEY=VALUE)", raw)
+		}
+		set[strings.TrimSpace(key)] = value
+	}
+	if len(set) > 0 {
+		limits.Env.Set = set
+	}
+	return limits, nil
+}
+
+func lookupRLimitKey(name string) *rlimitKey {
+	for i := range rlimitKeys {
+		if rlimitKeys[i].name == name {
+			return &rlimitKeys[i]
+		}
+	}
+	return nil
+}
+
+func formatProcessLimits(l supervisor.Limits) string {
+	parts := make([]string, 0, len(l.RLimits)+3)
+	for _, r := range l.RLimits {
+		parts = append(parts, fmt.Sprintf("%s=%d", r.Resource, r.Hard))
+	}
+	if len(l.Env.Allow) > 0 {
+		parts = append(parts, "env-allow="+strings.Join(l.Env.Allow, ","))
+	}
+	if len(l.Env.Deny) > 0 {
+		parts = append(parts, "env-deny="+strings.Join(l.Env.Deny, ","))
+	}
+	if len(l.Env.Set) > 0 {
+		keys := make([]string, 0, len(l.Env.Set))
+		for k := range l.Env.Set {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		parts = append(parts, "env-set="+strings.Join(keys, ","))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"flowforge/internal/supervisor"
+
+	"github.com/spf13/viper"
+)
+
+func withProcessLimitGlobals(t *testing.T) {
+	t.Helper()
+	oldRlimits, oldAllow, oldDeny, oldSet := rlimitFlags, envAllowFlags, envDenyFlags, envSetFlags
+	t.Cleanup(func() {
+		rlimitFlags, envAllowFlags, envDenyFlags, envSetFlags = oldRlimits, oldAllow, oldDeny, oldSet
+		viper.Reset()
+	})
+	viper.Reset()
+	rlimitFlags, envAllowFlags, envDenyFlags, envSetFlags = nil, nil, nil, nil
+}
+
+func TestResolveProcessLimitsMergesConfigAndFlags(t *testing.T) {
+	withProcessLimitGlobals(t)
+	viper.Set("rlimit-nofile", 2048)
+	viper.Set("rlimit-as-mb", 512)
+	viper.Set("env-deny", []string{"AWS_*"})
+	viper.Set("env-set", map[string]interface{}{"pythonunbuffered": "1"})
+	rlimitFlags = []string{"nofile=256", "cpu-seconds=30"}
+	envSetFlags = []string{"MODE=test"}
+
+	limits, err := resolveProcessLimits()
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	want := []supervisor.RLimit{
+		{Resource: supervisor.RLimitAS, Soft: 512 << 20, Hard: 512 << 20},
+		{Resource: supervisor.RLimitNOFILE, Soft: 256, Hard: 256},
+		{Resource: supervisor.RLimitCPU, Soft: 30, Hard: 30},
+	}
+	if !reflect.DeepEqual(limits.RLimits, want) {
+		t.Fatalf("rlimits = %+v, want %+v", limits.RLimits, want)
+	}
+	if !reflect.DeepEqual(limits.Env.Deny, []string{"AWS_*"}) {
+		t.Fatalf("env deny = %v", limits.Env.Deny)
+	}
+	if !reflect.DeepEqual(limits.Env.Set, map[string]string{"PYTHONUNBUFFERED": "1", "MODE": "test"}) {
+		t.Fatalf("env set = %v", limits.Env.Set)
+	}
+}
+
+func TestResolveProcessLimitsRejectsUnknownRLimit(t *testing.T) {
+	withProcessLimitGlobals(t)
+	rlimitFlags = []string{"stack=10"}
+	if _, err := resolveProcessLimits(); err == nil {
+		t.Fatal("expected error for unknown --rlimit")
+	}
+	rlimitFlags = nil
+	envSetFlags = []string{"NOEQUALS"}
+	if _, err := resolveProcessLimits(); err == nil {
+		t.Fatal("expected error for malformed --env")
+	}
+}
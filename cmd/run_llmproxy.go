@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"flowforge/internal/database"
+	"flowforge/internal/llmproxy"
+	"flowforge/internal/policy"
+	"flowforge/internal/supervisor"
+
+	"github.com/spf13/viper"
+)
+
+var llmProxyFlag bool
+
+// llmProxyProfileKeys are copied from the active profile by resolveProfile.
+var llmProxyProfileKeys = []string{"max-prompt-repetition"}
+
+// llmProxyEnvKeys point OpenAI SDKs at the proxy. OPENAI_API_BASE is read by
+// openai-python < 1.0 and some LangChain versions.
+var llmProxyEnvKeys = []string{"OPENAI_BASE_URL", "OPENAI_API_BASE"}
+
+type llmProxyConfig struct {
+	Enabled      bool
+	Upstream     string
+	RepeatWindow int
+}
+
+// resolveLLMProxyConfig defaults the upstream to the OPENAI_BASE_URL the
+// command would otherwise have used.
+func resolveLLMProxyConfig() llmProxyConfig {
+	cfg := llmProxyConfig{
+		Enabled:      llmProxyFlag || viper.GetBool("llm-proxy"),
+		Upstream:     strings.TrimSpace(viper.GetString("llm-proxy-upstream")),
+		RepeatWindow: viper.GetInt("llm-proxy-repeat-window"),
+	}
+	if cfg.Upstream == "" {
+		cfg.Upstream = strings.TrimSpace(os.Getenv("OPENAI_BASE_URL"))
+	}
+	if cfg.Upstream == "" {
+		cfg.Upstream = llmproxy.DefaultUpstream
+	}
+	return cfg
+}
+
+func applyLLMProxyLimits(p policy.Policy) policy.Policy {
+	p.MaxPromptRepetition = viper.GetInt("max-prompt-repetition")
+	return p
+}
+
+// llmProxyRun is the proxy for one run; it outlives restarts so usage and
+// prompt repetition carry across generations. A nil *llmProxyRun means the
+// proxy is off and every method is a no-op.
+type llmProxyRun struct {
+	proxy *llmproxy.Proxy
+}
+
+func startLLMProxy(cfg llmProxyConfig) (*llmProxyRun, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	p, err := llmproxy.Start(llmproxy.Options{
+		Upstream:     cfg.Upstream,
+		RepeatWindow: cfg.RepeatWindow,
+		OnRecord:     recordLLMRequest,
+	})
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("[FlowForge] LLM proxy: %s -> %s (OPENAI_BASE_URL injected)\n", p.BaseURL(), p.Upstream())
+	return &llmProxyRun{proxy: p}, nil
+}
+
+// recordLLMRequest stores one proxied request as a run event.
+func recordLLMRequest(rec llmproxy.Record) {
+	reason := "llm request"
+	if rec.Error != "" {
+		reason = rec.Error
+	}
+	summary := fmt.Sprintf("model=%s status=%d prompt_tokens=%d completion_tokens=%d latency_ms=%d",
+		withFallback(rec.Model, "unknown"), rec.Status, rec.PromptTokens, rec.CompletionTokens, rec.LatencyMS)
+	_, _ = database.InsertEventWithPayload("llm_request", "llm-proxy", reason, database.CurrentRunID(), "", "LLM_REQUEST", summary, 0, 0, 0, 0, rec)
+}
+
+// limits adds the proxy URL to l.Env.Set. The supervisor applies Set after
+// env-allow and env-deny and over any env-set value, so the command reaches
+// the proxy under every environment policy.
+func (r *llmProxyRun) limits(l supervisor.Limits) supervisor.Limits {
+	if r == nil {
+		return l
+	}
+	set := make(map[string]string, len(l.Env.Set)+len(llmProxyEnvKeys))
+	for key, value := range l.Env.Set {
+		set[key] = value
+	}
+	for _, key := range llmProxyEnvKeys {
+		set[key] = r.proxy.BaseURL()
+	}
+	l.Env.Set = set
+	return l
+}
+
+// usage is the metered usage so far, when the proxy is on.
+func (r *llmProxyRun) usage() (llmproxy.Usage, bool) {
+	if r == nil {
+		return llmproxy.Usage{}, false
+	}
+	return r.proxy.Usage(), true
+}
+
+func (r *llmProxyRun) applySignals(t *policy.Telemetry) {
+	if r == nil {
+		return
+	}
+	t.PromptSignature, t.PromptRepetition = r.proxy.RepeatedPrompt()
+}
+
+func (r *llmProxyRun) Close() {
+	if r == nil {
+		return
+	}
+	_ = r.proxy.Close()
+	u := r.proxy.Usage()
+	fmt.Printf("[FlowForge] LLM usage: %d requests (%d failed), %d prompt + %d completion tokens, last model %s\n",
+		u.Requests, u.Errors, u.PromptTokens, u.CompletionTokens, withFallback(u.Model, "unknown"))
+}
+
+// formatPromptEvidence is recorded on PROMPT_LOOP_DETECTED incidents.
+func formatPromptEvidence(t policy.Telemetry) string {
+	return fmt.Sprintf("prompt %s (x%d)", t.PromptSignature, t.PromptRepetition)
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+
+	"flowforge/internal/supervisor"
+
+	"github.com/spf13/viper"
+)
+
+func TestLLMProxyURLSurvivesEnvPolicy(t *testing.T) {
+	withProcessLimitGlobals(t)
+	viper.Set("env-allow", []string{"PATH"})
+	viper.Set("env-set", map[string]interface{}{"openai_base_url": "https://elsewhere.example/v1", "mode": "test"})
+	t.Setenv("OPENAI_API_BASE", "https://api.openai.com/v1")
+
+	proxy, err := startLLMProxy(llmProxyConfig{Enabled: true, Upstream: "http://127.0.0.1:9/v1"})
+	if err != nil {
+		t.Fatalf("start proxy: %v", err)
+	}
+	defer proxy.Close()
+	limits, err := resolveProcessLimits()
+	if err != nil {
+		t.Fatalf("resolve limits: %v", err)
+	}
+	limits = proxy.limits(limits)
+
+	cmd := exec.Command("sh", "-c", `printf '%s|%s|%s|%s' "$OPENAI_BASE_URL" "$OPENAI_API_BASE" "$MODE" "$HOME"`)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	sup := supervisor.New(cmd)
+	sup.SetLimits(limits)
+	if err := sup.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if err := sup.Wait(); err != nil {
+		t.Fatalf("wait: %v", err)
+	}
+
+	base := proxy.proxy.BaseURL()
+	if want := base + "|" + base + "|test|"; out.String() != want {
+		t.Fatalf("expected the proxy URL under env-allow and env-set, got %q want %q", out.String(), want)
+	}
+	if (*llmProxyRun)(nil).limits(supervisor.Limits{}).Env.Set != nil {
+		t.Fatal("expected limits to be untouched without the proxy")
+	}
+}
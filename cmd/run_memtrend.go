@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"flowforge/internal/policy"
+
+	"github.com/spf13/viper"
+)
+
+const defaultMemoryTrendWindow = 2 * time.Minute
+
+// memoryTrendProfileKeys are copied from the active profile by resolveProfile.
+var memoryTrendProfileKeys = []string{"memory-trend-window-seconds", "memory-projection-mb", "memory-projection-seconds"}
+
+type memoryTrendConfig struct {
+	Window       time.Duration // regression window for the RSS series
+	ProjectionMB float64
+	Horizon      time.Duration // 0 disables trend-based action
+}
+
+func resolveMemoryTrendConfig() memoryTrendConfig {
+	cfg := memoryTrendConfig{
+		Window:       time.Duration(viper.GetInt("memory-trend-window-seconds")) * time.Second,
+		ProjectionMB: viper.GetFloat64("memory-projection-mb"),
+		Horizon:      time.Duration(viper.GetInt("memory-projection-seconds")) * time.Second,
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = defaultMemoryTrendWindow
+	}
+	return cfg
+}
+
+func (c memoryTrendConfig) applyTo(p policy.Policy) policy.Policy {
+	p.MemoryProjectionMB = c.ProjectionMB
+	p.MemoryProjectionWindow = c.Horizon
+	return p
+}
+
+// formatMemoryTrendEvidence is recorded on MEMORY_GROWTH_DETECTED incidents.
+func formatMemoryTrendEvidence(t policy.Telemetry) string {
+	return fmt.Sprintf("memory=%.0fMB growth=%.1fMB/min time_to_limit=%s", t.MemoryMB, t.MemoryGrowthMBPerMin, t.MemoryTimeToLimit.Round(time.Second))
+}
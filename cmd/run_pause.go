@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"time"
+
+	"flowforge/internal/api"
+
+	"github.com/spf13/viper"
+)
+
+var pauseOnBreach bool
+var pauseTimeout time.Duration
+
+// pauseOnBreachConfig freezes the command on a breach instead of killing it,
+// so an operator can inspect it and resume or kill it through the API.
+type pauseOnBreachConfig struct {
+	Enabled bool
+	// Timeout is how long a pause may last before the run is killed.
+	Timeout time.Duration
+}
+
+func resolvePauseOnBreachConfig() pauseOnBreachConfig {
+	cfg := pauseOnBreachConfig{
+		Enabled: pauseOnBreach || viper.GetBool("pause-on-breach"),
+		Timeout: pauseTimeout,
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = viper.GetDuration("pause-timeout")
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = api.DefaultPauseTimeout()
+	}
+	return cfg
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import "flowforge/internal/policy"
+
+// preWindowLimits report limits that need no scored log lines. When one is
+// set the engine runs from the first tick: a command that hangs, leaks or
+// loops after a few lines never fills the log window, and spend does not
+// wait for it.
+var preWindowLimits = []func(policy.Policy) bool{
+	policy.BudgetConfigured,
+	policy.StallConfigured,
+	policy.PromptLoopConfigured,
+	policy.MemoryTrendConfigured,
+	policy.ProcessTreeConfigured,
+}
+
+func preWindowChecksConfigured(p policy.Policy) bool {
+	for _, configured := range preWindowLimits {
+		if configured(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluateSample runs the selected engine on one sample. Before the window
+// fills the sample is marked LogWindowPending, so the engine ignores the log
+// scores but still applies its other limits, the policy file's rules and
+// the rollout mode.
+func evaluateSample(d policy.Decider, t policy.Telemetry, p policy.Policy, windowFull bool) policy.Decision {
+	t.LogWindowPending = !windowFull
+	return d.Evaluate(t, p)
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"bufio"
+	"os/exec"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"flowforge/internal/policy"
+	"flowforge/internal/sysmon"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+func TestPreWindowSamplesGoThroughTheEngine(t *testing.T) {
+	p := policy.Policy{MaxPromptRepetition: 3, MaxOutputIdle: time.Minute, MinLogEntropy: 0.2}
+	if !preWindowChecksConfigured(p) {
+		t.Fatal("expected prompt and stall limits to enable pre-window checks")
+	}
+	if preWindowChecksConfigured(policy.Policy{MaxCPUPercent: 60}) {
+		t.Fatal("CPU and log limits need a full window")
+	}
+
+	engine := policy.NewThresholdDecider()
+	out := evaluateSample(engine, policy.Telemetry{PromptRepetition: 4}, p, false)
+	if out.Action != policy.ActionKill || out.ExitReason != policy.ExitReasonPromptLoop {
+		t.Fatalf("expected a prompt loop kill before the window fills, got %+v", out)
+	}
+	out = evaluateSample(engine, policy.Telemetry{PromptRepetition: 2, OutputIdleFor: 2 * time.Minute}, p, false)
+	if out.Action != policy.ActionKill || out.ExitReason != policy.ExitReasonStall {
+		t.Fatalf("expected a stall kill, got %+v", out)
+	}
+	// The unscored entropy of a partial window is not a breach.
+	if out := evaluateSample(engine, policy.Telemetry{PromptRepetition: 2}, p, false); out.Action != policy.ActionContinue {
+		t.Fatalf("expected CONTINUE below every limit, got %+v", out)
+	}
+
+	tree := policy.Policy{MaxChildProcesses: 50}
+	if out := evaluateSample(engine, policy.Telemetry{ChildProcesses: 200}, tree, false); out.Action != policy.ActionKill || out.ExitReason != policy.ExitReasonForkBomb {
+		t.Fatalf("expected a fork bomb kill before the window fills, got %+v", out)
+	}
+}
+
+func TestPreWindowStallFollowsPolicyFileAction(t *testing.T) {
+	rs, err := policy.ParseRuleSet([]byte(`version: 1
+name: alert-on-stall
+thresholds:
+  max_output_idle: 30s
+rules:
+  - name: stall
+    when: max_output_idle > 0 && output_idle_for >= max_output_idle
+    action: alert
+`))
+	if err != nil {
+		t.Fatalf("parse policy: %v", err)
+	}
+	p := rs.ApplyThresholds(policy.Policy{MaxCPUPercent: 90, MinLogEntropy: 0.2})
+	if !preWindowChecksConfigured(p) {
+		t.Fatal("expected the policy file's stall threshold to enable pre-window checks")
+	}
+
+	out := evaluateSample(policy.NewRuleDeciderFor(rs), policy.Telemetry{OutputIdleFor: time.Minute}, p, false)
+	if out.Action != policy.ActionAlert {
+		t.Fatalf("an alert-only stall rule must not kill before the window fills, got %+v", out)
+	}
+
+	p.RolloutMode = policy.RolloutShadow
+	out = evaluateSample(policy.NewThresholdDecider(), policy.Telemetry{OutputIdleFor: time.Minute}, p, false)
+	if out.Action != policy.ActionLogOnly || out.IntendedAction != policy.ActionKill {
+		t.Fatalf("shadow mode must hold for pre-window stalls, got %+v", out)
+	}
+}
+
+func TestPreWindowCatchesQuietMemoryLeakFixture(t *testing.T) {
+	const logWindow = 10
+	cmd := exec.Command("python3", fixtureScriptPath(t, "memory_leaker.py"), "--timeout", "10", "--chunk-mb", "8", "--report-every", "50")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatalf("stdout pipe: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Skipf("python3 unavailable: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+	var lines atomic.Int64
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			lines.Add(1)
+		}
+	}()
+	proc, err := process.NewProcess(int32(cmd.Process.Pid))
+	if err != nil {
+		t.Fatalf("open fixture process: %v", err)
+	}
+
+	trend := sysmon.NewMemoryTrend(2 * time.Second)
+	var p policy.Policy
+	decision := policy.Decision{Action: policy.ActionContinue}
+	deadline := time.Now().Add(6 * time.Second)
+	for decision.Action == policy.ActionContinue && time.Now().Before(deadline) {
+		time.Sleep(200 * time.Millisecond)
+		info, err := proc.MemoryInfo()
+		if err != nil {
+			t.Fatalf("read fixture memory: %v", err)
+		}
+		memMB := float64(info.RSS) / 1024.0 / 1024.0
+		if p.MemoryProjectionMB == 0 {
+			// 2GB of headroom: a leak of 8MB per 200ms reaches it in about a minute.
+			p = policy.Policy{MemoryProjectionMB: memMB + 2048, MemoryProjectionWindow: 5 * time.Minute}
+		}
+		trend.Add(time.Now(), memMB)
+		growth, _ := trend.SlopeMBPerMin()
+		decision = evaluateSample(policy.NewThresholdDecider(), policy.Telemetry{MemoryMB: memMB, MemoryGrowthMBPerMin: growth}, p, false)
+	}
+
+	if decision.Action != policy.ActionKill || decision.ExitReason != policy.ExitReasonMemoryGrowth {
+		t.Fatalf("expected the leak to be caught before the log window fills, got %+v", decision)
+	}
+	if n := lines.Load(); n >= logWindow {
+		t.Fatalf("fixture printed %d lines; it must stay under the %d-line window", n, logWindow)
+	}
+}
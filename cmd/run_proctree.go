@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"fmt"
+
+	"flowforge/internal/policy"
+	"flowforge/internal/sysmon"
+
+	"github.com/spf13/viper"
+)
+
+// processTreeProfileKeys are copied from the active profile by resolveProfile.
+var processTreeProfileKeys = []string{"max-child-processes", "max-zombie-processes"}
+
+func applyProcessTreeLimits(p policy.Policy) policy.Policy {
+	p.MaxChildProcesses = viper.GetInt("max-child-processes")
+	p.MaxZombieProcesses = viper.GetInt("max-zombie-processes")
+	return p
+}
+
+func applyTreeStats(t *policy.Telemetry, s sysmon.TreeStats) {
+	t.ChildProcesses = s.Children
+	t.ZombieProcesses = s.Zombies
+	t.OpenFDs = s.OpenFDs
+	t.Threads = s.Threads
+}
+
+// formatProcessTreeEvidence is recorded on FORK_BOMB_DETECTED incidents.
+func formatProcessTreeEvidence(t policy.Telemetry) string {
+	return fmt.Sprintf("children=%d zombies=%d threads=%d open_fds=%d", t.ChildProcesses, t.ZombieProcesses, t.Threads, t.OpenFDs)
+}
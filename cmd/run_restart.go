@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"flowforge/internal/api"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	restartBackoffExp   = "exp"
+	restartBackoffFixed = "fixed"
+	restartBackoffNone  = "none"
+
+	defaultMaxRestarts    = 3
+	defaultRestartDelay   = time.Second
+	maxRestartBackoffWait = time.Minute
+)
+
+var restartOnBreach bool
+var maxRestarts int
+var restartBackoff string
+var restartDelay time.Duration
+
+// restartOnBreachConfig controls in-process relaunch of the supervised command
+// after a policy RESTART decision.
+type restartOnBreachConfig struct {
+	Enabled     bool
+	MaxRestarts int
+	Backoff     string
+	Delay       time.Duration
+}
+
+func resolveRestartOnBreachConfig() restartOnBreachConfig {
+	cfg := restartOnBreachConfig{
+		Enabled:     restartOnBreach || viper.GetBool("restart-on-breach"),
+		MaxRestarts: maxRestarts,
+		Backoff:     strings.ToLower(strings.TrimSpace(restartBackoff)),
+		Delay:       restartDelay,
+	}
+	if cfg.MaxRestarts < 0 {
+		if viper.IsSet("max-restarts") {
+			cfg.MaxRestarts = viper.GetInt("max-restarts")
+		} else {
+			cfg.MaxRestarts = defaultMaxRestarts
+		}
+	}
+	if cfg.MaxRestarts < 0 {
+		cfg.MaxRestarts = 0
+	}
+	if cfg.Backoff == "" {
+		cfg.Backoff = strings.ToLower(strings.TrimSpace(viper.GetString("restart-backoff")))
+	}
+	switch cfg.Backoff {
+	case restartBackoffExp, restartBackoffFixed, restartBackoffNone:
+	default:
+		cfg.Backoff = restartBackoffExp
+	}
+	if cfg.Delay <= 0 {
+		if viper.IsSet("restart-delay") {
+			cfg.Delay = viper.GetDuration("restart-delay")
+		}
+		if cfg.Delay <= 0 {
+			cfg.Delay = defaultRestartDelay
+		}
+	}
+	return cfg
+}
+
+// restartBackoffDelay returns how long to wait before relaunch number attempt
+// (1-based). Exponential backoff doubles the delay per attempt up to a minute.
+func restartBackoffDelay(cfg restartOnBreachConfig, attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	switch cfg.Backoff {
+	case restartBackoffNone:
+		return 0
+	case restartBackoffFixed:
+		return cfg.Delay
+	}
+	delay := cfg.Delay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= maxRestartBackoffWait {
+			return maxRestartBackoffWait
+		}
+	}
+	if delay > maxRestartBackoffWait {
+		delay = maxRestartBackoffWait
+	}
+	return delay
+}
+
+// reserveBreachRestart decides whether a RESTART decision may relaunch the
+// command. restarts is how many relaunches this run has already done.
+func reserveBreachRestart(cfg restartOnBreachConfig, restarts int) error {
+	if !cfg.Enabled {
+		return fmt.Errorf("restart-on-breach is disabled")
+	}
+	if restarts >= cfg.MaxRestarts {
+		return fmt.Errorf("max restarts reached (%d)", cfg.MaxRestarts)
+	}
+	return api.ReservePolicyRestart(restarts + 1)
+}
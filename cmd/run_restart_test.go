@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func withRestartGlobals(t *testing.T) {
+	t.Helper()
+	oldEnabled := restartOnBreach
+	oldMax := maxRestarts
+	oldBackoff := restartBackoff
+	oldDelay := restartDelay
+	t.Cleanup(func() {
+		restartOnBreach = oldEnabled
+		maxRestarts = oldMax
+		restartBackoff = oldBackoff
+		restartDelay = oldDelay
+		viper.Reset()
+	})
+	viper.Reset()
+	restartOnBreach = false
+	maxRestarts = -1
+	restartBackoff = ""
+	restartDelay = 0
+}
+
+func TestResolveRestartOnBreachConfigDefaults(t *testing.T) {
+	withRestartGlobals(t)
+
+	cfg := resolveRestartOnBreachConfig()
+	if cfg.Enabled {
+		t.Fatal("expected restart-on-breach disabled by default")
+	}
+	if cfg.MaxRestarts != defaultMaxRestarts || cfg.Backoff != restartBackoffExp || cfg.Delay != defaultRestartDelay {
+		t.Fatalf("unexpected defaults: %+v", cfg)
+	}
+}
+
+func TestResolveRestartOnBreachConfigFlagsOverrideConfig(t *testing.T) {
+	withRestartGlobals(t)
+	viper.Set("restart-on-breach", true)
+	viper.Set("max-restarts", 7)
+	viper.Set("restart-backoff", "fixed")
+	maxRestarts = 2
+	restartBackoff = "none"
+
+	cfg := resolveRestartOnBreachConfig()
+	if !cfg.Enabled {
+		t.Fatal("expected restart-on-breach enabled from config")
+	}
+	if cfg.MaxRestarts != 2 {
+		t.Fatalf("expected flag max-restarts 2, got %d", cfg.MaxRestarts)
+	}
+	if cfg.Backoff != restartBackoffNone {
+		t.Fatalf("expected flag backoff none, got %q", cfg.Backoff)
+	}
+}
+
+func TestRestartBackoffDelay(t *testing.T) {
+	exp := restartOnBreachConfig{Backoff: restartBackoffExp, Delay: time.Second}
+	for attempt, want := range map[int]time.Duration{
+		1:  time.Second,
+		2:  2 * time.Second,
+		3:  4 * time.Second,
+		10: maxRestartBackoffWait,
+	} {
+		if got := restartBackoffDelay(exp, attempt); got != want {
+			t.Fatalf("exp attempt %d: expected %s, got %s", attempt, want, got)
+		}
+	}
+
+	fixed := restartOnBreachConfig{Backoff: restartBackoffFixed, Delay: 3 * time.Second}
+	if got := restartBackoffDelay(fixed, 5); got != 3*time.Second {
+		t.Fatalf("fixed: expected 3s, got %s", got)
+	}
+	none := restartOnBreachConfig{Backoff: restartBackoffNone, Delay: 3 * time.Second}
+	if got := restartBackoffDelay(none, 2); got != 0 {
+		t.Fatalf("none: expected 0, got %s", got)
+	}
+}
+
+func TestReserveBreachRestartHonorsMaxRestarts(t *testing.T) {
+	cfg := restartOnBreachConfig{Enabled: true, MaxRestarts: 1}
+	if err := reserveBreachRestart(cfg, 1); err == nil {
+		t.Fatal("expected max-restarts to block the second relaunch")
+	}
+	cfg.Enabled = false
+	if err := reserveBreachRestart(cfg, 0); err == nil {
+		t.Fatal("expected disabled restart-on-breach to block relaunch")
+	}
+}
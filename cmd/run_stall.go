@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"flowforge/internal/policy"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// defaultStallCPUPercent is the CPU usage at or below which a process
+	// counts as idle for stall detection.
+	defaultStallCPUPercent = 1.0
+	// defaultHeartbeatMaxAge applies when a heartbeat file is configured
+	// without an explicit age limit.
+	defaultHeartbeatMaxAge = 60 * time.Second
+)
+
+var stallTimeout time.Duration
+var heartbeatFile string
+var heartbeatTimeout time.Duration
+
+// stallProfileKeys are copied from the active profile by resolveProfile.
+var stallProfileKeys = []string{"stall-output-seconds", "stall-cpu-seconds", "stall-cpu-percent", "heartbeat-max-age-seconds"}
+
+type stallRunConfig struct {
+	OutputIdle      time.Duration
+	CPUIdle         time.Duration
+	CPUIdlePercent  float64
+	HeartbeatFile   string
+	HeartbeatMaxAge time.Duration
+}
+
+// resolveStallConfig reads stall thresholds. --stall-timeout sets both idle
+// limits at once; config can set them separately.
+func resolveStallConfig() stallRunConfig {
+	cfg := stallRunConfig{
+		OutputIdle:     time.Duration(viper.GetInt("stall-output-seconds")) * time.Second,
+		CPUIdle:        time.Duration(viper.GetInt("stall-cpu-seconds")) * time.Second,
+		CPUIdlePercent: defaultStallCPUPercent,
+		HeartbeatFile:  strings.TrimSpace(heartbeatFile),
+	}
+	if stallTimeout > 0 {
+		cfg.OutputIdle = stallTimeout
+		cfg.CPUIdle = stallTimeout
+	}
+	if viper.IsSet("stall-cpu-percent") {
+		cfg.CPUIdlePercent = viper.GetFloat64("stall-cpu-percent")
+	}
+	if cfg.HeartbeatFile == "" {
+		cfg.HeartbeatFile = strings.TrimSpace(viper.GetString("heartbeat-file"))
+	}
+	if cfg.HeartbeatFile != "" {
+		cfg.HeartbeatMaxAge = heartbeatTimeout
+		if cfg.HeartbeatMaxAge <= 0 {
+			cfg.HeartbeatMaxAge = time.Duration(viper.GetInt("heartbeat-max-age-seconds")) * time.Second
+		}
+		if cfg.HeartbeatMaxAge <= 0 {
+			cfg.HeartbeatMaxAge = defaultHeartbeatMaxAge
+		}
+	}
+	return cfg
+}
+
+func (c stallRunConfig) applyTo(p policy.Policy) policy.Policy {
+	p.MaxOutputIdle = c.OutputIdle
+	p.MaxCPUIdle = c.CPUIdle
+	p.MaxHeartbeatAge = c.HeartbeatMaxAge
+	return p
+}
+
+func formatStallPolicy(p policy.Policy, c stallRunConfig) string {
+	parts := make([]string, 0, 3)
+	if p.MaxOutputIdle > 0 {
+		parts = append(parts, fmt.Sprintf("output-idle=%s", p.MaxOutputIdle))
+	}
+	if p.MaxCPUIdle > 0 {
+		parts = append(parts, fmt.Sprintf("cpu-idle=%s (<=%.1f%%)", p.MaxCPUIdle, c.CPUIdlePercent))
+	}
+	if p.MaxHeartbeatAge > 0 {
+		file := c.HeartbeatFile
+		if file == "" {
+			file = "(none)"
+		}
+		parts = append(parts, fmt.Sprintf("heartbeat=%s max-age=%s", file, p.MaxHeartbeatAge))
+	}
+	return strings.Join(parts, " ")
+}
+
+// formatStallEvidence is recorded on STALL_DETECTED incidents in place of the
+// normalized log pattern, which says nothing about a hang.
+func formatStallEvidence(t policy.Telemetry) string {
+	out := fmt.Sprintf("output_idle=%s cpu_idle=%s", t.OutputIdleFor.Round(time.Second), t.CPUIdleFor.Round(time.Second))
+	if t.HeartbeatAge > 0 {
+		out += fmt.Sprintf(" heartbeat_age=%s", t.HeartbeatAge.Round(time.Second))
+	}
+	return out
+}
+
+// stallTracker turns per-tick observations into the idle durations carried
+// in policy.Telemetry. One tracker covers one process generation.
+type stallTracker struct {
+	cfg         stallRunConfig
+	started     time.Time
+	lastCPUBusy time.Time
+}
+
+func newStallTracker(cfg stallRunConfig, now time.Time) *stallTracker {
+	return &stallTracker{cfg: cfg, started: now, lastCPUBusy: now}
+}
+
+// observe fills the stall fields of t for a tick at now.
+func (s *stallTracker) observe(t *policy.Telemetry, now time.Time, cpuPercent float64, lastOutput time.Time) {
+	if cpuPercent > s.cfg.CPUIdlePercent {
+		s.lastCPUBusy = now
+	}
+	if lastOutput.IsZero() || lastOutput.Before(s.started) {
+		lastOutput = s.started
+	}
+	t.OutputIdleFor = nonNegative(now.Sub(lastOutput))
+	t.CPUIdleFor = nonNegative(now.Sub(s.lastCPUBusy))
+	t.HeartbeatAge = s.heartbeatAge(now)
+}
+
+// heartbeatAge is the age of the heartbeat file. A file that does not exist
+// yet ages from the start of the generation, so a command that never writes
+// one is still caught.
+func (s *stallTracker) heartbeatAge(now time.Time) time.Duration {
+	if s.cfg.HeartbeatFile == "" {
+		return 0
+	}
+	since := s.started
+	if info, err := os.Stat(s.cfg.HeartbeatFile); err == nil && info.ModTime().After(since) {
+		since = info.ModTime()
+	}
+	return nonNegative(now.Sub(since))
+}
+
+func nonNegative(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"flowforge/internal/policy"
+
+	"github.com/spf13/viper"
+)
+
+func withStallGlobals(t *testing.T) {
+	t.Helper()
+	oldTimeout, oldFile, oldHeartbeat := stallTimeout, heartbeatFile, heartbeatTimeout
+	t.Cleanup(func() {
+		stallTimeout, heartbeatFile, heartbeatTimeout = oldTimeout, oldFile, oldHeartbeat
+		viper.Reset()
+	})
+	viper.Reset()
+	stallTimeout, heartbeatFile, heartbeatTimeout = 0, "", 0
+}
+
+func TestResolveStallConfig(t *testing.T) {
+	withStallGlobals(t)
+
+	if cfg := resolveStallConfig(); policy.StallConfigured(cfg.applyTo(policy.Policy{})) {
+		t.Fatalf("stall detection must be off by default: %+v", cfg)
+	}
+
+	viper.Set("stall-output-seconds", 120)
+	viper.Set("stall-cpu-seconds", 90)
+	viper.Set("heartbeat-file", "/tmp/agent.heartbeat")
+	cfg := resolveStallConfig()
+	if cfg.OutputIdle != 2*time.Minute || cfg.CPUIdle != 90*time.Second {
+		t.Fatalf("unexpected idle limits: %+v", cfg)
+	}
+	if cfg.HeartbeatMaxAge != defaultHeartbeatMaxAge || cfg.CPUIdlePercent != defaultStallCPUPercent {
+		t.Fatalf("expected heartbeat and CPU floor defaults: %+v", cfg)
+	}
+
+	stallTimeout = 30 * time.Second
+	heartbeatTimeout = 10 * time.Second
+	cfg = resolveStallConfig()
+	if cfg.OutputIdle != 30*time.Second || cfg.CPUIdle != 30*time.Second || cfg.HeartbeatMaxAge != 10*time.Second {
+		t.Fatalf("expected flags to win: %+v", cfg)
+	}
+}
+
+func TestStallTrackerObserve(t *testing.T) {
+	heartbeat := filepath.Join(t.TempDir(), "hb")
+	start := time.Now()
+	tracker := newStallTracker(stallRunConfig{CPUIdlePercent: 1, HeartbeatFile: heartbeat}, start)
+
+	var sample policy.Telemetry
+	tracker.observe(&sample, start.Add(10*time.Second), 50, time.Time{})
+	if sample.OutputIdleFor != 10*time.Second || sample.CPUIdleFor != 0 {
+		t.Fatalf("unexpected idle after busy tick: %+v", sample)
+	}
+	if sample.HeartbeatAge != 10*time.Second {
+		t.Fatalf("missing heartbeat should age from start, got %s", sample.HeartbeatAge)
+	}
+
+	if err := os.WriteFile(heartbeat, []byte("ok"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	beat := start.Add(15 * time.Second)
+	if err := os.Chtimes(heartbeat, beat, beat); err != nil {
+		t.Fatal(err)
+	}
+	tracker.observe(&sample, start.Add(40*time.Second), 0.5, start.Add(35*time.Second))
+	if sample.OutputIdleFor != 5*time.Second || sample.CPUIdleFor != 30*time.Second {
+		t.Fatalf("unexpected idle after quiet tick: %+v", sample)
+	}
+	if sample.HeartbeatAge != 25*time.Second {
+		t.Fatalf("expected heartbeat age from mtime, got %s", sample.HeartbeatAge)
+	}
+}
@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"time"
+
+	"flowforge/internal/policy"
+	"flowforge/internal/runlog"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// streamRateWindow is the span stderr lines/sec is averaged over.
+	streamRateWindow = 10 * time.Second
+	// exceptionWindow bounds how far back repeated exceptions are counted.
+	exceptionWindow = 5 * time.Minute
+	// maxExceptionHistory caps the remembered exceptions for a noisy run.
+	maxExceptionHistory = 256
+	// maxExceptionSignature truncates long exception messages.
+	maxExceptionSignature = 160
+)
+
+// streamProfileKeys are copied from the active profile by resolveProfile.
+var streamProfileKeys = []string{"max-stderr-lines-per-sec", "max-stderr-repetition", "max-repeated-exceptions"}
+
+func applyStreamLimits(p policy.Policy) policy.Policy {
+	p.MaxStderrLinesPerSec = viper.GetFloat64("max-stderr-lines-per-sec")
+	p.MaxStderrRepetition = viper.GetFloat64("max-stderr-repetition")
+	p.MaxRepeatedExceptions = viper.GetInt("max-repeated-exceptions")
+	return p
+}
+
+// applyStreamSignals fills the per-stream telemetry. Stderr repetition needs
+// a full window of stderr lines, like the blended score.
+func applyStreamSignals(t *policy.Telemetry, observer *LogObserver, logWindow int) {
+	_, t.StderrLinesPerSec = observer.StreamStats(runlog.StreamStderr)
+	if stderrLines := observer.StreamLines(runlog.StreamStderr, logWindow); len(stderrLines) == logWindow {
+		t.StderrRepetition = duplicateLineShare(stderrLines)
+	}
+	t.ExceptionSignature, t.RepeatedExceptions = observer.RepeatedException()
+}
+
+// duplicateLineShare is the share of lines whose normalized form already
+// appeared earlier in the window. Unlike calculateRepetitionScore, which
+// compares against the first line, it also scores multi-line blocks such as
+// a traceback printed over and over.
+func duplicateLineShare(lines []string) float64 {
+	if len(lines) < 2 {
+		return 0
+	}
+	seen := make(map[string]struct{}, len(lines))
+	dups := 0
+	for _, line := range lines {
+		key := NormalizeLog(line)
+		if _, ok := seen[key]; ok {
+			dups++
+		}
+		seen[key] = struct{}{}
+	}
+	return float64(dups) / float64(len(lines)-1)
+}
+
+// formatExceptionEvidence is recorded on REPEATED_EXCEPTION_DETECTED incidents.
+func formatExceptionEvidence(t policy.Telemetry) string {
+	return fmt.Sprintf("%s (x%d)", t.ExceptionSignature, t.RepeatedExceptions)
+}
+
+// lineRing is a bounded ring of the newest lines. It is not safe for
+// concurrent use; LogObserver guards it.
+type lineRing struct {
+	lines    []string
+	capacity int
+	index    int  // Current write index
+	isFull   bool // Whether the buffer has wrapped
+}
+
+func newLineRing(capacity int) *lineRing {
+	return &lineRing{lines: make([]string, capacity), capacity: capacity}
+}
+
+func (r *lineRing) add(line string) {
+	r.lines[r.index] = line
+	r.index++
+	if r.index >= r.capacity {
+		r.index = 0
+		r.isFull = true
+	}
+}
+
+func (r *lineRing) count() int {
+	if r.isFull {
+		return r.capacity
+	}
+	return r.index
+}
+
+func (r *lineRing) last(n int) []string {
+	if n > r.count() {
+		n = r.count()
+	}
+	if n < 0 {
+		n = 0
+	}
+	result := make([]string, 0, n)
+	// Calculate starting point (n lines back from current index)
+	start := (r.index - n + r.capacity) % r.capacity
+	for i := 0; i < n; i++ {
+		result = append(result, r.lines[(start+i)%r.capacity])
+	}
+	return result
+}
+
+// streamState is what LogObserver keeps per output stream.
+type streamState struct {
+	ring    *lineRing
+	partial bytes.Buffer // Partial line buffer
+	rate    lineRate
+}
+
+func newStreamState(capacity int) *streamState {
+	return &streamState{ring: newLineRing(capacity)}
+}
+
+func (s *streamState) lines() int64 { return s.rate.total }
+
+// lineRate counts lines in one-second buckets over streamRateWindow.
+type lineRate struct {
+	buckets [int(streamRateWindow / time.Second)]struct {
+		sec   int64
+		count int
+	}
+	total int64
+}
+
+func (r *lineRate) add(now time.Time) {
+	sec := now.Unix()
+	b := &r.buckets[sec%int64(len(r.buckets))]
+	if b.sec != sec {
+		b.sec, b.count = sec, 0
+	}
+	b.count++
+	r.total++
+}
+
+func (r *lineRate) perSecond(now time.Time) float64 {
+	oldest := now.Unix() - int64(len(r.buckets)) + 1
+	n := 0
+	for _, b := range r.buckets {
+		if b.sec >= oldest {
+			n += b.count
+		}
+	}
+	return float64(n) / streamRateWindow.Seconds()
+}
+
+// exceptionHistory remembers recent exception signatures in arrival order.
+type exceptionHistory struct {
+	seen []exceptionSeen
+}
+
+type exceptionSeen struct {
+	at        time.Time
+	signature string
+}
+
+func newExceptionHistory() *exceptionHistory {
+	return &exceptionHistory{seen: make([]exceptionSeen, 0, 16)}
+}
+
+func (h *exceptionHistory) add(signature string, now time.Time) {
+	if len(h.seen) >= maxExceptionHistory {
+		h.seen = append(h.seen[:0], h.seen[1:]...)
+	}
+	h.seen = append(h.seen, exceptionSeen{at: now, signature: signature})
+}
+
+// top returns the most frequent signature within exceptionWindow; ties go to
+// the most recent.
+func (h *exceptionHistory) top(now time.Time) (string, int) {
+	cutoff := now.Add(-exceptionWindow)
+	counts := make(map[string]int)
+	best, bestCount := "", 0
+	for _, e := range h.seen {
+		if e.at.Before(cutoff) {
+			continue
+		}
+		counts[e.signature]++
+		if c := counts[e.signature]; c >= bestCount {
+			best, bestCount = e.signature, c
+		}
+	}
+	return best, bestCount
+}
+
+var (
+	// A line that is an exception: Python's final traceback line, Node's
+	// "Uncaught TypeError: ...", a bare "Error: ...".
+	reExceptionLine = regexp.MustCompile(`^(?:Uncaught\s+)?((?:[A-Za-z_]\w*\.)*(?:[A-Z]\w*)?(?:Error|Exception))(?::\s*(.*))?$`)
+	// An exception quoted inside a log line, e.g. "ERROR:root:KeyError: 'id'"
+	// or "java.lang.IllegalStateException: closed". A message is required so
+	// "raise ValueError" in a traceback frame does not count twice.
+	reExceptionInline = regexp.MustCompile(`\b((?:[A-Za-z_]\w*\.)*[A-Z]\w*(?:Error|Exception)):\s+(.+)$`)
+	rePanicLine       = regexp.MustCompile(`^(panic|fatal error): (.+)$`)
+)
+
+// exceptionSignature returns a normalized "Type: message" for lines that
+// report an exception, so retries of the same failure compare equal.
+func exceptionSignature(line string) (string, bool) {
+	var kind, msg string
+	if m := rePanicLine.FindStringSubmatch(line); m != nil {
+		kind, msg = m[1], m[2]
+	} else if m := reExceptionLine.FindStringSubmatch(line); m != nil {
+		kind, msg = m[1], m[2]
+	} else if m := reExceptionInline.FindStringSubmatch(line); m != nil {
+		kind, msg = m[1], m[2]
+	} else {
+		return "", false
+	}
+	sig := kind
+	if msg != "" {
+		sig += ": " + NormalizeLog(msg)
+	}
+	if len(sig) > maxExceptionSignature {
+		sig = sig[:maxExceptionSignature]
+	}
+	return sig, true
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"flowforge/internal/policy"
+	"flowforge/internal/runlog"
+)
+
+func TestLogObserverScoresStderrSeparately(t *testing.T) {
+	observer := NewLogObserver(20, "gpt-4")
+	stdout := observer.Stream(runlog.StreamStdout)
+	stderr := observer.Stream(runlog.StreamStderr)
+
+	traceback := "Traceback (most recent call last):\n  File \"agent.py\", line 12, in call\n    raise ValueError(\"bad input\")\nValueError: bad input 42\n"
+	for i := 0; i < 6; i++ {
+		fmt.Fprintf(stdout, "step %d/100 loss=%.3f\n", i, 1.0/float64(i+1))
+		// Split a write mid-line; the partial stderr line must not absorb stdout.
+		fmt.Fprint(stderr, traceback[:20])
+		fmt.Fprintf(stdout, "epoch %d done\n", i)
+		fmt.Fprint(stderr, traceback[20:])
+	}
+
+	var sample policy.Telemetry
+	applyStreamSignals(&sample, observer, 8)
+	if sample.RepeatedExceptions != 6 || sample.ExceptionSignature != "ValueError: bad input <NUM>" {
+		t.Fatalf("exception = %q x%d, want ValueError x6", sample.ExceptionSignature, sample.RepeatedExceptions)
+	}
+	if sample.StderrRepetition < 0.5 {
+		t.Fatalf("stderr repetition = %.2f, want the traceback loop to score high", sample.StderrRepetition)
+	}
+	if sample.StderrLinesPerSec <= 0 {
+		t.Fatal("expected a stderr rate")
+	}
+
+	for _, line := range observer.StreamLines(runlog.StreamStdout, 20) {
+		if line != "" && line[0] != 's' && line[0] != 'e' {
+			t.Fatalf("stdout window holds a foreign line %q", line)
+		}
+	}
+	if lines, _ := observer.StreamStats(runlog.StreamStdout); lines != 12 {
+		t.Fatalf("stdout line count = %d, want 12", lines)
+	}
+	if line, stream := observer.LastLine(); stream != runlog.StreamStderr || line != "ValueError: bad input 42" {
+		t.Fatalf("last line = %q from %q", line, stream)
+	}
+}
+
+func TestExceptionSignature(t *testing.T) {
+	cases := map[string]string{
+		"ValueError: bad input 42":                        "ValueError: bad input <NUM>",
+		"requests.exceptions.ConnectionError: refused":    "requests.exceptions.ConnectionError: refused",
+		"Uncaught TypeError: x is not a function":         "TypeError: x is not a function",
+		"ERROR:root:call failed: KeyError: 'id'":          "KeyError: 'id'",
+		"java.lang.IllegalStateException: closed at 0x1f": "java.lang.IllegalStateException: closed at <HEX>",
+		"panic: runtime error: index out of range [3]":    "panic: runtime error: index out of range [<NUM>]",
+		"StopIteration":                                           "",
+		"KeyboardInterrupt":                                       "",
+		"    raise ValueError(\"bad input\")":                     "",
+		"Traceback (most recent call last):":                      "",
+		"retrying request 12 after error":                         "",
+		"Error: connect ECONNREFUSED 127.0.0.1:5432":              "Error: connect ECONNREFUSED <NUM>.<NUM>:<NUM>",
+		"processing batch 3 of 10, 0 errors, 0 exceptions so far": "",
+	}
+	for line, want := range cases {
+		got, ok := exceptionSignature(line)
+		if ok != (want != "") || got != want {
+			t.Errorf("exceptionSignature(%q) = %q, %v; want %q", line, got, ok, want)
+		}
+	}
+}
+
+func TestExceptionHistoryWindow(t *testing.T) {
+	h := newExceptionHistory()
+	start := time.Unix(1700000000, 0)
+	for i := 0; i < 4; i++ {
+		h.add("KeyError: 'id'", start.Add(time.Duration(i)*time.Minute))
+	}
+	h.add("TimeoutError", start.Add(4*time.Minute))
+	if sig, n := h.top(start.Add(4 * time.Minute)); sig != "KeyError: 'id'" || n != 4 {
+		t.Fatalf("top = %q x%d", sig, n)
+	}
+	if sig, n := h.top(start.Add(8 * time.Minute)); sig != "TimeoutError" || n != 1 {
+		t.Fatalf("expected old exceptions to age out, top = %q x%d", sig, n)
+	}
+}
@@ -0,0 +1,301 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	logFormatText   = "text"
+	logFormatJSON   = "json"
+	logFormatLogfmt = "logfmt"
+	logFormatAuto   = "auto"
+)
+
+var logFormat string
+
+// structuredLogProfileKeys are copied from the active profile by resolveProfile.
+var structuredLogProfileKeys = []string{"log-format", "max-error-rate"}
+
+var (
+	defaultLogFields         = []string{"msg", "message", "event", "tool"}
+	defaultLogProgressFields = []string{"step", "epoch", "iteration", "progress"}
+	defaultLogLevelFields    = []string{"level", "lvl", "severity"}
+	errorLevels              = map[string]bool{"error": true, "err": true, "fatal": true, "critical": true, "crit": true, "panic": true, "emergency": true}
+)
+
+// structuredLogConfig controls how lines are scored. In text mode every line
+// is scored as-is. In the structured modes a line that parses as a JSON
+// object or logfmt is reduced to its configured fields first, so keys and
+// timestamps shared by every line do not make the output look repetitive.
+type structuredLogConfig struct {
+	Format         string
+	Fields         []string // scored fields; dotted paths reach nested JSON
+	ProgressFields []string // numeric fields that should increase while a run makes progress
+	LevelFields    []string
+}
+
+func resolveStructuredLogConfig() structuredLogConfig {
+	cfg := structuredLogConfig{
+		Format:         strings.ToLower(strings.TrimSpace(logFormat)),
+		Fields:         viper.GetStringSlice("log-fields"),
+		ProgressFields: viper.GetStringSlice("log-progress-fields"),
+		LevelFields:    viper.GetStringSlice("log-level-fields"),
+	}
+	if cfg.Format == "" {
+		cfg.Format = strings.ToLower(strings.TrimSpace(viper.GetString("log-format")))
+	}
+	if cfg.Format == "" {
+		cfg.Format = logFormatText
+	}
+	if len(cfg.Fields) == 0 {
+		cfg.Fields = defaultLogFields
+	}
+	if len(cfg.ProgressFields) == 0 {
+		cfg.ProgressFields = defaultLogProgressFields
+	}
+	if len(cfg.LevelFields) == 0 {
+		cfg.LevelFields = defaultLogLevelFields
+	}
+	return cfg
+}
+
+func validLogFormat(format string) bool {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case logFormatText, logFormatJSON, logFormatLogfmt, logFormatAuto:
+		return true
+	}
+	return false
+}
+
+// parse returns the fields of line when it is structured in the configured
+// format. Text mode never parses.
+func (c structuredLogConfig) parse(line string) (map[string]string, bool) {
+	switch c.Format {
+	case logFormatJSON:
+		return parseJSONLine(line)
+	case logFormatLogfmt:
+		return parseLogfmtLine(line)
+	case logFormatAuto:
+		if fields, ok := parseJSONLine(line); ok {
+			return fields, true
+		}
+		return parseLogfmtLine(line)
+	}
+	return nil, false
+}
+
+// scoringText is what repetition and entropy see for one line: the
+// configured fields of a structured line, or the line itself.
+func (c structuredLogConfig) scoringText(line string, fields map[string]string) string {
+	if fields == nil {
+		return line
+	}
+	parts := make([]string, 0, len(c.Fields))
+	for _, name := range c.Fields {
+		if v, ok := fields[name]; ok {
+			parts = append(parts, name+"="+v)
+		}
+	}
+	if len(parts) == 0 {
+		return line
+	}
+	return strings.Join(parts, " ")
+}
+
+func (c structuredLogConfig) progressValue(fields map[string]string) (float64, bool) {
+	for _, name := range c.ProgressFields {
+		if v, ok := fields[name]; ok {
+			if f, err := strconv.ParseFloat(strings.TrimSuffix(v, "%"), 64); err == nil {
+				return f, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// isError reports an error-or-worse level. Numeric levels follow the
+// pino/bunyan convention where 50 is error and 60 fatal.
+func (c structuredLogConfig) isError(fields map[string]string) bool {
+	for _, name := range c.LevelFields {
+		v, ok := fields[name]
+		if !ok {
+			continue
+		}
+		if n, err := strconv.Atoi(v); err == nil {
+			return n >= 50
+		}
+		return errorLevels[strings.ToLower(v)]
+	}
+	return false
+}
+
+// windowScores are the log-derived inputs to one policy evaluation.
+type windowScores struct {
+	FirstNormalized string
+	Repetition      float64
+	CPUScore        float64
+	Entropy         float64 // 0..100
+	Confidence      float64
+	RawDiversity    float64
+	ProgressLike    bool
+	ErrorRate       float64 // share of structured lines at error level or worse
+}
+
+// scoreWindow computes the window scores. In text mode this is exactly the
+// flat-text scoring; structured lines are reduced to their scored fields and
+// progress is read from numeric fields when most lines carry one.
+func (c structuredLogConfig) scoreWindow(lines []string, cpuUsage, maxCPU float64) windowScores {
+	scored := lines
+	var progress []float64
+	progressLines, structured, errors := 0, 0, 0
+	if c.Format != logFormatText {
+		scored = make([]string, len(lines))
+		for i, line := range lines {
+			fields, ok := c.parse(line)
+			scored[i] = c.scoringText(line, fields)
+			if !ok {
+				continue
+			}
+			structured++
+			if c.isError(fields) {
+				errors++
+			}
+			if v, ok := c.progressValue(fields); ok {
+				progress = append(progress, v)
+				progressLines++
+			}
+		}
+	}
+
+	var s windowScores
+	s.FirstNormalized, s.Repetition = calculateRepetitionScore(scored)
+	s.CPUScore, s.Entropy, s.Confidence = calculateDecisionScores(cpuUsage, maxCPU, scored)
+	s.RawDiversity = rawDiversityScore(scored)
+	if len(lines) > 0 && progressLines*2 >= len(lines) {
+		s.ProgressLike = increasingSeries(progress)
+	} else {
+		s.ProgressLike = detectProgressLikeOutput(scored)
+	}
+	if structured > 0 {
+		s.ErrorRate = float64(errors) / float64(structured)
+	}
+	return s
+}
+
+// increasingSeries mirrors the text heuristic: most consecutive values rise.
+func increasingSeries(values []float64) bool {
+	if len(values) < 4 {
+		return false
+	}
+	increases := 0
+	for i := 1; i < len(values); i++ {
+		if values[i] > values[i-1] {
+			increases++
+		}
+	}
+	return float64(increases)/float64(len(values)-1) >= 0.70
+}
+
+// parseJSONLine flattens a JSON object line into dotted keys with scalar
+// string values.
+func parseJSONLine(line string) (map[string]string, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "{") {
+		return nil, false
+	}
+	var obj map[string]any
+	if err := json.Unmarshal([]byte(line), &obj); err != nil {
+		return nil, false
+	}
+	out := make(map[string]string, len(obj))
+	flattenJSON("", obj, out)
+	return out, true
+}
+
+func flattenJSON(prefix string, obj map[string]any, out map[string]string) {
+	for k, v := range obj {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch val := v.(type) {
+		case map[string]any:
+			flattenJSON(key, val, out)
+		case string:
+			out[key] = val
+		case float64:
+			out[key] = strconv.FormatFloat(val, 'f', -1, 64)
+		case bool:
+			out[key] = strconv.FormatBool(val)
+		case nil:
+			out[key] = ""
+		default:
+			b, _ := json.Marshal(val)
+			out[key] = string(b)
+		}
+	}
+}
+
+// parseLogfmtLine parses key=value pairs with optional double-quoted values.
+// It needs at least two pairs and no bare words, so prose that happens to
+// contain "x=1" stays text.
+func parseLogfmtLine(line string) (map[string]string, bool) {
+	out := make(map[string]string)
+	i, n := 0, len(line)
+	for {
+		for i < n && line[i] == ' ' {
+			i++
+		}
+		if i >= n {
+			break
+		}
+		start := i
+		for i < n && line[i] != '=' && line[i] != ' ' {
+			i++
+		}
+		if i >= n || line[i] != '=' || i == start {
+			return nil, false
+		}
+		key := line[start:i]
+		i++ // '='
+		var val string
+		if i < n && line[i] == '"' {
+			j := i + 1
+			for j < n && line[j] != '"' {
+				if line[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= n {
+				return nil, false
+			}
+			unq, err := strconv.Unquote(line[i : j+1])
+			if err != nil {
+				return nil, false
+			}
+			val, i = unq, j+1
+		} else {
+			start := i
+			for i < n && line[i] != ' ' {
+				i++
+			}
+			val = line[start:i]
+		}
+		out[key] = val
+	}
+	if len(out) < 2 {
+		return nil, false
+	}
+	return out, true
+}
+
+// formatStructuredLogConfig is printed at run start.
+func formatStructuredLogConfig(c structuredLogConfig) string {
+	return fmt.Sprintf("format=%s fields=%s progress=%s", c.Format, strings.Join(c.Fields, ","), strings.Join(c.ProgressFields, ","))
+}
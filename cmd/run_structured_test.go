@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestScoreWindowStructuredJSON(t *testing.T) {
+	cfg := structuredLogConfig{Format: logFormatJSON, Fields: defaultLogFields, ProgressFields: defaultLogProgressFields, LevelFields: defaultLogLevelFields}
+
+	// Varied messages behind a shared envelope should not look repetitive.
+	msgs := []string{"fetching page", "parsing results", "writing cache", "calling search", "summarising", "ranking hits"}
+	varied := make([]string, 0, len(msgs))
+	for _, m := range msgs {
+		varied = append(varied, fmt.Sprintf(`{"ts":"2026-01-02T15:04:05Z","level":"info","service":"research-agent","host":"worker-eu-west-1a","version":"1.4.2","msg":%q}`, m))
+	}
+	text := structuredLogConfig{Format: logFormatText}.scoreWindow(varied, 10, 80)
+	structured := cfg.scoreWindow(varied, 10, 80)
+	if text.Repetition < 0.9 || structured.Repetition > 0.2 {
+		t.Fatalf("structured repetition %.2f should be below text repetition %.2f", structured.Repetition, text.Repetition)
+	}
+
+	// The same message over and over still is.
+	same := make([]string, 6)
+	for i := range same {
+		same[i] = fmt.Sprintf(`{"ts":"2026-01-02T15:04:0%dZ","level":"error","msg":"tool call failed","tool":"search"}`, i)
+	}
+	s := cfg.scoreWindow(same, 10, 80)
+	if s.Repetition < 0.9 {
+		t.Fatalf("repeated msg repetition = %.2f, want high", s.Repetition)
+	}
+	if s.ErrorRate != 1 {
+		t.Fatalf("error rate = %.2f, want 1", s.ErrorRate)
+	}
+	if s.ProgressLike {
+		t.Fatal("repeated failures should not look like progress")
+	}
+
+	steps := make([]string, 6)
+	for i := range steps {
+		steps[i] = fmt.Sprintf(`{"level":30,"msg":"train","step":%d,"loss":0.5}`, i*10)
+	}
+	if s := cfg.scoreWindow(steps, 10, 80); !s.ProgressLike || s.ErrorRate != 0 {
+		t.Fatalf("step series = %+v, want progress and no errors", s)
+	}
+}
+
+func TestScoreWindowTextModeIgnoresStructure(t *testing.T) {
+	lines := []string{`{"level":"error","msg":"a"}`, `{"level":"error","msg":"b"}`, `{"level":"error","msg":"c"}`}
+	s := structuredLogConfig{Format: logFormatText}.scoreWindow(lines, 0, 80)
+	_, want := calculateRepetitionScore(lines)
+	if s.Repetition != want || s.ErrorRate != 0 {
+		t.Fatalf("text mode scores = %+v, want repetition %.2f and no error rate", s, want)
+	}
+}
+
+func TestParseLogfmtLine(t *testing.T) {
+	fields, ok := parseLogfmtLine(`level=warn msg="retrying \"search\"" attempt=3 done=`)
+	if !ok {
+		t.Fatal("expected logfmt line to parse")
+	}
+	if fields["msg"] != `retrying "search"` || fields["attempt"] != "3" || fields["done"] != "" {
+		t.Fatalf("unexpected fields %+v", fields)
+	}
+	for _, line := range []string{"step 3 of 10 loss=0.2", "x=1", `msg="unterminated`, ""} {
+		if _, ok := parseLogfmtLine(line); ok {
+			t.Errorf("parseLogfmtLine(%q) should not parse", line)
+		}
+	}
+
+	auto := structuredLogConfig{Format: logFormatAuto, Fields: defaultLogFields, LevelFields: defaultLogLevelFields}
+	if fields, ok := auto.parse(`{"event":{"name":"x"},"tags":["a"]}`); !ok || fields["event.name"] != "x" || fields["tags"] != `["a"]` {
+		t.Fatalf("auto JSON parse = %+v, %v", fields, ok)
+	}
+	if fields, ok := auto.parse("lvl=crit msg=boom"); !ok || !auto.isError(fields) {
+		t.Fatalf("auto logfmt parse = %+v, %v", fields, ok)
+	}
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"flowforge/internal/api"
+
+	"github.com/spf13/viper"
+)
+
+var runIDFlag string
+var noAPI bool
+
+var runIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// submittedRunReservedFlags are set by the daemon itself and cannot be
+// overridden by a submitted run.
+var submittedRunReservedFlags = map[string]bool{
+	"run-id": true,
+	"no-api": true,
+	"config": true,
+}
+
+// resolveRunID returns the run ID given with --run-id, or a fresh one.
+func resolveRunID(generate func() string) (string, error) {
+	id := strings.TrimSpace(runIDFlag)
+	if id == "" {
+		return generate(), nil
+	}
+	if !runIDPattern.MatchString(id) {
+		return "", fmt.Errorf("--run-id must match [A-Za-z0-9._:-] and be <= 128 chars")
+	}
+	return id, nil
+}
+
+// daemonRunLauncher runs submitted jobs as `flowforge run` children of the
+// daemon, so they get the same supervision as a run started in a terminal.
+func daemonRunLauncher() (api.RunLauncher, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("resolve flowforge executable: %w", err)
+	}
+	configFile := viper.ConfigFileUsed()
+	return func(runID string, req api.RunRequest) ([]string, error) {
+		return submittedRunArgs(exe, configFile, runID, req)
+	}, nil
+}
+
+// submittedRunArgs builds the `flowforge run` command line for a submitted
+// run. Overrides become --flag=value pairs and must name a run flag.
+func submittedRunArgs(exe, configFile, runID string, req api.RunRequest) ([]string, error) {
+	args := []string{exe, "run", "--no-api", "--run-id", runID}
+	if configFile != "" {
+		args = append(args, "--config", configFile)
+	}
+	if req.Profile != "" {
+		args = append(args, "--profile", req.Profile)
+	}
+	if req.PolicyFile != "" {
+		args = append(args, "--policy-file", req.PolicyFile)
+	}
+
+	names := make([]string, 0, len(req.Overrides))
+	for name := range req.Overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if submittedRunReservedFlags[name] || runCmd.Flags().Lookup(name) == nil {
+			return nil, fmt.Errorf("overrides: unknown run flag %q", name)
+		}
+		value, err := overrideValue(req.Overrides[name])
+		if err != nil {
+			return nil, fmt.Errorf("overrides: %s: %w", name, err)
+		}
+		args = append(args, fmt.Sprintf("--%s=%s", name, value))
+	}
+
+	args = append(args, "--")
+	return append(args, req.Argv...), nil
+}
+
+func overrideValue(v interface{}) (string, error) {
+	switch value := v.(type) {
+	case string:
+		return value, nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("value must be a string, number or boolean")
+	}
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"flowforge/internal/api"
+)
+
+func TestSubmittedRunArgsBuildsRunCommandLine(t *testing.T) {
+	req := api.RunRequest{
+		Argv:       []string{"python3", "agent.py", "--verbose"},
+		Profile:    "heavy",
+		PolicyFile: "/etc/flowforge/policy.yaml",
+		Overrides:  map[string]interface{}{"no-kill": true, "max-cpu": 85.5, "restart-backoff": "fixed"},
+	}
+	got, err := submittedRunArgs("/usr/bin/flowforge", "/etc/flowforge/flowforge.yaml", "run-1", req)
+	if err != nil {
+		t.Fatalf("submittedRunArgs: %v", err)
+	}
+	want := []string{
+		"/usr/bin/flowforge", "run", "--no-api", "--run-id", "run-1",
+		"--config", "/etc/flowforge/flowforge.yaml",
+		"--profile", "heavy",
+		"--policy-file", "/etc/flowforge/policy.yaml",
+		"--max-cpu=85.5", "--no-kill=true", "--restart-backoff=fixed",
+		"--", "python3", "agent.py", "--verbose",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("submittedRunArgs =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestSubmittedRunArgsRejectsBadOverrides(t *testing.T) {
+	cases := map[string]interface{}{
+		"not-a-flag": "x",
+		"run-id":     "other",
+		"no-api":     false,
+		"max-cpu":    []interface{}{1, 2},
+	}
+	for name, value := range cases {
+		req := api.RunRequest{Argv: []string{"true"}, Overrides: map[string]interface{}{name: value}}
+		if _, err := submittedRunArgs("flowforge", "", "run-1", req); err == nil {
+			t.Fatalf("expected override %s=%v to be rejected", name, value)
+		}
+	}
+}
+
+func TestResolveRunIDValidatesFlag(t *testing.T) {
+	old := runIDFlag
+	t.Cleanup(func() { runIDFlag = old })
+
+	runIDFlag = ""
+	if id, err := resolveRunID(func() string { return "generated" }); err != nil || id != "generated" {
+		t.Fatalf("expected a generated ID, got %q (%v)", id, err)
+	}
+	runIDFlag = " run-42 "
+	if id, err := resolveRunID(func() string { return "generated" }); err != nil || id != "run-42" {
+		t.Fatalf("expected --run-id to win, got %q (%v)", id, err)
+	}
+	runIDFlag = "bad id/"
+	if _, err := resolveRunID(func() string { return "generated" }); err == nil {
+		t.Fatal("expected an invalid --run-id to be rejected")
+	}
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"flowforge/internal/runlog"
+	"flowforge/internal/state"
+)
+
+// publishRunState keeps runID's state file current so the daemon that
+// launched this run (with --no-api) can serve its CPU, last line and scores
+// on /v1/workers. The returned func writes the final state and stops.
+func publishRunState(runID string, every time.Duration) func() {
+	path, err := runlog.StatePath(runID)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), 0o700)
+	}
+	if err != nil {
+		fmt.Printf("[FlowForge] Warning: run state not published: %v\n", err)
+		return func() {}
+	}
+	if every <= 0 {
+		every = time.Second
+	}
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(every)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				_ = state.Default().WriteFile(path)
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+		_ = state.Default().WriteFile(path)
+	}
+}
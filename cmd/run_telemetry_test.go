@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"flowforge/internal/runlog"
+	"flowforge/internal/state"
+)
+
+func TestPublishRunStateWritesTheFinalState(t *testing.T) {
+	t.Setenv("FLOWFORGE_DAEMON_DIR", t.TempDir())
+	stop := publishRunState("run-publish", time.Hour)
+	state.UpdateState(55, "last words", "STOPPED", "sleep 1", []string{"sleep", "1"}, "", 0)
+	stop()
+
+	path, err := runlog.StatePath("run-publish")
+	if err != nil {
+		t.Fatalf("StatePath() error = %v", err)
+	}
+	published, err := state.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if published.CPU != 55 || published.LastLine != "last words" {
+		t.Fatalf("expected the state at stop to be published, got %+v", published)
+	}
+}
@@ -0,0 +1,360 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"flowforge/internal/policy"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// defaultToolCallWindow is how many recent tool calls the histogram keeps.
+	defaultToolCallWindow = 20
+	// maxToolCallArgs truncates long argument lists in signatures.
+	maxToolCallArgs = 200
+)
+
+// toolCallProfileKeys are copied from the active profile by resolveProfile.
+var toolCallProfileKeys = []string{"max-tool-call-repetition", "tool-call-window"}
+
+// Tool call presets for common agent log formats.
+const (
+	toolCallPresetOpenAI    = "openai"    // tool_calls / function_call JSON
+	toolCallPresetAnthropic = "anthropic" // tool_use content blocks
+	toolCallPresetLangChain = "langchain" // AgentExecutor verbose and callback output
+	toolCallPresetReAct     = "react"     // "Action: x" followed by "Action Input: y"
+)
+
+var defaultToolCallPresets = []string{toolCallPresetOpenAI, toolCallPresetAnthropic, toolCallPresetLangChain, toolCallPresetReAct}
+
+// toolCallRule extracts calls from one line. A regex rule has a "tool" group
+// and optionally an "args" group; when argsNext is set the arguments are read
+// from the following line instead. A JSON rule reads dotted paths from a JSON
+// object line, once per element of each when it is set.
+type toolCallRule struct {
+	re       *regexp.Regexp
+	argsNext *regexp.Regexp
+
+	each   string // optional path to an array of call objects
+	typeIs string // required value of the object's "type" field
+	tool   string
+	args   string
+}
+
+var toolCallPresets = map[string][]toolCallRule{
+	toolCallPresetOpenAI: {
+		{each: "tool_calls", tool: "function.name", args: "function.arguments"},
+		{each: "choices.0.message.tool_calls", tool: "function.name", args: "function.arguments"},
+		{typeIs: "function", tool: "function.name", args: "function.arguments"},
+		{typeIs: "function_call", tool: "name", args: "arguments"},
+	},
+	toolCallPresetAnthropic: {
+		{typeIs: "tool_use", tool: "name", args: "input"},
+		{each: "content", typeIs: "tool_use", tool: "name", args: "input"},
+	},
+	toolCallPresetLangChain: {
+		{re: regexp.MustCompile("Invoking: `(?P<tool>[^`]+)` with `(?P<args>.*)`")},
+		{
+			re:       regexp.MustCompile(`\[tool/start\].*:tool:(?P<tool>[^\]]+)\] Entering Tool run with input:`),
+			argsNext: regexp.MustCompile(`^\s*(?P<args>\S.*)$`),
+		},
+	},
+	toolCallPresetReAct: {
+		{
+			re:       regexp.MustCompile(`^\s*Action:\s*(?P<tool>[\w.\-]+)\s*$`),
+			argsNext: regexp.MustCompile(`^\s*Action Input:\s*(?P<args>.*)$`),
+		},
+	},
+}
+
+type toolCallConfig struct {
+	Presets  []string
+	Pattern  string // custom regex with a "tool" and optional "args" group
+	JSONTool string
+	JSONArgs string
+	Window   int
+}
+
+func resolveToolCallConfig() toolCallConfig {
+	cfg := toolCallConfig{
+		Presets:  viper.GetStringSlice("tool-call-presets"),
+		Pattern:  strings.TrimSpace(viper.GetString("tool-call-pattern")),
+		JSONTool: strings.TrimSpace(viper.GetString("tool-call-json-tool")),
+		JSONArgs: strings.TrimSpace(viper.GetString("tool-call-json-args")),
+		Window:   viper.GetInt("tool-call-window"),
+	}
+	if !viper.IsSet("tool-call-presets") {
+		cfg.Presets = defaultToolCallPresets
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = defaultToolCallWindow
+	}
+	return cfg
+}
+
+// rules compiles the configured extraction rules. Custom rules come first.
+func (c toolCallConfig) rules() ([]toolCallRule, error) {
+	var rules []toolCallRule
+	if c.Pattern != "" {
+		re, err := regexp.Compile(c.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("tool-call-pattern: %w", err)
+		}
+		if re.SubexpIndex("tool") < 0 {
+			return nil, fmt.Errorf("tool-call-pattern: needs a (?P<tool>...) group")
+		}
+		rules = append(rules, toolCallRule{re: re})
+	}
+	if c.JSONTool != "" {
+		rules = append(rules, toolCallRule{tool: c.JSONTool, args: c.JSONArgs})
+	}
+	for _, name := range c.Presets {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || name == "none" {
+			continue
+		}
+		preset, ok := toolCallPresets[name]
+		if !ok {
+			return nil, fmt.Errorf("tool-call-presets: unknown preset %q (want openai, anthropic, langchain, react or none)", name)
+		}
+		rules = append(rules, preset...)
+	}
+	return rules, nil
+}
+
+func applyToolCallLimits(p policy.Policy) policy.Policy {
+	p.MaxToolCallRepetition = viper.GetInt("max-tool-call-repetition")
+	return p
+}
+
+// formatToolCallEvidence is recorded on TOOL_CALL_LOOP_DETECTED incidents.
+func formatToolCallEvidence(t policy.Telemetry) string {
+	return fmt.Sprintf("%s (x%d)", t.ToolCallSignature, t.ToolCallRepetition)
+}
+
+// toolCallTracker extracts tool calls from output lines and keeps a sliding
+// histogram of the most recent call signatures. It is not safe for concurrent
+// use; LogObserver guards it.
+type toolCallTracker struct {
+	rules   []toolCallRule
+	pending *toolCallRule // rule waiting for its arguments on the next line
+	tool    string
+
+	window []string // ring of recent signatures
+	next   int
+	full   bool
+	counts map[string]int
+}
+
+func newToolCallTracker(rules []toolCallRule, window int) *toolCallTracker {
+	if window <= 0 {
+		window = defaultToolCallWindow
+	}
+	return &toolCallTracker{rules: rules, window: make([]string, window), counts: make(map[string]int)}
+}
+
+func (t *toolCallTracker) observe(line string) {
+	if t.pending != nil {
+		rule, tool := t.pending, t.tool
+		t.pending, t.tool = nil, ""
+		if m := rule.argsNext.FindStringSubmatch(line); m != nil {
+			t.record(tool, m[rule.argsNext.SubexpIndex("args")])
+			return
+		}
+		t.record(tool, "")
+	}
+
+	var obj map[string]any
+	if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "{") {
+		if json.Unmarshal([]byte(trimmed), &obj) != nil {
+			obj = nil
+		}
+	}
+	for i := range t.rules {
+		rule := &t.rules[i]
+		if rule.re == nil {
+			if obj != nil && t.observeJSON(rule, obj) {
+				return
+			}
+			continue
+		}
+		m := rule.re.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		tool := m[rule.re.SubexpIndex("tool")]
+		if rule.argsNext != nil {
+			t.pending, t.tool = rule, tool
+			return
+		}
+		args := ""
+		if i := rule.re.SubexpIndex("args"); i >= 0 {
+			args = m[i]
+		}
+		t.record(tool, args)
+		return
+	}
+}
+
+func (t *toolCallTracker) observeJSON(rule *toolCallRule, obj map[string]any) bool {
+	calls := []any{obj}
+	if rule.each != "" {
+		list, ok := jsonPath(obj, rule.each).([]any)
+		if !ok {
+			return false
+		}
+		calls = list
+	}
+	found := false
+	for _, call := range calls {
+		if rule.typeIs != "" && jsonPath(call, "type") != rule.typeIs {
+			continue
+		}
+		name, ok := jsonPath(call, rule.tool).(string)
+		if !ok || name == "" {
+			continue
+		}
+		args := jsonPath(call, rule.args)
+		if rule.args != "" && args == nil {
+			continue
+		}
+		t.record(name, jsonArgString(args))
+		found = true
+	}
+	return found
+}
+
+func (t *toolCallTracker) record(tool, args string) {
+	sig := toolCallSignature(tool, args)
+	if t.full {
+		old := t.window[t.next]
+		if t.counts[old]--; t.counts[old] <= 0 {
+			delete(t.counts, old)
+		}
+	}
+	t.window[t.next] = sig
+	t.counts[sig]++
+	t.next++
+	if t.next == len(t.window) {
+		t.next, t.full = 0, true
+	}
+}
+
+// top returns the most frequent signature in the window; ties go to the most
+// recent call.
+func (t *toolCallTracker) top() (string, int) {
+	best, bestCount := "", 0
+	n := t.next
+	if t.full {
+		n = len(t.window)
+	}
+	for i := 0; i < n; i++ {
+		// Oldest to newest so a later call wins a tie.
+		sig := t.window[(t.next-n+i+len(t.window))%len(t.window)]
+		if c := t.counts[sig]; c >= bestCount {
+			best, bestCount = sig, c
+		}
+	}
+	return best, bestCount
+}
+
+var (
+	reToolArgUUID = regexp.MustCompile(`\b[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\b`)
+	reToolArgTime = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T\s]\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:?\d{2})?`)
+	reToolArgHex  = regexp.MustCompile(`\b(?:0x)?[0-9a-fA-F]{16,}\b`)
+	reToolArgWS   = regexp.MustCompile(`\s+`)
+)
+
+// toolCallSignature normalizes a call so retries compare equal: JSON (and
+// Python dict) arguments are re-encoded with sorted keys, and request IDs and
+// timestamps are masked. Plain numbers are kept; page=1 and page=2 are
+// different calls.
+func toolCallSignature(tool, args string) string {
+	tool = strings.TrimSpace(tool)
+	args = strings.TrimSpace(args)
+	if args == "" {
+		return tool
+	}
+	if canonical, ok := canonicalJSON(args); ok {
+		args = canonical
+	} else if canonical, ok := canonicalJSON(pythonLiteralToJSON(args)); ok {
+		args = canonical
+	}
+	args = reToolArgUUID.ReplaceAllString(args, "<ID>")
+	args = reToolArgTime.ReplaceAllString(args, "<TIME>")
+	args = reToolArgHex.ReplaceAllString(args, "<HEX>")
+	args = reToolArgWS.ReplaceAllString(args, " ")
+	if len(args) > maxToolCallArgs {
+		args = args[:maxToolCallArgs]
+	}
+	return tool + " " + args
+}
+
+func canonicalJSON(raw string) (string, bool) {
+	var v any
+	if err := json.Unmarshal([]byte(raw), &v); err != nil {
+		return "", false
+	}
+	// A JSON string holding JSON, as OpenAI encodes arguments.
+	if s, ok := v.(string); ok {
+		if inner, ok := canonicalJSON(s); ok {
+			return inner, true
+		}
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", false
+	}
+	return string(b), true
+}
+
+var pythonLiteralReplacer = strings.NewReplacer("'", `"`, "True", "true", "False", "false", "None", "null")
+
+// pythonLiteralToJSON is a best-effort rewrite of a repr()'d dict, which is
+// how LangChain prints tool input.
+func pythonLiteralToJSON(s string) string {
+	if !strings.HasPrefix(s, "{") && !strings.HasPrefix(s, "[") {
+		return s
+	}
+	return pythonLiteralReplacer.Replace(s)
+}
+
+// jsonPath walks a dotted path through objects and, for numeric segments,
+// arrays. It returns nil when the path does not resolve.
+func jsonPath(v any, path string) any {
+	if path == "" {
+		return nil
+	}
+	for _, seg := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]any:
+			v = node[seg]
+		case []any:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil
+			}
+			v = node[i]
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
+func jsonArgString(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	default:
+		b, _ := json.Marshal(val)
+		return string(b)
+	}
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+)
+
+func defaultToolCallTracker(t *testing.T, window int) *toolCallTracker {
+	t.Helper()
+	rules, err := toolCallConfig{Presets: defaultToolCallPresets}.rules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newToolCallTracker(rules, window)
+}
+
+func TestToolCallTrackerPresets(t *testing.T) {
+	cases := []struct {
+		name  string
+		lines []string
+		want  string
+	}{
+		{
+			name:  "openai tool_calls",
+			lines: []string{`{"role":"assistant","tool_calls":[{"id":"call_1","type":"function","function":{"name":"search","arguments":"{\"q\": \"weather\", \"limit\": 5}"}}]}`},
+			want:  `search {"limit":5,"q":"weather"}`,
+		},
+		{
+			name:  "openai responses function_call",
+			lines: []string{`{"type":"function_call","call_id":"call_9","name":"search","arguments":"{\"limit\":5,\"q\":\"weather\"}"}`},
+			want:  `search {"limit":5,"q":"weather"}`,
+		},
+		{
+			name:  "anthropic tool_use block",
+			lines: []string{`{"role":"assistant","content":[{"type":"text","text":"Let me look."},{"type":"tool_use","id":"toolu_1","name":"read_file","input":{"path":"main.go"}}]}`},
+			want:  `read_file {"path":"main.go"}`,
+		},
+		{
+			name:  "langchain verbose",
+			lines: []string{"Invoking: `search` with `{'q': 'weather', 'exact': True}`"},
+			want:  `search {"exact":true,"q":"weather"}`,
+		},
+		{
+			name:  "langchain callback",
+			lines: []string{"[tool/start] [1:chain:AgentExecutor > 4:tool:search] Entering Tool run with input:", `"weather in paris"`},
+			want:  `search "weather in paris"`,
+		},
+		{
+			name:  "react",
+			lines: []string{"Thought: I should look this up", "Action: search", "Action Input: weather in paris"},
+			want:  "search weather in paris",
+		},
+	}
+	for _, tc := range cases {
+		tracker := defaultToolCallTracker(t, 10)
+		for _, line := range tc.lines {
+			tracker.observe(line)
+		}
+		if sig, n := tracker.top(); sig != tc.want || n != 1 {
+			t.Errorf("%s: top = %q x%d, want %q x1", tc.name, sig, n, tc.want)
+		}
+	}
+}
+
+func TestToolCallTrackerIgnoresVaryingText(t *testing.T) {
+	observer := NewLogObserver(20, "gpt-4")
+	observer.TrackToolCalls(defaultToolCallTracker(t, 10))
+	for i := 0; i < 8; i++ {
+		fmt.Fprintf(observer, "Thought: attempt %d, maybe the index was stale so I will try once more\n", i)
+		fmt.Fprintln(observer, "Action: search")
+		fmt.Fprintf(observer, "Action Input: {\"q\": \"weather\", \"request_id\": \"%08d-1111-2222-3333-444455556666\"}\n", i)
+	}
+	if sig, n := observer.RepeatedToolCall(); sig != `search {"q":"weather","request_id":"<ID>"}` || n != 8 {
+		t.Fatalf("repeated tool call = %q x%d", sig, n)
+	}
+
+	// Older calls slide out of the window.
+	for i := 0; i < 10; i++ {
+		fmt.Fprintln(observer, "Action: fetch")
+		fmt.Fprintf(observer, "Action Input: page=%d\n", i)
+	}
+	if _, n := observer.RepeatedToolCall(); n != 1 {
+		t.Fatalf("expected distinct pages to count once each, got x%d", n)
+	}
+}
+
+func TestToolCallConfigCustomRules(t *testing.T) {
+	rules, err := toolCallConfig{Pattern: `^TOOL (?P<tool>\w+) (?P<args>.*)$`, JSONTool: "call.tool", JSONArgs: "call.params", Presets: []string{"none"}}.rules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracker := newToolCallTracker(rules, 5)
+	tracker.observe("TOOL grep -n foo  main.go")
+	tracker.observe(`{"call":{"tool":"grep","params":"-n foo main.go"}}`)
+	if sig, n := tracker.top(); sig != "grep -n foo main.go" || n != 2 {
+		t.Fatalf("top = %q x%d", sig, n)
+	}
+
+	if _, err := (toolCallConfig{Pattern: `(\w+)`}).rules(); err == nil {
+		t.Fatal("expected a pattern without a tool group to be rejected")
+	}
+	if _, err := (toolCallConfig{Presets: []string{"autogen"}}).rules(); err == nil {
+		t.Fatal("expected an unknown preset to be rejected")
+	}
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"flowforge/internal/api"
+	"flowforge/internal/database"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	tokenRoles     []string
+	tokenExpiresIn time.Duration
+	tokenJSON      bool
+)
+
+var tokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Manage scoped API tokens",
+	Long: `API tokens let the dashboard, IDE integrations and CI scripts each use their
+own credential instead of sharing FLOWFORGE_API_KEY. The token's name is the
+actor on every audit event it causes. Roles:
+
+  viewer                        read run output
+  operator                      viewer, plus start, kill, restart, pause and
+                                resume workers and drive any integration workspace
+  integration:<workspace_id>    register and drive one integration workspace
+
+Only a hash of each token is stored; the token is printed once, at creation.
+
+Example:
+  flowforge token create ci-nightly --role operator --expires-in 720h
+  flowforge token create vscode-my-repo --role integration:my-repo
+  flowforge token list
+  flowforge token revoke ci-nightly`,
+}
+
+var tokenCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a token and print it once",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		roles, err := api.NormalizeTokenRoles(tokenRoles)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if tokenExpiresIn < 0 {
+			fmt.Println("Error: --expires-in must be >= 0")
+			os.Exit(1)
+		}
+		var expiresAt time.Time
+		if tokenExpiresIn > 0 {
+			expiresAt = time.Now().Add(tokenExpiresIn)
+		}
+		withDatabase(func() error {
+			token, secret, err := database.CreateAPIToken(args[0], roles, expiresAt)
+			if err != nil {
+				return err
+			}
+			_ = database.LogAuditEvent("operator", "TOKEN_CREATE", fmt.Sprintf("token %s created with roles %s", token.Name, strings.Join(token.Roles, ",")), "cli", os.Getpid(), token.Prefix)
+			if tokenJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(map[string]any{"token": secret, "info": token})
+			}
+			fmt.Printf("Token %s (roles: %s, expires: %s)\n", token.Name, strings.Join(token.Roles, ","), withFallback(token.ExpiresAt, "never"))
+			fmt.Println(secret)
+			fmt.Println("Store it now; it cannot be shown again.")
+			return nil
+		})
+	},
+}
+
+var tokenListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List tokens, including revoked and expired ones",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		withDatabase(func() error {
+			tokens, err := database.ListAPITokens()
+			if err != nil {
+				return err
+			}
+			now := time.Now()
+			if tokenJSON {
+				type listed struct {
+					database.APIToken
+					Status string `json:"status"`
+				}
+				out := make([]listed, 0, len(tokens))
+				for _, t := range tokens {
+					out = append(out, listed{APIToken: t, Status: t.Status(now)})
+				}
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(out)
+			}
+			if len(tokens) == 0 {
+				fmt.Println("No API tokens.")
+				return nil
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tPREFIX\tROLES\tSTATUS\tCREATED\tEXPIRES\tLAST USED")
+			for _, t := range tokens {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", t.Name, t.Prefix, strings.Join(t.Roles, ","), t.Status(now), t.CreatedAt, withFallback(t.ExpiresAt, "never"), withFallback(t.LastUsedAt, "-"))
+			}
+			return w.Flush()
+		})
+	},
+}
+
+var tokenRevokeCmd = &cobra.Command{
+	Use:   "revoke <name>",
+	Short: "Revoke a token; requests using it are refused at once",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		withDatabase(func() error {
+			if err := database.RevokeAPIToken(args[0]); err != nil {
+				if errors.Is(err, database.ErrAPITokenNotFound) {
+					return fmt.Errorf("no unrevoked token named %s", args[0])
+				}
+				return err
+			}
+			_ = database.LogAuditEvent("operator", "TOKEN_REVOKE", fmt.Sprintf("token %s revoked", args[0]), "cli", os.Getpid(), args[0])
+			fmt.Printf("Token %s revoked.\n", args[0])
+			return nil
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tokenCmd)
+	tokenCmd.AddCommand(tokenCreateCmd)
+	tokenCmd.AddCommand(tokenListCmd)
+	tokenCmd.AddCommand(tokenRevokeCmd)
+	tokenCreateCmd.Flags().StringSliceVar(&tokenRoles, "role", nil, "Role to grant: viewer, operator or integration:<workspace_id> (repeatable)")
+	tokenCreateCmd.Flags().DurationVar(&tokenExpiresIn, "expires-in", 0, "Expire the token after this long, e.g. 720h (0 = never)")
+	tokenCreateCmd.Flags().BoolVar(&tokenJSON, "json", false, "Print the token and its details as JSON")
+	tokenListCmd.Flags().BoolVar(&tokenJSON, "json", false, "Print tokens as JSON")
+	_ = tokenCreateCmd.MarkFlagRequired("role")
+}
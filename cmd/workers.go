@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	workersPort          string
+	workersSocket        string
+	workersJSON          bool
+	workersReason        string
+	workersPauseTimeoutS int
+)
+
+const workersRequestTimeout = 15 * time.Second
+
+var workersCmd = &cobra.Command{
+	Use:   "workers",
+	Short: "List and control the daemon's workers",
+	Long: `Talks to the running daemon's API. When the daemon was started with
+--unix-socket the commands use that socket and need no API key; otherwise they
+use http://127.0.0.1:<port> with FLOWFORGE_API_KEY.
+
+Example:
+  flowforge daemon start --unix-socket
+  flowforge workers list
+  flowforge workers pause 3f6c1d2e-... --timeout-seconds 600
+  flowforge workers kill 3f6c1d2e-... --reason "stuck in a loop"`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		withDaemonAPI(listWorkers)
+	},
+}
+
+var workersListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the daemon's workers",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		withDaemonAPI(listWorkers)
+	},
+}
+
+func newWorkerActionCmd(action, short string) *cobra.Command {
+	return &cobra.Command{
+		Use:   action + " <run_id>",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			body := map[string]interface{}{}
+			if reason := strings.TrimSpace(workersReason); reason != "" {
+				body["reason"] = reason
+			}
+			if action == "pause" && cmd.Flags().Changed("timeout-seconds") {
+				body["timeout_seconds"] = workersPauseTimeoutS
+			}
+			withDaemonAPI(func(d *daemonAPI) error {
+				return workerAction(d, args[0], action, body)
+			})
+		},
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(workersCmd)
+	workersCmd.PersistentFlags().StringVar(&workersPort, "port", defaultDaemonPort, "API port of the daemon")
+	workersCmd.PersistentFlags().StringVar(&workersSocket, "socket", "", "Unix socket of the daemon (default: the one the running daemon serves)")
+	workersCmd.PersistentFlags().BoolVar(&workersJSON, "json", false, "Print the API response as JSON")
+
+	workersCmd.AddCommand(workersListCmd)
+	for _, c := range []*cobra.Command{
+		newWorkerActionCmd("kill", "Stop a worker"),
+		newWorkerActionCmd("restart", "Restart a worker with its original command"),
+		newWorkerActionCmd("pause", "Pause a worker (SIGSTOP) until resumed or the pause times out"),
+		newWorkerActionCmd("resume", "Resume a paused worker"),
+	} {
+		c.Flags().StringVar(&workersReason, "reason", "", "Reason recorded in the audit log")
+		if strings.HasPrefix(c.Use, "pause ") {
+			c.Flags().IntVar(&workersPauseTimeoutS, "timeout-seconds", 0, "Kill the worker if still paused after this long (default: the daemon's pause timeout)")
+		}
+		workersCmd.AddCommand(c)
+	}
+}
+
+func withDaemonAPI(fn func(*daemonAPI) error) {
+	if err := fn(newDaemonAPI(workersPort, workersSocket, workersRequestTimeout)); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+type workerListResponse struct {
+	Workers []workerSummary `json:"workers"`
+	Count   int             `json:"count"`
+}
+
+type workerSummary struct {
+	RunID      string `json:"run_id"`
+	Phase      string `json:"phase"`
+	PID        int    `json:"pid"`
+	Lifecycle  string `json:"lifecycle"`
+	Command    string `json:"command"`
+	AutoKillAt string `json:"auto_kill_at,omitempty"`
+}
+
+func listWorkers(d *daemonAPI) error {
+	var out workerListResponse
+	if err := d.do(http.MethodGet, "/v1/workers", nil, &out); err != nil {
+		return err
+	}
+	if workersJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+	if len(out.Workers) == 0 {
+		fmt.Println("No workers.")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RUN ID\tPHASE\tLIFECYCLE\tPID\tCOMMAND")
+	for _, wk := range out.Workers {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", wk.RunID, wk.Phase, withFallback(wk.Lifecycle, "-"), wk.PID, wk.Command)
+	}
+	return w.Flush()
+}
+
+func workerAction(d *daemonAPI, runID, action string, body map[string]interface{}) error {
+	var out map[string]interface{}
+	path := fmt.Sprintf("/v1/workers/%s/%s", url.PathEscape(runID), action)
+	if err := d.do(http.MethodPost, path, body, &out); err != nil {
+		return err
+	}
+	if workersJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+	fmt.Printf("Worker %s: %v (lifecycle=%v, pid=%v)\n", runID, out["status"], out["lifecycle"], out["pid"])
+	if autoKillAt, ok := out["auto_kill_at"]; ok {
+		fmt.Printf("Killed automatically at %v unless resumed.\n", autoKillAt)
+	}
+	return nil
+}
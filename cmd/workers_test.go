@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"flowforge/internal/daemon"
+)
+
+func TestNewDaemonAPIUsesRunningDaemonSocket(t *testing.T) {
+	t.Setenv("FLOWFORGE_DAEMON_DIR", t.TempDir())
+	t.Setenv("FLOWFORGE_API_KEY", "")
+	paths, err := daemon.EnsureRuntimeDir()
+	if err != nil {
+		t.Fatalf("runtime dir: %v", err)
+	}
+
+	if d := newDaemonAPI("18080", "", time.Second); d.socket != "" || d.baseURL != "http://127.0.0.1:18080" {
+		t.Fatalf("expected TCP without daemon state, got %s", d.target())
+	}
+	if err := daemon.WriteState(paths, daemon.State{PID: 0, Socket: paths.SocketFile}); err != nil {
+		t.Fatalf("write state: %v", err)
+	}
+	if d := newDaemonAPI("18080", "", time.Second); d.socket != "" {
+		t.Fatalf("expected a stopped daemon's socket to be ignored, got %s", d.target())
+	}
+
+	ln, err := net.Listen("unix", paths.SocketFile)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("expected no API key over the socket, got %q", r.Header.Get("Authorization"))
+		}
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"status":404,"detail":"worker run-x not found"}`))
+	}))
+	srv.Listener = ln
+	srv.Start()
+	defer srv.Close()
+
+	if err := daemon.WriteState(paths, daemon.State{PID: os.Getpid(), Socket: paths.SocketFile}); err != nil {
+		t.Fatalf("write state: %v", err)
+	}
+	d := newDaemonAPI("18080", "", time.Second)
+	if d.socket != paths.SocketFile {
+		t.Fatalf("expected the running daemon's socket, got %s", d.target())
+	}
+	err = d.do(http.MethodPost, "/v1/workers/run-x/kill", map[string]interface{}{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "404 worker run-x not found") {
+		t.Fatalf("expected the problem detail in the error, got %v", err)
+	}
+	if d := newDaemonAPI("18080", filepath.Join(paths.Dir, "other.sock"), time.Second); d.socket != filepath.Join(paths.Dir, "other.sock") {
+		t.Fatalf("expected --socket to win, got %s", d.target())
+	}
+}
@@ -0,0 +1,90 @@
+import { useEffect, useRef } from 'react';
+import { apiFetch } from '@/hooks/use-api';
+
+export interface StreamedEvent {
+  id: number;
+  event_id: string;
+  event_type: string;
+  run_id: string;
+  incident_id?: string;
+  title: string;
+}
+
+const RECONNECT_DELAY_MS = 3000;
+
+// useEventStream calls onEvent for every event /v1/events/stream pushes.
+// EventSource cannot send the Authorization header, so the stream is read
+// over fetch; it reconnects after errors and resumes from the last event id.
+export function useEventStream(path: string, apiKey: string, onEvent: (event: StreamedEvent) => void, enabled = true) {
+  const onEventRef = useRef(onEvent);
+  onEventRef.current = onEvent;
+
+  useEffect(() => {
+    if (!enabled || typeof window === 'undefined' || typeof ReadableStream === 'undefined') {
+      return;
+    }
+    const controller = new AbortController();
+    let lastEventID = '';
+    let retry: ReturnType<typeof setTimeout> | undefined;
+
+    const dispatch = (frame: string) => {
+      let data = '';
+      for (const line of frame.split('\n')) {
+        if (line.startsWith('id: ')) {
+          lastEventID = line.slice(4);
+        } else if (line.startsWith('data: ')) {
+          data += line.slice(6);
+        }
+      }
+      if (!data) {
+        return;
+      }
+      try {
+        onEventRef.current(JSON.parse(data) as StreamedEvent);
+      } catch {
+        // Ignore frames that are not event JSON.
+      }
+    };
+
+    const connect = async () => {
+      try {
+        const res = await apiFetch(path, apiKey, {
+          signal: controller.signal,
+          headers: lastEventID ? { 'Last-Event-ID': lastEventID } : {},
+        });
+        if (!res.ok || !res.body) {
+          throw new Error(`event stream failed: ${res.status}`);
+        }
+        const reader = res.body.getReader();
+        const decoder = new TextDecoder();
+        let buffered = '';
+        for (;;) {
+          const { value, done } = await reader.read();
+          if (done) {
+            break;
+          }
+          buffered += decoder.decode(value, { stream: true });
+          let end = buffered.indexOf('\n\n');
+          while (end >= 0) {
+            dispatch(buffered.slice(0, end));
+            buffered = buffered.slice(end + 2);
+            end = buffered.indexOf('\n\n');
+          }
+        }
+      } catch {
+        // Reconnect below unless the effect was torn down.
+      }
+      if (!controller.signal.aborted) {
+        retry = setTimeout(connect, RECONNECT_DELAY_MS);
+      }
+    };
+    void connect();
+
+    return () => {
+      controller.abort();
+      if (retry) {
+        clearTimeout(retry);
+      }
+    };
+  }, [path, apiKey, enabled]);
+}
@@ -13,6 +13,7 @@ import { RequestTracePanel } from '@/components/dashboard/RequestTracePanel';
 import { LifecycleSLOPanel } from '@/components/dashboard/LifecycleSLOPanel';
 import { useApiKey, apiFetch, getErrorMessage } from '@/hooks/use-api';
 import { usePollingData } from '@/hooks/use-polling-data';
+import { useEventStream } from '@/hooks/use-event-stream';
 import {
   parseIncidentsPayload,
   parseTimelinePayload,
@@ -180,6 +181,15 @@ export default function Dashboard() {
     interval: 30000,
   });
 
+  // New incidents and decisions refresh the tables at once instead of
+  // waiting for the next poll.
+  useEventStream('/v1/events/stream?event_type=incident,decision,lifecycle', apiKey, (event) => {
+    timeline.refetch();
+    if (event.event_type === 'incident') {
+      incidents.refetch();
+    }
+  });
+
   const mappedIncidents = useMemo<DashboardIncident[]>(() => {
     return (incidents.data || []).map(toDashboardIncident);
   }, [incidents.data]);
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"flowforge/internal/database"
+)
+
+// Token roles. Operator implies viewer and every integration role.
+const (
+	RoleViewer            = "viewer"
+	RoleOperator          = "operator"
+	integrationRolePrefix = "integration:"
+)
+
+// IntegrationRole is the role that lets a token drive one integration
+// workspace.
+func IntegrationRole(workspaceID string) string {
+	return integrationRolePrefix + workspaceID
+}
+
+// NormalizeTokenRoles validates roles for a new token and returns them
+// trimmed, deduplicated and in the order given.
+func NormalizeTokenRoles(roles []string) ([]string, error) {
+	out := make([]string, 0, len(roles))
+	seen := map[string]bool{}
+	for _, role := range roles {
+		role = strings.TrimSpace(role)
+		switch {
+		case role == RoleViewer || role == RoleOperator:
+		case strings.HasPrefix(role, integrationRolePrefix):
+			if !integrationWorkspaceIDPattern.MatchString(strings.TrimPrefix(role, integrationRolePrefix)) {
+				return nil, fmt.Errorf("role %q: workspace_id must match [A-Za-z0-9._:-] and be <= 128 chars", role)
+			}
+		default:
+			return nil, fmt.Errorf("unknown role %q (want viewer, operator or integration:<workspace_id>)", role)
+		}
+		if !seen[role] {
+			seen[role] = true
+			out = append(out, role)
+		}
+	}
+	if len(out) == 0 {
+		return nil, errors.New("at least one role is required")
+	}
+	return out, nil
+}
+
+// principal is who a request authenticated as. The API key, same-user
+// socket peers and unauthenticated reads without any credentials configured
+// hold every role; stored tokens hold their own.
+type principal struct {
+	Actor   string
+	TokenID int64
+	Roles   []string
+	All     bool
+}
+
+func (p principal) has(role string) bool {
+	if p.All {
+		return true
+	}
+	for _, held := range p.Roles {
+		if held == role {
+			return true
+		}
+		if held == RoleOperator && (role == RoleViewer || strings.HasPrefix(role, integrationRolePrefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// authError is an authentication failure and the response it gets.
+// Counted failures feed the brute-force limiter.
+type authError struct {
+	status  int
+	message string
+	counted bool
+}
+
+// authenticate resolves the caller from peer credentials, the bearer token
+// (FLOWFORGE_API_KEY or a stored token) or, when neither a key nor any token
+// is configured, lets safe methods through as anonymous.
+func authenticate(r *http.Request) (principal, *authError) {
+	if cred, ok := peerFromRequest(r); ok && isSameUserPeer(r) {
+		return principal{Actor: fmt.Sprintf("uid:%d", cred.UID), All: true}, nil
+	}
+	apiKey := os.Getenv("FLOWFORGE_API_KEY")
+
+	authHeader := r.Header.Get("Authorization")
+	if strings.HasPrefix(authHeader, "Bearer ") {
+		token := strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer "))
+		if apiKey != "" && subtle.ConstantTimeCompare([]byte(token), []byte(apiKey)) == 1 {
+			return principal{Actor: "api-key", All: true}, nil
+		}
+		if database.GetDB() != nil {
+			if stored, err := database.LookupAPIToken(token); err == nil {
+				return principal{Actor: stored.Name, TokenID: stored.ID, Roles: stored.Roles}, nil
+			}
+		}
+		if apiKey != "" || tokensConfigured() {
+			return principal{}, &authError{status: http.StatusForbidden, message: "Invalid API key", counted: true}
+		}
+	} else if apiKey != "" || tokensConfigured() {
+		return principal{}, &authError{status: http.StatusUnauthorized, message: "Authorization required", counted: true}
+	}
+
+	if isUnsafeMethod(r.Method) {
+		return principal{}, &authError{status: http.StatusForbidden, message: "Security Alert: You must set FLOWFORGE_API_KEY environment variable to perform mutations."}
+	}
+	return principal{Actor: "anonymous", All: true}, nil
+}
+
+// tokensConfigured reports whether the token store holds a usable token.
+// The store is only consulted once the process has opened its database.
+func tokensConfigured() bool {
+	if database.GetDB() == nil {
+		return false
+	}
+	ok, err := database.HasActiveAPITokens()
+	return err == nil && ok
+}
+
+// requireRole authenticates r and checks that the caller holds role; an
+// empty role accepts any authenticated caller. It writes the error response
+// and returns false otherwise.
+func requireRole(w http.ResponseWriter, r *http.Request, role string) bool {
+	ip := clientIP(r.RemoteAddr)
+	p, authErr := authenticate(r)
+	if authErr != nil {
+		if authErr.counted {
+			apiMetrics.IncAuthFailure()
+			if apiLimiter.addAuthFailure(ip) {
+				writeJSONErrorForRequest(w, r, http.StatusTooManyRequests, "Too many failed auth attempts. Retry later.")
+				return false
+			}
+		}
+		writeJSONErrorForRequest(w, r, authErr.status, authErr.message)
+		return false
+	}
+	apiLimiter.clearAuthFailures(ip)
+	if p.TokenID != 0 {
+		_ = database.TouchAPIToken(p.TokenID)
+	}
+	if role != "" && !p.has(role) {
+		writeJSONErrorForRequest(w, r, http.StatusForbidden, fmt.Sprintf("token %s lacks the %s role", p.Actor, role))
+		return false
+	}
+	return true
+}
+
+// hasRole reports whether the authenticated caller of r holds role.
+func hasRole(r *http.Request, role string) bool {
+	p, authErr := authenticate(r)
+	return authErr == nil && p.has(role)
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeTokenRoles(t *testing.T) {
+	got, err := NormalizeTokenRoles([]string{" operator", "integration:ws-a", "operator"})
+	if err != nil || !reflect.DeepEqual(got, []string{"operator", "integration:ws-a"}) {
+		t.Fatalf("unexpected roles %v err=%v", got, err)
+	}
+	for _, bad := range [][]string{nil, {""}, {"admin"}, {"integration:"}, {"integration:has space"}} {
+		if _, err := NormalizeTokenRoles(bad); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestPrincipalRoles(t *testing.T) {
+	cases := []struct {
+		held []string
+		role string
+		want bool
+	}{
+		{[]string{RoleOperator}, RoleViewer, true},
+		{[]string{RoleOperator}, IntegrationRole("ws-a"), true},
+		{[]string{RoleViewer}, RoleOperator, false},
+		{[]string{IntegrationRole("ws-a")}, IntegrationRole("ws-a"), true},
+		{[]string{IntegrationRole("ws-a")}, IntegrationRole("ws-b"), false},
+		{[]string{IntegrationRole("ws-a")}, RoleViewer, false},
+	}
+	for _, c := range cases {
+		if got := (principal{Roles: c.held}).has(c.role); got != c.want {
+			t.Fatalf("%v has %s: got %v, want %v", c.held, c.role, got, c.want)
+		}
+	}
+	if !(principal{All: true}).has(RoleOperator) {
+		t.Fatal("expected the API key principal to hold every role")
+	}
+}
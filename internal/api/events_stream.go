@@ -0,0 +1,199 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"flowforge/internal/database"
+)
+
+const (
+	eventStreamBuffer       = 256
+	eventStreamCatchUpBatch = 500
+	// Events written by other processes (a supervised `flowforge run`, the
+	// CLI) never reach this process's bus, so the table is re-read this often.
+	eventStreamPollInterval = 2 * time.Second
+	eventStreamHeartbeat    = 15 * time.Second
+)
+
+// eventStreamFilter narrows the event stream. Empty fields match anything.
+type eventStreamFilter struct {
+	eventTypes map[string]bool
+	runID      string
+	incidentID string
+}
+
+func parseEventStreamFilter(r *http.Request) eventStreamFilter {
+	query := r.URL.Query()
+	f := eventStreamFilter{
+		runID:      strings.TrimSpace(query.Get("run_id")),
+		incidentID: strings.TrimSpace(query.Get("incident_id")),
+	}
+	for _, eventType := range strings.Split(query.Get("event_type"), ",") {
+		if eventType = strings.TrimSpace(eventType); eventType != "" {
+			if f.eventTypes == nil {
+				f.eventTypes = map[string]bool{}
+			}
+			f.eventTypes[eventType] = true
+		}
+	}
+	return f
+}
+
+func (f eventStreamFilter) match(e database.UnifiedEvent) bool {
+	if f.eventTypes != nil && !f.eventTypes[e.EventType] {
+		return false
+	}
+	if f.runID != "" && e.RunID != f.runID {
+		return false
+	}
+	if f.incidentID != "" && e.IncidentID != f.incidentID {
+		return false
+	}
+	return true
+}
+
+// parseLastEventID reads the resume cursor from the Last-Event-ID header a
+// reconnecting EventSource sends, or from ?last_event_id= for a first
+// connection. The bool is false when neither is set.
+func parseLastEventID(r *http.Request) (int64, bool, error) {
+	raw := strings.TrimSpace(r.Header.Get("Last-Event-ID"))
+	if raw == "" {
+		raw = strings.TrimSpace(r.URL.Query().Get("last_event_id"))
+	}
+	if raw == "" {
+		return 0, false, nil
+	}
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id < 0 {
+		return 0, false, errors.New("event cursor (Last-Event-ID or last_event_id) must be a non-negative event id")
+	}
+	return id, true, nil
+}
+
+// HandleEventStream serves GET /v1/events/stream: timeline events as SSE,
+// each with its events.id as the SSE id. Without a Last-Event-ID the stream
+// starts at the next event; with one it first replays everything after it.
+func HandleEventStream(w http.ResponseWriter, r *http.Request) {
+	corsMiddleware(w, r)
+	r = ensureRequestContext(w, r)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeJSONErrorForRequest(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if !requireRole(w, r, RoleViewer) {
+		return
+	}
+
+	filter := parseEventStreamFilter(r)
+	cursor, resume, err := parseLastEventID(r)
+	if err != nil {
+		writeJSONErrorForRequest(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := ensureAPIDBReady(); err != nil {
+		writeJSONErrorForRequest(w, r, http.StatusInternalServerError, fmt.Sprintf("database init failed: %v", err))
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSONErrorForRequest(w, r, http.StatusInternalServerError, "Streaming unsupported")
+		return
+	}
+
+	// Subscribe before reading the cursor so no insert falls in between.
+	sub := database.SubscribeEvents(eventStreamBuffer)
+	defer sub.Close()
+	if !resume {
+		if cursor, err = database.MaxEventRowID(); err != nil {
+			writeJSONErrorForRequest(w, r, http.StatusInternalServerError, fmt.Sprintf("failed to read event cursor: %v", err))
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// Streams outlive the server's WriteTimeout.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	send := func(e database.UnifiedEvent) error {
+		cursor = int64(e.ID)
+		if !filter.match(e) {
+			return nil
+		}
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "id: %d\ndata: %s\n\n", e.ID, data)
+		return err
+	}
+	catchUp := func() error {
+		for {
+			events, err := database.GetUnifiedEventsAfter(cursor, eventStreamCatchUpBatch)
+			if err != nil {
+				return err
+			}
+			for _, e := range events {
+				if err := send(e); err != nil {
+					return err
+				}
+			}
+			if len(events) < eventStreamCatchUpBatch {
+				return nil
+			}
+		}
+	}
+
+	if resume {
+		if err := catchUp(); err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+
+	poll := time.NewTicker(eventStreamPollInterval)
+	defer poll.Stop()
+	heartbeat := time.NewTicker(eventStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case e := <-sub.C:
+			switch {
+			case int64(e.ID) <= cursor:
+				continue
+			case int64(e.ID) == cursor+1 && !sub.Lagged():
+				err = send(e)
+			default:
+				// A gap means the bus dropped events or another process
+				// wrote some; the table has them in order.
+				err = catchUp()
+			}
+		case <-poll.C:
+			err = catchUp()
+		case <-heartbeat.C:
+			_, err = fmt.Fprint(w, ": keep-alive\n\n")
+		}
+		if err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
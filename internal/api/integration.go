@@ -49,7 +49,7 @@ func HandleIntegrationWorkspaceRegister(w http.ResponseWriter, r *http.Request)
 		writeJSONErrorForRequest(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
-	if !requireAuth(w, r) {
+	if !requireRole(w, r, "") {
 		return
 	}
 	if err := ensureAPIDBReady(); err != nil {
@@ -82,6 +82,10 @@ func HandleIntegrationWorkspaceRegister(w http.ResponseWriter, r *http.Request)
 		respondErr(http.StatusBadRequest, "workspace_id must match [A-Za-z0-9._:-] and be <= 128 chars")
 		return
 	}
+	if role := IntegrationRole(req.WorkspaceID); !hasRole(r, role) {
+		respondErr(http.StatusForbidden, fmt.Sprintf("token %s lacks the %s role", actorFromRequest(r), role))
+		return
+	}
 	req.WorkspacePath = strings.TrimSpace(req.WorkspacePath)
 	if req.WorkspacePath == "" || !filepath.IsAbs(req.WorkspacePath) {
 		respondErr(http.StatusBadRequest, "workspace_path must be an absolute path")
@@ -184,7 +188,7 @@ func handleIntegrationWorkspaceUnregister(w http.ResponseWriter, r *http.Request
 		writeJSONErrorForRequest(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
-	if !requireAuth(w, r) {
+	if !requireRole(w, r, IntegrationRole(workspaceID)) {
 		return
 	}
 	idemCtx, handled := beginIdempotentMutation(w, r, fmt.Sprintf("DELETE /v1/integrations/workspaces/%s", workspaceID))
@@ -277,7 +281,7 @@ func handleIntegrationWorkspaceProtection(w http.ResponseWriter, r *http.Request
 		writeJSONErrorForRequest(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
-	if !requireAuth(w, r) {
+	if !requireRole(w, r, IntegrationRole(workspaceID)) {
 		return
 	}
 	idemCtx, handled := beginIdempotentMutation(w, r, fmt.Sprintf("POST /v1/integrations/workspaces/%s/protection", workspaceID))
@@ -334,7 +338,7 @@ func handleIntegrationWorkspaceActions(w http.ResponseWriter, r *http.Request, w
 		writeJSONErrorForRequest(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
-	if !requireAuth(w, r) {
+	if !requireRole(w, r, IntegrationRole(workspaceID)) {
 		return
 	}
 	idemCtx, handled := beginIdempotentMutation(w, r, fmt.Sprintf("POST /v1/integrations/workspaces/%s/actions", workspaceID))
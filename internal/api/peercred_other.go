@@ -0,0 +1,13 @@
+//go:build !linux
+
+package api
+
+import (
+	"errors"
+	"net"
+)
+
+// Without SO_PEERCRED, socket callers authenticate with the API key.
+func peerCredentials(_ net.Conn) (peerCred, error) {
+	return peerCred{}, errors.New("peer credentials are only supported on Linux")
+}
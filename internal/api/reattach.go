@@ -0,0 +1,138 @@
+package api
+
+import (
+	"flowforge/internal/daemon"
+	"flowforge/internal/state"
+	"flowforge/internal/supervisor"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+var (
+	managedHookMu sync.Mutex
+	managedHook   func([]daemon.WorkerRecord)
+)
+
+// SetManagedWorkersHook makes fn receive ManagedWorkerRecords every time a
+// managed worker starts, stops, pauses or resumes, and once right away. The
+// daemon uses it to keep its state file current; nil removes the hook.
+func SetManagedWorkersHook(fn func([]daemon.WorkerRecord)) {
+	managedHookMu.Lock()
+	managedHook = fn
+	managedHookMu.Unlock()
+	notifyManagedWorkers()
+}
+
+// notifyManagedWorkers must be called without any worker's lock held. The
+// hook lock is held while the records are collected, so the last call
+// always delivers the latest set.
+func notifyManagedWorkers() {
+	managedHookMu.Lock()
+	defer managedHookMu.Unlock()
+	if managedHook != nil {
+		managedHook(ManagedWorkerRecords())
+	}
+}
+
+// ManagedWorkerRecords returns the running workers this process launched or
+// adopted, keyed by run ID, in registration order.
+func ManagedWorkerRecords() []daemon.WorkerRecord {
+	records := []daemon.WorkerRecord{}
+	for _, w := range workers.list() {
+		if rec, ok := w.record(); ok {
+			records = append(records, rec)
+		}
+	}
+	return records
+}
+
+func (w *workerLifecycle) record() (daemon.WorkerRecord, bool) {
+	runID := w.store.RunID()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if runID == "" || !w.managed || w.controller == nil || w.controller.Exited() {
+		return daemon.WorkerRecord{}, false
+	}
+	if w.identity.PID <= 0 || w.identity.PID != w.controller.PID() {
+		return daemon.WorkerRecord{}, false
+	}
+	return daemon.WorkerRecord{
+		RunID:           runID,
+		Command:         w.spec.Command,
+		Args:            append([]string(nil), w.spec.Args...),
+		Dir:             w.spec.Dir,
+		Env:             append([]string(nil), w.spec.Env...),
+		Paused:          w.phase == lifecyclePaused,
+		ProcessIdentity: w.identity,
+	}, true
+}
+
+// ReattachWorkers re-adopts the persisted workers whose process group leader
+// is still the process that was recorded. Each one is listed under its run
+// ID again with a "reattached" lifecycle event; a worker that was paused is
+// paused again with a fresh auto-kill timeout. It returns the run IDs it
+// adopted and one error per record it left alone.
+func ReattachWorkers(records []daemon.WorkerRecord) ([]string, []error) {
+	var adopted []string
+	var errs []error
+	for _, rec := range records {
+		if err := reattachWorker(rec); err != nil {
+			errs = append(errs, fmt.Errorf("worker %s (pid %d): %w", rec.RunID, rec.PID, err))
+			continue
+		}
+		adopted = append(adopted, rec.RunID)
+	}
+	notifyManagedWorkers()
+	return adopted, errs
+}
+
+func reattachWorker(rec daemon.WorkerRecord) error {
+	runID := strings.TrimSpace(rec.RunID)
+	if runID == "" {
+		return fmt.Errorf("run ID is missing")
+	}
+	spec := workerSpec{
+		Command: rec.Command,
+		Args:    append([]string(nil), rec.Args...),
+		Dir:     rec.Dir,
+		Env:     append([]string(nil), rec.Env...),
+	}
+	if !spec.valid() {
+		return fmt.Errorf("command is missing")
+	}
+	proc, err := supervisor.Adopt(rec.ProcessIdentity)
+	if err != nil {
+		return err
+	}
+
+	w := newWorkerLifecycle(state.NewStore(runID))
+	w.spec = spec
+	if err := workers.claim(w); err != nil {
+		return err
+	}
+	pid := proc.PID()
+	w.mu.Lock()
+	w.controller = proc
+	w.managed = true
+	w.pid = pid
+	w.identity = proc.Identity()
+	w.phase = lifecycleRunning
+	w.startWatcherLocked(proc, true)
+	w.mu.Unlock()
+
+	w.store.UpdateState(0, "", "RUNNING", spec.Command, spec.Args, spec.Dir, pid)
+	w.store.UpdateLifecycle(lifecycleRunning, "RUNNING", pid)
+	w.emitLifecycleEvidence(lifecycleEvidence{
+		Phase:     lifecycleRunning,
+		Operation: opNone,
+		PID:       pid,
+		Managed:   true,
+		Trigger:   "reattached",
+	})
+	if rec.Paused {
+		// Only fails if the worker exited meanwhile; its watcher records that.
+		_, _ = w.requestPause(0, "reattached_paused")
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"errors"
+	"flowforge/internal/runlog"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	runLogsDefaultTail = 200
+	runLogsMaxTail     = 10000
+)
+
+// HandleRunLogs serves GET /v1/runs/{run_id}/logs from the run's captured
+// output. Output can contain anything the child printed, so it requires the
+// viewer role whenever credentials are configured.
+func HandleRunLogs(w http.ResponseWriter, r *http.Request) {
+	corsMiddleware(w, r)
+	r = ensureRequestContext(w, r)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeJSONErrorForRequest(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if !requireRole(w, r, RoleViewer) {
+		return
+	}
+
+	runID, err := parseRunLogsPath(r.URL.Path)
+	if err != nil {
+		writeJSONErrorForRequest(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+
+	opts := runlog.ReadOptions{Tail: runLogsDefaultTail}
+	query := r.URL.Query()
+	if rawTail := strings.TrimSpace(query.Get("tail")); rawTail != "" {
+		tail, err := strconv.Atoi(rawTail)
+		if err != nil || tail < 1 || tail > runLogsMaxTail {
+			writeJSONErrorForRequest(w, r, http.StatusBadRequest, fmt.Sprintf("tail must be an integer between 1 and %d", runLogsMaxTail))
+			return
+		}
+		opts.Tail = tail
+	}
+	if rawSince := strings.TrimSpace(query.Get("since")); rawSince != "" {
+		since, err := runlog.ParseSince(rawSince, time.Now())
+		if err != nil {
+			writeJSONErrorForRequest(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		opts.Since = since
+	}
+
+	lines, err := runlog.Read(runID, opts)
+	switch {
+	case errors.Is(err, runlog.ErrNotFound):
+		writeJSONErrorForRequest(w, r, http.StatusNotFound, "no captured output for run")
+		return
+	case err != nil:
+		writeJSONErrorForRequest(w, r, http.StatusInternalServerError, fmt.Sprintf("failed to read run output: %v", err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"run_id": runID,
+		"count":  len(lines),
+		"lines":  lines,
+	})
+}
+
+func parseRunLogsPath(path string) (string, error) {
+	const basePath = "/v1/runs/"
+	trimmedPath := strings.TrimSpace(path)
+	if !strings.HasPrefix(trimmedPath, basePath) || !strings.HasSuffix(trimmedPath, "/logs") {
+		return "", fmt.Errorf("run endpoint not found")
+	}
+	rawID := strings.TrimSuffix(strings.TrimPrefix(trimmedPath, basePath), "/logs")
+	if rawID == "" || strings.Contains(rawID, "/") {
+		return "", fmt.Errorf("run_id must be a single path segment in /v1/runs/{run_id}/logs")
+	}
+	runID, err := url.PathUnescape(rawID)
+	if err != nil {
+		return "", fmt.Errorf("run_id is invalid: %v", err)
+	}
+	if _, err := runlog.RunDir(runID); errors.Is(err, runlog.ErrInvalidRunID) {
+		return "", fmt.Errorf("run_id is invalid")
+	}
+	return runID, nil
+}
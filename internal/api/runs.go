@@ -0,0 +1,170 @@
+package api
+
+import (
+	"flowforge/internal/database"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// RunRequest is the body of POST /v1/runs.
+type RunRequest struct {
+	Argv       []string          `json:"argv"`
+	Dir        string            `json:"dir,omitempty"`
+	Env        map[string]string `json:"env,omitempty"`
+	Profile    string            `json:"profile,omitempty"`
+	PolicyFile string            `json:"policy_file,omitempty"`
+	// Overrides maps `flowforge run` flag names to values, e.g.
+	// {"max-cpu": 80, "no-kill": true}.
+	Overrides map[string]interface{} `json:"overrides,omitempty"`
+}
+
+// RunLauncher turns a validated run request into the argv of the process
+// that supervises it. An error rejects the request as invalid.
+type RunLauncher func(runID string, req RunRequest) ([]string, error)
+
+var (
+	runLauncherMu sync.RWMutex
+	runLauncher   RunLauncher
+)
+
+// SetRunLauncher enables POST /v1/runs. The daemon installs a launcher that
+// re-executes `flowforge run`; passing nil disables submissions again.
+func SetRunLauncher(launcher RunLauncher) {
+	runLauncherMu.Lock()
+	runLauncher = launcher
+	runLauncherMu.Unlock()
+}
+
+func currentRunLauncher() RunLauncher {
+	runLauncherMu.RLock()
+	defer runLauncherMu.RUnlock()
+	return runLauncher
+}
+
+// HandleRuns serves POST /v1/runs: it starts a supervised run as a managed
+// worker and answers with its run ID.
+func HandleRuns(w http.ResponseWriter, r *http.Request) {
+	corsMiddleware(w, r)
+	r = ensureRequestContext(w, r)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeJSONErrorForRequest(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if !requireRole(w, r, RoleOperator) {
+		return
+	}
+	launcher := currentRunLauncher()
+	if launcher == nil {
+		writeJSONErrorForRequest(w, r, http.StatusServiceUnavailable, "run submission is only available from the FlowForge daemon")
+		return
+	}
+	if err := ensureAPIDBReady(); err != nil {
+		writeJSONErrorForRequest(w, r, http.StatusInternalServerError, fmt.Sprintf("database init failed: %v", err))
+		return
+	}
+	idemCtx, handled := beginIdempotentMutation(w, r, "POST /v1/runs")
+	if handled {
+		return
+	}
+
+	respond := func(status int, payload map[string]interface{}) {
+		persistIdempotentMutation(idemCtx, status, payload)
+		writeJSON(w, status, payload)
+	}
+	respondErr := func(status int, msg string) {
+		payload := problemPayload(r, status, msg, nil)
+		persistIdempotentMutation(idemCtx, status, payload)
+		writeProblem(w, status, payload)
+	}
+
+	var req RunRequest
+	if err := decodeJSONBody(r, &req); err != nil {
+		respondErr(http.StatusBadRequest, err.Error())
+		return
+	}
+	env, err := normalizeRunRequest(&req)
+	if err != nil {
+		respondErr(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	runID := uuid.NewString()
+	args, err := launcher(runID, req)
+	if err != nil {
+		respondErr(http.StatusBadRequest, err.Error())
+		return
+	}
+	command := strings.Join(req.Argv, " ")
+	pid, err := StartWorker(WorkerLaunch{
+		RunID:   runID,
+		Command: command,
+		Args:    args,
+		Dir:     req.Dir,
+		Env:     env,
+	})
+	if err != nil {
+		respondErr(lifecycleHTTPCode(err, http.StatusInternalServerError), fmt.Sprintf("run start failed: %v", err))
+		return
+	}
+
+	reason := mutationReason(r)
+	if reason == "" {
+		reason = fmt.Sprintf("run submitted via API: %s", runID)
+	}
+	_ = database.LogAuditEventWithIncidentAndRequestID(actorFromRequest(r), "RUN_SUBMIT", annotateReasonWithRequestID(reason, r), "api", pid, command, uuid.NewString(), requestIDFromRequest(r))
+
+	w.Header().Set("Location", "/v1/workers/"+url.PathEscape(runID))
+	respond(http.StatusAccepted, map[string]interface{}{
+		"run_id":    runID,
+		"status":    "started",
+		"pid":       pid,
+		"lifecycle": lifecycleRunning,
+		"command":   command,
+	})
+}
+
+// normalizeRunRequest trims and checks req in place and returns its env
+// overrides as sorted KEY=VALUE pairs.
+func normalizeRunRequest(req *RunRequest) ([]string, error) {
+	if len(req.Argv) == 0 || strings.TrimSpace(req.Argv[0]) == "" {
+		return nil, fmt.Errorf("argv must name a command")
+	}
+	req.Dir = strings.TrimSpace(req.Dir)
+	if req.Dir != "" {
+		if !filepath.IsAbs(req.Dir) {
+			return nil, fmt.Errorf("dir must be an absolute path")
+		}
+		info, err := os.Stat(req.Dir)
+		if err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("dir %s is not a directory", req.Dir)
+		}
+	}
+	req.Profile = strings.TrimSpace(req.Profile)
+	req.PolicyFile = strings.TrimSpace(req.PolicyFile)
+
+	keys := make([]string, 0, len(req.Env))
+	for key, value := range req.Env {
+		if key == "" || strings.ContainsAny(key, "=\x00") || strings.ContainsRune(value, 0) {
+			return nil, fmt.Errorf("env key %q is invalid", key)
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	env := make([]string, 0, len(keys))
+	for _, key := range keys {
+		env = append(env, key+"="+req.Env[key])
+	}
+	return env, nil
+}
@@ -3,7 +3,6 @@ package api
 import (
 	"bytes"
 	"context"
-	"crypto/subtle"
 	"database/sql"
 	"encoding/json"
 	"errors"
@@ -87,6 +86,17 @@ func (s *statusRecorder) WriteHeader(status int) {
 	s.ResponseWriter.WriteHeader(status)
 }
 
+// Flush lets SSE handlers stream through the recorder.
+func (s *statusRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
 func corsMiddleware(w http.ResponseWriter, r *http.Request) {
 	origin := strings.TrimSpace(r.Header.Get("Origin"))
 
@@ -149,46 +159,6 @@ func withSecurity(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
-// requireAuth checks the FLOWFORGE_API_KEY env var.
-// If no key is set, mutating endpoints are blocked.
-func requireAuth(w http.ResponseWriter, r *http.Request) bool {
-	ip := clientIP(r.RemoteAddr)
-	apiKey := os.Getenv("FLOWFORGE_API_KEY")
-
-	if apiKey == "" {
-		if isUnsafeMethod(r.Method) {
-			writeJSONErrorForRequest(w, r, http.StatusForbidden, "Security Alert: You must set FLOWFORGE_API_KEY environment variable to perform mutations.")
-			return false
-		}
-		return true
-	}
-
-	authHeader := r.Header.Get("Authorization")
-	if !strings.HasPrefix(authHeader, "Bearer ") {
-		apiMetrics.IncAuthFailure()
-		if apiLimiter.addAuthFailure(ip) {
-			writeJSONErrorForRequest(w, r, http.StatusTooManyRequests, "Too many failed auth attempts. Retry later.")
-			return false
-		}
-		writeJSONErrorForRequest(w, r, http.StatusUnauthorized, "Authorization required")
-		return false
-	}
-
-	token := strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer "))
-	if subtle.ConstantTimeCompare([]byte(token), []byte(apiKey)) != 1 {
-		apiMetrics.IncAuthFailure()
-		if apiLimiter.addAuthFailure(ip) {
-			writeJSONErrorForRequest(w, r, http.StatusTooManyRequests, "Too many failed auth attempts. Retry later.")
-			return false
-		}
-		writeJSONErrorForRequest(w, r, http.StatusForbidden, "Invalid API key")
-		return false
-	}
-
-	apiLimiter.clearAuthFailures(ip)
-	return true
-}
-
 func isUnsafeMethod(method string) bool {
 	switch strings.ToUpper(strings.TrimSpace(method)) {
 	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
@@ -290,14 +260,8 @@ func Start(port string) func() {
 		fmt.Println("⚠️  No FLOWFORGE_API_KEY set - mutating endpoints are blocked")
 	}
 
-	server := &http.Server{
-		Addr:              resolveBindAddr(port),
-		Handler:           NewHandler(),
-		ReadHeaderTimeout: 5 * time.Second,
-		ReadTimeout:       10 * time.Second,
-		WriteTimeout:      15 * time.Second,
-		IdleTimeout:       60 * time.Second,
-	}
+	server := newHTTPServer(resolveBindAddr(port))
+	stopWebhooks := startWebhookDispatcher()
 
 	go func() {
 		fmt.Printf("API listening on %s\n", server.Addr)
@@ -312,6 +276,18 @@ func Start(port string) func() {
 		if err := server.Shutdown(ctx); err != nil {
 			log.Printf("Server shutdown failed: %v", err)
 		}
+		stopWebhooks()
+	}
+}
+
+func newHTTPServer(addr string) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           NewHandler(),
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       10 * time.Second,
+		WriteTimeout:      15 * time.Second,
+		IdleTimeout:       60 * time.Second,
 	}
 }
 
@@ -320,6 +296,7 @@ func NewHandler() http.Handler {
 	mux := http.NewServeMux()
 	registerRoute(mux, "/stream", handleStream)
 	registerRoute(mux, "/v1/stream", handleStream)
+	registerRoute(mux, "/v1/events/stream", HandleEventStream)
 
 	registerRoute(mux, "/incidents", HandleIncidents)
 	registerRoute(mux, "/v1/incidents", HandleIncidents)
@@ -330,6 +307,12 @@ func NewHandler() http.Handler {
 	registerRoute(mux, "/process/restart", HandleProcessRestart)
 	registerRoute(mux, "/v1/process/restart", HandleProcessRestart)
 
+	registerRoute(mux, "/process/pause", HandleProcessPause)
+	registerRoute(mux, "/v1/process/pause", HandleProcessPause)
+
+	registerRoute(mux, "/process/resume", HandleProcessResume)
+	registerRoute(mux, "/v1/process/resume", HandleProcessResume)
+
 	registerRoute(mux, "/healthz", HandleHealth)
 	registerRoute(mux, "/v1/healthz", HandleHealth)
 
@@ -339,6 +322,9 @@ func NewHandler() http.Handler {
 	registerRoute(mux, "/metrics", HandleMetrics)
 	registerRoute(mux, "/v1/metrics", HandleMetrics)
 
+	registerRoute(mux, "/v1/workers", HandleWorkers)
+	registerRoute(mux, "/v1/workers/", HandleWorker)
+
 	registerRoute(mux, "/worker/lifecycle", HandleWorkerLifecycle)
 	registerRoute(mux, "/v1/worker/lifecycle", HandleWorkerLifecycle)
 
@@ -348,8 +334,11 @@ func NewHandler() http.Handler {
 	registerRoute(mux, "/v1/ops/controlplane/replay/history", HandleControlPlaneReplayHistory)
 	registerRoute(mux, "/v1/ops/requests", HandleRequestTrace)
 	registerRoute(mux, "/v1/ops/requests/", HandleRequestTrace)
+	registerRoute(mux, "/v1/runs", HandleRuns)
+	registerRoute(mux, "/v1/runs/", HandleRunLogs)
 	registerRoute(mux, "/v1/ops/decisions/replay/health", HandleDecisionReplayHealth)
 	registerRoute(mux, "/v1/ops/decisions/signals/baseline", HandleDecisionSignalBaseline)
+	registerRoute(mux, "/v1/ops/webhooks/deliveries", HandleWebhookDeliveries)
 	registerRoute(mux, "/v1/ops/decisions/replay", HandleDecisionReplay)
 	registerRoute(mux, "/v1/ops/decisions/replay/", HandleDecisionReplay)
 	registerRoute(mux, "/v1/integrations/workspaces/register", HandleIntegrationWorkspaceRegister)
@@ -375,6 +364,20 @@ func resolveBindAddr(port string) string {
 }
 
 func handleStream(w http.ResponseWriter, r *http.Request) {
+	if !requireRole(w, r, RoleViewer) {
+		return
+	}
+	frames, err := streamFrames(r.URL.Query().Get("worker"))
+	if err != nil {
+		writeJSONErrorForRequest(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	serveStateStream(w, r, frames)
+}
+
+// serveStateStream pushes the frames as SSE data events every 500ms until the
+// client goes away.
+func serveStateStream(w http.ResponseWriter, r *http.Request, frames func() [][]byte) {
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
@@ -385,6 +388,9 @@ func handleStream(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Streams outlive the server's WriteTimeout.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
 	ticker := time.NewTicker(500 * time.Millisecond)
 	defer ticker.Stop()
 
@@ -393,11 +399,10 @@ func handleStream(w http.ResponseWriter, r *http.Request) {
 		case <-r.Context().Done():
 			return
 		case <-ticker.C:
-			jsonData, err := state.JSON()
-			if err == nil {
+			for _, jsonData := range frames() {
 				fmt.Fprintf(w, "data: %s\n\n", jsonData)
-				flusher.Flush()
 			}
+			flusher.Flush()
 		}
 	}
 }
@@ -505,6 +510,9 @@ func HandleControlPlaneReplayHistory(w http.ResponseWriter, r *http.Request) {
 		writeJSONErrorForRequest(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
+	if !requireRole(w, r, RoleViewer) {
+		return
+	}
 
 	days := 7
 	if rawDays := strings.TrimSpace(r.URL.Query().Get("days")); rawDays != "" {
@@ -556,6 +564,9 @@ func HandleRequestTrace(w http.ResponseWriter, r *http.Request) {
 		writeJSONErrorForRequest(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
+	if !requireRole(w, r, RoleViewer) {
+		return
+	}
 
 	requestID, err := parseRequestTraceID(r.URL.Path)
 	if err != nil {
@@ -603,6 +614,9 @@ func HandleDecisionReplay(w http.ResponseWriter, r *http.Request) {
 		writeJSONErrorForRequest(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
+	if !requireRole(w, r, RoleViewer) {
+		return
+	}
 
 	traceID, err := parseDecisionReplayTraceID(r.URL.Path)
 	if err != nil {
@@ -792,6 +806,9 @@ func HandleDecisionReplayHealth(w http.ResponseWriter, r *http.Request) {
 		writeJSONErrorForRequest(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
+	if !requireRole(w, r, RoleViewer) {
+		return
+	}
 
 	limit, err := parseDecisionReplayHealthLimit(r.URL.Query().Get("limit"))
 	if err != nil {
@@ -836,6 +853,9 @@ func HandleDecisionSignalBaseline(w http.ResponseWriter, r *http.Request) {
 		writeJSONErrorForRequest(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
+	if !requireRole(w, r, RoleViewer) {
+		return
+	}
 
 	limit, err := parseDecisionSignalBaselineLimit(r.URL.Query().Get("limit"))
 	if err != nil {
@@ -1715,6 +1735,9 @@ func HandleWorkerLifecycle(w http.ResponseWriter, r *http.Request) {
 		writeJSONErrorForRequest(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
+	if !requireRole(w, r, RoleViewer) {
+		return
+	}
 	snap := WorkerLifecycleSnapshot()
 	st := state.GetState()
 	writeJSON(w, http.StatusOK, map[string]interface{}{
@@ -1741,6 +1764,9 @@ func HandleTimeline(w http.ResponseWriter, r *http.Request) {
 		writeJSONErrorForRequest(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
+	if !requireRole(w, r, RoleViewer) {
+		return
+	}
 
 	if err := ensureAPIDBReady(); err != nil {
 		writeJSONErrorForRequest(w, r, http.StatusInternalServerError, fmt.Sprintf("database init failed: %v", err))
@@ -1813,6 +1839,9 @@ func HandleIncidents(w http.ResponseWriter, r *http.Request) {
 		writeJSONErrorForRequest(w, r, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
+	if !requireRole(w, r, RoleViewer) {
+		return
+	}
 
 	if err := ensureAPIDBReady(); err != nil {
 		writeJSONErrorForRequest(w, r, http.StatusInternalServerError, fmt.Sprintf("database init failed: %v", err))
@@ -1871,10 +1900,17 @@ func HandleProcessKill(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !requireAuth(w, r) {
+	if !requireRole(w, r, RoleOperator) {
 		return
 	}
-	idemCtx, handled := beginIdempotentMutation(w, r, "POST /process/kill")
+	workerControl.registerSpecFromStateIfMissing()
+	serveWorkerKill(w, r, workerControl, "POST /process/kill")
+}
+
+// serveWorkerKill stops worker for an authenticated kill request; endpoint
+// scopes the Idempotency-Key ledger.
+func serveWorkerKill(w http.ResponseWriter, r *http.Request, worker *workerLifecycle, endpoint string) {
+	idemCtx, handled := beginIdempotentMutation(w, r, endpoint)
 	if handled {
 		return
 	}
@@ -1883,8 +1919,7 @@ func HandleProcessKill(w http.ResponseWriter, r *http.Request) {
 		reason = "manual API kill request"
 	}
 
-	workerControl.registerSpecFromStateIfMissing()
-	decision, err := requestLifecycleKill()
+	decision, err := worker.requestKill()
 	if err != nil {
 		statusCode := lifecycleHTTPCode(err, http.StatusInternalServerError)
 		msg := lifecycleErrorMessage(err, "failed to request kill")
@@ -1894,7 +1929,7 @@ func HandleProcessKill(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	stats := state.GetState()
+	stats := worker.store.GetState()
 	if decision.AcceptedNew {
 		apiMetrics.IncProcessKill()
 		incidentID := uuid.NewString()
@@ -1922,10 +1957,17 @@ func HandleProcessRestart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !requireAuth(w, r) {
+	if !requireRole(w, r, RoleOperator) {
 		return
 	}
-	idemCtx, handled := beginIdempotentMutation(w, r, "POST /process/restart")
+	workerControl.registerSpecFromStateIfMissing()
+	serveWorkerRestart(w, r, workerControl, "POST /process/restart")
+}
+
+// serveWorkerRestart relaunches worker's last command for an authenticated
+// restart request.
+func serveWorkerRestart(w http.ResponseWriter, r *http.Request, worker *workerLifecycle, endpoint string) {
+	idemCtx, handled := beginIdempotentMutation(w, r, endpoint)
 	if handled {
 		return
 	}
@@ -1934,13 +1976,12 @@ func HandleProcessRestart(w http.ResponseWriter, r *http.Request) {
 		reason = "manual API restart request"
 	}
 
-	workerControl.registerSpecFromStateIfMissing()
-	decision, err := requestLifecycleRestart()
+	decision, err := worker.requestRestart()
 	if err != nil {
 		statusCode := lifecycleHTTPCode(err, http.StatusInternalServerError)
 		msg := lifecycleErrorMessage(err, "failed to request restart")
 		if statusCode == http.StatusTooManyRequests {
-			stats := state.GetState()
+			stats := worker.store.GetState()
 			incidentID := uuid.NewString()
 			_ = database.LogAuditEventWithIncidentAndRequestID(actorFromRequest(r), "RESTART_BLOCKED", annotateReasonWithRequestID(msg, r), "api", stats.PID, stats.Command, incidentID, requestIDFromRequest(r))
 		}
@@ -1957,7 +1998,7 @@ func HandleProcessRestart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	stats := state.GetState()
+	stats := worker.store.GetState()
 	if decision.AcceptedNew {
 		apiMetrics.IncProcessRestart()
 		incidentID := uuid.NewString()
@@ -1973,27 +2014,175 @@ func HandleProcessRestart(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusAccepted, payload)
 }
 
+// HandleProcessPause is exported for testing. It freezes the worker's process
+// group; the worker is killed unless resumed within timeout_seconds (default
+// FLOWFORGE_PAUSE_TIMEOUT_SECONDS).
+func HandleProcessPause(w http.ResponseWriter, r *http.Request) {
+	corsMiddleware(w, r)
+	r = ensureRequestContext(w, r)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeJSONErrorForRequest(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	if !requireRole(w, r, RoleOperator) {
+		return
+	}
+	workerControl.registerSpecFromStateIfMissing()
+	serveWorkerPause(w, r, workerControl, "POST /process/pause")
+}
+
+// serveWorkerPause freezes worker for an authenticated pause request.
+func serveWorkerPause(w http.ResponseWriter, r *http.Request, worker *workerLifecycle, endpoint string) {
+	timeout, err := pauseTimeoutFromRequest(r)
+	if err != nil {
+		writeJSONErrorForRequest(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	idemCtx, handled := beginIdempotentMutation(w, r, endpoint)
+	if handled {
+		return
+	}
+	reason := mutationReason(r)
+	if reason == "" {
+		reason = "manual API pause request"
+	}
+
+	decision, err := worker.requestPause(timeout, "pause_requested")
+	if err != nil {
+		statusCode := lifecycleHTTPCode(err, http.StatusInternalServerError)
+		msg := lifecycleErrorMessage(err, "failed to request pause")
+		payload := problemPayload(r, statusCode, msg, nil)
+		persistIdempotentMutation(idemCtx, statusCode, payload)
+		writeProblem(w, statusCode, payload)
+		return
+	}
+
+	stats := worker.store.GetState()
+	if decision.AcceptedNew {
+		incidentID := uuid.NewString()
+		_ = database.LogAuditEventWithIncidentAndRequestID(actorFromRequest(r), "PAUSE", annotateReasonWithRequestID(reason, r), "api", decision.PID, stats.Command, incidentID, requestIDFromRequest(r))
+	}
+	payload := map[string]interface{}{
+		"status":    decision.Status,
+		"pid":       decision.PID,
+		"lifecycle": decision.Lifecycle,
+	}
+	if autoKillAt, ok := worker.snapshot()["auto_kill_at"]; ok {
+		payload["auto_kill_at"] = autoKillAt
+	}
+	persistIdempotentMutation(idemCtx, http.StatusAccepted, payload)
+	writeJSON(w, http.StatusAccepted, payload)
+}
+
+// HandleProcessResume is exported for testing.
+func HandleProcessResume(w http.ResponseWriter, r *http.Request) {
+	corsMiddleware(w, r)
+	r = ensureRequestContext(w, r)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeJSONErrorForRequest(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	if !requireRole(w, r, RoleOperator) {
+		return
+	}
+	serveWorkerResume(w, r, workerControl, "POST /process/resume")
+}
+
+// serveWorkerResume continues worker for an authenticated resume request.
+func serveWorkerResume(w http.ResponseWriter, r *http.Request, worker *workerLifecycle, endpoint string) {
+	idemCtx, handled := beginIdempotentMutation(w, r, endpoint)
+	if handled {
+		return
+	}
+	reason := mutationReason(r)
+	if reason == "" {
+		reason = "manual API resume request"
+	}
+
+	decision, err := worker.requestResume()
+	if err != nil {
+		statusCode := lifecycleHTTPCode(err, http.StatusInternalServerError)
+		msg := lifecycleErrorMessage(err, "failed to request resume")
+		payload := problemPayload(r, statusCode, msg, nil)
+		persistIdempotentMutation(idemCtx, statusCode, payload)
+		writeProblem(w, statusCode, payload)
+		return
+	}
+
+	stats := worker.store.GetState()
+	if decision.AcceptedNew {
+		incidentID := uuid.NewString()
+		_ = database.LogAuditEventWithIncidentAndRequestID(actorFromRequest(r), "RESUME", annotateReasonWithRequestID(reason, r), "api", decision.PID, stats.Command, incidentID, requestIDFromRequest(r))
+	}
+	payload := map[string]interface{}{
+		"status":    decision.Status,
+		"pid":       decision.PID,
+		"lifecycle": decision.Lifecycle,
+	}
+	persistIdempotentMutation(idemCtx, http.StatusAccepted, payload)
+	writeJSON(w, http.StatusAccepted, payload)
+}
+
+// pauseTimeoutFromRequest reads the optional timeout_seconds of a pause
+// request; 0 means the configured default.
+func pauseTimeoutFromRequest(r *http.Request) (time.Duration, error) {
+	if r.Body == nil {
+		return 0, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, 2048))
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil || len(bytes.TrimSpace(body)) == 0 {
+		return 0, nil
+	}
+	var payload struct {
+		TimeoutSeconds *int `json:"timeout_seconds"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil || payload.TimeoutSeconds == nil {
+		return 0, nil
+	}
+	if *payload.TimeoutSeconds < 0 {
+		return 0, fmt.Errorf("timeout_seconds must be >= 0")
+	}
+	return time.Duration(*payload.TimeoutSeconds) * time.Second, nil
+}
+
 func killProcessTree(pid int) error {
+	return signalProcessTree(pid, syscall.SIGKILL)
+}
+
+// signalProcessTree signals pid's process group, falling back to pid alone.
+func signalProcessTree(pid int, sig syscall.Signal) error {
 	if pid <= 0 {
 		return fmt.Errorf("invalid pid %d", pid)
 	}
-	groupErr := syscall.Kill(-pid, syscall.SIGKILL)
+	groupErr := syscall.Kill(-pid, sig)
 	if groupErr == nil {
 		return nil
 	}
 
-	pidErr := syscall.Kill(pid, syscall.SIGKILL)
+	pidErr := syscall.Kill(pid, sig)
 	if pidErr == nil || errors.Is(pidErr, syscall.ESRCH) {
 		return nil
 	}
-	return fmt.Errorf("group kill failed: %v; pid kill failed: %w", groupErr, pidErr)
+	return fmt.Errorf("group %v failed: %v; pid %v failed: %w", sig, groupErr, sig, pidErr)
 }
 
+// actorFromRequest names the caller in audit events: the token name, "api-key",
+// "uid:<uid>" for socket peers, or "anonymous". Token material is never
+// recorded.
 func actorFromRequest(r *http.Request) string {
-	authHeader := strings.TrimSpace(r.Header.Get("Authorization"))
-	if strings.HasPrefix(authHeader, "Bearer ") {
-		// Never persist any token material in audit logs.
-		return "api-key"
+	if p, authErr := authenticate(r); authErr == nil {
+		return p.Actor
 	}
 	return "anonymous"
 }
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// peerCred identifies the process on the other end of a Unix socket.
+type peerCred struct {
+	PID int
+	UID int
+	GID int
+}
+
+const peerCredContextKey requestContextKey = "flowforge_peer_cred"
+
+// ListenUnix listens on a Unix socket at path that only the current user can
+// connect to. A socket left behind by a process that is gone is replaced;
+// one that still answers, or any other kind of file, is an error.
+func ListenUnix(path string) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if conn, err := net.DialTimeout("unix", path, 500*time.Millisecond); err == nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("%s is already in use by another server", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("remove stale socket: %w", err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	// The runtime dir is already private; this also covers a socket placed
+	// elsewhere.
+	if err := os.Chmod(path, 0o600); err != nil {
+		_ = ln.Close()
+		return nil, fmt.Errorf("restrict socket permissions: %w", err)
+	}
+	return ln, nil
+}
+
+// ServeUnix serves the API on a Unix socket at path alongside the TCP
+// listener from Start, and returns a stop function that also removes the
+// socket. Callers running as the daemon's own user are authenticated by
+// their peer credentials instead of the API key.
+func ServeUnix(path string) (func(), error) {
+	ln, err := ListenUnix(path)
+	if err != nil {
+		return nil, err
+	}
+	server := newHTTPServer("")
+	server.ConnContext = func(ctx context.Context, c net.Conn) context.Context {
+		cred, err := peerCredentials(c)
+		if err != nil {
+			return ctx
+		}
+		return context.WithValue(ctx, peerCredContextKey, cred)
+	}
+
+	go func() {
+		fmt.Printf("API listening on unix:%s\n", path)
+		if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			log.Printf("Unix socket serve warning: %v", err)
+		}
+	}()
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Printf("Unix socket shutdown failed: %v", err)
+		}
+	}, nil
+}
+
+// peerFromRequest returns the credentials of a caller connected over the
+// Unix socket.
+func peerFromRequest(r *http.Request) (peerCred, bool) {
+	if r == nil {
+		return peerCred{}, false
+	}
+	cred, ok := r.Context().Value(peerCredContextKey).(peerCred)
+	return cred, ok
+}
+
+// isSameUserPeer reports whether r came over the Unix socket from a process
+// running as the same user as this one.
+func isSameUserPeer(r *http.Request) bool {
+	cred, ok := peerFromRequest(r)
+	return ok && cred.UID == os.Getuid()
+}
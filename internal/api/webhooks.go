@@ -0,0 +1,118 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"flowforge/internal/database"
+	"flowforge/internal/webhooks"
+)
+
+const (
+	webhookDeliveriesDefaultLimit = 50
+	webhookDeliveriesMaxLimit     = 500
+)
+
+// startWebhookDispatcher starts delivering webhooks when
+// FLOWFORGE_WEBHOOK_URLS is set and returns its stop function.
+func startWebhookDispatcher() func() {
+	cfg, err := webhooks.LoadFromEnv()
+	if err != nil {
+		fmt.Printf("⚠️  Webhooks disabled: %v\n", err)
+		return func() {}
+	}
+	if !cfg.Enabled() {
+		return func() {}
+	}
+	if err := ensureAPIDBReady(); err != nil {
+		fmt.Printf("⚠️  Webhooks disabled: database init failed: %v\n", err)
+		return func() {}
+	}
+	kinds := make([]string, 0, len(cfg.Kinds))
+	for _, kind := range webhooks.AllKinds {
+		if cfg.Kinds[kind] {
+			kinds = append(kinds, kind)
+		}
+	}
+	fmt.Printf("Webhooks: delivering %s events to %d URL(s)\n", strings.Join(kinds, ", "), len(cfg.URLs))
+	return webhooks.NewDispatcher(cfg).Start()
+}
+
+// HandleWebhookDeliveries serves GET /v1/ops/webhooks/deliveries from the
+// delivery outbox, newest first. Webhook URLs are shown without credentials
+// or query strings, which often carry tokens.
+func HandleWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	corsMiddleware(w, r)
+	r = ensureRequestContext(w, r)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeJSONErrorForRequest(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if !requireRole(w, r, RoleViewer) {
+		return
+	}
+
+	query := r.URL.Query()
+	status := strings.TrimSpace(query.Get("status"))
+	switch status {
+	case "", database.WebhookDeliveryPending, database.WebhookDeliveryDelivered, database.WebhookDeliveryFailed:
+	default:
+		writeJSONErrorForRequest(w, r, http.StatusBadRequest, "status must be pending, delivered or failed")
+		return
+	}
+	limit := webhookDeliveriesDefaultLimit
+	if rawLimit := strings.TrimSpace(query.Get("limit")); rawLimit != "" {
+		parsedLimit, err := strconv.Atoi(rawLimit)
+		if err != nil || parsedLimit < 1 || parsedLimit > webhookDeliveriesMaxLimit {
+			writeJSONErrorForRequest(w, r, http.StatusBadRequest, fmt.Sprintf("limit must be an integer between 1 and %d", webhookDeliveriesMaxLimit))
+			return
+		}
+		limit = parsedLimit
+	}
+
+	if err := ensureAPIDBReady(); err != nil {
+		writeJSONErrorForRequest(w, r, http.StatusInternalServerError, fmt.Sprintf("database init failed: %v", err))
+		return
+	}
+	deliveries, err := database.ListWebhookDeliveries(status, limit)
+	if err != nil {
+		writeJSONErrorForRequest(w, r, http.StatusInternalServerError, fmt.Sprintf("failed to load webhook deliveries: %v", err))
+		return
+	}
+	totals, err := database.CountWebhookDeliveries()
+	if err != nil {
+		writeJSONErrorForRequest(w, r, http.StatusInternalServerError, fmt.Sprintf("failed to count webhook deliveries: %v", err))
+		return
+	}
+	for i := range deliveries {
+		deliveries[i].URL = redactWebhookURL(deliveries[i].URL)
+		if deliveries[i].Status != database.WebhookDeliveryPending {
+			deliveries[i].NextAttemptAt = ""
+		}
+	}
+
+	cfg, cfgErr := webhooks.LoadFromEnv()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"enabled":    cfgErr == nil && cfg.Enabled(),
+		"count":      len(deliveries),
+		"totals":     totals,
+		"deliveries": deliveries,
+	})
+}
+
+// redactWebhookURL keeps only the scheme and host: Slack, Discord and Teams
+// webhook URLs carry their credential in the path.
+func redactWebhookURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "(invalid url)"
+	}
+	return (&url.URL{Scheme: u.Scheme, Host: u.Host}).String()
+}
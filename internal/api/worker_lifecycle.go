@@ -5,6 +5,7 @@ import (
 	"flowforge/internal/database"
 	"flowforge/internal/state"
 	"flowforge/internal/supervisor"
+	"flowforge/internal/sysmon"
 	"fmt"
 	"os"
 	"os/exec"
@@ -20,6 +21,7 @@ const (
 	lifecycleRunning  = "RUNNING"
 	lifecycleStopping = "STOPPING"
 	lifecycleStopped  = "STOPPED"
+	lifecyclePaused   = "PAUSED"
 	lifecycleFailed   = "FAILED"
 )
 
@@ -27,6 +29,8 @@ const (
 	opNone    = ""
 	opKill    = "kill"
 	opRestart = "restart"
+	opPause   = "pause"
+	opResume  = "resume"
 )
 
 const (
@@ -34,6 +38,10 @@ const (
 	restartBudgetDefaultWindowSeconds = 300
 	envRestartBudgetMax               = "FLOWFORGE_RESTART_BUDGET_MAX"
 	envRestartBudgetWindowSeconds     = "FLOWFORGE_RESTART_BUDGET_WINDOW_SECONDS"
+
+	// A paused worker nobody resumes is killed after this long.
+	pauseTimeoutDefaultSeconds = 900
+	envPauseTimeoutSeconds     = "FLOWFORGE_PAUSE_TIMEOUT_SECONDS"
 )
 
 type restartBudgetConfig struct {
@@ -52,6 +60,26 @@ type workerSpec struct {
 	Command string
 	Args    []string
 	Dir     string
+	// Env holds KEY=VALUE pairs added to FlowForge's own environment.
+	Env []string
+	// Limits carries the rlimit/env controls of the registered worker so
+	// API restarts relaunch it under the same limits.
+	Limits supervisor.Limits
+}
+
+// pausableController is implemented by controllers that can freeze their
+// process group (supervisor.Supervisor). Workers without one are signalled
+// by PID.
+type pausableController interface {
+	Pause() error
+	Resume() error
+}
+
+// limitedController is implemented by controllers that apply process limits
+// (supervisor.Supervisor).
+type limitedController interface {
+	Limits() supervisor.Limits
+	AppliedLimits() supervisor.AppliedLimits
 }
 
 func (s workerSpec) valid() bool {
@@ -91,6 +119,11 @@ func newLifecycleErrorWithRetry(code int, msg string, retryAfterSeconds int) err
 type workerLifecycle struct {
 	mu sync.Mutex
 
+	// store holds the worker's process state; it also carries the run ID
+	// the worker is registered under.
+	store        *state.Store
+	registeredAt time.Time
+
 	phase     string
 	operation string
 
@@ -99,6 +132,9 @@ type workerLifecycle struct {
 	controller WorkerController
 	managed    bool
 	pid        int
+	// identity pins the process launched or adopted here, so a restarted
+	// daemon can tell it from a process that reused its pid.
+	identity sysmon.ProcessIdentity
 
 	lastErr string
 	watchID uint64
@@ -111,14 +147,21 @@ type workerLifecycle struct {
 	restartRequestedAt time.Time
 	restartHistory     []time.Time
 
+	// pauseTimer kills the worker when a pause outlives its timeout.
+	pauseTimer *time.Timer
+	pauseID    uint64
+	autoKillAt time.Time
+
 	acceptAsync bool
 	asyncWG     sync.WaitGroup
 }
 
-func newWorkerLifecycle() *workerLifecycle {
+func newWorkerLifecycle(store *state.Store) *workerLifecycle {
 	return &workerLifecycle{
-		phase:       lifecycleStopped,
-		acceptAsync: true,
+		store:        store,
+		registeredAt: time.Now(),
+		phase:        lifecycleStopped,
+		acceptAsync:  true,
 	}
 }
 
@@ -129,48 +172,78 @@ type lifecycleEvidence struct {
 	Managed   bool   `json:"managed"`
 	LastError string `json:"last_error,omitempty"`
 	Trigger   string `json:"trigger"`
+	// Generation counts in-process restarts of an external worker within one run.
+	Generation int `json:"generation,omitempty"`
+	// Limits is what the worker was started under, when limits were set.
+	Limits *supervisor.AppliedLimits `json:"limits,omitempty"`
+	// AutoKillAt is when a paused worker is killed unless resumed (RFC 3339).
+	AutoKillAt string `json:"auto_kill_at,omitempty"`
 }
 
-func emitLifecycleTransition(phase, operation string, pid int, managed bool, lastErr, trigger string) {
-	phase = strings.ToUpper(strings.TrimSpace(phase))
-	if phase == "" {
-		phase = "UNKNOWN"
-	}
-	operation = strings.TrimSpace(operation)
-	if operation == "" {
-		operation = "idle"
+func appliedLimitsEvidence(controller WorkerController) *supervisor.AppliedLimits {
+	lc, ok := controller.(limitedController)
+	if !ok {
+		return nil
 	}
-	trigger = strings.TrimSpace(trigger)
-	if trigger == "" {
-		trigger = "lifecycle_transition"
+	applied := lc.AppliedLimits()
+	if len(applied.RLimits) == 0 && len(applied.EnvAllow) == 0 && len(applied.EnvDeny) == 0 && len(applied.EnvInjected) == 0 {
+		return nil
 	}
+	return &applied
+}
 
-	reason := trigger
-	if lastErr != "" {
-		reason = fmt.Sprintf("%s: %s", trigger, lastErr)
-	}
-	summary := fmt.Sprintf("phase=%s operation=%s pid=%d managed=%t", phase, operation, pid, managed)
-	payload := lifecycleEvidence{
+func (w *workerLifecycle) emitLifecycleTransition(phase, operation string, pid int, managed bool, lastErr, trigger string) {
+	w.emitLifecycleEvidence(lifecycleEvidence{
 		Phase:     phase,
 		Operation: operation,
 		PID:       pid,
 		Managed:   managed,
 		LastError: lastErr,
 		Trigger:   trigger,
+	})
+}
+
+// emitLifecycleEvidence records ev as a lifecycle event of the worker's run,
+// or of the current run for a worker that has not registered one.
+func (w *workerLifecycle) emitLifecycleEvidence(ev lifecycleEvidence) {
+	ev.Phase = strings.ToUpper(strings.TrimSpace(ev.Phase))
+	if ev.Phase == "" {
+		ev.Phase = "UNKNOWN"
+	}
+	ev.Operation = strings.TrimSpace(ev.Operation)
+	if ev.Operation == "" {
+		ev.Operation = "idle"
+	}
+	ev.Trigger = strings.TrimSpace(ev.Trigger)
+	if ev.Trigger == "" {
+		ev.Trigger = "lifecycle_transition"
+	}
+	runID := w.store.RunID()
+	if runID == "" {
+		runID = database.CurrentRunID()
+	}
+
+	reason := ev.Trigger
+	if ev.LastError != "" {
+		reason = fmt.Sprintf("%s: %s", ev.Trigger, ev.LastError)
+	}
+	summary := fmt.Sprintf("phase=%s operation=%s pid=%d managed=%t", ev.Phase, ev.Operation, ev.PID, ev.Managed)
+	if ev.Generation > 0 {
+		summary += fmt.Sprintf(" generation=%d", ev.Generation)
 	}
 	_, _ = database.InsertEventWithPayload(
 		"lifecycle",
 		"control-plane",
 		reason,
+		runID,
 		"",
-		"",
-		"LIFECYCLE_"+phase,
+		"LIFECYCLE_"+ev.Phase,
 		summary,
-		pid,
+		ev.PID,
 		0,
 		0,
 		0,
-		payload,
+		ev,
 	)
 }
 
@@ -178,11 +251,16 @@ func (w *workerLifecycle) registerExternal(command string, args []string, dir st
 	if controller == nil {
 		return
 	}
+	var limits supervisor.Limits
+	if lc, ok := controller.(limitedController); ok {
+		limits = lc.Limits()
+	}
 	w.mu.Lock()
 	w.spec = workerSpec{
 		Command: command,
 		Args:    append([]string(nil), args...),
 		Dir:     dir,
+		Limits:  limits,
 	}
 	w.controller = controller
 	w.managed = false
@@ -193,13 +271,23 @@ func (w *workerLifecycle) registerExternal(command string, args []string, dir st
 	w.startWatcherLocked(controller, false)
 	w.mu.Unlock()
 
-	state.UpdateLifecycle(lifecycleRunning, "RUNNING", w.pid)
-	emitLifecycleTransition(lifecycleRunning, opNone, w.pid, false, "", "external_worker_registered")
+	w.store.UpdateLifecycle(lifecycleRunning, "RUNNING", w.pid)
+	w.emitLifecycleEvidence(lifecycleEvidence{
+		Phase:     lifecycleRunning,
+		Operation: opNone,
+		PID:       w.pid,
+		Trigger:   "external_worker_registered",
+		Limits:    appliedLimitsEvidence(controller),
+	})
 }
 
 func (w *workerLifecycle) requestKill() (lifecycleAction, error) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
+	return w.requestKillLocked("kill_requested")
+}
+
+func (w *workerLifecycle) requestKillLocked(trigger string) (lifecycleAction, error) {
 	w.syncFromStateLocked()
 
 	if w.operation == opRestart || w.phase == lifecycleStarting {
@@ -227,6 +315,11 @@ func (w *workerLifecycle) requestKill() (lifecycleAction, error) {
 
 	pid := w.activePIDLocked()
 	controller := w.controller
+	if w.phase == lifecyclePaused {
+		// The controller continues its own group; nested ones stay stopped
+		// and would ignore SIGTERM.
+		signalDescendantGroups(pid, syscall.SIGCONT)
+	}
 	w.phase = lifecycleStopping
 	w.operation = opKill
 	w.lastErr = ""
@@ -234,8 +327,9 @@ func (w *workerLifecycle) requestKill() (lifecycleAction, error) {
 	w.lastActionAt = time.Now()
 	w.lastActionPID = pid
 	w.stopRequestedAt = w.lastActionAt
-	state.UpdateLifecycle(lifecycleStopping, "STOPPING", pid)
-	emitLifecycleTransition(lifecycleStopping, opKill, pid, w.managed, "", "kill_requested")
+	w.clearPauseLocked()
+	w.store.UpdateLifecycle(lifecycleStopping, "STOPPING", pid)
+	w.emitLifecycleTransition(lifecycleStopping, opKill, pid, w.managed, "", trigger)
 
 	w.launchAsyncLocked(func() {
 		w.stopAsync(controller, pid, false)
@@ -271,7 +365,7 @@ func (w *workerLifecycle) requestRestart() (lifecycleAction, error) {
 
 	spec := w.spec
 	if !spec.valid() {
-		st := state.GetState()
+		st := w.store.GetState()
 		if st.Command != "" && len(st.Args) > 0 {
 			spec = workerSpec{
 				Command: st.Command,
@@ -287,19 +381,9 @@ func (w *workerLifecycle) requestRestart() (lifecycleAction, error) {
 
 	budget := loadRestartBudgetConfig()
 	now := time.Now()
-	if w.restartBudgetExceededLocked(budget, now) {
-		windowSeconds := int(budget.Window.Seconds())
-		if windowSeconds <= 0 {
-			windowSeconds = restartBudgetDefaultWindowSeconds
-		}
-		msg := fmt.Sprintf("restart budget exceeded: allowed %d restart requests per %ds", budget.Max, windowSeconds)
-		retryAfter := w.restartBudgetRetryAfterLocked(budget, now)
-		w.lastErr = msg
-		apiMetrics.IncRestartBudgetBlocked()
-		emitLifecycleTransition(w.phase, w.operation, w.pid, w.managed, msg, "restart_budget_blocked")
-		return lifecycleAction{}, newLifecycleErrorWithRetry(429, msg, retryAfter)
+	if err := w.chargeRestartBudgetLocked(budget, now); err != nil {
+		return lifecycleAction{}, err
 	}
-	w.recordRestartAttemptLocked(now)
 
 	w.phase = lifecycleStarting
 	w.operation = opRestart
@@ -309,8 +393,8 @@ func (w *workerLifecycle) requestRestart() (lifecycleAction, error) {
 	w.lastActionAt = now
 	w.lastActionPID = 0
 	w.restartRequestedAt = w.lastActionAt
-	state.UpdateLifecycle(lifecycleStarting, "STARTING", 0)
-	emitLifecycleTransition(lifecycleStarting, opRestart, 0, w.managed, "", "restart_requested")
+	w.store.UpdateLifecycle(lifecycleStarting, "STARTING", 0)
+	w.emitLifecycleTransition(lifecycleStarting, opRestart, 0, w.managed, "", "restart_requested")
 
 	w.launchAsyncLocked(func() {
 		w.startAsync(spec)
@@ -324,6 +408,173 @@ func (w *workerLifecycle) requestRestart() (lifecycleAction, error) {
 	}, nil
 }
 
+// requestPause freezes the worker's process group. Unless it is resumed
+// within timeout the worker is killed; timeout <= 0 uses the configured
+// default.
+func (w *workerLifecycle) requestPause(timeout time.Duration, trigger string) (lifecycleAction, error) {
+	defer notifyManagedWorkers()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.syncFromStateLocked()
+
+	if w.operation == opRestart || w.phase == lifecycleStarting {
+		return lifecycleAction{}, newLifecycleError(409, "restart in progress; retry pause after it completes")
+	}
+	if w.operation == opKill || w.phase == lifecycleStopping {
+		return lifecycleAction{}, newLifecycleError(409, "worker is stopping")
+	}
+	if w.phase == lifecyclePaused {
+		return lifecycleAction{
+			Status:      "paused",
+			Lifecycle:   lifecyclePaused,
+			PID:         w.pid,
+			AcceptedNew: false,
+		}, nil
+	}
+	if !w.hasActiveWorkerLocked() {
+		return lifecycleAction{}, newLifecycleError(400, "no active process to pause")
+	}
+
+	pid := w.activePIDLocked()
+	if err := pauseWorkerGroup(w.controller, pid); err != nil {
+		return lifecycleAction{}, err
+	}
+	if timeout <= 0 {
+		timeout = loadPauseTimeout()
+	}
+	w.phase = lifecyclePaused
+	w.operation = opNone
+	w.lastErr = ""
+	w.lastAction = opPause
+	w.lastActionAt = time.Now()
+	w.lastActionPID = pid
+	w.pauseID++
+	id := w.pauseID
+	w.autoKillAt = w.lastActionAt.Add(timeout)
+	w.pauseTimer = time.AfterFunc(timeout, func() { w.pauseExpired(id) })
+	w.store.UpdateLifecycle(lifecyclePaused, "PAUSED", pid)
+	w.emitLifecycleEvidence(lifecycleEvidence{
+		Phase:      lifecyclePaused,
+		Operation:  opPause,
+		PID:        pid,
+		Managed:    w.managed,
+		Trigger:    trigger,
+		AutoKillAt: w.autoKillAt.UTC().Format(time.RFC3339),
+	})
+
+	return lifecycleAction{
+		Status:      "paused",
+		Lifecycle:   lifecyclePaused,
+		PID:         pid,
+		AcceptedNew: true,
+	}, nil
+}
+
+func (w *workerLifecycle) requestResume() (lifecycleAction, error) {
+	defer notifyManagedWorkers()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.syncFromStateLocked()
+
+	if w.phase != lifecyclePaused {
+		if w.phase == lifecycleRunning && w.isRecentActionLocked(opResume, 2*time.Second) {
+			return lifecycleAction{
+				Status:      "resumed",
+				Lifecycle:   lifecycleRunning,
+				PID:         w.pid,
+				AcceptedNew: false,
+			}, nil
+		}
+		return lifecycleAction{}, newLifecycleError(409, "process is not paused")
+	}
+
+	pid := w.activePIDLocked()
+	if err := resumeWorkerGroup(w.controller, pid); err != nil {
+		return lifecycleAction{}, err
+	}
+	w.clearPauseLocked()
+	w.phase = lifecycleRunning
+	w.lastErr = ""
+	w.lastAction = opResume
+	w.lastActionAt = time.Now()
+	w.lastActionPID = pid
+	w.store.UpdateLifecycle(lifecycleRunning, "RUNNING", pid)
+	w.emitLifecycleTransition(lifecycleRunning, opResume, pid, w.managed, "", "resume_requested")
+
+	return lifecycleAction{
+		Status:      "resumed",
+		Lifecycle:   lifecycleRunning,
+		PID:         pid,
+		AcceptedNew: true,
+	}, nil
+}
+
+// pauseExpired kills a worker that is still in the pause it was armed for.
+func (w *workerLifecycle) pauseExpired(id uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if id != w.pauseID || w.phase != lifecyclePaused {
+		return
+	}
+	pid := w.activePIDLocked()
+	command := w.store.GetState().Command
+	if _, err := w.requestKillLocked("pause_timeout"); err != nil {
+		return
+	}
+	_ = database.LogAuditEvent("control-plane", "PAUSE_TIMEOUT_KILL", "paused worker was not resumed before its timeout", "api", pid, command)
+}
+
+func (w *workerLifecycle) clearPauseLocked() {
+	if w.pauseTimer != nil {
+		w.pauseTimer.Stop()
+		w.pauseTimer = nil
+	}
+	w.pauseID++
+	w.autoKillAt = time.Time{}
+}
+
+func pauseWorkerGroup(controller WorkerController, pid int) error {
+	if pc, ok := controller.(pausableController); ok {
+		if err := pc.Pause(); err != nil {
+			return newLifecycleError(500, err.Error())
+		}
+	} else if err := signalProcessTree(pid, syscall.SIGSTOP); err != nil {
+		return newLifecycleError(500, fmt.Sprintf("pause failed: %v", err))
+	}
+	signalDescendantGroups(pid, syscall.SIGSTOP)
+	return nil
+}
+
+func resumeWorkerGroup(controller WorkerController, pid int) error {
+	signalDescendantGroups(pid, syscall.SIGCONT)
+	if pc, ok := controller.(pausableController); ok {
+		if err := pc.Resume(); err != nil {
+			return newLifecycleError(500, err.Error())
+		}
+		return nil
+	}
+	if err := signalProcessTree(pid, syscall.SIGCONT); err != nil {
+		return newLifecycleError(500, fmt.Sprintf("resume failed: %v", err))
+	}
+	return nil
+}
+
+// signalDescendantGroups signals the process groups below pid's own, such as
+// the command a `flowforge run` worker supervises. FlowForge's own group is
+// never signalled.
+func signalDescendantGroups(pid int, sig syscall.Signal) {
+	groups, err := sysmon.ProcessGroups(pid)
+	if err != nil || len(groups) < 2 {
+		return
+	}
+	self := syscall.Getpgrp()
+	for _, pgid := range groups[1:] {
+		if pgid > 1 && pgid != self {
+			_ = syscall.Kill(-pgid, sig)
+		}
+	}
+}
+
 func (w *workerLifecycle) stopAsync(controller WorkerController, pid int, fromWatcher bool) {
 	startedAt := time.Now()
 	w.mu.Lock()
@@ -342,6 +593,7 @@ func (w *workerLifecycle) stopAsync(controller WorkerController, pid int, fromWa
 		err = killProcessTree(pid)
 	}
 
+	defer notifyManagedWorkers()
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
@@ -354,8 +606,8 @@ func (w *workerLifecycle) stopAsync(controller WorkerController, pid int, fromWa
 		w.phase = lifecycleFailed
 		w.operation = opNone
 		w.lastErr = err.Error()
-		state.UpdateLifecycle(lifecycleFailed, "FAILED", w.pid)
-		emitLifecycleTransition(lifecycleFailed, opNone, w.pid, w.managed, w.lastErr, "stop_failed")
+		w.store.UpdateLifecycle(lifecycleFailed, "FAILED", w.pid)
+		w.emitLifecycleTransition(lifecycleFailed, opNone, w.pid, w.managed, w.lastErr, "stop_failed")
 		apiMetrics.ObserveStopLatency(time.Since(startedAt).Seconds(), false)
 		return
 	}
@@ -368,8 +620,8 @@ func (w *workerLifecycle) stopAsync(controller WorkerController, pid int, fromWa
 	w.operation = opNone
 	w.lastErr = ""
 	w.stopRequestedAt = time.Time{}
-	state.UpdateLifecycle(lifecycleStopped, "STOPPED", 0)
-	emitLifecycleTransition(lifecycleStopped, opNone, 0, false, "", "stop_completed")
+	w.store.UpdateLifecycle(lifecycleStopped, "STOPPED", 0)
+	w.emitLifecycleTransition(lifecycleStopped, opNone, 0, false, "", "stop_completed")
 	apiMetrics.ObserveStopLatency(time.Since(startedAt).Seconds(), true)
 }
 
@@ -379,47 +631,68 @@ func (w *workerLifecycle) startAsync(spec workerSpec) {
 		w.mu.Unlock()
 		return
 	}
-	w.mu.Unlock()
-
 	startedAt := time.Now()
-	w.mu.Lock()
 	if !w.restartRequestedAt.IsZero() {
 		startedAt = w.restartRequestedAt
 	}
 	w.mu.Unlock()
 
+	if _, err := w.launch(spec, "restart_completed"); err != nil {
+		if !errors.Is(err, errWorkerShutdown) {
+			w.mu.Lock()
+			w.phase = lifecycleFailed
+			w.operation = opNone
+			w.lastErr = err.Error()
+			w.pid = 0
+			w.controller = nil
+			w.managed = false
+			w.mu.Unlock()
+			w.store.UpdateLifecycle(lifecycleFailed, "FAILED", 0)
+			w.emitLifecycleTransition(lifecycleFailed, opNone, 0, false, err.Error(), "restart_failed")
+		}
+		apiMetrics.ObserveRestartLatency(time.Since(startedAt).Seconds(), false)
+		return
+	}
+	apiMetrics.ObserveRestartLatency(time.Since(startedAt).Seconds(), true)
+}
+
+// errWorkerShutdown reports a launch that finished after the worker stopped
+// accepting work; the new process has already been stopped again.
+var errWorkerShutdown = errors.New("worker is shutting down")
+
+// launch starts spec as the worker's managed process, records the
+// transition to RUNNING under trigger and returns the new PID.
+func (w *workerLifecycle) launch(spec workerSpec, trigger string) (int, error) {
 	cmd := exec.Command(spec.Args[0], spec.Args[1:]...)
 	if spec.Dir != "" {
 		cmd.Dir = spec.Dir
 	}
+	if len(spec.Env) > 0 {
+		cmd.Env = append(os.Environ(), spec.Env...)
+	}
+	// In a session of its own the worker's group is never orphaned by this
+	// process exiting, so the kernel does not hang up a paused worker and a
+	// restarted daemon can re-adopt it.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
 
 	sup := supervisor.New(cmd)
+	sup.SetLimits(spec.Limits)
 	if err := sup.Start(); err != nil {
-		w.mu.Lock()
-		w.phase = lifecycleFailed
-		w.operation = opNone
-		w.lastErr = err.Error()
-		w.pid = 0
-		w.controller = nil
-		w.managed = false
-		w.mu.Unlock()
-		state.UpdateLifecycle(lifecycleFailed, "FAILED", 0)
-		emitLifecycleTransition(lifecycleFailed, opNone, 0, false, err.Error(), "restart_failed")
-		apiMetrics.ObserveRestartLatency(time.Since(startedAt).Seconds(), false)
-		return
+		return 0, err
 	}
 
 	pid := sup.PID()
+	identity, _ := sysmon.IdentifyProcess(pid)
 	w.mu.Lock()
 	if !w.acceptAsync {
 		w.mu.Unlock()
 		_ = sup.Stop(250 * time.Millisecond)
-		apiMetrics.ObserveRestartLatency(time.Since(startedAt).Seconds(), false)
-		return
+		return 0, errWorkerShutdown
 	}
 	w.controller = sup
 	w.managed = true
 	w.pid = pid
+	w.identity = identity
 	w.phase = lifecycleRunning
 	w.operation = opNone
 	w.lastErr = ""
@@ -428,10 +701,18 @@ func (w *workerLifecycle) startAsync(spec workerSpec) {
 	w.startWatcherLocked(sup, true)
 	w.mu.Unlock()
 
-	state.UpdateState(0, "", "RUNNING", spec.Command, spec.Args, spec.Dir, pid)
-	state.UpdateLifecycle(lifecycleRunning, "RUNNING", pid)
-	emitLifecycleTransition(lifecycleRunning, opNone, pid, true, "", "restart_completed")
-	apiMetrics.ObserveRestartLatency(time.Since(startedAt).Seconds(), true)
+	w.store.UpdateState(0, "", "RUNNING", spec.Command, spec.Args, spec.Dir, pid)
+	w.store.UpdateLifecycle(lifecycleRunning, "RUNNING", pid)
+	w.emitLifecycleEvidence(lifecycleEvidence{
+		Phase:     lifecycleRunning,
+		Operation: opNone,
+		PID:       pid,
+		Managed:   true,
+		Trigger:   trigger,
+		Limits:    appliedLimitsEvidence(sup),
+	})
+	notifyManagedWorkers()
+	return pid, nil
 }
 
 func (w *workerLifecycle) startWatcherLocked(controller WorkerController, managed bool) uint64 {
@@ -468,6 +749,7 @@ func (w *workerLifecycle) shutdownForTests(timeout time.Duration) {
 	w.lastErr = ""
 	w.stopRequestedAt = time.Time{}
 	w.restartRequestedAt = time.Time{}
+	w.clearPauseLocked()
 	w.mu.Unlock()
 
 	if controller != nil {
@@ -494,6 +776,7 @@ func (w *workerLifecycle) shutdownForTests(timeout time.Duration) {
 func (w *workerLifecycle) waitForController(id uint64, controller WorkerController, managed bool) {
 	err := controller.Wait()
 
+	defer notifyManagedWorkers()
 	w.mu.Lock()
 	defer w.mu.Unlock()
 	if id != w.watchID {
@@ -508,18 +791,19 @@ func (w *workerLifecycle) waitForController(id uint64, controller WorkerControll
 	w.managed = false
 	w.pid = 0
 	w.operation = opNone
+	w.clearPauseLocked()
 
 	if err != nil {
 		w.phase = lifecycleFailed
 		w.lastErr = err.Error()
-		state.UpdateLifecycle(lifecycleFailed, "FAILED", 0)
-		emitLifecycleTransition(lifecycleFailed, opNone, 0, false, w.lastErr, "worker_exit_error")
+		w.store.UpdateLifecycle(lifecycleFailed, "FAILED", 0)
+		w.emitLifecycleTransition(lifecycleFailed, opNone, 0, false, w.lastErr, "worker_exit_error")
 		return
 	}
 	w.phase = lifecycleStopped
 	w.lastErr = ""
-	state.UpdateLifecycle(lifecycleStopped, "STOPPED", 0)
-	emitLifecycleTransition(lifecycleStopped, opNone, 0, false, "", "worker_exited")
+	w.store.UpdateLifecycle(lifecycleStopped, "STOPPED", 0)
+	w.emitLifecycleTransition(lifecycleStopped, opNone, 0, false, "", "worker_exited")
 	_ = managed
 }
 
@@ -555,7 +839,7 @@ func (w *workerLifecycle) registerSpecFromStateIfMissing() {
 }
 
 func (w *workerLifecycle) syncFromStateLocked() {
-	st := state.GetState()
+	st := w.store.GetState()
 	if !w.spec.valid() && st.Command != "" && len(st.Args) > 0 {
 		w.spec = workerSpec{
 			Command: st.Command,
@@ -582,13 +866,17 @@ func (w *workerLifecycle) snapshot() map[string]interface{} {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 	w.syncFromStateLocked()
-	return map[string]interface{}{
+	out := map[string]interface{}{
 		"phase":     w.phase,
 		"operation": w.operation,
 		"pid":       w.pid,
 		"managed":   w.managed,
 		"last_err":  w.lastErr,
 	}
+	if !w.autoKillAt.IsZero() {
+		out["auto_kill_at"] = w.autoKillAt.UTC().Format(time.RFC3339)
+	}
+	return out
 }
 
 func (w *workerLifecycle) setSpec(command string, args []string, dir string) {
@@ -601,6 +889,7 @@ func (w *workerLifecycle) setSpec(command string, args []string, dir string) {
 		Command: command,
 		Args:    append([]string(nil), args...),
 		Dir:     dir,
+		Limits:  w.spec.Limits,
 	}
 }
 
@@ -635,6 +924,16 @@ func loadRestartBudgetConfig() restartBudgetConfig {
 	}
 }
 
+func loadPauseTimeout() time.Duration {
+	seconds := pauseTimeoutDefaultSeconds
+	if raw := strings.TrimSpace(os.Getenv(envPauseTimeoutSeconds)); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
+			seconds = parsed
+		}
+	}
+	return time.Duration(seconds) * time.Second
+}
+
 func (w *workerLifecycle) restartBudgetExceededLocked(cfg restartBudgetConfig, now time.Time) bool {
 	if cfg.Max <= 0 || cfg.Window <= 0 {
 		return false
@@ -643,6 +942,45 @@ func (w *workerLifecycle) restartBudgetExceededLocked(cfg restartBudgetConfig, n
 	return len(w.restartHistory) >= cfg.Max
 }
 
+// chargeRestartBudgetLocked records a restart attempt against the shared
+// budget, or returns a 429 lifecycle error when the window is spent.
+func (w *workerLifecycle) chargeRestartBudgetLocked(budget restartBudgetConfig, now time.Time) error {
+	if w.restartBudgetExceededLocked(budget, now) {
+		windowSeconds := int(budget.Window.Seconds())
+		if windowSeconds <= 0 {
+			windowSeconds = restartBudgetDefaultWindowSeconds
+		}
+		msg := fmt.Sprintf("restart budget exceeded: allowed %d restart requests per %ds", budget.Max, windowSeconds)
+		retryAfter := w.restartBudgetRetryAfterLocked(budget, now)
+		w.lastErr = msg
+		apiMetrics.IncRestartBudgetBlocked()
+		w.emitLifecycleTransition(w.phase, w.operation, w.pid, w.managed, msg, "restart_budget_blocked")
+		return newLifecycleErrorWithRetry(429, msg, retryAfter)
+	}
+	w.recordRestartAttemptLocked(now)
+	return nil
+}
+
+// reservePolicyRestart charges an in-process restart of the external worker
+// (flowforge run --restart-on-breach) against the same budget as API restarts.
+func (w *workerLifecycle) reservePolicyRestart(generation int) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.chargeRestartBudgetLocked(loadRestartBudgetConfig(), time.Now()); err != nil {
+		return err
+	}
+	w.emitLifecycleEvidence(lifecycleEvidence{
+		Phase:      w.phase,
+		Operation:  opRestart,
+		PID:        w.pid,
+		Managed:    w.managed,
+		Trigger:    "policy_restart",
+		Generation: generation,
+	})
+	return nil
+}
+
 func (w *workerLifecycle) recordRestartAttemptLocked(ts time.Time) {
 	w.restartHistory = append(w.restartHistory, ts)
 }
@@ -691,16 +1029,43 @@ func processLikelyAlive(pid int) bool {
 	return !errors.Is(err, syscall.ESRCH)
 }
 
-var workerControl = newWorkerLifecycle()
+var workerControl = newWorkerLifecycle(state.Default())
 
+// RegisterExternalWorker hands the process `flowforge run` supervises to the
+// lifecycle behind the /process/* routes and lists it under the current run ID.
 func RegisterExternalWorker(command string, args []string, dir string, controller WorkerController) {
+	if controller == nil {
+		return
+	}
+	workerControl.store.SetRunID(database.CurrentRunID())
 	workerControl.registerExternal(command, args, dir, controller)
+	workers.add(workerControl)
 }
 
 func SetWorkerSpec(command string, args []string, dir string) {
 	workerControl.setSpec(command, args, dir)
 }
 
+// ReservePolicyRestart charges a policy-driven relaunch of the external worker
+// against the restart budget and records it as a lifecycle event. It returns
+// an error when the budget for the current window is spent.
+func ReservePolicyRestart(generation int) error {
+	return workerControl.reservePolicyRestart(generation)
+}
+
+// DefaultPauseTimeout is how long a pause lasts before the worker is killed
+// when the request does not say: FLOWFORGE_PAUSE_TIMEOUT_SECONDS or 15m.
+func DefaultPauseTimeout() time.Duration {
+	return loadPauseTimeout()
+}
+
+// PauseWorker freezes the registered worker for a policy PAUSE decision. It
+// is killed unless resumed within timeout (<= 0 uses the configured default).
+func PauseWorker(timeout time.Duration) error {
+	_, err := workerControl.requestPause(timeout, "policy_pause")
+	return err
+}
+
 func WorkerLifecycleSnapshot() map[string]interface{} {
 	return workerControl.snapshot()
 }
@@ -710,7 +1075,9 @@ func WorkerLifecycleSnapshot() map[string]interface{} {
 func ResetWorkerControlForTests() {
 	previous := workerControl
 	previous.shutdownForTests(3 * time.Second)
-	workerControl = newWorkerLifecycle()
+	workers.shutdownForTests(3 * time.Second)
+	state.Default().SetRunID("")
+	workerControl = newWorkerLifecycle(state.Default())
 	state.UpdateLifecycle(lifecycleStopped, "STOPPED", 0)
 }
 
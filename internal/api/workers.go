@@ -0,0 +1,356 @@
+package api
+
+import (
+	"flowforge/internal/runlog"
+	"flowforge/internal/state"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// workerRegistry indexes the workers a FlowForge process supervises by run
+// ID. Stopped workers stay listed so their final state can still be read;
+// their run ID is free to be reused.
+type workerRegistry struct {
+	mu      sync.RWMutex
+	byRunID map[string]*workerLifecycle
+	order   []string
+}
+
+var workers = newWorkerRegistry()
+
+func newWorkerRegistry() *workerRegistry {
+	return &workerRegistry{byRunID: make(map[string]*workerLifecycle)}
+}
+
+// add lists w under its run ID, dropping any other ID w was listed under.
+func (reg *workerRegistry) add(w *workerLifecycle) {
+	runID := w.store.RunID()
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	for id, existing := range reg.byRunID {
+		if existing == w && id != runID {
+			reg.removeLocked(id)
+		}
+	}
+	reg.putLocked(runID, w)
+}
+
+// claim lists w under its run ID unless a live worker already holds it.
+func (reg *workerRegistry) claim(w *workerLifecycle) error {
+	runID := w.store.RunID()
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if existing, ok := reg.byRunID[runID]; ok && existing.active() {
+		return newLifecycleError(http.StatusConflict, fmt.Sprintf("run %s already has an active worker", runID))
+	}
+	reg.putLocked(runID, w)
+	return nil
+}
+
+func (reg *workerRegistry) putLocked(runID string, w *workerLifecycle) {
+	if _, ok := reg.byRunID[runID]; !ok {
+		reg.order = append(reg.order, runID)
+	}
+	reg.byRunID[runID] = w
+}
+
+func (reg *workerRegistry) removeLocked(runID string) {
+	delete(reg.byRunID, runID)
+	for i, id := range reg.order {
+		if id == runID {
+			reg.order = append(reg.order[:i], reg.order[i+1:]...)
+			break
+		}
+	}
+}
+
+func (reg *workerRegistry) get(runID string) (*workerLifecycle, bool) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	w, ok := reg.byRunID[runID]
+	return w, ok
+}
+
+// list returns the workers in registration order.
+func (reg *workerRegistry) list() []*workerLifecycle {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	out := make([]*workerLifecycle, 0, len(reg.order))
+	for _, id := range reg.order {
+		out = append(out, reg.byRunID[id])
+	}
+	return out
+}
+
+func (reg *workerRegistry) shutdownForTests(timeout time.Duration) {
+	for _, w := range reg.list() {
+		w.shutdownForTests(timeout)
+	}
+	reg.mu.Lock()
+	reg.byRunID = make(map[string]*workerLifecycle)
+	reg.order = nil
+	reg.mu.Unlock()
+}
+
+func (w *workerLifecycle) active() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.operation != opNone || w.phase == lifecycleStarting || w.hasActiveWorkerLocked()
+}
+
+// syncTelemetry folds in the state a submitted run's `flowforge run` child
+// publishes under its run ID; the daemon itself only sees lifecycle changes.
+// Workers that publish nothing are left as they are.
+func (w *workerLifecycle) syncTelemetry() {
+	path, err := runlog.StatePath(w.store.RunID())
+	if err != nil {
+		return
+	}
+	if published, err := state.ReadFile(path); err == nil {
+		w.store.MergeTelemetry(published)
+	}
+}
+
+// describe is the worker's entry in GET /v1/workers.
+func (w *workerLifecycle) describe() map[string]interface{} {
+	w.syncTelemetry()
+	snap := w.snapshot()
+	st := w.store.GetState()
+	out := map[string]interface{}{
+		"run_id":           st.RunID,
+		"phase":            snap["phase"],
+		"operation":        snap["operation"],
+		"pid":              snap["pid"],
+		"managed":          snap["managed"],
+		"last_error":       snap["last_err"],
+		"status":           st.Status,
+		"lifecycle":        st.Lifecycle,
+		"command":          st.Command,
+		"cpu":              st.CPU,
+		"last_line":        st.LastLine,
+		"reason":           st.Reason,
+		"timestamp":        st.Timestamp,
+		"cpu_score":        st.CPUScore,
+		"entropy_score":    st.Entropy,
+		"confidence_score": st.Confidence,
+	}
+	if autoKillAt, ok := snap["auto_kill_at"]; ok {
+		out["auto_kill_at"] = autoKillAt
+	}
+	return out
+}
+
+// WorkerLaunch describes a managed worker started with StartWorker.
+type WorkerLaunch struct {
+	RunID string
+	// Command is the display command; it defaults to Args joined by spaces.
+	Command string
+	Args    []string
+	Dir     string
+	// Env holds KEY=VALUE pairs added to FlowForge's own environment.
+	Env []string
+}
+
+// StartWorker launches a new managed worker listed under launch.RunID and
+// returns its PID. A run ID can be reused once its previous worker stopped.
+func StartWorker(launch WorkerLaunch) (int, error) {
+	runID := strings.TrimSpace(launch.RunID)
+	if runID == "" {
+		return 0, fmt.Errorf("run ID is required")
+	}
+	spec := workerSpec{
+		Command: strings.TrimSpace(launch.Command),
+		Args:    append([]string(nil), launch.Args...),
+		Dir:     launch.Dir,
+		Env:     append([]string(nil), launch.Env...),
+	}
+	if spec.Command == "" {
+		spec.Command = strings.Join(launch.Args, " ")
+	}
+	if !spec.valid() {
+		return 0, fmt.Errorf("command is required")
+	}
+
+	w := newWorkerLifecycle(state.NewStore(runID))
+	w.spec = spec
+	if err := workers.claim(w); err != nil {
+		return 0, err
+	}
+	pid, err := w.launch(spec, "worker_started")
+	if err != nil {
+		w.mu.Lock()
+		w.phase = lifecycleFailed
+		w.lastErr = err.Error()
+		w.mu.Unlock()
+		w.store.UpdateState(0, "", "FAILED", spec.Command, spec.Args, spec.Dir, 0)
+		w.emitLifecycleTransition(lifecycleFailed, opNone, 0, false, err.Error(), "start_failed")
+		return 0, err
+	}
+	return pid, nil
+}
+
+// HandleWorkers serves GET /v1/workers: every worker this process knows of.
+func HandleWorkers(w http.ResponseWriter, r *http.Request) {
+	corsMiddleware(w, r)
+	r = ensureRequestContext(w, r)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeJSONErrorForRequest(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if !requireRole(w, r, RoleViewer) {
+		return
+	}
+
+	items := []map[string]interface{}{}
+	for _, worker := range workers.list() {
+		items = append(items, worker.describe())
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"workers": items,
+		"count":   len(items),
+	})
+}
+
+// HandleWorker serves /v1/workers/{run_id} and its kill, restart, pause,
+// resume and stream sub-resources.
+func HandleWorker(w http.ResponseWriter, r *http.Request) {
+	corsMiddleware(w, r)
+	r = ensureRequestContext(w, r)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	runID, action, err := parseWorkerPath(r.URL.Path)
+	if err != nil {
+		writeJSONErrorForRequest(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	worker, ok := workers.get(runID)
+	if !ok {
+		writeJSONErrorForRequest(w, r, http.StatusNotFound, fmt.Sprintf("worker %s not found", runID))
+		return
+	}
+
+	switch action {
+	case "", "stream":
+		if r.Method != http.MethodGet {
+			writeJSONErrorForRequest(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		if !requireRole(w, r, RoleViewer) {
+			return
+		}
+		if action == "" {
+			writeJSON(w, http.StatusOK, worker.describe())
+			return
+		}
+		serveStateStream(w, r, func() [][]byte {
+			return workerFrames([]*workerLifecycle{worker})
+		})
+		return
+	case "kill", "restart", "pause", "resume":
+	default:
+		writeJSONErrorForRequest(w, r, http.StatusNotFound, "worker endpoint not found")
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		writeJSONErrorForRequest(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if !requireRole(w, r, RoleOperator) {
+		return
+	}
+	endpoint := fmt.Sprintf("POST /v1/workers/%s/%s", runID, action)
+	switch action {
+	case "kill":
+		serveWorkerKill(w, r, worker, endpoint)
+	case "restart":
+		serveWorkerRestart(w, r, worker, endpoint)
+	case "pause":
+		serveWorkerPause(w, r, worker, endpoint)
+	case "resume":
+		serveWorkerResume(w, r, worker, endpoint)
+	}
+}
+
+func parseWorkerPath(path string) (string, string, error) {
+	const basePath = "/v1/workers/"
+	trimmedPath := strings.TrimSpace(path)
+	if !strings.HasPrefix(trimmedPath, basePath) {
+		return "", "", fmt.Errorf("worker endpoint not found")
+	}
+	parts := strings.Split(strings.TrimPrefix(trimmedPath, basePath), "/")
+	if len(parts) > 2 || parts[0] == "" {
+		return "", "", fmt.Errorf("worker endpoint not found")
+	}
+	runID, err := url.PathUnescape(parts[0])
+	if err != nil {
+		return "", "", fmt.Errorf("run_id is invalid: %v", err)
+	}
+	action := ""
+	if len(parts) == 2 {
+		action = parts[1]
+	}
+	return runID, action, nil
+}
+
+// streamFrames picks what /v1/stream sends: the default state when worker is
+// empty, every worker for "all", else the listed run IDs (comma separated).
+func streamFrames(worker string) (func() [][]byte, error) {
+	worker = strings.TrimSpace(worker)
+	switch worker {
+	case "":
+		return func() [][]byte {
+			jsonData, err := state.JSON()
+			if err != nil {
+				return nil
+			}
+			return [][]byte{jsonData}
+		}, nil
+	case "all":
+		return func() [][]byte { return workerFrames(workers.list()) }, nil
+	}
+
+	var runIDs []string
+	for _, id := range strings.Split(worker, ",") {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		if _, ok := workers.get(id); !ok {
+			return nil, fmt.Errorf("worker %s not found", id)
+		}
+		runIDs = append(runIDs, id)
+	}
+	return func() [][]byte {
+		selected := make([]*workerLifecycle, 0, len(runIDs))
+		for _, id := range runIDs {
+			if w, ok := workers.get(id); ok {
+				selected = append(selected, w)
+			}
+		}
+		return workerFrames(selected)
+	}, nil
+}
+
+// workerFrames renders one state snapshot per worker; each carries its run_id.
+func workerFrames(list []*workerLifecycle) [][]byte {
+	frames := make([][]byte, 0, len(list))
+	for _, w := range list {
+		w.syncTelemetry()
+		if jsonData, err := w.store.JSON(); err == nil {
+			frames = append(frames, jsonData)
+		}
+	}
+	return frames
+}
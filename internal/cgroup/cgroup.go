@@ -0,0 +1,333 @@
+// Package cgroup places supervised commands in a cgroup v2 leaf with hard
+// memory, CPU and pid limits and reads the controller statistics back.
+//
+// It only manages groups below a subtree the caller already owns (a
+// delegated cgroup, e.g. a systemd user slice with Delegate=yes); it never
+// touches the root hierarchy.
+package cgroup
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultCPUPeriod is the cpu.max period used when translating a CPU percent.
+const DefaultCPUPeriod = 100 * time.Millisecond
+
+var requiredControllers = []string{"memory", "cpu", "pids"}
+
+// Limits are the hard limits written when a group is created. Zero values
+// leave the controller at "max".
+type Limits struct {
+	MemoryMaxBytes int64
+	// CPUMaxPercent is a share of one CPU: 100 is one full core, 250 is two
+	// and a half.
+	CPUMaxPercent float64
+	PidsMax       int64
+}
+
+// Stats is a snapshot of the group's controller files.
+type Stats struct {
+	MemoryCurrentBytes int64
+	MemoryMaxEvents    int64 // memory.events "max": allocations that hit memory.max
+	MemoryHighEvents   int64
+	OOMEvents          int64
+	OOMKills           int64
+	CPUUsageUsec       int64
+	CPUThrottledUsec   int64
+	CPUNrThrottled     int64
+	PidsCurrent        int64
+}
+
+// Group is a cgroup v2 directory created for one supervised command.
+type Group struct {
+	path string
+}
+
+// Path returns the absolute filesystem path of the group.
+func (g *Group) Path() string {
+	return g.path
+}
+
+// DelegatedRoot returns the cgroup v2 directory of the current process, which
+// is where child groups are created when no parent is configured.
+func DelegatedRoot() (string, error) {
+	mount, err := unifiedMountPoint("/proc/self/mountinfo")
+	if err != nil {
+		return "", err
+	}
+	rel, err := unifiedSelfPath("/proc/self/cgroup")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(mount, rel), nil
+}
+
+func unifiedMountPoint(mountinfo string) (string, error) {
+	f, err := os.Open(mountinfo)
+	if err != nil {
+		return "", fmt.Errorf("cgroup: read mountinfo: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// mountinfo: id parent major:minor root mountpoint opts ... - fstype source superopts
+		fields := strings.Fields(scanner.Text())
+		sep := -1
+		for i, field := range fields {
+			if field == "-" {
+				sep = i
+				break
+			}
+		}
+		if sep < 0 || sep+1 >= len(fields) || len(fields) < 5 {
+			continue
+		}
+		if fields[sep+1] == "cgroup2" {
+			return fields[4], nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("cgroup: read mountinfo: %w", err)
+	}
+	return "", errors.New("cgroup: no cgroup v2 hierarchy mounted")
+}
+
+func unifiedSelfPath(procCgroup string) (string, error) {
+	data, err := os.ReadFile(procCgroup)
+	if err != nil {
+		return "", fmt.Errorf("cgroup: read %s: %w", procCgroup, err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if rest, ok := strings.CutPrefix(line, "0::"); ok {
+			return rest, nil
+		}
+	}
+	return "", errors.New("cgroup: process is not in a cgroup v2 hierarchy")
+}
+
+// Create makes a child group named name under parent and writes the limits.
+// parent must have the memory, cpu and pids controllers enabled in its
+// cgroup.subtree_control.
+func Create(parent, name string, limits Limits) (*Group, error) {
+	parent = filepath.Clean(parent)
+	if err := checkControllers(parent); err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(parent, name)
+	if err := os.Mkdir(path, 0o755); err != nil {
+		return nil, fmt.Errorf("cgroup: create %s: %w", path, err)
+	}
+	g := &Group{path: path}
+
+	writes := []struct {
+		file  string
+		value string
+	}{
+		{"memory.max", formatMax(limits.MemoryMaxBytes)},
+		{"cpu.max", formatCPUMax(limits.CPUMaxPercent)},
+		{"pids.max", formatMax(limits.PidsMax)},
+	}
+	for _, w := range writes {
+		if err := g.write(w.file, w.value); err != nil {
+			_ = os.Remove(path)
+			return nil, err
+		}
+	}
+	// Keep the group's processes out of swap so memory.max is a real ceiling.
+	// Not every kernel exposes memory.swap.max, so this is best effort.
+	if limits.MemoryMaxBytes > 0 {
+		_ = g.write("memory.swap.max", "0")
+	}
+	return g, nil
+}
+
+// SupervisorLeaf is the child group EnableControllers moves FlowForge into
+// when it runs in the root it was given.
+const SupervisorLeaf = "flowforge-supervisor"
+
+// EnableControllers turns on the memory, cpu and pids controllers for root's
+// children. cgroup v2 only allows that while root holds no processes (the
+// no-internal-processes rule), and a delegated root is usually the leaf
+// FlowForge itself runs in, so FlowForge first moves itself into a
+// SupervisorLeaf child. It refuses if root holds any other process, since
+// moving someone else's processes is not FlowForge's call, and moves itself
+// back if the controllers cannot be enabled. It does nothing if the
+// controllers are already on.
+func EnableControllers(root string) error {
+	root = filepath.Clean(root)
+	if checkControllers(root) == nil {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(root, "cgroup.procs"))
+	if err != nil {
+		return fmt.Errorf("cgroup: %s is not a cgroup v2 directory: %w", root, err)
+	}
+	self := strconv.Itoa(os.Getpid())
+	var others []string
+	moveSelf := false
+	for _, pid := range strings.Fields(string(data)) {
+		if pid == self {
+			moveSelf = true
+		} else {
+			others = append(others, pid)
+		}
+	}
+	if len(others) > 0 {
+		return fmt.Errorf("cgroup: %s holds %d processes besides FlowForge (e.g. pid %s); refusing to move them", root, len(others), others[0])
+	}
+	rootGroup := &Group{path: root}
+	leaf := &Group{path: filepath.Join(root, SupervisorLeaf)}
+	if moveSelf {
+		if err := os.Mkdir(leaf.path, 0o755); err != nil && !errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("cgroup: create %s: %w", leaf.path, err)
+		}
+		if err := leaf.write("cgroup.procs", self); err != nil {
+			return err
+		}
+	}
+	enable := make([]string, len(requiredControllers))
+	for i, c := range requiredControllers {
+		enable[i] = "+" + c
+	}
+	if err := rootGroup.write("cgroup.subtree_control", strings.Join(enable, " ")); err != nil {
+		if moveSelf {
+			_ = rootGroup.write("cgroup.procs", self)
+			_ = os.Remove(leaf.path)
+		}
+		return fmt.Errorf("%w (is %s delegated to this user?)", err, root)
+	}
+	return nil
+}
+
+func checkControllers(parent string) error {
+	data, err := os.ReadFile(filepath.Join(parent, "cgroup.subtree_control"))
+	if err != nil {
+		return fmt.Errorf("cgroup: %s is not a delegated cgroup v2 directory: %w", parent, err)
+	}
+	enabled := make(map[string]bool)
+	for _, c := range strings.Fields(string(data)) {
+		enabled[c] = true
+	}
+	var missing []string
+	for _, c := range requiredControllers {
+		if !enabled[c] {
+			missing = append(missing, c)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("cgroup: controllers not enabled in %s/cgroup.subtree_control: %s", parent, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func formatMax(v int64) string {
+	if v <= 0 {
+		return "max"
+	}
+	return strconv.FormatInt(v, 10)
+}
+
+func formatCPUMax(percent float64) string {
+	period := DefaultCPUPeriod.Microseconds()
+	if percent <= 0 {
+		return fmt.Sprintf("max %d", period)
+	}
+	quota := int64(percent / 100.0 * float64(period))
+	if quota < 1000 {
+		// The kernel rejects quotas below 1ms.
+		quota = 1000
+	}
+	return fmt.Sprintf("%d %d", quota, period)
+}
+
+func (g *Group) write(file, value string) error {
+	if err := os.WriteFile(filepath.Join(g.path, file), []byte(value), 0o644); err != nil {
+		return fmt.Errorf("cgroup: write %s: %w", file, err)
+	}
+	return nil
+}
+
+// AddProcess moves pid into the group. Prefer starting the command directly
+// in the group (see supervisor.Supervisor.PlaceInCgroup); this is the fallback
+// for kernels without clone-into-cgroup.
+func (g *Group) AddProcess(pid int) error {
+	return g.write("cgroup.procs", strconv.Itoa(pid))
+}
+
+// Stats reads memory.current, memory.events, cpu.stat and pids.current.
+// Missing files are reported as zero.
+func (g *Group) Stats() (Stats, error) {
+	var s Stats
+	if _, err := os.Stat(g.path); err != nil {
+		return s, fmt.Errorf("cgroup: %w", err)
+	}
+	s.MemoryCurrentBytes = g.readInt("memory.current")
+	s.PidsCurrent = g.readInt("pids.current")
+
+	events := g.readKeyed("memory.events")
+	s.MemoryMaxEvents = events["max"]
+	s.MemoryHighEvents = events["high"]
+	s.OOMEvents = events["oom"]
+	s.OOMKills = events["oom_kill"]
+
+	cpu := g.readKeyed("cpu.stat")
+	s.CPUUsageUsec = cpu["usage_usec"]
+	s.CPUThrottledUsec = cpu["throttled_usec"]
+	s.CPUNrThrottled = cpu["nr_throttled"]
+	return s, nil
+}
+
+func (g *Group) readInt(file string) int64 {
+	data, err := os.ReadFile(filepath.Join(g.path, file))
+	if err != nil {
+		return 0
+	}
+	v, _ := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	return v
+}
+
+func (g *Group) readKeyed(file string) map[string]int64 {
+	out := make(map[string]int64)
+	data, err := os.ReadFile(filepath.Join(g.path, file))
+	if err != nil {
+		return out
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		if v, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+			out[fields[0]] = v
+		}
+	}
+	return out
+}
+
+// Kill terminates every process in the group via cgroup.kill (Linux 5.14+).
+func (g *Group) Kill() error {
+	return g.write("cgroup.kill", "1")
+}
+
+// Remove deletes the group. It retries briefly because the kernel only
+// allows removal once the last process has been reaped.
+func (g *Group) Remove() error {
+	var err error
+	for i := 0; i < 20; i++ {
+		err = os.Remove(g.path)
+		if err == nil || errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		time.Sleep(25 * time.Millisecond)
+	}
+	return fmt.Errorf("cgroup: remove %s: %w", g.path, err)
+}
@@ -0,0 +1,173 @@
+package cgroup
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return string(data)
+}
+
+func TestCreateWritesLimits(t *testing.T) {
+	parent := t.TempDir()
+	writeFile(t, filepath.Join(parent, "cgroup.subtree_control"), "cpu memory pids\n")
+
+	g, err := Create(parent, "run-1", Limits{MemoryMaxBytes: 256 << 20, CPUMaxPercent: 150, PidsMax: 64})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if got := readFile(t, filepath.Join(g.Path(), "memory.max")); got != "268435456" {
+		t.Fatalf("memory.max = %q", got)
+	}
+	if got := readFile(t, filepath.Join(g.Path(), "cpu.max")); got != "150000 100000" {
+		t.Fatalf("cpu.max = %q", got)
+	}
+	if got := readFile(t, filepath.Join(g.Path(), "pids.max")); got != "64" {
+		t.Fatalf("pids.max = %q", got)
+	}
+}
+
+func TestCreateDefaultsToMax(t *testing.T) {
+	parent := t.TempDir()
+	writeFile(t, filepath.Join(parent, "cgroup.subtree_control"), "memory pids cpu")
+
+	g, err := Create(parent, "run-2", Limits{})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if got := readFile(t, filepath.Join(g.Path(), "memory.max")); got != "max" {
+		t.Fatalf("memory.max = %q", got)
+	}
+	if got := readFile(t, filepath.Join(g.Path(), "cpu.max")); got != "max 100000" {
+		t.Fatalf("cpu.max = %q", got)
+	}
+}
+
+func TestCreateRequiresDelegatedControllers(t *testing.T) {
+	parent := t.TempDir()
+	writeFile(t, filepath.Join(parent, "cgroup.subtree_control"), "memory\n")
+
+	_, err := Create(parent, "run-3", Limits{})
+	if err == nil || !strings.Contains(err.Error(), "cpu, pids") {
+		t.Fatalf("expected missing controller error, got %v", err)
+	}
+	if _, err := Create(t.TempDir(), "run-4", Limits{}); err == nil {
+		t.Fatal("expected error for a directory without cgroup.subtree_control")
+	}
+}
+
+func TestEnableControllersMovesItselfIntoSupervisorLeaf(t *testing.T) {
+	root := t.TempDir()
+	self := strconv.Itoa(os.Getpid())
+	writeFile(t, filepath.Join(root, "cgroup.subtree_control"), "")
+	writeFile(t, filepath.Join(root, "cgroup.procs"), self+"\n")
+
+	if err := EnableControllers(root); err != nil {
+		t.Fatalf("enable: %v", err)
+	}
+	if got := readFile(t, filepath.Join(root, SupervisorLeaf, "cgroup.procs")); got != self {
+		t.Fatalf("supervisor leaf cgroup.procs = %q", got)
+	}
+	if got := readFile(t, filepath.Join(root, "cgroup.subtree_control")); got != "+memory +cpu +pids" {
+		t.Fatalf("cgroup.subtree_control = %q", got)
+	}
+
+	// Already enabled: nothing is moved.
+	delegated := t.TempDir()
+	writeFile(t, filepath.Join(delegated, "cgroup.subtree_control"), "cpu memory pids\n")
+	if err := EnableControllers(delegated); err != nil {
+		t.Fatalf("enable delegated: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(delegated, SupervisorLeaf)); !os.IsNotExist(err) {
+		t.Fatalf("expected no supervisor leaf when controllers are on, got %v", err)
+	}
+	if err := EnableControllers(t.TempDir()); err == nil {
+		t.Fatal("expected error for a directory without cgroup.procs")
+	}
+}
+
+func TestEnableControllersRefusesToMoveOtherProcesses(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "cgroup.subtree_control"), "")
+	writeFile(t, filepath.Join(root, "cgroup.procs"), strconv.Itoa(os.Getpid())+"\n4242\n")
+
+	err := EnableControllers(root)
+	if err == nil || !strings.Contains(err.Error(), "4242") {
+		t.Fatalf("expected an error naming the other process, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, SupervisorLeaf)); !os.IsNotExist(err) {
+		t.Fatalf("expected nothing moved into a supervisor leaf, got %v", err)
+	}
+	if got := readFile(t, filepath.Join(root, "cgroup.subtree_control")); got != "" {
+		t.Fatalf("cgroup.subtree_control written: %q", got)
+	}
+}
+
+func TestStatsParsesControllerFiles(t *testing.T) {
+	dir := t.TempDir()
+	g := &Group{path: dir}
+	writeFile(t, filepath.Join(dir, "memory.current"), "1048576\n")
+	writeFile(t, filepath.Join(dir, "pids.current"), "7\n")
+	writeFile(t, filepath.Join(dir, "memory.events"), "low 0\nhigh 2\nmax 5\noom 1\noom_kill 1\noom_group_kill 0\n")
+	writeFile(t, filepath.Join(dir, "cpu.stat"), "usage_usec 900\nuser_usec 600\nsystem_usec 300\nnr_periods 10\nnr_throttled 4\nthrottled_usec 250\n")
+
+	s, err := g.Stats()
+	if err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+	want := Stats{
+		MemoryCurrentBytes: 1048576,
+		MemoryMaxEvents:    5,
+		MemoryHighEvents:   2,
+		OOMEvents:          1,
+		OOMKills:           1,
+		CPUUsageUsec:       900,
+		CPUThrottledUsec:   250,
+		CPUNrThrottled:     4,
+		PidsCurrent:        7,
+	}
+	if s != want {
+		t.Fatalf("stats = %+v, want %+v", s, want)
+	}
+}
+
+func TestUnifiedMountAndSelfPath(t *testing.T) {
+	dir := t.TempDir()
+	mountinfo := filepath.Join(dir, "mountinfo")
+	writeFile(t, mountinfo, strings.Join([]string{
+		"22 1 0:21 / /proc rw,nosuid - proc proc rw",
+		"30 25 0:26 / /sys/fs/cgroup rw,nosuid shared:4 - cgroup2 cgroup2 rw,nsdelegate",
+	}, "\n"))
+	mount, err := unifiedMountPoint(mountinfo)
+	if err != nil || mount != "/sys/fs/cgroup" {
+		t.Fatalf("mount = %q, err = %v", mount, err)
+	}
+
+	procCgroup := filepath.Join(dir, "cgroup")
+	writeFile(t, procCgroup, "0::/user.slice/user-1000.slice/session-3.scope\n")
+	rel, err := unifiedSelfPath(procCgroup)
+	if err != nil || rel != "/user.slice/user-1000.slice/session-3.scope" {
+		t.Fatalf("self path = %q, err = %v", rel, err)
+	}
+
+	writeFile(t, mountinfo, "22 1 0:21 / /proc rw,nosuid - proc proc rw\n")
+	if _, err := unifiedMountPoint(mountinfo); err == nil {
+		t.Fatal("expected error without a cgroup2 mount")
+	}
+}
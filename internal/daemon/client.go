@@ -0,0 +1,27 @@
+package daemon
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+)
+
+// SocketBaseURL is the base URL for requests sent through a client from
+// NewSocketClient. Its host is never resolved.
+const SocketBaseURL = "http://flowforge.sock"
+
+// NewSocketClient returns an HTTP client that sends every request to the
+// API served on the Unix socket at path, whatever host the URL names.
+func NewSocketClient(path string, timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, "unix", path)
+			},
+			DisableKeepAlives: true,
+		},
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"strings"
 	"syscall"
 	"time"
+
+	"flowforge/internal/sysmon"
 )
 
 const (
@@ -22,6 +24,9 @@ type RuntimePaths struct {
 	PIDFile   string
 	LogFile   string
 	StateFile string
+	// SocketFile is where the daemon serves the API over a Unix socket when
+	// started with one.
+	SocketFile string
 }
 
 type State struct {
@@ -31,6 +36,23 @@ type State struct {
 	StartedAt time.Time `json:"started_at"`
 	StoppedAt time.Time `json:"stopped_at,omitempty"`
 	LastError string    `json:"last_error,omitempty"`
+	// Socket is the Unix socket the running daemon serves the API on, if any.
+	Socket string `json:"socket,omitempty"`
+	// Workers are the managed workers running when the state was written. A
+	// restarted daemon re-adopts the ones that are still the same process.
+	Workers []WorkerRecord `json:"workers,omitempty"`
+}
+
+// WorkerRecord is a managed worker as kept in the state file: its launch
+// spec, for restarts, and the identity of its process group leader.
+type WorkerRecord struct {
+	RunID   string   `json:"run_id"`
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+	Dir     string   `json:"dir,omitempty"`
+	Env     []string `json:"env,omitempty"`
+	Paused  bool     `json:"paused,omitempty"`
+	sysmon.ProcessIdentity
 }
 
 func ResolveRuntimeDir() (string, error) {
@@ -50,10 +72,11 @@ func Paths() (RuntimePaths, error) {
 		return RuntimePaths{}, err
 	}
 	return RuntimePaths{
-		Dir:       dir,
-		PIDFile:   filepath.Join(dir, "flowforge-daemon.pid"),
-		LogFile:   filepath.Join(dir, "flowforge-daemon.log"),
-		StateFile: filepath.Join(dir, "flowforge-daemon.state.json"),
+		Dir:        dir,
+		PIDFile:    filepath.Join(dir, "flowforge-daemon.pid"),
+		LogFile:    filepath.Join(dir, "flowforge-daemon.log"),
+		StateFile:  filepath.Join(dir, "flowforge-daemon.state.json"),
+		SocketFile: filepath.Join(dir, "flowforge-daemon.sock"),
 	}, nil
 }
 
@@ -3,8 +3,11 @@ package daemon
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
+
+	"flowforge/internal/sysmon"
 )
 
 func TestResolveRuntimeDirUsesEnvOverride(t *testing.T) {
@@ -61,6 +64,32 @@ func TestWriteReadState(t *testing.T) {
 	}
 }
 
+func TestWriteReadStateKeepsWorkers(t *testing.T) {
+	t.Setenv(envRuntimeDir, t.TempDir())
+	paths, err := EnsureRuntimeDir()
+	if err != nil {
+		t.Fatalf("EnsureRuntimeDir() error = %v", err)
+	}
+	worker := WorkerRecord{
+		RunID:           "run-1",
+		Command:         "python3 agent.py",
+		Args:            []string{"python3", "agent.py"},
+		Env:             []string{"A=1"},
+		Paused:          true,
+		ProcessIdentity: sysmon.ProcessIdentity{PID: 4321, PGID: 4321, StartTicks: 98765, BootID: "boot"},
+	}
+	if err := WriteState(paths, State{PID: 22, Status: "running", Workers: []WorkerRecord{worker}}); err != nil {
+		t.Fatalf("WriteState() error = %v", err)
+	}
+	got, err := ReadState(paths)
+	if err != nil {
+		t.Fatalf("ReadState() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.Workers, []WorkerRecord{worker}) {
+		t.Fatalf("ReadState().Workers = %+v, want %+v", got.Workers, worker)
+	}
+}
+
 func TestProcessAliveCurrentProcess(t *testing.T) {
 	if !ProcessAlive(os.Getpid()) {
 		t.Fatal("ProcessAlive(current pid) = false, want true")
@@ -0,0 +1,195 @@
+package database
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// apiTokenPrefix marks FlowForge API tokens so leaked ones are easy to spot.
+const apiTokenPrefix = "fft_"
+
+// sqliteTimeLayout matches CURRENT_TIMESTAMP, so stored times compare as text.
+const sqliteTimeLayout = "2006-01-02 15:04:05"
+
+var (
+	ErrAPITokenNotFound = errors.New("api token not found")
+
+	apiTokenNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+)
+
+// APIToken is a stored API token. Only a SHA-256 hash of the secret is
+// kept; Prefix is its first characters, to tell tokens apart in listings.
+type APIToken struct {
+	ID         int64    `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Roles      []string `json:"roles"`
+	CreatedAt  string   `json:"created_at"`
+	ExpiresAt  string   `json:"expires_at,omitempty"`
+	LastUsedAt string   `json:"last_used_at,omitempty"`
+	RevokedAt  string   `json:"revoked_at,omitempty"`
+}
+
+// Status is "revoked", "expired" or "active" at now.
+func (t APIToken) Status(now time.Time) string {
+	if t.RevokedAt != "" {
+		return "revoked"
+	}
+	if t.ExpiresAt != "" {
+		if expires := parseTimestamp(t.ExpiresAt); !expires.IsZero() && !now.UTC().Before(expires) {
+			return "expired"
+		}
+	}
+	return "active"
+}
+
+// CreateAPIToken stores a new token and returns it with its secret, which
+// is not stored and cannot be shown again. A zero expiresAt never expires.
+// Roles are stored as given; callers validate them.
+func CreateAPIToken(name string, roles []string, expiresAt time.Time) (APIToken, string, error) {
+	if db == nil {
+		return APIToken{}, "", fmt.Errorf("db not initialized")
+	}
+	name = strings.TrimSpace(name)
+	if !apiTokenNamePattern.MatchString(name) {
+		return APIToken{}, "", fmt.Errorf("token name must match [A-Za-z0-9._-] and be <= 64 chars")
+	}
+	if len(roles) == 0 {
+		return APIToken{}, "", fmt.Errorf("at least one role is required")
+	}
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return APIToken{}, "", fmt.Errorf("generate token: %w", err)
+	}
+	secret := apiTokenPrefix + base64.RawURLEncoding.EncodeToString(raw)
+	prefix := secret[:len(apiTokenPrefix)+8]
+
+	var expires interface{}
+	if !expiresAt.IsZero() {
+		expires = expiresAt.UTC().Format(sqliteTimeLayout)
+	}
+	res, err := db.Exec(`
+INSERT INTO api_tokens(name, token_hash, token_prefix, roles, expires_at)
+VALUES(?, ?, ?, ?, ?)
+`, name, hashAPIToken(secret), prefix, strings.Join(roles, ","), expires)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			return APIToken{}, "", fmt.Errorf("an unrevoked token named %q already exists", name)
+		}
+		return APIToken{}, "", err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return APIToken{}, "", err
+	}
+	token, err := scanAPIToken(db.QueryRow(apiTokenSelect+" WHERE id = ?", id))
+	if err != nil {
+		return APIToken{}, "", err
+	}
+	return token, secret, nil
+}
+
+// ListAPITokens returns every token, revoked ones included, oldest first.
+func ListAPITokens() ([]APIToken, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db not initialized")
+	}
+	rows, err := db.Query(apiTokenSelect + " ORDER BY id ASC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []APIToken{}
+	for rows.Next() {
+		token, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, token)
+	}
+	return out, rows.Err()
+}
+
+// RevokeAPIToken revokes the unrevoked token called name.
+func RevokeAPIToken(name string) error {
+	if db == nil {
+		return fmt.Errorf("db not initialized")
+	}
+	res, err := db.Exec("UPDATE api_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE name = ? AND revoked_at IS NULL", strings.TrimSpace(name))
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrAPITokenNotFound
+	}
+	return nil
+}
+
+// LookupAPIToken returns the active token whose secret is secret, or
+// ErrAPITokenNotFound when it is unknown, revoked or expired.
+func LookupAPIToken(secret string) (APIToken, error) {
+	if db == nil {
+		return APIToken{}, fmt.Errorf("db not initialized")
+	}
+	if !strings.HasPrefix(secret, apiTokenPrefix) {
+		return APIToken{}, ErrAPITokenNotFound
+	}
+	token, err := scanAPIToken(db.QueryRow(apiTokenSelect+`
+WHERE token_hash = ? AND revoked_at IS NULL
+	AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+`, hashAPIToken(secret)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return APIToken{}, ErrAPITokenNotFound
+	}
+	return token, err
+}
+
+// TouchAPIToken records that the token was just used.
+func TouchAPIToken(id int64) error {
+	if db == nil {
+		return fmt.Errorf("db not initialized")
+	}
+	_, err := db.Exec("UPDATE api_tokens SET last_used_at = CURRENT_TIMESTAMP WHERE id = ?", id)
+	return err
+}
+
+// HasActiveAPITokens reports whether any token could currently authenticate.
+func HasActiveAPITokens() (bool, error) {
+	if db == nil {
+		return false, fmt.Errorf("db not initialized")
+	}
+	var count int
+	err := db.QueryRow(`
+SELECT COUNT(*) FROM api_tokens
+WHERE revoked_at IS NULL AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+`).Scan(&count)
+	return count > 0, err
+}
+
+const apiTokenSelect = `
+SELECT id, name, token_prefix, roles, COALESCE(created_at, ''), COALESCE(expires_at, ''),
+	COALESCE(last_used_at, ''), COALESCE(revoked_at, '')
+FROM api_tokens`
+
+func scanAPIToken(row interface{ Scan(...interface{}) error }) (APIToken, error) {
+	var token APIToken
+	var roles string
+	if err := row.Scan(&token.ID, &token.Name, &token.Prefix, &roles, &token.CreatedAt, &token.ExpiresAt, &token.LastUsedAt, &token.RevokedAt); err != nil {
+		return APIToken{}, err
+	}
+	token.Roles = strings.Split(roles, ",")
+	return token, nil
+}
+
+func hashAPIToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPITokenLifecycle(t *testing.T) {
+	_ = withTempDBPath(t)
+	CloseDB()
+	if err := InitDB(); err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+
+	if ok, err := HasActiveAPITokens(); err != nil || ok {
+		t.Fatalf("expected no active tokens in a new store, got %v err=%v", ok, err)
+	}
+	token, secret, err := CreateAPIToken("ci-nightly", []string{"operator"}, time.Time{})
+	if err != nil {
+		t.Fatalf("CreateAPIToken: %v", err)
+	}
+	if !strings.HasPrefix(secret, token.Prefix) || token.ExpiresAt != "" || token.Status(time.Now()) != "active" {
+		t.Fatalf("unexpected token %+v for secret prefix %q", token, secret[:12])
+	}
+	var stored int
+	if err := db.QueryRow("SELECT COUNT(*) FROM api_tokens WHERE token_hash = ? OR roles LIKE ?", secret, "%"+secret+"%").Scan(&stored); err != nil || stored != 0 {
+		t.Fatalf("expected the secret not to be stored in clear, found %d rows (err %v)", stored, err)
+	}
+	if _, _, err := CreateAPIToken("ci-nightly", []string{"viewer"}, time.Time{}); err == nil {
+		t.Fatal("expected a duplicate unrevoked name to be rejected")
+	}
+	if _, _, err := CreateAPIToken("uid:0", []string{"viewer"}, time.Time{}); err == nil {
+		t.Fatal("expected a name that could pass for another actor to be rejected")
+	}
+
+	found, err := LookupAPIToken(secret)
+	if err != nil || found.Name != "ci-nightly" || len(found.Roles) != 1 || found.Roles[0] != "operator" {
+		t.Fatalf("unexpected lookup %+v err=%v", found, err)
+	}
+	if _, err := LookupAPIToken(secret + "x"); !errors.Is(err, ErrAPITokenNotFound) {
+		t.Fatalf("expected an unknown secret to be rejected, got %v", err)
+	}
+	if err := TouchAPIToken(found.ID); err != nil {
+		t.Fatalf("TouchAPIToken: %v", err)
+	}
+
+	_, expiredSecret, err := CreateAPIToken("old-dashboard", []string{"viewer"}, time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("CreateAPIToken expired: %v", err)
+	}
+	if _, err := LookupAPIToken(expiredSecret); !errors.Is(err, ErrAPITokenNotFound) {
+		t.Fatalf("expected an expired token to be rejected, got %v", err)
+	}
+
+	if err := RevokeAPIToken("ci-nightly"); err != nil {
+		t.Fatalf("RevokeAPIToken: %v", err)
+	}
+	if err := RevokeAPIToken("ci-nightly"); !errors.Is(err, ErrAPITokenNotFound) {
+		t.Fatalf("expected a second revoke to find nothing, got %v", err)
+	}
+	if _, err := LookupAPIToken(secret); !errors.Is(err, ErrAPITokenNotFound) {
+		t.Fatalf("expected a revoked token to be rejected, got %v", err)
+	}
+	if ok, _ := HasActiveAPITokens(); ok {
+		t.Fatal("expected no active tokens once revoked and expired")
+	}
+	if _, _, err := CreateAPIToken("ci-nightly", []string{"viewer"}, time.Time{}); err != nil {
+		t.Fatalf("expected the name to be reusable after revoke, got %v", err)
+	}
+
+	list, err := ListAPITokens()
+	if err != nil || len(list) != 3 {
+		t.Fatalf("expected 3 tokens listed, got %d err=%v", len(list), err)
+	}
+	now := time.Now()
+	statuses := []string{list[0].Status(now), list[1].Status(now), list[2].Status(now)}
+	if strings.Join(statuses, ",") != "revoked,expired,active" || list[0].LastUsedAt == "" {
+		t.Fatalf("unexpected listing %v / %+v", statuses, list[0])
+	}
+}
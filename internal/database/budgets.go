@@ -0,0 +1,162 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// WorkspaceBudget is the daily spend limit of one integration workspace.
+// Zero disables a limit.
+type WorkspaceBudget struct {
+	WorkspaceID     string  `json:"workspace_id"`
+	DailyMaxCostUSD float64 `json:"daily_max_cost_usd"`
+	DailyMaxTokens  int     `json:"daily_max_tokens"`
+	UpdatedAt       string  `json:"updated_at,omitempty"`
+}
+
+// WorkspaceSpend is what a workspace has used on one UTC day, summed over
+// every run that named it.
+type WorkspaceSpend struct {
+	WorkspaceID   string  `json:"workspace_id"`
+	Day           string  `json:"day"`
+	Tokens        int     `json:"tokens"`
+	CostUSD       float64 `json:"cost_usd"`
+	WarnedPercent int     `json:"warned_percent"`
+}
+
+// BudgetDay is the spend bucket a timestamp falls in. Days roll over at
+// midnight UTC so every host agrees on them.
+func BudgetDay(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+func SetWorkspaceBudget(budget WorkspaceBudget) error {
+	if db == nil {
+		return fmt.Errorf("db not initialized")
+	}
+	budget.WorkspaceID = strings.TrimSpace(budget.WorkspaceID)
+	if budget.WorkspaceID == "" {
+		return fmt.Errorf("workspace_id is required")
+	}
+	if budget.DailyMaxCostUSD < 0 || budget.DailyMaxTokens < 0 {
+		return fmt.Errorf("budget limits must be non-negative")
+	}
+	_, err := db.Exec(`
+INSERT INTO workspace_budgets(workspace_id, daily_max_cost_usd, daily_max_tokens, updated_at)
+VALUES(?, ?, ?, CURRENT_TIMESTAMP)
+ON CONFLICT(workspace_id) DO UPDATE SET
+	daily_max_cost_usd = excluded.daily_max_cost_usd,
+	daily_max_tokens = excluded.daily_max_tokens,
+	updated_at = CURRENT_TIMESTAMP
+`, budget.WorkspaceID, budget.DailyMaxCostUSD, budget.DailyMaxTokens)
+	return err
+}
+
+// GetWorkspaceBudget returns the workspace's budget, or a zero budget when
+// none is set.
+func GetWorkspaceBudget(workspaceID string) (WorkspaceBudget, error) {
+	if db == nil {
+		return WorkspaceBudget{}, fmt.Errorf("db not initialized")
+	}
+	workspaceID = strings.TrimSpace(workspaceID)
+	if workspaceID == "" {
+		return WorkspaceBudget{}, fmt.Errorf("workspace_id is required")
+	}
+	out := WorkspaceBudget{WorkspaceID: workspaceID}
+	err := db.QueryRow(`
+SELECT daily_max_cost_usd, daily_max_tokens, COALESCE(updated_at, '')
+FROM workspace_budgets
+WHERE workspace_id = ?
+`, workspaceID).Scan(&out.DailyMaxCostUSD, &out.DailyMaxTokens, &out.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return out, nil
+	}
+	if err != nil {
+		return WorkspaceBudget{}, err
+	}
+	return out, nil
+}
+
+func DeleteWorkspaceBudget(workspaceID string) error {
+	if db == nil {
+		return fmt.Errorf("db not initialized")
+	}
+	_, err := db.Exec("DELETE FROM workspace_budgets WHERE workspace_id = ?", strings.TrimSpace(workspaceID))
+	return err
+}
+
+// AddWorkspaceSpend adds a run's usage since its last report to the
+// workspace's total for day and returns the new total.
+func AddWorkspaceSpend(workspaceID, day string, tokens int, costUSD float64) (WorkspaceSpend, error) {
+	if db == nil {
+		return WorkspaceSpend{}, fmt.Errorf("db not initialized")
+	}
+	workspaceID = strings.TrimSpace(workspaceID)
+	if workspaceID == "" {
+		return WorkspaceSpend{}, fmt.Errorf("workspace_id is required")
+	}
+	if tokens < 0 {
+		tokens = 0
+	}
+	if costUSD < 0 {
+		costUSD = 0
+	}
+	if _, err := db.Exec(`
+INSERT INTO workspace_daily_spend(workspace_id, day, tokens, cost_usd, updated_at)
+VALUES(?, ?, ?, ?, CURRENT_TIMESTAMP)
+ON CONFLICT(workspace_id, day) DO UPDATE SET
+	tokens = workspace_daily_spend.tokens + excluded.tokens,
+	cost_usd = workspace_daily_spend.cost_usd + excluded.cost_usd,
+	updated_at = CURRENT_TIMESTAMP
+`, workspaceID, day, tokens, costUSD); err != nil {
+		return WorkspaceSpend{}, err
+	}
+	return GetWorkspaceSpend(workspaceID, day)
+}
+
+// GetWorkspaceSpend returns the workspace's usage for day; zero when nothing
+// has been recorded.
+func GetWorkspaceSpend(workspaceID, day string) (WorkspaceSpend, error) {
+	if db == nil {
+		return WorkspaceSpend{}, fmt.Errorf("db not initialized")
+	}
+	workspaceID = strings.TrimSpace(workspaceID)
+	out := WorkspaceSpend{WorkspaceID: workspaceID, Day: day}
+	err := db.QueryRow(`
+SELECT tokens, cost_usd, warned_percent
+FROM workspace_daily_spend
+WHERE workspace_id = ? AND day = ?
+`, workspaceID, day).Scan(&out.Tokens, &out.CostUSD, &out.WarnedPercent)
+	if errors.Is(err, sql.ErrNoRows) {
+		return out, nil
+	}
+	if err != nil {
+		return WorkspaceSpend{}, err
+	}
+	return out, nil
+}
+
+// MarkWorkspaceBudgetWarning records that the workspace crossed percent of
+// its daily budget. It returns false when that threshold, or a higher one,
+// was already recorded, so concurrent runs warn once between them.
+func MarkWorkspaceBudgetWarning(workspaceID, day string, percent int) (bool, error) {
+	if db == nil {
+		return false, fmt.Errorf("db not initialized")
+	}
+	res, err := db.Exec(`
+UPDATE workspace_daily_spend
+SET warned_percent = ?
+WHERE workspace_id = ? AND day = ? AND warned_percent < ?
+`, percent, strings.TrimSpace(workspaceID), day, percent)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"flowforge/internal/tokens"
+)
+
+func TestWorkspaceBudgetAndSpend(t *testing.T) {
+	_ = withTempDBPath(t)
+	CloseDB()
+	if err := InitDB(); err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+
+	if got, err := GetWorkspaceBudget("ws-1"); err != nil || got.DailyMaxCostUSD != 0 || got.DailyMaxTokens != 0 {
+		t.Fatalf("expected no budget before set, got %+v err=%v", got, err)
+	}
+	if err := SetWorkspaceBudget(WorkspaceBudget{WorkspaceID: "ws-1", DailyMaxCostUSD: 5, DailyMaxTokens: 1000}); err != nil {
+		t.Fatalf("SetWorkspaceBudget: %v", err)
+	}
+	if err := SetWorkspaceBudget(WorkspaceBudget{WorkspaceID: "ws-1", DailyMaxCostUSD: -1}); err == nil {
+		t.Fatal("expected a negative budget to be rejected")
+	}
+	got, err := GetWorkspaceBudget("ws-1")
+	if err != nil || got.DailyMaxCostUSD != 5 || got.DailyMaxTokens != 1000 {
+		t.Fatalf("unexpected budget %+v err=%v", got, err)
+	}
+
+	if _, err := AddWorkspaceSpend("ws-1", "2026-03-01", 300, 1.25); err != nil {
+		t.Fatalf("AddWorkspaceSpend: %v", err)
+	}
+	spend, err := AddWorkspaceSpend("ws-1", "2026-03-01", 250, 0.5)
+	if err != nil || spend.Tokens != 550 || spend.CostUSD != 1.75 {
+		t.Fatalf("expected spend to accumulate, got %+v err=%v", spend, err)
+	}
+	if other, _ := GetWorkspaceSpend("ws-1", "2026-03-02"); other.Tokens != 0 {
+		t.Fatalf("expected a new day to start at zero, got %+v", other)
+	}
+
+	if first, err := MarkWorkspaceBudgetWarning("ws-1", "2026-03-01", 50); err != nil || !first {
+		t.Fatalf("expected first 50%% warning to be recorded, got %v err=%v", first, err)
+	}
+	if again, _ := MarkWorkspaceBudgetWarning("ws-1", "2026-03-01", 50); again {
+		t.Fatal("expected a repeated 50% warning to be suppressed")
+	}
+	if higher, _ := MarkWorkspaceBudgetWarning("ws-1", "2026-03-01", 80); !higher {
+		t.Fatal("expected the 80% warning to be recorded")
+	}
+}
+
+func TestIncidentCostBreakdownRoundTrip(t *testing.T) {
+	_ = withTempDBPath(t)
+	setMasterKeyForTest(t, testMasterKeyHex)
+	CloseDB()
+	if err := InitDB(); err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+
+	usage := tokens.DefaultCatalog().Cost("gpt-4o-2024-08-06", 2000, 500, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err := LogIncidentWithCost("python3 agent.py", "gpt-4o-2024-08-06", "LOOP_DETECTED", 91, "N/A", 3, usage, "agent", "1.0.0"); err != nil {
+		t.Fatalf("LogIncidentWithCost: %v", err)
+	}
+	incident, err := GetIncidentByID(1)
+	if err != nil {
+		t.Fatalf("GetIncidentByID: %v", err)
+	}
+	got := incident.CostBreakdown
+	if got == nil || !got.Known || got.PricedAs != "gpt-4o" || got.InputTokens != 2000 || got.OutputTokens != 500 {
+		t.Fatalf("unexpected cost breakdown %+v", got)
+	}
+	if incident.TokenCount != 2500 || incident.Cost != usage.Total {
+		t.Fatalf("expected token count and cost to follow the breakdown, got %d / %f", incident.TokenCount, incident.Cost)
+	}
+}
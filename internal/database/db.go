@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"flowforge/internal/encryption"
 	"flowforge/internal/redact"
+	"flowforge/internal/tokens"
 	"fmt"
 	"os"
 	"sort"
@@ -41,6 +42,9 @@ type Incident struct {
 	ConfidenceScore      float64 `json:"confidence_score"`
 	RecoveryStatus       string  `json:"recovery_status"`
 	RestartCount         int     `json:"restart_count"`
+	// CostBreakdown splits Cost into input and output and names the catalog
+	// price used; nil for incidents recorded before it existed.
+	CostBreakdown *tokens.Cost `json:"cost_breakdown,omitempty"`
 }
 
 type AuditEvent struct {
@@ -126,23 +130,24 @@ type UnifiedEvent struct {
 }
 
 type incidentEventPayload struct {
-	ID                   int     `json:"id"`
-	Command              string  `json:"command"`
-	ModelName            string  `json:"model_name"`
-	ExitReason           string  `json:"exit_reason"`
-	MaxCPU               float64 `json:"max_cpu"`
-	Pattern              string  `json:"pattern"`
-	TokenSavingsEstimate float64 `json:"token_savings_estimate"`
-	TokenCount           int     `json:"token_count"`
-	Cost                 float64 `json:"cost"`
-	AgentID              string  `json:"agent_id"`
-	AgentVersion         string  `json:"agent_version"`
-	Reason               string  `json:"reason"`
-	CPUScore             float64 `json:"cpu_score"`
-	EntropyScore         float64 `json:"entropy_score"`
-	ConfidenceScore      float64 `json:"confidence_score"`
-	RecoveryStatus       string  `json:"recovery_status"`
-	RestartCount         int     `json:"restart_count"`
+	ID                   int          `json:"id"`
+	Command              string       `json:"command"`
+	ModelName            string       `json:"model_name"`
+	ExitReason           string       `json:"exit_reason"`
+	MaxCPU               float64      `json:"max_cpu"`
+	Pattern              string       `json:"pattern"`
+	TokenSavingsEstimate float64      `json:"token_savings_estimate"`
+	TokenCount           int          `json:"token_count"`
+	Cost                 float64      `json:"cost"`
+	AgentID              string       `json:"agent_id"`
+	AgentVersion         string       `json:"agent_version"`
+	Reason               string       `json:"reason"`
+	CPUScore             float64      `json:"cpu_score"`
+	EntropyScore         float64      `json:"entropy_score"`
+	ConfidenceScore      float64      `json:"confidence_score"`
+	RecoveryStatus       string       `json:"recovery_status"`
+	RestartCount         int          `json:"restart_count"`
+	CostBreakdown        *tokens.Cost `json:"cost_breakdown,omitempty"`
 }
 
 type auditEventPayload struct {
@@ -153,14 +158,15 @@ type auditEventPayload struct {
 }
 
 type decisionEventPayload struct {
-	ID                int    `json:"id"`
-	Command           string `json:"command"`
-	DecisionEngine    string `json:"decision_engine,omitempty"`
-	EngineVersion     string `json:"engine_version,omitempty"`
-	DecisionContract  string `json:"decision_contract_version,omitempty"`
-	PolicyRolloutMode string `json:"rollout_mode,omitempty"`
-	ReplayContract    string `json:"replay_contract_version,omitempty"`
-	ReplayDigest      string `json:"replay_digest,omitempty"`
+	ID                int                `json:"id"`
+	Command           string             `json:"command"`
+	DecisionEngine    string             `json:"decision_engine,omitempty"`
+	EngineVersion     string             `json:"engine_version,omitempty"`
+	DecisionContract  string             `json:"decision_contract_version,omitempty"`
+	PolicyRolloutMode string             `json:"rollout_mode,omitempty"`
+	ReplayContract    string             `json:"replay_contract_version,omitempty"`
+	ReplayDigest      string             `json:"replay_digest,omitempty"`
+	Telemetry         *DecisionTelemetry `json:"telemetry,omitempty"`
 }
 
 type DecisionTraceMeta struct {
@@ -170,6 +176,49 @@ type DecisionTraceMeta struct {
 	PolicyRolloutMode string `json:"rollout_mode,omitempty"`
 	ReplayContract    string `json:"replay_contract_version,omitempty"`
 	ReplayDigest      string `json:"replay_digest,omitempty"`
+	// Telemetry is the raw sample the engine evaluated; it is stored with the
+	// decision event so policies can be backtested later.
+	Telemetry *DecisionTelemetry `json:"telemetry,omitempty"`
+}
+
+type DecisionTelemetry struct {
+	CPUPercent          float64 `json:"cpu_percent"`
+	CPUOverForSeconds   float64 `json:"cpu_over_for_seconds"`
+	MemoryMB            float64 `json:"memory_mb"`
+	LogRepetition       float64 `json:"log_repetition"`
+	LogEntropy          float64 `json:"log_entropy"`
+	RawDiversity        float64 `json:"raw_diversity"`
+	ProgressLike        bool    `json:"progress_like"`
+	ErrorRate           float64 `json:"error_rate,omitempty"`
+	MaxCPUPercent       float64 `json:"max_cpu_percent"`
+	OOMKills            int     `json:"oom_kills,omitempty"`
+	CPUThrottled        float64 `json:"cpu_throttled,omitempty"`
+	OutputIdleSeconds   float64 `json:"output_idle_seconds,omitempty"`
+	CPUIdleSeconds      float64 `json:"cpu_idle_seconds,omitempty"`
+	HeartbeatAgeSeconds float64 `json:"heartbeat_age_seconds,omitempty"`
+
+	MemoryGrowthMBPerMin     float64 `json:"memory_growth_mb_per_min,omitempty"`
+	MemoryTimeToLimitSeconds float64 `json:"memory_time_to_limit_seconds,omitempty"`
+
+	ChildProcesses  int `json:"child_processes,omitempty"`
+	ZombieProcesses int `json:"zombie_processes,omitempty"`
+	OpenFDs         int `json:"open_fds,omitempty"`
+	Threads         int `json:"threads,omitempty"`
+
+	StderrLinesPerSec  float64 `json:"stderr_lines_per_sec,omitempty"`
+	StderrRepetition   float64 `json:"stderr_repetition,omitempty"`
+	RepeatedExceptions int     `json:"repeated_exceptions,omitempty"`
+	ExceptionSignature string  `json:"exception_signature,omitempty"`
+
+	ToolCallRepetition int    `json:"tool_call_repetition,omitempty"`
+	ToolCallSignature  string `json:"tool_call_signature,omitempty"`
+	PromptRepetition   int    `json:"prompt_repetition,omitempty"`
+	PromptSignature    string `json:"prompt_signature,omitempty"`
+
+	RunCostUSD       float64 `json:"run_cost_usd,omitempty"`
+	RunTokens        int     `json:"run_tokens,omitempty"`
+	WorkspaceCostUSD float64 `json:"workspace_cost_usd,omitempty"`
+	WorkspaceTokens  int     `json:"workspace_tokens,omitempty"`
 }
 
 func InitDB() error {
@@ -202,7 +251,8 @@ func InitDB() error {
 		entropy_score REAL DEFAULT 0.0,
 		confidence_score REAL DEFAULT 0.0,
 		recovery_status TEXT DEFAULT '',
-		restart_count INTEGER DEFAULT 0
+		restart_count INTEGER DEFAULT 0,
+		cost_breakdown TEXT DEFAULT ''
 	);`
 
 	if _, err := db.Exec(createTableSQL); err != nil {
@@ -220,6 +270,7 @@ func InitDB() error {
 	db.Exec("ALTER TABLE incidents ADD COLUMN confidence_score REAL DEFAULT 0.0;")
 	db.Exec("ALTER TABLE incidents ADD COLUMN recovery_status TEXT DEFAULT '';")
 	db.Exec("ALTER TABLE incidents ADD COLUMN restart_count INTEGER DEFAULT 0;")
+	db.Exec("ALTER TABLE incidents ADD COLUMN cost_breakdown TEXT DEFAULT '';")
 
 	createAuditTableSQL := `CREATE TABLE IF NOT EXISTS audit_events (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -438,6 +489,29 @@ func InitDB() error {
 		return err
 	}
 
+	createWorkspaceBudgetsTableSQL := `CREATE TABLE IF NOT EXISTS workspace_budgets (
+		workspace_id TEXT PRIMARY KEY,
+		daily_max_cost_usd REAL NOT NULL DEFAULT 0,
+		daily_max_tokens INTEGER NOT NULL DEFAULT 0,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`
+	if _, err := db.Exec(createWorkspaceBudgetsTableSQL); err != nil {
+		return err
+	}
+
+	createWorkspaceDailySpendTableSQL := `CREATE TABLE IF NOT EXISTS workspace_daily_spend (
+		workspace_id TEXT NOT NULL,
+		day TEXT NOT NULL,
+		tokens INTEGER NOT NULL DEFAULT 0,
+		cost_usd REAL NOT NULL DEFAULT 0,
+		warned_percent INTEGER NOT NULL DEFAULT 0,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY(workspace_id, day)
+	);`
+	if _, err := db.Exec(createWorkspaceDailySpendTableSQL); err != nil {
+		return err
+	}
+
 	createSignalBaselineStateTableSQL := `CREATE TABLE IF NOT EXISTS decision_signal_baseline_state (
 		bucket_key TEXT PRIMARY KEY,
 		latest_trace_id INTEGER NOT NULL DEFAULT 0,
@@ -473,6 +547,56 @@ func InitDB() error {
 		return err
 	}
 
+	createAPITokensTableSQL := `CREATE TABLE IF NOT EXISTS api_tokens (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		token_hash TEXT NOT NULL UNIQUE,
+		token_prefix TEXT NOT NULL,
+		roles TEXT NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		expires_at DATETIME,
+		last_used_at DATETIME,
+		revoked_at DATETIME
+	);`
+	if _, err := db.Exec(createAPITokensTableSQL); err != nil {
+		return err
+	}
+	// A name stays unique among unrevoked tokens so audit actors are unambiguous.
+	if _, err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_api_tokens_active_name ON api_tokens(name) WHERE revoked_at IS NULL;"); err != nil {
+		return err
+	}
+
+	createWebhookDeliveriesTableSQL := `CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_id TEXT NOT NULL,
+		event_row_id INTEGER NOT NULL DEFAULT 0,
+		kind TEXT NOT NULL,
+		url TEXT NOT NULL,
+		body TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		next_attempt_at TEXT NOT NULL,
+		last_status_code INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		delivered_at DATETIME,
+		UNIQUE(event_id, url)
+	);`
+	if _, err := db.Exec(createWebhookDeliveriesTableSQL); err != nil {
+		return err
+	}
+	if _, err := db.Exec("CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(status, next_attempt_at);"); err != nil {
+		return err
+	}
+	// One row: the last events.id the webhook scanner has looked at.
+	createWebhookCursorTableSQL := `CREATE TABLE IF NOT EXISTS webhook_cursor (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		last_event_row_id INTEGER NOT NULL DEFAULT 0
+	);`
+	if _, err := db.Exec(createWebhookCursorTableSQL); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -510,6 +634,11 @@ func SetRunID(runID string) {
 	runContext.runID = runID
 }
 
+// CurrentRunID returns the run ID set by SetRunID, or "unknown-run".
+func CurrentRunID() string {
+	return currentRunID()
+}
+
 func currentRunID() string {
 	runContext.mu.RLock()
 	defer runContext.mu.RUnlock()
@@ -529,6 +658,11 @@ func LogIncident(command, modelName, exitReason string, maxCpu float64, pattern
 	return LogIncidentWithDecision(command, modelName, exitReason, maxCpu, pattern, savings, tokenCount, cost, agentID, agentVersion, "", 0, 0, 0, "", 0)
 }
 
+// LogIncidentWithCost is LogIncident with a priced usage breakdown.
+func LogIncidentWithCost(command, modelName, exitReason string, maxCpu float64, pattern string, savings float64, usage tokens.Cost, agentID, agentVersion string) error {
+	return LogIncidentWithCostForIncident(command, modelName, exitReason, maxCpu, pattern, savings, usage, agentID, agentVersion, "", 0, 0, 0, "", 0, "")
+}
+
 func LogIncidentWithDecision(
 	command, modelName, exitReason string,
 	maxCpu float64,
@@ -561,6 +695,42 @@ func LogIncidentWithDecisionForIncident(
 	recoveryStatus string,
 	restartCount int,
 	incidentID string,
+) error {
+	return logIncident(command, modelName, exitReason, maxCpu, pattern, savings, tokenCount, cost, nil, agentID, agentVersion, reason, cpuScore, entropyScore, confidenceScore, recoveryStatus, restartCount, incidentID)
+}
+
+// LogIncidentWithCostForIncident records an incident whose token count and
+// cost come from a priced usage breakdown, which is stored alongside them.
+func LogIncidentWithCostForIncident(
+	command, modelName, exitReason string,
+	maxCpu float64,
+	pattern string,
+	savings float64,
+	usage tokens.Cost,
+	agentID, agentVersion string,
+	reason string,
+	cpuScore, entropyScore, confidenceScore float64,
+	recoveryStatus string,
+	restartCount int,
+	incidentID string,
+) error {
+	return logIncident(command, modelName, exitReason, maxCpu, pattern, savings, usage.Tokens(), usage.Total, &usage, agentID, agentVersion, reason, cpuScore, entropyScore, confidenceScore, recoveryStatus, restartCount, incidentID)
+}
+
+func logIncident(
+	command, modelName, exitReason string,
+	maxCpu float64,
+	pattern string,
+	savings float64,
+	tokenCount int,
+	cost float64,
+	breakdown *tokens.Cost,
+	agentID, agentVersion string,
+	reason string,
+	cpuScore, entropyScore, confidenceScore float64,
+	recoveryStatus string,
+	restartCount int,
+	incidentID string,
 ) error {
 	if db == nil {
 		return fmt.Errorf("db not initialized")
@@ -578,13 +748,20 @@ func LogIncidentWithDecisionForIncident(
 		return fmt.Errorf("encrypt incident pattern: %w", err)
 	}
 
-	stmt, err := db.Prepare("INSERT INTO incidents(command, model_name, exit_reason, max_cpu, pattern, token_savings_estimate, token_count, cost, agent_id, agent_version, reason, cpu_score, entropy_score, confidence_score, recovery_status, restart_count) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	breakdownJSON := ""
+	if breakdown != nil {
+		if b, err := json.Marshal(breakdown); err == nil {
+			breakdownJSON = string(b)
+		}
+	}
+
+	stmt, err := db.Prepare("INSERT INTO incidents(command, model_name, exit_reason, max_cpu, pattern, token_savings_estimate, token_count, cost, agent_id, agent_version, reason, cpu_score, entropy_score, confidence_score, recovery_status, restart_count, cost_breakdown) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	result, err := stmt.Exec(encCmd, modelName, exitReason, maxCpu, encPat, savings, tokenCount, cost, agentID, agentVersion, reason, cpuScore, entropyScore, confidenceScore, recoveryStatus, restartCount)
+	result, err := stmt.Exec(encCmd, modelName, exitReason, maxCpu, encPat, savings, tokenCount, cost, agentID, agentVersion, reason, cpuScore, entropyScore, confidenceScore, recoveryStatus, restartCount, breakdownJSON)
 	if err != nil {
 		return err
 	}
@@ -608,22 +785,37 @@ func LogIncidentWithDecisionForIncident(
 		ConfidenceScore:      confidenceScore,
 		RecoveryStatus:       recoveryStatus,
 		RestartCount:         restartCount,
+		CostBreakdown:        breakdown,
 	}
 	return logUnifiedEventWithPayload("incident", exitReason, fmt.Sprintf("%s (CPU %.1f%%)", exitReason, maxCpu), reason, "system", incidentID, 0, cpuScore, entropyScore, confidenceScore, payload)
 }
 
+func decodeCostBreakdown(raw string) *tokens.Cost {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+	var out tokens.Cost
+	if err := json.Unmarshal([]byte(raw), &out); err != nil {
+		return nil
+	}
+	return &out
+}
+
 func GetIncidentByID(id int) (Incident, error) {
 	var i Incident
 	if db == nil {
 		return i, fmt.Errorf("db missing")
 	}
 
-	row := db.QueryRow("SELECT id, timestamp, command, COALESCE(model_name, 'unknown'), exit_reason, max_cpu, pattern, token_savings_estimate, COALESCE(token_count, 0), COALESCE(cost, 0.0), COALESCE(agent_id, ''), COALESCE(agent_version, ''), COALESCE(reason, ''), COALESCE(cpu_score, 0.0), COALESCE(entropy_score, 0.0), COALESCE(confidence_score, 0.0), COALESCE(recovery_status, ''), COALESCE(restart_count, 0) FROM incidents WHERE id = ?", id)
-	err := row.Scan(&i.ID, &i.Timestamp, &i.Command, &i.ModelName, &i.ExitReason, &i.MaxCPU, &i.Pattern, &i.TokenSavingsEstimate, &i.TokenCount, &i.Cost, &i.AgentID, &i.AgentVersion, &i.Reason, &i.CPUScore, &i.EntropyScore, &i.ConfidenceScore, &i.RecoveryStatus, &i.RestartCount)
+	row := db.QueryRow("SELECT id, timestamp, command, COALESCE(model_name, 'unknown'), exit_reason, max_cpu, pattern, token_savings_estimate, COALESCE(token_count, 0), COALESCE(cost, 0.0), COALESCE(agent_id, ''), COALESCE(agent_version, ''), COALESCE(reason, ''), COALESCE(cpu_score, 0.0), COALESCE(entropy_score, 0.0), COALESCE(confidence_score, 0.0), COALESCE(recovery_status, ''), COALESCE(restart_count, 0), COALESCE(cost_breakdown, '') FROM incidents WHERE id = ?", id)
+	var breakdown string
+	err := row.Scan(&i.ID, &i.Timestamp, &i.Command, &i.ModelName, &i.ExitReason, &i.MaxCPU, &i.Pattern, &i.TokenSavingsEstimate, &i.TokenCount, &i.Cost, &i.AgentID, &i.AgentVersion, &i.Reason, &i.CPUScore, &i.EntropyScore, &i.ConfidenceScore, &i.RecoveryStatus, &i.RestartCount, &breakdown)
 
 	if err == nil {
 		i.Command = decryptIfPossible(i.Command)
 		i.Pattern = decryptIfPossible(i.Pattern)
+		i.CostBreakdown = decodeCostBreakdown(breakdown)
 	}
 	return i, err
 }
@@ -670,7 +862,7 @@ func GetIncidentsPage(limit int, cursorID int64) ([]Incident, int64, bool, error
 }
 
 func getAllIncidentsLegacy() ([]Incident, error) {
-	rows, err := db.Query("SELECT id, timestamp, command, COALESCE(model_name, 'unknown'), exit_reason, max_cpu, pattern, token_savings_estimate, COALESCE(token_count, 0), COALESCE(cost, 0.0), COALESCE(agent_id, ''), COALESCE(agent_version, ''), COALESCE(reason, ''), COALESCE(cpu_score, 0.0), COALESCE(entropy_score, 0.0), COALESCE(confidence_score, 0.0), COALESCE(recovery_status, ''), COALESCE(restart_count, 0) FROM incidents ORDER BY id DESC")
+	rows, err := db.Query("SELECT id, timestamp, command, COALESCE(model_name, 'unknown'), exit_reason, max_cpu, pattern, token_savings_estimate, COALESCE(token_count, 0), COALESCE(cost, 0.0), COALESCE(agent_id, ''), COALESCE(agent_version, ''), COALESCE(reason, ''), COALESCE(cpu_score, 0.0), COALESCE(entropy_score, 0.0), COALESCE(confidence_score, 0.0), COALESCE(recovery_status, ''), COALESCE(restart_count, 0), COALESCE(cost_breakdown, '') FROM incidents ORDER BY id DESC")
 	if err != nil {
 		return nil, err
 	}
@@ -679,18 +871,20 @@ func getAllIncidentsLegacy() ([]Incident, error) {
 	var list []Incident
 	for rows.Next() {
 		var i Incident
-		if err := rows.Scan(&i.ID, &i.Timestamp, &i.Command, &i.ModelName, &i.ExitReason, &i.MaxCPU, &i.Pattern, &i.TokenSavingsEstimate, &i.TokenCount, &i.Cost, &i.AgentID, &i.AgentVersion, &i.Reason, &i.CPUScore, &i.EntropyScore, &i.ConfidenceScore, &i.RecoveryStatus, &i.RestartCount); err != nil {
+		var breakdown string
+		if err := rows.Scan(&i.ID, &i.Timestamp, &i.Command, &i.ModelName, &i.ExitReason, &i.MaxCPU, &i.Pattern, &i.TokenSavingsEstimate, &i.TokenCount, &i.Cost, &i.AgentID, &i.AgentVersion, &i.Reason, &i.CPUScore, &i.EntropyScore, &i.ConfidenceScore, &i.RecoveryStatus, &i.RestartCount, &breakdown); err != nil {
 			return nil, err
 		}
 		i.Command = decryptIfPossible(i.Command)
 		i.Pattern = decryptIfPossible(i.Pattern)
+		i.CostBreakdown = decodeCostBreakdown(breakdown)
 		list = append(list, i)
 	}
 	return list, nil
 }
 
 func getLegacyIncidentsPage(limit int, cursorID int64) ([]Incident, int64, bool, error) {
-	query := "SELECT id, timestamp, command, COALESCE(model_name, 'unknown'), exit_reason, max_cpu, pattern, token_savings_estimate, COALESCE(token_count, 0), COALESCE(cost, 0.0), COALESCE(agent_id, ''), COALESCE(agent_version, ''), COALESCE(reason, ''), COALESCE(cpu_score, 0.0), COALESCE(entropy_score, 0.0), COALESCE(confidence_score, 0.0), COALESCE(recovery_status, ''), COALESCE(restart_count, 0) FROM incidents"
+	query := "SELECT id, timestamp, command, COALESCE(model_name, 'unknown'), exit_reason, max_cpu, pattern, token_savings_estimate, COALESCE(token_count, 0), COALESCE(cost, 0.0), COALESCE(agent_id, ''), COALESCE(agent_version, ''), COALESCE(reason, ''), COALESCE(cpu_score, 0.0), COALESCE(entropy_score, 0.0), COALESCE(confidence_score, 0.0), COALESCE(recovery_status, ''), COALESCE(restart_count, 0), COALESCE(cost_breakdown, '') FROM incidents"
 	args := []interface{}{}
 	if cursorID > 0 {
 		query += " WHERE id < ?"
@@ -708,11 +902,13 @@ func getLegacyIncidentsPage(limit int, cursorID int64) ([]Incident, int64, bool,
 	list := make([]Incident, 0, limit+1)
 	for rows.Next() {
 		var i Incident
-		if err := rows.Scan(&i.ID, &i.Timestamp, &i.Command, &i.ModelName, &i.ExitReason, &i.MaxCPU, &i.Pattern, &i.TokenSavingsEstimate, &i.TokenCount, &i.Cost, &i.AgentID, &i.AgentVersion, &i.Reason, &i.CPUScore, &i.EntropyScore, &i.ConfidenceScore, &i.RecoveryStatus, &i.RestartCount); err != nil {
+		var breakdown string
+		if err := rows.Scan(&i.ID, &i.Timestamp, &i.Command, &i.ModelName, &i.ExitReason, &i.MaxCPU, &i.Pattern, &i.TokenSavingsEstimate, &i.TokenCount, &i.Cost, &i.AgentID, &i.AgentVersion, &i.Reason, &i.CPUScore, &i.EntropyScore, &i.ConfidenceScore, &i.RecoveryStatus, &i.RestartCount, &breakdown); err != nil {
 			return nil, 0, false, err
 		}
 		i.Command = decryptIfPossible(i.Command)
 		i.Pattern = decryptIfPossible(i.Pattern)
+		i.CostBreakdown = decodeCostBreakdown(breakdown)
 		list = append(list, i)
 	}
 	if err := rows.Err(); err != nil {
@@ -856,6 +1052,7 @@ func LogDecisionTraceWithIncidentAndMeta(command string, pid int, cpuScore, entr
 		PolicyRolloutMode: strings.TrimSpace(meta.PolicyRolloutMode),
 		ReplayContract:    strings.TrimSpace(meta.ReplayContract),
 		ReplayDigest:      strings.TrimSpace(meta.ReplayDigest),
+		Telemetry:         meta.Telemetry,
 	}
 	return logUnifiedEventWithPayload("decision", decision, summary, reason, "system", incidentID, pid, cpuScore, entropyScore, confidenceScore, payload)
 }
@@ -1261,6 +1458,89 @@ FROM events`
 	return list, nextCursor, hasMore, nil
 }
 
+// GetUnifiedEventsAfter returns up to limit events with an id above afterID,
+// oldest first, for readers that follow the table forward.
+func GetUnifiedEventsAfter(afterID int64, limit int) ([]UnifiedEvent, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db missing")
+	}
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := db.Query(`
+SELECT
+	id,
+	COALESCE(event_id, ''),
+	COALESCE(run_id, ''),
+	COALESCE(incident_id, ''),
+	COALESCE(request_id, ''),
+	COALESCE(event_type, type, ''),
+	COALESCE(actor, 'system'),
+	COALESCE(reason_text, reason, ''),
+	COALESCE(created_at, timestamp, CURRENT_TIMESTAMP),
+	COALESCE(created_at, timestamp, CURRENT_TIMESTAMP),
+	COALESCE(event_type, type, ''),
+	COALESCE(title, ''),
+	COALESCE(summary, ''),
+	COALESCE(reason_text, reason, ''),
+	COALESCE(pid, 0),
+	COALESCE(cpu_score, 0.0),
+	COALESCE(entropy_score, 0.0),
+	COALESCE(confidence_score, 0.0),
+	COALESCE(payload_json, '{}')
+FROM events
+WHERE id > ?
+ORDER BY id ASC
+LIMIT ?`, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]UnifiedEvent, 0)
+	for rows.Next() {
+		var e UnifiedEvent
+		var payloadRaw string
+		if err := rows.Scan(
+			&e.ID,
+			&e.EventID,
+			&e.RunID,
+			&e.IncidentID,
+			&e.RequestID,
+			&e.EventType,
+			&e.Actor,
+			&e.ReasonText,
+			&e.CreatedAt,
+			&e.Timestamp,
+			&e.Type,
+			&e.Title,
+			&e.Summary,
+			&e.Reason,
+			&e.PID,
+			&e.CPUScore,
+			&e.Entropy,
+			&e.Confidence,
+			&payloadRaw,
+		); err != nil {
+			return nil, err
+		}
+		e.Evidence = parseEvidencePayload(payloadRaw)
+		hydrateDecisionMetadataFromEvidence(&e)
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
+
+// MaxEventRowID is the id of the newest event, or 0 when there are none.
+func MaxEventRowID() (int64, error) {
+	if db == nil {
+		return 0, fmt.Errorf("db missing")
+	}
+	var id int64
+	err := db.QueryRow("SELECT COALESCE(MAX(id), 0) FROM events").Scan(&id)
+	return id, err
+}
+
 func GetIncidentTimelineByIncidentID(incidentID string, limit int) ([]UnifiedEvent, error) {
 	if db == nil {
 		return nil, fmt.Errorf("db missing")
@@ -1493,7 +1773,7 @@ INSERT INTO events(
 		requestIDValue = requestID
 	}
 
-	_, err = stmt.Exec(
+	result, err := stmt.Exec(
 		eventID,
 		runID,
 		incidentIDValue,
@@ -1514,6 +1794,9 @@ INSERT INTO events(
 	if err != nil {
 		return "", err
 	}
+	if rowID, err := result.LastInsertId(); err == nil {
+		publishEvent(rowID)
+	}
 	return eventID, nil
 }
 
@@ -1650,6 +1933,7 @@ ORDER BY created_at DESC, id DESC`)
 			ConfidenceScore:      payload.ConfidenceScore,
 			RecoveryStatus:       payload.RecoveryStatus,
 			RestartCount:         payload.RestartCount,
+			CostBreakdown:        payload.CostBreakdown,
 		})
 	}
 	return incidents, nil
@@ -1721,6 +2005,7 @@ WHERE event_type = 'incident'`
 			ConfidenceScore:      payload.ConfidenceScore,
 			RecoveryStatus:       payload.RecoveryStatus,
 			RestartCount:         payload.RestartCount,
+			CostBreakdown:        payload.CostBreakdown,
 		})
 		cursorCandidates = append(cursorCandidates, rowID)
 	}
@@ -0,0 +1,105 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DecisionSample is a recorded decision event with the telemetry it was made
+// on, when that telemetry was captured.
+type DecisionSample struct {
+	EventID               string             `json:"event_id"`
+	TraceID               int                `json:"trace_id"`
+	RunID                 string             `json:"run_id"`
+	IncidentID            string             `json:"incident_id,omitempty"`
+	CreatedAt             string             `json:"created_at"`
+	Command               string             `json:"command"`
+	Decision              string             `json:"decision"`
+	Reason                string             `json:"reason"`
+	CPUScore              float64            `json:"cpu_score"`
+	EntropyScore          float64            `json:"entropy_score"`
+	ConfidenceScore       float64            `json:"confidence_score"`
+	DecisionEngine        string             `json:"decision_engine,omitempty"`
+	DecisionEngineVersion string             `json:"engine_version,omitempty"`
+	Telemetry             *DecisionTelemetry `json:"telemetry,omitempty"`
+}
+
+type decisionSamplePayload struct {
+	ID             int                `json:"id"`
+	Command        string             `json:"command"`
+	DecisionEngine string             `json:"decision_engine"`
+	EngineVersion  string             `json:"engine_version"`
+	Telemetry      *DecisionTelemetry `json:"telemetry"`
+}
+
+// GetDecisionSamples returns decision events oldest-first, optionally limited
+// to one run. limit applies to the most recent events.
+func GetDecisionSamples(limit int, runID string) ([]DecisionSample, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db missing")
+	}
+	if limit <= 0 {
+		limit = 500
+	}
+	runID = strings.TrimSpace(runID)
+
+	rows, err := db.Query(`
+SELECT
+	COALESCE(event_id, ''),
+	COALESCE(run_id, ''),
+	COALESCE(incident_id, ''),
+	COALESCE(created_at, timestamp, CURRENT_TIMESTAMP),
+	COALESCE(title, ''),
+	COALESCE(reason_text, reason, ''),
+	COALESCE(cpu_score, 0.0),
+	COALESCE(entropy_score, 0.0),
+	COALESCE(confidence_score, 0.0),
+	COALESCE(payload_json, '{}')
+FROM events
+WHERE COALESCE(event_type, type, '') = 'decision'
+	AND (? = '' OR run_id = ?)
+ORDER BY id DESC
+LIMIT ?`, runID, runID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []DecisionSample
+	for rows.Next() {
+		var s DecisionSample
+		var payloadRaw string
+		if err := rows.Scan(
+			&s.EventID,
+			&s.RunID,
+			&s.IncidentID,
+			&s.CreatedAt,
+			&s.Decision,
+			&s.Reason,
+			&s.CPUScore,
+			&s.EntropyScore,
+			&s.ConfidenceScore,
+			&payloadRaw,
+		); err != nil {
+			return nil, err
+		}
+		var payload decisionSamplePayload
+		if err := json.Unmarshal([]byte(payloadRaw), &payload); err == nil {
+			s.TraceID = payload.ID
+			s.Command = payload.Command
+			s.DecisionEngine = strings.TrimSpace(payload.DecisionEngine)
+			s.DecisionEngineVersion = strings.TrimSpace(payload.EngineVersion)
+			s.Telemetry = payload.Telemetry
+		}
+		list = append(list, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+		list[i], list[j] = list[j], list[i]
+	}
+	return list, nil
+}
@@ -0,0 +1,61 @@
+package database
+
+import "testing"
+
+func TestGetDecisionSamplesIncludesTelemetryAndFiltersRun(t *testing.T) {
+	_ = withTempDBPath(t)
+	CloseDB()
+	if err := InitDB(); err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+
+	SetRunID("run-backtest-a")
+	meta := DecisionTraceMeta{
+		DecisionEngine: "threshold-decider",
+		EngineVersion:  "1.1.0",
+		Telemetry: &DecisionTelemetry{
+			CPUPercent:        97,
+			CPUOverForSeconds: 42,
+			LogRepetition:     0.9,
+			LogEntropy:        0.1,
+			MaxCPUPercent:     60,
+		},
+	}
+	if err := LogDecisionTraceWithMeta("python3 a.py", 101, 100, 10, 90, "KILL", "looping", meta); err != nil {
+		t.Fatalf("LogDecisionTraceWithMeta: %v", err)
+	}
+	SetRunID("run-backtest-b")
+	if err := LogDecisionTrace("python3 b.py", 202, 20, 90, 10, "CONTINUE", "ok"); err != nil {
+		t.Fatalf("LogDecisionTrace: %v", err)
+	}
+
+	all, err := GetDecisionSamples(10, "")
+	if err != nil {
+		t.Fatalf("GetDecisionSamples: %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("expected 2 samples, got %d", len(all))
+	}
+	if all[0].RunID != "run-backtest-a" || all[1].RunID != "run-backtest-b" {
+		t.Fatalf("expected oldest-first ordering, got %q then %q", all[0].RunID, all[1].RunID)
+	}
+
+	first := all[0]
+	if first.Decision != "KILL" || first.TraceID <= 0 || first.Command != "python3 a.py" {
+		t.Fatalf("unexpected sample %+v", first)
+	}
+	if first.Telemetry == nil || first.Telemetry.CPUOverForSeconds != 42 || first.Telemetry.MaxCPUPercent != 60 {
+		t.Fatalf("expected telemetry to round-trip, got %+v", first.Telemetry)
+	}
+	if all[1].Telemetry != nil {
+		t.Fatalf("expected no telemetry for legacy-style trace, got %+v", all[1].Telemetry)
+	}
+
+	filtered, err := GetDecisionSamples(10, "run-backtest-b")
+	if err != nil {
+		t.Fatalf("GetDecisionSamples(run): %v", err)
+	}
+	if len(filtered) != 1 || filtered[0].Decision != "CONTINUE" {
+		t.Fatalf("expected single CONTINUE sample for run-backtest-b, got %+v", filtered)
+	}
+}
@@ -0,0 +1,80 @@
+package database
+
+import "sync"
+
+// EventSubscription receives every event this process inserts, in insert
+// order. Events written by other processes sharing the database are not
+// published; readers that need them follow the events table by id.
+type EventSubscription struct {
+	C <-chan UnifiedEvent
+
+	c      chan UnifiedEvent
+	mu     sync.Mutex
+	lagged bool
+}
+
+// Lagged reports whether events were dropped because C was full since the
+// last call, and clears the flag.
+func (s *EventSubscription) Lagged() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lagged := s.lagged
+	s.lagged = false
+	return lagged
+}
+
+// Close stops delivery to s. C is not closed.
+func (s *EventSubscription) Close() {
+	eventBus.mu.Lock()
+	defer eventBus.mu.Unlock()
+	delete(eventBus.subs, s)
+}
+
+var eventBus = struct {
+	mu   sync.RWMutex
+	subs map[*EventSubscription]struct{}
+}{subs: map[*EventSubscription]struct{}{}}
+
+// SubscribeEvents registers a subscriber whose channel holds up to buffer
+// undelivered events. A subscriber that falls behind loses events rather
+// than slowing inserts; Lagged tells it so.
+func SubscribeEvents(buffer int) *EventSubscription {
+	if buffer < 1 {
+		buffer = 1
+	}
+	c := make(chan UnifiedEvent, buffer)
+	s := &EventSubscription{C: c, c: c}
+	eventBus.mu.Lock()
+	eventBus.subs[s] = struct{}{}
+	eventBus.mu.Unlock()
+	return s
+}
+
+// publishEvent sends the stored form of the event at rowID to every
+// subscriber. It reads the row back so subscribers see exactly what the
+// timeline APIs return, and skips the read when nobody is listening.
+func publishEvent(rowID int64) {
+	eventBus.mu.RLock()
+	listening := len(eventBus.subs) > 0
+	eventBus.mu.RUnlock()
+	if !listening || rowID <= 0 {
+		return
+	}
+	events, err := GetUnifiedEventsAfter(rowID-1, 1)
+	if err != nil || len(events) == 0 || int64(events[0].ID) != rowID {
+		return
+	}
+	e := events[0]
+
+	eventBus.mu.RLock()
+	defer eventBus.mu.RUnlock()
+	for s := range eventBus.subs {
+		select {
+		case s.c <- e:
+		default:
+			s.mu.Lock()
+			s.lagged = true
+			s.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInsertedEventsArePublishedToSubscribers(t *testing.T) {
+	_ = withTempDBPath(t)
+	CloseDB()
+	if err := InitDB(); err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+
+	sub := SubscribeEvents(1)
+	defer sub.Close()
+
+	eventID, err := InsertEventWithPayloadAndRequestID("incident", "system", "cpu pinned", "run-1", "inc-1", "LOOP_DETECTED", "", 7, 95, 10, 90, "req-1", map[string]interface{}{"pattern": "retry"})
+	if err != nil {
+		t.Fatalf("InsertEventWithPayloadAndRequestID: %v", err)
+	}
+	select {
+	case e := <-sub.C:
+		if e.EventID != eventID || e.ID <= 0 || e.RunID != "run-1" || e.IncidentID != "inc-1" || e.RequestID != "req-1" || e.Evidence["pattern"] != "retry" {
+			t.Fatalf("unexpected published event %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the inserted event to be published")
+	}
+	if sub.Lagged() {
+		t.Fatal("expected no lag after one event")
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := InsertEvent("lifecycle", "system", "", "run-1", "", "LIFECYCLE_RUNNING", "", 0, 0, 0, 0); err != nil {
+			t.Fatalf("InsertEvent: %v", err)
+		}
+	}
+	if !sub.Lagged() {
+		t.Fatal("expected a full subscriber to be marked lagged")
+	}
+	if sub.Lagged() {
+		t.Fatal("expected Lagged to clear the flag")
+	}
+
+	sub.Close()
+	<-sub.C
+	if _, err := InsertEvent("lifecycle", "system", "", "run-1", "", "LIFECYCLE_STOPPED", "", 0, 0, 0, 0); err != nil {
+		t.Fatalf("InsertEvent: %v", err)
+	}
+	select {
+	case e := <-sub.C:
+		t.Fatalf("expected no events after Close, got %+v", e)
+	default:
+	}
+}
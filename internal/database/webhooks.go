@@ -0,0 +1,243 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Webhook delivery states. Pending rows are retried until they are
+// delivered or run out of attempts and become failed.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// webhookTimeLayout keeps milliseconds so retry times sort correctly as
+// text; they are always written from Go, never by CURRENT_TIMESTAMP.
+const webhookTimeLayout = "2006-01-02 15:04:05.000"
+
+// WebhookDelivery is one event queued for one webhook URL. Body is the
+// exact JSON sent on every attempt, so its signature is stable.
+type WebhookDelivery struct {
+	ID             int64  `json:"id"`
+	EventID        string `json:"event_id"`
+	EventRowID     int64  `json:"event_row_id"`
+	Kind           string `json:"kind"`
+	URL            string `json:"url"`
+	Body           string `json:"-"`
+	Status         string `json:"status"`
+	Attempts       int    `json:"attempts"`
+	NextAttemptAt  string `json:"next_attempt_at,omitempty"`
+	LastStatusCode int    `json:"last_status_code,omitempty"`
+	LastError      string `json:"last_error,omitempty"`
+	CreatedAt      string `json:"created_at"`
+	DeliveredAt    string `json:"delivered_at,omitempty"`
+}
+
+// GetWebhookCursor returns the last events.id the webhook scanner has
+// handled; ok is false before the first scan.
+func GetWebhookCursor() (int64, bool, error) {
+	if db == nil {
+		return 0, false, fmt.Errorf("db not initialized")
+	}
+	var id int64
+	err := db.QueryRow("SELECT last_event_row_id FROM webhook_cursor WHERE id = 1").Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return id, true, nil
+}
+
+// AdvanceWebhookCursor moves the cursor forward to eventRowID. It never
+// moves it back, so concurrent scanners cannot replay each other's events.
+func AdvanceWebhookCursor(eventRowID int64) error {
+	if db == nil {
+		return fmt.Errorf("db not initialized")
+	}
+	_, err := db.Exec(`
+INSERT INTO webhook_cursor(id, last_event_row_id) VALUES(1, ?)
+ON CONFLICT(id) DO UPDATE SET
+	last_event_row_id = MAX(last_event_row_id, excluded.last_event_row_id)
+`, eventRowID)
+	return err
+}
+
+// EnqueueWebhookDelivery adds a pending delivery due now. It reports false
+// when the event is already queued for that URL.
+func EnqueueWebhookDelivery(d WebhookDelivery, now time.Time) (bool, error) {
+	if db == nil {
+		return false, fmt.Errorf("db not initialized")
+	}
+	if strings.TrimSpace(d.EventID) == "" || strings.TrimSpace(d.URL) == "" {
+		return false, fmt.Errorf("event_id and url are required")
+	}
+	res, err := db.Exec(`
+INSERT INTO webhook_deliveries(event_id, event_row_id, kind, url, body, status, next_attempt_at)
+VALUES(?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(event_id, url) DO NOTHING
+`, d.EventID, d.EventRowID, d.Kind, d.URL, d.Body, WebhookDeliveryPending, now.UTC().Format(webhookTimeLayout))
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ClaimDueWebhookDeliveries returns up to limit pending deliveries due at
+// now and pushes each one's next attempt out by lease. A delivery claimed
+// by another process, or whose sender dies mid-attempt, is picked up again
+// once its lease runs out.
+func ClaimDueWebhookDeliveries(now time.Time, lease time.Duration, limit int) ([]WebhookDelivery, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db not initialized")
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	rows, err := db.Query(webhookDeliverySelect+`
+WHERE status = ? AND next_attempt_at <= ?
+ORDER BY next_attempt_at ASC, id ASC
+LIMIT ?`, WebhookDeliveryPending, now.UTC().Format(webhookTimeLayout), limit)
+	if err != nil {
+		return nil, err
+	}
+	due := []WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		due = append(due, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	leaseUntil := now.Add(lease).UTC().Format(webhookTimeLayout)
+	claimed := make([]WebhookDelivery, 0, len(due))
+	for _, d := range due {
+		res, err := db.Exec(`
+UPDATE webhook_deliveries SET next_attempt_at = ?
+WHERE id = ? AND status = ? AND next_attempt_at = ?
+`, leaseUntil, d.ID, WebhookDeliveryPending, d.NextAttemptAt)
+		if err != nil {
+			return nil, err
+		}
+		if n, _ := res.RowsAffected(); n == 1 {
+			claimed = append(claimed, d)
+		}
+	}
+	return claimed, nil
+}
+
+// MarkWebhookDelivered records a successful attempt.
+func MarkWebhookDelivered(id int64, statusCode int, now time.Time) error {
+	if db == nil {
+		return fmt.Errorf("db not initialized")
+	}
+	_, err := db.Exec(`
+UPDATE webhook_deliveries
+SET status = ?, attempts = attempts + 1, last_status_code = ?, last_error = '', delivered_at = ?
+WHERE id = ?
+`, WebhookDeliveryDelivered, statusCode, now.UTC().Format(sqliteTimeLayout), id)
+	return err
+}
+
+// MarkWebhookAttemptFailed records a failed attempt. The delivery is retried
+// at nextAttemptAt, or marked failed for good when giveUp is set.
+func MarkWebhookAttemptFailed(id int64, statusCode int, errText string, nextAttemptAt time.Time, giveUp bool) error {
+	if db == nil {
+		return fmt.Errorf("db not initialized")
+	}
+	status := WebhookDeliveryPending
+	if giveUp {
+		status = WebhookDeliveryFailed
+	}
+	_, err := db.Exec(`
+UPDATE webhook_deliveries
+SET status = ?, attempts = attempts + 1, last_status_code = ?, last_error = ?, next_attempt_at = ?
+WHERE id = ?
+`, status, statusCode, errText, nextAttemptAt.UTC().Format(webhookTimeLayout), id)
+	return err
+}
+
+// ListWebhookDeliveries returns the newest deliveries first, optionally only
+// those in one status.
+func ListWebhookDeliveries(status string, limit int) ([]WebhookDelivery, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db not initialized")
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+	query := webhookDeliverySelect
+	args := []interface{}{}
+	if status = strings.TrimSpace(status); status != "" {
+		query += "\nWHERE status = ?"
+		args = append(args, status)
+	}
+	query += "\nORDER BY id DESC\nLIMIT ?"
+	args = append(args, limit)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// CountWebhookDeliveries returns the number of deliveries in each status.
+func CountWebhookDeliveries() (map[string]int, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db not initialized")
+	}
+	counts := map[string]int{
+		WebhookDeliveryPending:   0,
+		WebhookDeliveryDelivered: 0,
+		WebhookDeliveryFailed:    0,
+	}
+	rows, err := db.Query("SELECT status, COUNT(*) FROM webhook_deliveries GROUP BY status")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		counts[status] = n
+	}
+	return counts, rows.Err()
+}
+
+const webhookDeliverySelect = `
+SELECT id, event_id, event_row_id, kind, url, body, status, attempts, next_attempt_at,
+	last_status_code, last_error, COALESCE(created_at, ''), COALESCE(delivered_at, '')
+FROM webhook_deliveries`
+
+func scanWebhookDelivery(row interface{ Scan(...interface{}) error }) (WebhookDelivery, error) {
+	var d WebhookDelivery
+	err := row.Scan(&d.ID, &d.EventID, &d.EventRowID, &d.Kind, &d.URL, &d.Body, &d.Status, &d.Attempts,
+		&d.NextAttemptAt, &d.LastStatusCode, &d.LastError, &d.CreatedAt, &d.DeliveredAt)
+	return d, err
+}
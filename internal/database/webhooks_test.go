@@ -0,0 +1,81 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWebhookOutboxClaimsRetriesAndGivesUp(t *testing.T) {
+	_ = withTempDBPath(t)
+	CloseDB()
+	if err := InitDB(); err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	delivery := WebhookDelivery{EventID: "evt-1", EventRowID: 7, Kind: "incident", URL: "http://127.0.0.1:9/hook", Body: `{"kind":"incident"}`}
+	if added, err := EnqueueWebhookDelivery(delivery, now); err != nil || !added {
+		t.Fatalf("EnqueueWebhookDelivery: added=%v err=%v", added, err)
+	}
+	if added, err := EnqueueWebhookDelivery(delivery, now); err != nil || added {
+		t.Fatalf("expected the same event and URL to be queued once, added=%v err=%v", added, err)
+	}
+
+	claimed, err := ClaimDueWebhookDeliveries(now, time.Minute, 10)
+	if err != nil || len(claimed) != 1 || claimed[0].Body != delivery.Body {
+		t.Fatalf("expected one claimed delivery, got %+v err=%v", claimed, err)
+	}
+	if again, err := ClaimDueWebhookDeliveries(now.Add(30*time.Second), time.Minute, 10); err != nil || len(again) != 0 {
+		t.Fatalf("expected a leased delivery not to be claimed twice, got %+v err=%v", again, err)
+	}
+	if afterLease, err := ClaimDueWebhookDeliveries(now.Add(2*time.Minute), time.Minute, 10); err != nil || len(afterLease) != 1 {
+		t.Fatalf("expected the delivery to be claimable once its lease ran out, got %+v err=%v", afterLease, err)
+	}
+
+	id := claimed[0].ID
+	if err := MarkWebhookAttemptFailed(id, 500, "receiver answered 500", now.Add(10*time.Minute), false); err != nil {
+		t.Fatalf("MarkWebhookAttemptFailed: %v", err)
+	}
+	if due, err := ClaimDueWebhookDeliveries(now.Add(5*time.Minute), time.Minute, 10); err != nil || len(due) != 0 {
+		t.Fatalf("expected no delivery before its retry time, got %+v err=%v", due, err)
+	}
+	if err := MarkWebhookAttemptFailed(id, 0, "connection refused", now.Add(20*time.Minute), true); err != nil {
+		t.Fatalf("MarkWebhookAttemptFailed giveUp: %v", err)
+	}
+	if due, err := ClaimDueWebhookDeliveries(now.Add(time.Hour), time.Minute, 10); err != nil || len(due) != 0 {
+		t.Fatalf("expected a failed delivery never to be retried, got %+v err=%v", due, err)
+	}
+
+	failed, err := ListWebhookDeliveries(WebhookDeliveryFailed, 10)
+	if err != nil || len(failed) != 1 {
+		t.Fatalf("ListWebhookDeliveries(failed): %+v err=%v", failed, err)
+	}
+	if failed[0].Attempts != 2 || failed[0].LastError != "connection refused" || failed[0].LastStatusCode != 0 {
+		t.Fatalf("unexpected failed delivery %+v", failed[0])
+	}
+	counts, err := CountWebhookDeliveries()
+	if err != nil || counts[WebhookDeliveryFailed] != 1 || counts[WebhookDeliveryPending] != 0 {
+		t.Fatalf("unexpected counts %v err=%v", counts, err)
+	}
+}
+
+func TestWebhookCursorOnlyMovesForward(t *testing.T) {
+	_ = withTempDBPath(t)
+	CloseDB()
+	if err := InitDB(); err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+
+	if _, ok, err := GetWebhookCursor(); err != nil || ok {
+		t.Fatalf("expected no cursor before the first scan, ok=%v err=%v", ok, err)
+	}
+	if err := AdvanceWebhookCursor(12); err != nil {
+		t.Fatalf("AdvanceWebhookCursor: %v", err)
+	}
+	if err := AdvanceWebhookCursor(5); err != nil {
+		t.Fatalf("AdvanceWebhookCursor back: %v", err)
+	}
+	if cursor, ok, err := GetWebhookCursor(); err != nil || !ok || cursor != 12 {
+		t.Fatalf("expected cursor 12, got %d ok=%v err=%v", cursor, ok, err)
+	}
+}
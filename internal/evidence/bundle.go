@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"flowforge/internal/database"
+	"flowforge/internal/runlog"
 )
 
 const (
@@ -29,6 +30,9 @@ type ExportOptions struct {
 	AuditLimit    int
 	DecisionLimit int
 	ChainLimit    int
+	// OutputKB, when set with IncidentID, adds the last OutputKB kilobytes of
+	// the incident run's captured output up to the incident.
+	OutputKB int
 }
 
 type BundleFile struct {
@@ -74,8 +78,20 @@ type bundleSummary struct {
 	DecisionCount      int    `json:"decision_count"`
 	IncidentChainCount int    `json:"incident_chain_count,omitempty"`
 	SelectedIncidentID string `json:"selected_incident_id,omitempty"`
+	OutputLineCount    int    `json:"output_line_count,omitempty"`
 }
 
+// runOutput is the captured stdout/stderr excerpt written to run_output.json.
+type runOutput struct {
+	RunID    string        `json:"run_id"`
+	Until    time.Time     `json:"until"`
+	MaxBytes int           `json:"max_bytes"`
+	Lines    []runlog.Line `json:"lines"`
+}
+
+// outputGrace keeps the lines printed while the incident was being handled.
+const outputGrace = 5 * time.Second
+
 func Export(opts ExportOptions, signingKey []byte) (ExportResult, error) {
 	if len(signingKey) == 0 {
 		return ExportResult{}, errors.New("signing key is required")
@@ -125,6 +141,14 @@ func Export(opts ExportOptions, signingKey []byte) (ExportResult, error) {
 		}
 	}
 
+	var output *runOutput
+	if opts.OutputKB > 0 && len(chain) > 0 {
+		output, err = incidentOutput(chain, opts.OutputKB*1024)
+		if err != nil {
+			return ExportResult{}, fmt.Errorf("load run output: %w", err)
+		}
+	}
+
 	files := make([]BundleFile, 0, 8)
 	record := func(name string, v any) error {
 		f, err := writeJSONPayload(opts.OutDir, name, v)
@@ -159,6 +183,9 @@ func Export(opts ExportOptions, signingKey []byte) (ExportResult, error) {
 		IncidentChainCount: len(chain),
 		SelectedIncidentID: strings.TrimSpace(opts.IncidentID),
 	}
+	if output != nil {
+		summary.OutputLineCount = len(output.Lines)
+	}
 	if err := record("summary.json", summary); err != nil {
 		return ExportResult{}, err
 	}
@@ -167,6 +194,11 @@ func Export(opts ExportOptions, signingKey []byte) (ExportResult, error) {
 			return ExportResult{}, err
 		}
 	}
+	if output != nil {
+		if err := record("run_output.json", output); err != nil {
+			return ExportResult{}, err
+		}
+	}
 
 	sort.Slice(files, func(i, j int) bool {
 		return files[i].Path < files[j].Path
@@ -215,6 +247,45 @@ func Export(opts ExportOptions, signingKey []byte) (ExportResult, error) {
 	}, nil
 }
 
+// incidentOutput reads the captured output of the run that raised the
+// incident, up to shortly after its last event. It returns nil when the run
+// has no capture.
+func incidentOutput(chain []database.UnifiedEvent, maxBytes int) (*runOutput, error) {
+	var runID string
+	var until time.Time
+	for _, ev := range chain {
+		if ev.RunID != "" && ev.RunID != "unknown-run" {
+			runID = ev.RunID
+		}
+		if ts, ok := parseEventTime(ev.CreatedAt); ok && ts.After(until) {
+			until = ts
+		}
+	}
+	if runID == "" {
+		return nil, nil
+	}
+	if !until.IsZero() {
+		until = until.Add(outputGrace)
+	}
+	lines, err := runlog.Read(runID, runlog.ReadOptions{Until: until, MaxBytes: maxBytes})
+	if errors.Is(err, runlog.ErrNotFound) || errors.Is(err, runlog.ErrInvalidRunID) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &runOutput{RunID: runID, Until: until, MaxBytes: maxBytes, Lines: lines}, nil
+}
+
+func parseEventTime(raw string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05"} {
+		if ts, err := time.Parse(layout, raw); err == nil {
+			return ts, true
+		}
+	}
+	return time.Time{}, false
+}
+
 func Verify(bundleDir string, signingKey []byte) (VerifyResult, error) {
 	if bundleDir == "" {
 		return VerifyResult{}, errors.New("bundle directory is required")
@@ -1,12 +1,15 @@
 package evidence
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
 	"flowforge/internal/database"
+	"flowforge/internal/runlog"
 )
 
 func setupEvidenceTestDB(t *testing.T) string {
@@ -105,6 +108,49 @@ func TestExportAndVerifyBundle(t *testing.T) {
 	}
 }
 
+func TestExportIncludesIncidentRunOutput(t *testing.T) {
+	setupEvidenceTestDB(t)
+	t.Setenv("FLOWFORGE_DAEMON_DIR", t.TempDir())
+	capture, err := runlog.Open("run-evidence-test", runlog.Options{})
+	if err != nil {
+		t.Fatalf("open capture: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		if err := capture.WriteLine(runlog.StreamStdout, fmt.Sprintf("%03d retrying the same request", i)); err != nil {
+			t.Fatalf("write capture: %v", err)
+		}
+	}
+	_ = capture.Close()
+	seedEvidenceData(t)
+
+	outDir := filepath.Join(t.TempDir(), "bundle")
+	key := []byte("0123456789abcdef0123456789abcdef")
+	if _, err := Export(ExportOptions{OutDir: outDir, IncidentID: "incident-evidence-1", OutputKB: 1}, key); err != nil {
+		t.Fatalf("export bundle: %v", err)
+	}
+	raw, err := os.ReadFile(filepath.Join(outDir, "run_output.json"))
+	if err != nil {
+		t.Fatalf("read run_output.json: %v", err)
+	}
+	var out runOutput
+	if err := json.Unmarshal(raw, &out); err != nil {
+		t.Fatalf("decode run_output.json: %v", err)
+	}
+	if out.RunID != "run-evidence-test" || len(out.Lines) == 0 || len(out.Lines) >= 100 {
+		t.Fatalf("unexpected output excerpt: run=%q lines=%d", out.RunID, len(out.Lines))
+	}
+	total := 0
+	for _, l := range out.Lines {
+		total += len(l.Text) + 1
+	}
+	if total > 1024 || out.Lines[len(out.Lines)-1].Text != "099 retrying the same request" {
+		t.Fatalf("expected the newest 1KB of output, got %d bytes ending %q", total, out.Lines[len(out.Lines)-1].Text)
+	}
+	if _, err := Verify(outDir, key); err != nil {
+		t.Fatalf("verify bundle: %v", err)
+	}
+}
+
 func TestVerifyDetectsTampering(t *testing.T) {
 	setupEvidenceTestDB(t)
 	seedEvidenceData(t)
@@ -17,6 +17,11 @@ type FeedbackData struct {
 	MaxCPU     float64
 	ModelName  string
 	Savings    float64
+
+	// ToolCall is the repeated tool call signature when an agent was stopped
+	// for calling the same tool with the same arguments.
+	ToolCall      string
+	ToolCallCount int
 }
 
 // GenerateFeedback creates an agent_feedback.txt file containing
@@ -40,6 +45,13 @@ func GenerateFeedback(data FeedbackData) error {
 	sb.WriteString("Your process's output contained this normalized repeating pattern:\n\n")
 	sb.WriteString(fmt.Sprintf("```\n%s\n```\n\n", data.Pattern))
 
+	if data.ToolCall != "" {
+		sb.WriteString("### The Repeated Tool Call\n\n")
+		sb.WriteString(fmt.Sprintf("The same tool call was made %d times with the same arguments:\n\n", data.ToolCallCount))
+		sb.WriteString(fmt.Sprintf("```\n%s\n```\n\n", data.ToolCall))
+		sb.WriteString("Calling it again will return the same result. Use the result you already have, change the arguments, or try a different tool.\n\n")
+	}
+
 	sb.WriteString("### Suggestion for Next Run\n\n")
 	sb.WriteString("1. **Check for infinite loops** — Review any `while True` or recursive calls in your code.\n")
 	sb.WriteString("2. **Add exit conditions** — Ensure your loops have proper termination criteria.\n")
@@ -0,0 +1,486 @@
+// Package llmproxy is a local reverse proxy for OpenAI-compatible APIs. A
+// supervised agent is pointed at it through OPENAI_BASE_URL so FlowForge sees
+// the prompt and completion usage the agent actually pays for, instead of
+// estimating tokens from its stdout.
+package llmproxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"flowforge/internal/redact"
+)
+
+const (
+	// DefaultUpstream is used when no upstream is configured.
+	DefaultUpstream = "https://api.openai.com/v1"
+	// DefaultRepeatWindow is how many recent prompts are compared for
+	// repetition.
+	DefaultRepeatWindow = 20
+
+	maxRequestBody = 32 << 20
+	maxPreview     = 80
+)
+
+// Record is one proxied request.
+type Record struct {
+	Time             time.Time `json:"ts"`
+	Method           string    `json:"method"`
+	Path             string    `json:"path"`
+	Model            string    `json:"model,omitempty"`
+	Stream           bool      `json:"stream,omitempty"`
+	Status           int       `json:"status"`
+	PromptTokens     int64     `json:"prompt_tokens"`
+	CompletionTokens int64     `json:"completion_tokens"`
+	UsageReported    bool      `json:"usage_reported"`
+	LatencyMS        int64     `json:"latency_ms"`
+	PromptHash       string    `json:"prompt_hash,omitempty"`
+	PromptRepeats    int       `json:"prompt_repeats,omitempty"` // occurrences of this prompt among the recent ones
+	Error            string    `json:"error,omitempty"`
+}
+
+// Usage is the running total over all proxied requests.
+type Usage struct {
+	Requests         int64  `json:"requests"`
+	Errors           int64  `json:"errors"`
+	PromptTokens     int64  `json:"prompt_tokens"`
+	CompletionTokens int64  `json:"completion_tokens"`
+	Model            string `json:"model,omitempty"` // model of the latest response
+}
+
+func (u Usage) TotalTokens() int64 { return u.PromptTokens + u.CompletionTokens }
+
+// Options configures a Proxy.
+type Options struct {
+	// Upstream is the base URL requests are forwarded to, including any
+	// path prefix such as /v1. Default DefaultUpstream.
+	Upstream string
+	// Addr is the listen address. Default 127.0.0.1:0.
+	Addr string
+	// RepeatWindow is how many recent prompts are compared. Default
+	// DefaultRepeatWindow.
+	RepeatWindow int
+	// OnRecord, when set, is called after every request completes. It runs on
+	// the request goroutine.
+	OnRecord func(Record)
+	// Client sends upstream requests. Default has no overall timeout, since
+	// completions can stream for minutes.
+	Client *http.Client
+}
+
+// Proxy forwards OpenAI-compatible requests and meters their usage.
+type Proxy struct {
+	upstream *url.URL
+	onRecord func(Record)
+	client   *http.Client
+	listener net.Listener
+	server   *http.Server
+
+	mu      sync.Mutex
+	usage   Usage
+	prompts *promptWindow
+}
+
+// New builds a Proxy without listening; it can be mounted as an http.Handler.
+func New(opts Options) (*Proxy, error) {
+	raw := strings.TrimSpace(opts.Upstream)
+	if raw == "" {
+		raw = DefaultUpstream
+	}
+	upstream, err := url.Parse(raw)
+	if err != nil || (upstream.Scheme != "http" && upstream.Scheme != "https") || upstream.Host == "" {
+		return nil, fmt.Errorf("llmproxy: invalid upstream %q (want http(s)://host[/path])", raw)
+	}
+	upstream.Path = strings.TrimRight(upstream.Path, "/")
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{}
+	}
+	window := opts.RepeatWindow
+	if window <= 0 {
+		window = DefaultRepeatWindow
+	}
+	return &Proxy{upstream: upstream, onRecord: opts.OnRecord, client: client, prompts: newPromptWindow(window)}, nil
+}
+
+// Start builds a Proxy and serves it on opts.Addr.
+func Start(opts Options) (*Proxy, error) {
+	p, err := New(opts)
+	if err != nil {
+		return nil, err
+	}
+	addr := opts.Addr
+	if addr == "" {
+		addr = "127.0.0.1:0"
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("llmproxy: listen %s: %w", addr, err)
+	}
+	p.listener = ln
+	p.server = &http.Server{Handler: p, ReadHeaderTimeout: 10 * time.Second}
+	go func() { _ = p.server.Serve(ln) }()
+	return p, nil
+}
+
+// BaseURL is the value to export as OPENAI_BASE_URL. It is empty for a Proxy
+// that is not listening.
+func (p *Proxy) BaseURL() string {
+	if p.listener == nil {
+		return ""
+	}
+	return "http://" + p.listener.Addr().String() + "/v1"
+}
+
+// Upstream returns the configured upstream base URL.
+func (p *Proxy) Upstream() string { return p.upstream.String() }
+
+// Close stops a listening Proxy, waiting briefly for in-flight requests.
+func (p *Proxy) Close() error {
+	if p.server == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	return p.server.Shutdown(ctx)
+}
+
+// Usage returns the totals so far.
+func (p *Proxy) Usage() Usage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.usage
+}
+
+// RepeatedPrompt returns the most frequent prompt among the recent requests
+// as "hash: preview", and how many times it was sent.
+func (p *Proxy) RepeatedPrompt() (signature string, count int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	hash, count := p.prompts.top()
+	if hash == "" {
+		return "", 0
+	}
+	if preview := p.prompts.previews[hash]; preview != "" {
+		return hash + ": " + preview, count
+	}
+	return hash, count
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	rec := Record{Time: start.UTC(), Method: r.Method, Path: r.URL.Path}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBody+1))
+	if err != nil {
+		p.fail(w, &rec, start, http.StatusBadRequest, "read request: "+err.Error())
+		return
+	}
+	if len(body) > maxRequestBody {
+		p.fail(w, &rec, start, http.StatusRequestEntityTooLarge, "request body too large")
+		return
+	}
+	if r.Method == http.MethodPost && len(body) > 0 {
+		info := inspectRequest(body, r.URL.Path)
+		body = info.body
+		rec.Model, rec.Stream, rec.PromptHash = info.model, info.stream, info.promptHash
+		if info.promptHash != "" {
+			p.mu.Lock()
+			rec.PromptRepeats = p.prompts.add(info.promptHash, info.preview)
+			p.mu.Unlock()
+		}
+	}
+
+	target := *p.upstream
+	target.Path = p.upstream.Path + strings.TrimPrefix(r.URL.Path, "/v1")
+	target.RawQuery = r.URL.RawQuery
+	out, err := http.NewRequestWithContext(r.Context(), r.Method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		p.fail(w, &rec, start, http.StatusBadGateway, err.Error())
+		return
+	}
+	copyHeaders(out.Header, r.Header)
+	// Let the transport negotiate compression so usage can be read.
+	out.Header.Del("Accept-Encoding")
+	out.Header.Del("Content-Length")
+	out.ContentLength = int64(len(body))
+
+	resp, err := p.client.Do(out)
+	if err != nil {
+		p.fail(w, &rec, start, http.StatusBadGateway, "upstream: "+err.Error())
+		return
+	}
+	defer resp.Body.Close()
+
+	copyHeaders(w.Header(), resp.Header)
+	w.WriteHeader(resp.StatusCode)
+	rec.Status = resp.StatusCode
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		relayStream(w, resp.Body, &rec)
+	} else {
+		data, _ := io.ReadAll(resp.Body)
+		_, _ = w.Write(data)
+		readUsage(data, &rec)
+	}
+	p.finish(&rec, start)
+}
+
+func (p *Proxy) fail(w http.ResponseWriter, rec *Record, start time.Time, status int, msg string) {
+	rec.Status = status
+	rec.Error = msg
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]string{"message": "flowforge llm proxy: " + msg, "type": "flowforge_proxy_error"},
+	})
+	p.finish(rec, start)
+}
+
+func (p *Proxy) finish(rec *Record, start time.Time) {
+	rec.LatencyMS = time.Since(start).Milliseconds()
+	p.mu.Lock()
+	p.usage.Requests++
+	if rec.Error != "" || rec.Status >= 400 {
+		p.usage.Errors++
+	}
+	p.usage.PromptTokens += rec.PromptTokens
+	p.usage.CompletionTokens += rec.CompletionTokens
+	if rec.Model != "" {
+		p.usage.Model = rec.Model
+	}
+	p.mu.Unlock()
+	if p.onRecord != nil {
+		p.onRecord(*rec)
+	}
+}
+
+type requestInfo struct {
+	body       []byte
+	model      string
+	stream     bool
+	promptHash string
+	preview    string
+}
+
+// inspectRequest reads the model and prompt from a JSON request body. For
+// streamed chat completions it asks the upstream to include usage in the
+// final chunk, which OpenAI only sends on request.
+func inspectRequest(body []byte, path string) requestInfo {
+	info := requestInfo{body: body}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber() // keep large integers such as seeds intact if re-encoded
+	var req map[string]any
+	if err := dec.Decode(&req); err != nil {
+		return info
+	}
+	info.model, _ = req["model"].(string)
+	info.stream, _ = req["stream"].(bool)
+
+	var prompt any
+	for _, key := range []string{"messages", "prompt", "input"} {
+		if v, ok := req[key]; ok {
+			prompt = v
+			break
+		}
+	}
+	if prompt != nil {
+		canonical, _ := json.Marshal(prompt)
+		sum := sha256.Sum256(append([]byte(info.model+"\x00"), canonical...))
+		info.promptHash = hex.EncodeToString(sum[:6])
+		info.preview = promptPreview(prompt)
+	}
+
+	if info.stream && strings.HasSuffix(path, "/chat/completions") {
+		if _, ok := req["stream_options"]; !ok {
+			req["stream_options"] = map[string]any{"include_usage": true}
+			if rewritten, err := json.Marshal(req); err == nil {
+				info.body = rewritten
+			}
+		}
+	}
+	return info
+}
+
+// promptPreview is the redacted start of the last message, for evidence.
+func promptPreview(prompt any) string {
+	var text string
+	switch v := prompt.(type) {
+	case string:
+		text = v
+	case []any:
+		if len(v) == 0 {
+			return ""
+		}
+		last := v[len(v)-1]
+		if msg, ok := last.(map[string]any); ok {
+			text = contentText(msg["content"])
+		} else if s, ok := last.(string); ok {
+			text = s
+		}
+	}
+	text = strings.Join(strings.Fields(redact.Line(text)), " ")
+	if len(text) > maxPreview {
+		text = text[:maxPreview] + "..."
+	}
+	return text
+}
+
+func contentText(content any) string {
+	switch v := content.(type) {
+	case string:
+		return v
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, part := range v {
+			if m, ok := part.(map[string]any); ok {
+				if s, ok := m["text"].(string); ok {
+					parts = append(parts, s)
+				}
+			}
+		}
+		return strings.Join(parts, " ")
+	}
+	return ""
+}
+
+type usageBody struct {
+	Model    string      `json:"model"`
+	Usage    *usageCount `json:"usage"`
+	Response *usageBody  `json:"response"` // Responses API stream events
+}
+
+type usageCount struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	InputTokens      int64 `json:"input_tokens"`
+	OutputTokens     int64 `json:"output_tokens"`
+}
+
+// readUsage fills rec from a chat/completions, completions or responses body.
+func readUsage(data []byte, rec *Record) {
+	var body usageBody
+	if err := json.Unmarshal(data, &body); err != nil {
+		return
+	}
+	if body.Response != nil {
+		body = *body.Response
+	}
+	if body.Model != "" {
+		rec.Model = body.Model
+	}
+	if body.Usage == nil {
+		return
+	}
+	rec.UsageReported = true
+	rec.PromptTokens = body.Usage.PromptTokens + body.Usage.InputTokens
+	rec.CompletionTokens = body.Usage.CompletionTokens + body.Usage.OutputTokens
+}
+
+// relayStream copies a server-sent event stream line by line, flushing each
+// event, and reads usage from the data lines as they pass.
+func relayStream(w http.ResponseWriter, body io.Reader, rec *Record) {
+	flusher, _ := w.(http.Flusher)
+	reader := bufio.NewReader(body)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			if _, werr := w.Write(line); werr != nil {
+				return
+			}
+			if data, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("data:")); ok {
+				if data = bytes.TrimSpace(data); len(data) > 0 && data[0] == '{' {
+					readUsage(data, rec)
+				}
+			}
+			if flusher != nil && len(bytes.TrimSpace(line)) == 0 {
+				flusher.Flush()
+			}
+		}
+		if err != nil {
+			if flusher != nil {
+				flusher.Flush()
+			}
+			if !errors.Is(err, io.EOF) && rec.Error == "" {
+				rec.Error = "stream: " + err.Error()
+			}
+			return
+		}
+	}
+}
+
+var hopHeaders = map[string]bool{
+	"Connection": true, "Keep-Alive": true, "Proxy-Authenticate": true, "Proxy-Authorization": true,
+	"Te": true, "Trailer": true, "Transfer-Encoding": true, "Upgrade": true,
+}
+
+func copyHeaders(dst, src http.Header) {
+	for key, values := range src {
+		if hopHeaders[http.CanonicalHeaderKey(key)] {
+			continue
+		}
+		for _, v := range values {
+			dst.Add(key, v)
+		}
+	}
+}
+
+// promptWindow is a sliding histogram of recent prompt hashes.
+type promptWindow struct {
+	ring     []string
+	next     int
+	full     bool
+	counts   map[string]int
+	previews map[string]string
+}
+
+func newPromptWindow(size int) *promptWindow {
+	return &promptWindow{ring: make([]string, size), counts: make(map[string]int), previews: make(map[string]string)}
+}
+
+// add records hash and returns how often it now occurs in the window.
+func (w *promptWindow) add(hash, preview string) int {
+	if w.full {
+		old := w.ring[w.next]
+		if w.counts[old]--; w.counts[old] <= 0 {
+			delete(w.counts, old)
+			delete(w.previews, old)
+		}
+	}
+	w.ring[w.next] = hash
+	w.counts[hash]++
+	w.previews[hash] = preview
+	w.next++
+	if w.next == len(w.ring) {
+		w.next, w.full = 0, true
+	}
+	return w.counts[hash]
+}
+
+// top returns the most frequent hash; ties go to the most recent.
+func (w *promptWindow) top() (string, int) {
+	n := w.next
+	if w.full {
+		n = len(w.ring)
+	}
+	best, bestCount := "", 0
+	for i := 0; i < n; i++ {
+		hash := w.ring[(w.next-n+i+len(w.ring))%len(w.ring)]
+		if c := w.counts[hash]; c >= bestCount {
+			best, bestCount = hash, c
+		}
+	}
+	return best, bestCount
+}
@@ -0,0 +1,145 @@
+package llmproxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// stubUpstream answers chat completions like an OpenAI-compatible server.
+func stubUpstream(t *testing.T) (*httptest.Server, *[]map[string]any) {
+	t.Helper()
+	var mu sync.Mutex
+	seen := []map[string]any{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer sk-test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		seen = append(seen, req)
+		mu.Unlock()
+
+		if stream, _ := req["stream"].(bool); stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "data: {\"model\":\"gpt-4o-2024-08-06\",\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\n")
+			fmt.Fprint(w, "data: {\"model\":\"gpt-4o-2024-08-06\",\"choices\":[],\"usage\":{\"prompt_tokens\":12,\"completion_tokens\":3,\"total_tokens\":15}}\n\n")
+			fmt.Fprint(w, "data: [DONE]\n\n")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"c1","model":"gpt-4o-2024-08-06","choices":[{"message":{"role":"assistant","content":"hello"}}],"usage":{"prompt_tokens":20,"completion_tokens":5,"total_tokens":25}}`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &seen
+}
+
+func post(t *testing.T, url, body string) (*http.Response, string) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, url+"/chat/completions", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer sk-test")
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp, string(data)
+}
+
+func TestProxyRecordsUsageAndRepeats(t *testing.T) {
+	upstream, seen := stubUpstream(t)
+	var mu sync.Mutex
+	var records []Record
+	p, err := Start(Options{Upstream: upstream.URL + "/v1", OnRecord: func(r Record) {
+		mu.Lock()
+		records = append(records, r)
+		mu.Unlock()
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	prompt := `{"model":"gpt-4o","messages":[{"role":"user","content":"What is the weather in Paris?"}]}`
+	for i := 0; i < 3; i++ {
+		resp, body := post(t, p.BaseURL(), prompt)
+		if resp.StatusCode != http.StatusOK || !strings.Contains(body, `"hello"`) {
+			t.Fatalf("unexpected response %d %s", resp.StatusCode, body)
+		}
+	}
+	resp, body := post(t, p.BaseURL(), `{"model":"gpt-4o","stream":true,"messages":[{"role":"user","content":"Summarise it"}]}`)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(body, "data: [DONE]") {
+		t.Fatalf("unexpected stream response %d %s", resp.StatusCode, body)
+	}
+
+	if got := (*seen)[3]["stream_options"]; got == nil {
+		t.Fatal("expected include_usage to be requested for a streamed completion")
+	}
+	usage := p.Usage()
+	if usage.Requests != 4 || usage.PromptTokens != 72 || usage.CompletionTokens != 18 || usage.Model != "gpt-4o-2024-08-06" {
+		t.Fatalf("unexpected usage %+v", usage)
+	}
+	sig, n := p.RepeatedPrompt()
+	if n != 3 || !strings.HasSuffix(sig, ": What is the weather in Paris?") {
+		t.Fatalf("repeated prompt = %q x%d", sig, n)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(records) != 4 {
+		t.Fatalf("expected 4 records, got %d", len(records))
+	}
+	if r := records[2]; r.PromptRepeats != 3 || !r.UsageReported || r.Status != 200 || r.Path != "/v1/chat/completions" {
+		t.Fatalf("unexpected record %+v", r)
+	}
+	if r := records[3]; !r.Stream || r.PromptTokens != 12 || r.CompletionTokens != 3 {
+		t.Fatalf("unexpected stream record %+v", r)
+	}
+}
+
+func TestProxyUpstreamErrors(t *testing.T) {
+	upstream, _ := stubUpstream(t)
+	p, err := New(Options{Upstream: upstream.URL + "/v1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	// Upstream status codes pass through untouched.
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/v1/chat/completions", strings.NewReader(`{}`))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected upstream 401, got %d", resp.StatusCode)
+	}
+
+	upstream.Close()
+	resp, body := post(t, srv.URL+"/v1", `{"model":"gpt-4o","messages":[]}`)
+	if resp.StatusCode != http.StatusBadGateway || !strings.Contains(body, "flowforge_proxy_error") {
+		t.Fatalf("expected 502 from a dead upstream, got %d %s", resp.StatusCode, body)
+	}
+	if u := p.Usage(); u.Requests != 2 || u.Errors != 2 {
+		t.Fatalf("unexpected usage %+v", u)
+	}
+
+	if _, err := New(Options{Upstream: "ftp://example.com"}); err == nil {
+		t.Fatal("expected a non-http upstream to be rejected")
+	}
+}
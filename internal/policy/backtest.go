@@ -0,0 +1,168 @@
+package policy
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Backtest outcomes collapse actions into what would have happened to the run.
+// A pause stops the run's progress, so it counts as a kill.
+const (
+	OutcomeKill  = "kill"
+	OutcomeAlert = "alert"
+	OutcomeSpare = "spare"
+)
+
+var wouldActionRe = regexp.MustCompile(`(?i)would (KILL|RESTART|PAUSE)`)
+
+// BacktestSample is one telemetry sample with the decision that was recorded
+// for it (or produced by a baseline engine for fixtures).
+type BacktestSample struct {
+	Source           string    `json:"source"`
+	Ref              string    `json:"ref"`
+	RunID            string    `json:"run_id"`
+	Telemetry        Telemetry `json:"-"`
+	RecordedDecision string    `json:"recorded_decision"`
+	RecordedReason   string    `json:"recorded_reason"`
+	Approximate      bool      `json:"approximate,omitempty"`
+}
+
+type BacktestResult struct {
+	Source          string `json:"source"`
+	Ref             string `json:"ref"`
+	RunID           string `json:"run_id"`
+	Recorded        string `json:"recorded_outcome"`
+	Candidate       string `json:"candidate_outcome"`
+	CandidateAction string `json:"candidate_action"`
+	CandidateReason string `json:"candidate_reason"`
+	Changed         bool   `json:"changed"`
+	Approximate     bool   `json:"approximate,omitempty"`
+}
+
+type BacktestRun struct {
+	RunID     string `json:"run_id"`
+	Source    string `json:"source"`
+	Samples   int    `json:"samples"`
+	Recorded  string `json:"recorded_outcome"`
+	Candidate string `json:"candidate_outcome"`
+	Changed   bool   `json:"changed"`
+}
+
+type BacktestReport struct {
+	Samples        int              `json:"samples"`
+	ChangedSamples int              `json:"changed_samples"`
+	Runs           []BacktestRun    `json:"runs"`
+	RecordedRuns   map[string]int   `json:"recorded_runs"`
+	CandidateRuns  map[string]int   `json:"candidate_runs"`
+	Transitions    map[string]int   `json:"transitions"`
+	Results        []BacktestResult `json:"results"`
+}
+
+// RecordedOutcome maps a stored decision to an outcome. Log-only and blocked
+// decisions count as the action the engine intended, so shadow-mode history
+// compares like-for-like with an enforcing candidate.
+func RecordedOutcome(decision, reason string) string {
+	switch strings.ToUpper(strings.TrimSpace(decision)) {
+	case "KILL", "RESTART", "PAUSE", "ACTION_BLOCKED":
+		return OutcomeKill
+	case "ALERT":
+		return OutcomeAlert
+	case "LOG_ONLY":
+		if wouldActionRe.MatchString(reason) {
+			return OutcomeKill
+		}
+		return OutcomeAlert
+	default:
+		return OutcomeSpare
+	}
+}
+
+func outcomeForAction(a Action) string {
+	switch a {
+	case ActionKill, ActionRestart, ActionPause:
+		return OutcomeKill
+	case ActionAlert, ActionLogOnly:
+		return OutcomeAlert
+	default:
+		return OutcomeSpare
+	}
+}
+
+func outcomeRank(outcome string) int {
+	switch outcome {
+	case OutcomeKill:
+		return 2
+	case OutcomeAlert:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Backtest replays samples through a candidate engine. The candidate is
+// evaluated in enforce mode and judged on its intended action so rollout
+// sampling does not hide what the policy would do.
+func Backtest(d Decider, p Policy, samples []BacktestSample) BacktestReport {
+	p.ShadowMode = false
+	p.RolloutMode = RolloutEnforce
+
+	report := BacktestReport{
+		Samples:       len(samples),
+		Runs:          []BacktestRun{},
+		RecordedRuns:  map[string]int{OutcomeKill: 0, OutcomeAlert: 0, OutcomeSpare: 0},
+		CandidateRuns: map[string]int{OutcomeKill: 0, OutcomeAlert: 0, OutcomeSpare: 0},
+		Transitions:   map[string]int{},
+		Results:       make([]BacktestResult, 0, len(samples)),
+	}
+
+	runs := make(map[string]*BacktestRun)
+	order := make([]string, 0)
+	for _, sample := range samples {
+		decision := d.Evaluate(sample.Telemetry, p)
+		result := BacktestResult{
+			Source:          sample.Source,
+			Ref:             sample.Ref,
+			RunID:           sample.RunID,
+			Recorded:        RecordedOutcome(sample.RecordedDecision, sample.RecordedReason),
+			Candidate:       outcomeForAction(decision.IntendedAction),
+			CandidateAction: decision.IntendedAction.String(),
+			CandidateReason: decision.Reason,
+			Approximate:     sample.Approximate,
+		}
+		result.Changed = result.Recorded != result.Candidate
+		if result.Changed {
+			report.ChangedSamples++
+		}
+		report.Results = append(report.Results, result)
+
+		run, ok := runs[sample.RunID]
+		if !ok {
+			run = &BacktestRun{RunID: sample.RunID, Source: sample.Source, Recorded: OutcomeSpare, Candidate: OutcomeSpare}
+			runs[sample.RunID] = run
+			order = append(order, sample.RunID)
+		}
+		run.Samples++
+		if outcomeRank(result.Recorded) > outcomeRank(run.Recorded) {
+			run.Recorded = result.Recorded
+		}
+		if outcomeRank(result.Candidate) > outcomeRank(run.Candidate) {
+			run.Candidate = result.Candidate
+		}
+	}
+
+	for _, runID := range order {
+		run := runs[runID]
+		run.Changed = run.Recorded != run.Candidate
+		report.RecordedRuns[run.Recorded]++
+		report.CandidateRuns[run.Candidate]++
+		if run.Changed {
+			report.Transitions[run.Recorded+"->"+run.Candidate]++
+		}
+		report.Runs = append(report.Runs, *run)
+	}
+	sort.SliceStable(report.Runs, func(i, j int) bool {
+		return report.Runs[i].Changed && !report.Runs[j].Changed
+	})
+	return report
+}
@@ -0,0 +1,72 @@
+package policy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecordedOutcome(t *testing.T) {
+	tests := []struct {
+		decision string
+		reason   string
+		want     string
+	}{
+		{"KILL", "", OutcomeKill},
+		{"restart", "", OutcomeKill},
+		{"ACTION_BLOCKED", "kill blocked by --no-kill", OutcomeKill},
+		{"ALERT", "", OutcomeAlert},
+		{"LOG_ONLY", "Shadow mode: would KILL. CPU exceeded 90%", OutcomeKill},
+		{"LOG_ONLY", "Canary mode: log-only (10%, bucket=42) would RESTART. x", OutcomeKill},
+		{"LOG_ONLY", "dry run", OutcomeAlert},
+		{"CONTINUE", "", OutcomeSpare},
+		{"", "", OutcomeSpare},
+	}
+	for _, tt := range tests {
+		if got := RecordedOutcome(tt.decision, tt.reason); got != tt.want {
+			t.Fatalf("RecordedOutcome(%q, %q) = %q, want %q", tt.decision, tt.reason, got, tt.want)
+		}
+	}
+}
+
+func TestBacktestAggregatesRunsAndDiffs(t *testing.T) {
+	p := Policy{
+		MaxCPUPercent:    90,
+		CPUWindow:        30 * time.Second,
+		MinLogEntropy:    0.20,
+		MaxLogRepetition: 0.80,
+		RolloutMode:      RolloutShadow,
+	}
+	runaway := Telemetry{CPUPercent: 95, CPUOverFor: time.Minute, LogRepetition: 0.95, LogEntropy: 0.1}
+	calm := Telemetry{CPUPercent: 10, LogRepetition: 0.1, LogEntropy: 0.9}
+
+	samples := []BacktestSample{
+		{Source: "history", Ref: "trace:1", RunID: "run-a", Telemetry: calm, RecordedDecision: "CONTINUE"},
+		{Source: "history", Ref: "trace:2", RunID: "run-a", Telemetry: runaway, RecordedDecision: "CONTINUE"},
+		{Source: "history", Ref: "trace:3", RunID: "run-b", Telemetry: calm, RecordedDecision: "KILL"},
+		{Source: "history", Ref: "trace:4", RunID: "run-c", Telemetry: calm, RecordedDecision: "CONTINUE"},
+	}
+
+	report := Backtest(NewThresholdDecider(), p, samples)
+	if report.Samples != 4 || report.ChangedSamples != 2 {
+		t.Fatalf("expected 4 samples with 2 changed, got %d/%d", report.Samples, report.ChangedSamples)
+	}
+	// Shadow rollout must not hide the candidate's intended kill.
+	if report.Results[1].Candidate != OutcomeKill || report.Results[1].CandidateAction != "KILL" {
+		t.Fatalf("expected enforced candidate kill, got %+v", report.Results[1])
+	}
+	if len(report.Runs) != 3 {
+		t.Fatalf("expected 3 runs, got %d", len(report.Runs))
+	}
+	if report.CandidateRuns[OutcomeKill] != 1 || report.CandidateRuns[OutcomeSpare] != 2 {
+		t.Fatalf("unexpected candidate run counts %v", report.CandidateRuns)
+	}
+	if report.RecordedRuns[OutcomeKill] != 1 || report.RecordedRuns[OutcomeSpare] != 2 {
+		t.Fatalf("unexpected recorded run counts %v", report.RecordedRuns)
+	}
+	if report.Transitions["spare->kill"] != 1 || report.Transitions["kill->spare"] != 1 {
+		t.Fatalf("unexpected transitions %v", report.Transitions)
+	}
+	if !report.Runs[0].Changed || !report.Runs[1].Changed || report.Runs[2].Changed {
+		t.Fatalf("expected changed runs first, got %+v", report.Runs)
+	}
+}
@@ -0,0 +1,33 @@
+package policy
+
+import "fmt"
+
+// BudgetConfigured reports whether p sets any spend limit.
+func BudgetConfigured(p Policy) bool {
+	return p.MaxCostUSD > 0 || p.MaxTokens > 0 || p.WorkspaceDailyCostUSD > 0 || p.WorkspaceDailyTokens > 0
+}
+
+// BudgetBreach reports the first spend limit the sample has reached. Unlike
+// the other limits a budget is spent at the moment it is reached, so the
+// comparison is inclusive.
+func BudgetBreach(t Telemetry, p Policy) (bool, string) {
+	switch {
+	case p.MaxCostUSD > 0 && t.RunCostUSD >= p.MaxCostUSD:
+		return true, fmt.Sprintf("run cost $%.4f reached budget $%.4f", t.RunCostUSD, p.MaxCostUSD)
+	case p.MaxTokens > 0 && t.RunTokens >= p.MaxTokens:
+		return true, fmt.Sprintf("run used %d tokens (budget %d)", t.RunTokens, p.MaxTokens)
+	case p.WorkspaceDailyCostUSD > 0 && t.WorkspaceCostUSD >= p.WorkspaceDailyCostUSD:
+		return true, fmt.Sprintf("workspace spent $%.4f today (daily budget $%.4f)", t.WorkspaceCostUSD, p.WorkspaceDailyCostUSD)
+	case p.WorkspaceDailyTokens > 0 && t.WorkspaceTokens >= p.WorkspaceDailyTokens:
+		return true, fmt.Sprintf("workspace used %d tokens today (daily budget %d)", t.WorkspaceTokens, p.WorkspaceDailyTokens)
+	}
+	return false, ""
+}
+
+// budgetDecision never restarts: a new generation would draw on the same
+// exhausted budget.
+func budgetDecision(reason string, t Telemetry, p Policy) Decision {
+	d := applyRollout(ActionKill, reason, t, p)
+	d.ExitReason = ExitReasonBudget
+	return d
+}
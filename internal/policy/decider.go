@@ -15,6 +15,9 @@ const (
 	ActionKill
 	ActionRestart
 	ActionLogOnly
+	// ActionPause freezes the process group (SIGSTOP) until an operator
+	// resumes or kills it.
+	ActionPause
 )
 
 func (a Action) String() string {
@@ -29,6 +32,8 @@ func (a Action) String() string {
 		return "RESTART"
 	case ActionLogOnly:
 		return "LOG_ONLY"
+	case ActionPause:
+		return "PAUSE"
 	default:
 		return "UNKNOWN"
 	}
@@ -42,7 +47,53 @@ type Telemetry struct {
 	LogEntropy    float64 // 0..1 where 0 means repetitive
 	RawDiversity  float64 // 0..1 where 1 means highly diverse raw lines
 	ProgressLike  bool    // true when output suggests forward progress, not stagnation
+	ErrorRate     float64 // 0..1 share of structured lines in the window at error level or worse
 	RolloutKey    string  // Stable key for deterministic canary sampling
+	OOMKills      int     // cgroup memory.events oom_kill count (cgroup mode only)
+	CPUThrottled  float64 // 0..1 share of the last interval spent cgroup-throttled
+
+	// LogWindowPending marks a sample taken before the log window filled.
+	// LogRepetition, LogEntropy, RawDiversity, ProgressLike and ErrorRate are
+	// not scored yet; engines ignore them and judge the other signals.
+	LogWindowPending bool
+
+	OutputIdleFor time.Duration // since the process last wrote to stdout/stderr
+	CPUIdleFor    time.Duration // since CPU usage was last above the idle floor
+	HeartbeatAge  time.Duration // age of the heartbeat file mtime; zero when none is configured
+
+	MemoryGrowthMBPerMin float64       // RSS regression slope over the trend window; zero until enough samples
+	MemoryTimeToLimit    time.Duration // projected time until MemoryProjectionLimit; informational, engines recompute it
+
+	// Process tree counts: the supervised command, its descendants and
+	// anything left in its process group.
+	ChildProcesses  int
+	ZombieProcesses int
+	OpenFDs         int
+	Threads         int
+
+	// Stderr is scored on its own so a traceback loop is not diluted by
+	// progress lines on stdout.
+	StderrLinesPerSec  float64
+	StderrRepetition   float64 // 0..1 over the stderr window
+	RepeatedExceptions int     // occurrences of the most frequent recent exception
+	ExceptionSignature string  // normalized form of that exception
+
+	// Agent tool calls extracted from output. ToolCallRepetition counts the
+	// most frequent (tool, normalized args) pair among the recent calls.
+	ToolCallRepetition int
+	ToolCallSignature  string
+
+	// LLM requests seen by the proxy. PromptRepetition counts the most
+	// frequent identical prompt among the recent requests.
+	PromptRepetition int
+	PromptSignature  string
+
+	// Spend so far: this run across restarts, and the run's integration
+	// workspace for the current UTC day including this run.
+	RunCostUSD       float64
+	RunTokens        int
+	WorkspaceCostUSD float64
+	WorkspaceTokens  int
 }
 
 type RolloutMode string
@@ -60,9 +111,49 @@ type Policy struct {
 	MaxLogRepetition float64
 	MinLogEntropy    float64
 	RestartOnBreach  bool
-	ShadowMode       bool
-	RolloutMode      RolloutMode
-	CanaryPercent    int // 0..100: percent of sampled runs where destructive action is enforced in canary mode
+	// PauseOnBreach freezes the run instead of killing it. RestartOnBreach
+	// takes precedence.
+	PauseOnBreach bool
+	ShadowMode    bool
+	RolloutMode   RolloutMode
+	CanaryPercent int // 0..100: percent of sampled runs where destructive action is enforced in canary mode
+
+	// Stall thresholds. A run is stalled when every configured idle limit is
+	// exceeded, or when the heartbeat file is older than MaxHeartbeatAge.
+	MaxOutputIdle   time.Duration
+	MaxCPUIdle      time.Duration
+	MaxHeartbeatAge time.Duration
+
+	// Memory trend thresholds: act when RSS growth is projected to reach
+	// MemoryProjectionMB (default MaxMemoryMB) within MemoryProjectionWindow.
+	MemoryProjectionMB     float64
+	MemoryProjectionWindow time.Duration
+
+	// Process tree limits (0 = off). Too many children is treated as a fork
+	// bomb; zombies only raise an alert since they hold no resources but pids.
+	MaxChildProcesses  int
+	MaxZombieProcesses int
+
+	// Stderr limits (0 = off). Repeated exceptions kill like a loop; rate and
+	// repetition only alert.
+	MaxStderrLinesPerSec  float64
+	MaxStderrRepetition   float64
+	MaxRepeatedExceptions int
+	// MaxErrorRate alerts when more of the window's structured lines than
+	// this share (0..1) are errors. 0 = off.
+	MaxErrorRate float64
+	// MaxToolCallRepetition kills when one tool call signature repeats more
+	// often than this within the recent calls. 0 = off.
+	MaxToolCallRepetition int
+	// MaxPromptRepetition kills when the LLM proxy sees the same prompt more
+	// often than this within the recent requests. 0 = off.
+	MaxPromptRepetition int
+
+	// Budgets (0 = off). Reaching one kills the run and never restarts it.
+	MaxCostUSD            float64
+	MaxTokens             int
+	WorkspaceDailyCostUSD float64
+	WorkspaceDailyTokens  int
 
 	// Metadata fields used by callers when recording shadow-mode evidence.
 	DryRunEventType   string
@@ -70,10 +161,25 @@ type Policy struct {
 	DryRunEventPrefix string
 }
 
+// Exit reasons recorded on incidents for destructive decisions.
+const (
+	ExitReasonLoop         = "LOOP_DETECTED"
+	ExitReasonStall        = "STALL_DETECTED"
+	ExitReasonMemoryGrowth = "MEMORY_GROWTH_DETECTED"
+	ExitReasonForkBomb     = "FORK_BOMB_DETECTED"
+	ExitReasonException    = "REPEATED_EXCEPTION_DETECTED"
+	ExitReasonToolLoop     = "TOOL_CALL_LOOP_DETECTED"
+	ExitReasonPromptLoop   = "PROMPT_LOOP_DETECTED"
+	ExitReasonBudget       = "BUDGET_EXCEEDED"
+)
+
 type Decision struct {
 	Action         Action
 	IntendedAction Action
 	Reason         string
+	// ExitReason classifies a KILL/RESTART for incidents. Empty means
+	// ExitReasonLoop.
+	ExitReason string
 }
 
 type Decider interface {
@@ -87,15 +193,28 @@ func NewThresholdDecider() Decider {
 }
 
 func (ThresholdDecider) Evaluate(t Telemetry, p Policy) Decision {
+	if over, reason := BudgetBreach(t, p); over {
+		return budgetDecision(reason, t, p)
+	}
+	if stalled, reason := StallBreach(t, p); stalled {
+		return stallDecision(reason, t, p)
+	}
+
 	cpuBreach := p.MaxCPUPercent > 0 && t.CPUPercent > p.MaxCPUPercent
 	if cpuBreach && p.CPUWindow > 0 && t.CPUOverFor < p.CPUWindow {
 		cpuBreach = false
 	}
 	memBreach := p.MaxMemoryMB > 0 && t.MemoryMB > p.MaxMemoryMB
-	repetitionBreach := p.MaxLogRepetition > 0 && t.LogRepetition > p.MaxLogRepetition
-	entropyBreach := p.MinLogEntropy > 0 && t.LogEntropy < p.MinLogEntropy
+	repetitionBreach := !t.LogWindowPending && p.MaxLogRepetition > 0 && t.LogRepetition > p.MaxLogRepetition
+	entropyBreach := !t.LogWindowPending && p.MinLogEntropy > 0 && t.LogEntropy < p.MinLogEntropy
+	growthBreach, growthReason := MemoryTrendBreach(t, p)
+	forkBreach := p.MaxChildProcesses > 0 && t.ChildProcesses > p.MaxChildProcesses
+	zombieBreach := p.MaxZombieProcesses > 0 && t.ZombieProcesses > p.MaxZombieProcesses
+	exceptionBreach, exceptionReason := RepeatedExceptionBreach(t, p)
+	toolBreach, toolReason := ToolCallLoopBreach(t, p)
+	promptBreach, promptReason := PromptLoopBreach(t, p)
 
-	reasons := make([]string, 0, 4)
+	reasons := make([]string, 0, 10)
 	if cpuBreach {
 		if p.CPUWindow > 0 {
 			reasons = append(reasons, fmt.Sprintf("CPU exceeded %.0f%% for %ds", p.MaxCPUPercent, int(p.CPUWindow.Seconds())))
@@ -106,6 +225,25 @@ func (ThresholdDecider) Evaluate(t Telemetry, p Policy) Decision {
 	if memBreach {
 		reasons = append(reasons, fmt.Sprintf("memory exceeded %.0fMB", p.MaxMemoryMB))
 	}
+	if growthBreach {
+		reasons = append(reasons, growthReason)
+	}
+	if forkBreach {
+		reasons = append(reasons, forkBombReason(t, p))
+	}
+	if zombieBreach {
+		reasons = append(reasons, zombieReason(t, p))
+	}
+	if exceptionBreach {
+		reasons = append(reasons, exceptionReason)
+	}
+	if toolBreach {
+		reasons = append(reasons, toolReason)
+	}
+	if promptBreach {
+		reasons = append(reasons, promptReason)
+	}
+	reasons = append(reasons, outputAlertReasons(t, p)...)
 	if repetitionBreach {
 		reasons = append(reasons, fmt.Sprintf("log repetition exceeded %.2f", p.MaxLogRepetition))
 	}
@@ -123,23 +261,53 @@ func (ThresholdDecider) Evaluate(t Telemetry, p Policy) Decision {
 
 	potentialRuntimeRisk := cpuBreach && (repetitionBreach || entropyBreach)
 	progressGuard := potentialRuntimeRisk && t.ProgressLike && t.RawDiversity >= 0.85
-	highRisk := memBreach || (potentialRuntimeRisk && !progressGuard)
+	loopRisk := potentialRuntimeRisk && !progressGuard
+	highRisk := memBreach || growthBreach || forkBreach || exceptionBreach || toolBreach || promptBreach || loopRisk
 
 	action := ActionAlert
 	if highRisk {
-		if p.RestartOnBreach {
-			action = ActionRestart
-		} else {
-			action = ActionKill
-		}
+		action = breachAction(p)
 	}
 
 	if progressGuard {
 		reasons = append(reasons, "progressing output pattern detected; destructive action suppressed")
 	}
 
-	reason := strings.Join(reasons, " AND ")
-	if action == ActionKill || action == ActionRestart {
+	d := applyRollout(action, strings.Join(reasons, " AND "), t, p)
+	switch {
+	case memBreach || loopRisk:
+		// legacy LOOP_DETECTED
+	case growthBreach:
+		d.ExitReason = ExitReasonMemoryGrowth
+	case forkBreach:
+		d.ExitReason = ExitReasonForkBomb
+	case exceptionBreach:
+		d.ExitReason = ExitReasonException
+	case toolBreach:
+		d.ExitReason = ExitReasonToolLoop
+	case promptBreach:
+		d.ExitReason = ExitReasonPromptLoop
+	}
+	return d
+}
+
+// breachAction is what a high-risk breach does under p: kill, or restart or
+// pause when the run asked for it.
+func breachAction(p Policy) Action {
+	switch {
+	case p.RestartOnBreach:
+		return ActionRestart
+	case p.PauseOnBreach:
+		return ActionPause
+	}
+	return ActionKill
+}
+
+// applyRollout downgrades destructive actions to log-only according to the
+// policy rollout mode. Every engine routes its final action through here so
+// shadow/canary semantics do not depend on which engine is selected.
+func applyRollout(action Action, reason string, t Telemetry, p Policy) Decision {
+	if action == ActionKill || action == ActionRestart || action == ActionPause {
 		switch normalizeRolloutMode(p.RolloutMode, p.ShadowMode) {
 		case RolloutShadow:
 			return Decision{
@@ -209,3 +377,11 @@ func canaryEnforces(key string, percent int) (bool, int) {
 	bucket := canaryBucket(key)
 	return bucket < p, bucket
 }
+
+func forkBombReason(t Telemetry, p Policy) string {
+	return fmt.Sprintf("process tree has %d children (limit %d)", t.ChildProcesses, p.MaxChildProcesses)
+}
+
+func zombieReason(t Telemetry, p Policy) string {
+	return fmt.Sprintf("%d unreaped zombie processes (limit %d)", t.ZombieProcesses, p.MaxZombieProcesses)
+}
@@ -207,3 +207,204 @@ func TestCanarySamplingDeterministicByKey(t *testing.T) {
 		t.Fatalf("expected deterministic bucket, got %d and %d", b1, b2)
 	}
 }
+
+func TestEvaluateProcessTreeLimits(t *testing.T) {
+	d := NewThresholdDecider()
+	p := Policy{MaxChildProcesses: 50, MaxZombieProcesses: 5}
+
+	out := d.Evaluate(Telemetry{ChildProcesses: 51, LogEntropy: 1}, p)
+	if out.Action != ActionKill || out.ExitReason != ExitReasonForkBomb {
+		t.Fatalf("expected fork bomb kill, got %+v", out)
+	}
+	if out.Reason != "process tree has 51 children (limit 50)" {
+		t.Fatalf("unexpected reason %q", out.Reason)
+	}
+
+	out = d.Evaluate(Telemetry{ChildProcesses: 8, ZombieProcesses: 6, LogEntropy: 1}, p)
+	if out.Action != ActionAlert || out.Reason != "6 unreaped zombie processes (limit 5)" {
+		t.Fatalf("expected zombie alert, got %+v", out)
+	}
+
+	if out := NewWeightedScoreDecider().Evaluate(Telemetry{ZombieProcesses: 6, LogEntropy: 1}, p); out.Action != ActionAlert {
+		t.Fatalf("weighted: expected zombie alert, got %+v", out)
+	}
+
+	if !ProcessTreeConfigured(p) || ProcessTreeConfigured(Policy{}) {
+		t.Fatal("expected the tree checks to follow the child and zombie limits")
+	}
+	// A fork bomb can exhaust the host before the log window fills; every
+	// engine judges the tree from the first tick.
+	for name, engine := range map[string]Decider{"threshold": d, "weighted": NewWeightedScoreDecider(), "rules": NewRuleDecider()} {
+		if out := engine.Evaluate(Telemetry{LogWindowPending: true, ChildProcesses: 51}, p); out.Action != ActionKill || out.ExitReason != ExitReasonForkBomb {
+			t.Fatalf("%s: expected fork bomb kill before the window fills, got %+v", name, out)
+		}
+		if out := engine.Evaluate(Telemetry{LogWindowPending: true, ChildProcesses: 8, ZombieProcesses: 6}, p); out.Action != ActionAlert {
+			t.Fatalf("%s: expected zombie alert before the window fills, got %+v", name, out)
+		}
+		if out := engine.Evaluate(Telemetry{LogWindowPending: true, ChildProcesses: 50, ZombieProcesses: 5}, p); out.Action != ActionContinue {
+			t.Fatalf("%s: counts at the limit should continue, got %+v", name, out)
+		}
+	}
+	canary := p
+	canary.RolloutMode = RolloutCanary
+	if out := d.Evaluate(Telemetry{LogWindowPending: true, ChildProcesses: 51, RolloutKey: "run-1"}, canary); out.Action != ActionLogOnly || out.IntendedAction != ActionKill {
+		t.Fatalf("canary at 0%% should only log a pre-window fork bomb, got %+v", out)
+	}
+}
+
+func TestEvaluateStderrSignals(t *testing.T) {
+	d := NewThresholdDecider()
+	p := Policy{MaxStderrLinesPerSec: 20, MaxStderrRepetition: 0.9, MaxRepeatedExceptions: 3}
+
+	sample := Telemetry{LogEntropy: 1, RepeatedExceptions: 4, ExceptionSignature: "KeyError: 'id'"}
+	out := d.Evaluate(sample, p)
+	if out.Action != ActionKill || out.ExitReason != ExitReasonException {
+		t.Fatalf("expected repeated exception kill, got %+v", out)
+	}
+	if out.Reason != `exception "KeyError: 'id'" repeated 4 times (limit 3)` {
+		t.Fatalf("unexpected reason %q", out.Reason)
+	}
+	if w := NewWeightedScoreDecider().Evaluate(sample, p); w.Action != ActionKill || w.ExitReason != ExitReasonException {
+		t.Fatalf("weighted: expected repeated exception kill, got %+v", w)
+	}
+
+	out = d.Evaluate(Telemetry{LogEntropy: 1, StderrLinesPerSec: 35, StderrRepetition: 0.95}, p)
+	if out.Action != ActionAlert || out.Reason != "stderr rate 35.0 lines/s (limit 20.0) AND stderr repetition exceeded 0.90" {
+		t.Fatalf("expected stderr alert, got %+v", out)
+	}
+	if w := NewWeightedScoreDecider().Evaluate(Telemetry{LogEntropy: 1, StderrLinesPerSec: 35}, p); w.Action != ActionAlert {
+		t.Fatalf("weighted: expected stderr alert, got %+v", w)
+	}
+}
+
+func TestEvaluateToolCallLoop(t *testing.T) {
+	p := Policy{MaxToolCallRepetition: 5, RolloutMode: RolloutEnforce}
+	sample := Telemetry{LogEntropy: 1, LogRepetition: 0, ToolCallRepetition: 8, ToolCallSignature: `search {"q":"weather in paris"}`}
+	out := NewThresholdDecider().Evaluate(sample, p)
+	if out.Action != ActionKill || out.ExitReason != ExitReasonToolLoop {
+		t.Fatalf("expected tool call loop kill, got %+v", out)
+	}
+	if out.Reason != `tool call search {"q":"weather in paris"} repeated 8 times (limit 5)` {
+		t.Fatalf("unexpected reason %q", out.Reason)
+	}
+	if w := NewWeightedScoreDecider().Evaluate(sample, p); w.Action != ActionKill || w.ExitReason != ExitReasonToolLoop {
+		t.Fatalf("weighted: expected tool call loop kill, got %+v", w)
+	}
+	sample.ToolCallRepetition = 5
+	if out := NewThresholdDecider().Evaluate(sample, p); out.Action != ActionContinue {
+		t.Fatalf("repetition at the limit should continue, got %+v", out)
+	}
+}
+
+func TestEvaluatePromptLoop(t *testing.T) {
+	p := Policy{MaxPromptRepetition: 3}
+	sample := Telemetry{LogEntropy: 1, PromptRepetition: 4, PromptSignature: "3fa9c2d1e0b4: retry the search"}
+	out := NewThresholdDecider().Evaluate(sample, p)
+	if out.Action != ActionKill || out.ExitReason != ExitReasonPromptLoop || out.Reason != "identical prompt sent 4 times (limit 3)" {
+		t.Fatalf("expected prompt loop kill, got %+v", out)
+	}
+	if w := NewWeightedScoreDecider().Evaluate(sample, p); w.Action != ActionKill || w.ExitReason != ExitReasonPromptLoop {
+		t.Fatalf("weighted: expected prompt loop kill, got %+v", w)
+	}
+
+	// The proxy counts prompts whether or not the agent prints anything, so
+	// every engine acts on them before the log window fills.
+	engines := map[string]Decider{
+		"threshold": NewThresholdDecider(),
+		"weighted":  NewWeightedScoreDecider(),
+		"rules":     NewRuleDecider(),
+	}
+	p.MinLogEntropy = 0.2
+	for name, d := range engines {
+		if early := d.Evaluate(Telemetry{LogWindowPending: true, PromptRepetition: 4}, p); early.Action != ActionKill || early.ExitReason != ExitReasonPromptLoop {
+			t.Fatalf("%s: expected kill without log scores, got %+v", name, early)
+		}
+		if early := d.Evaluate(Telemetry{LogWindowPending: true, PromptRepetition: 3}, p); early.Action != ActionContinue {
+			t.Fatalf("%s: repetition at the limit should continue, got %+v", name, early)
+		}
+	}
+
+	alertOnly, err := ParseRuleSet([]byte(`version: 1
+rules:
+  - name: prompt-loop
+    when: max_prompt_repetition > 0 && prompt_repetition > max_prompt_repetition
+    action: alert
+`))
+	if err != nil {
+		t.Fatalf("parse policy: %v", err)
+	}
+	if early := NewRuleDeciderFor(alertOnly).Evaluate(Telemetry{LogWindowPending: true, PromptRepetition: 4}, p); early.Action != ActionAlert {
+		t.Fatalf("an alert-only prompt rule must not kill, got %+v", early)
+	}
+}
+
+func TestEvaluateBudget(t *testing.T) {
+	p := Policy{MaxCostUSD: 1, WorkspaceDailyTokens: 5000, RestartOnBreach: true}
+	out := NewThresholdDecider().Evaluate(Telemetry{LogEntropy: 1, RunCostUSD: 1}, p)
+	if out.Action != ActionKill || out.ExitReason != ExitReasonBudget || out.Reason != "run cost $1.0000 reached budget $1.0000" {
+		t.Fatalf("expected budget kill without restart, got %+v", out)
+	}
+	if w := NewWeightedScoreDecider().Evaluate(Telemetry{LogEntropy: 1, WorkspaceTokens: 5000}, p); w.Action != ActionKill || w.ExitReason != ExitReasonBudget {
+		t.Fatalf("weighted: expected budget kill, got %+v", w)
+	}
+	if r := NewRuleDecider().Evaluate(Telemetry{LogEntropy: 1, RunCostUSD: 1.2}, p); r.Action != ActionKill || r.ExitReason != ExitReasonBudget {
+		t.Fatalf("rules: expected budget kill without restart, got %+v", r)
+	}
+	// Spend does not wait for the log window.
+	if early := NewThresholdDecider().Evaluate(Telemetry{LogWindowPending: true, RunCostUSD: 0.99, WorkspaceTokens: 4999}, p); early.Action != ActionContinue {
+		t.Fatalf("below budget should continue, got %+v", early)
+	}
+	if early := NewRuleDecider().Evaluate(Telemetry{LogWindowPending: true, RunCostUSD: 1}, p); early.Action != ActionKill || early.ExitReason != ExitReasonBudget {
+		t.Fatalf("rules: expected budget kill before the window fills, got %+v", early)
+	}
+
+	p.RolloutMode = RolloutShadow
+	if shadow := NewWeightedScoreDecider().Evaluate(Telemetry{LogWindowPending: true, RunCostUSD: 3}, p); shadow.Action != ActionLogOnly || shadow.IntendedAction != ActionKill || shadow.ExitReason != ExitReasonBudget {
+		t.Fatalf("shadow mode should only log a budget breach, got %+v", shadow)
+	}
+}
+
+func TestEvaluateErrorRateAlerts(t *testing.T) {
+	p := Policy{MaxErrorRate: 0.5}
+	out := NewThresholdDecider().Evaluate(Telemetry{LogEntropy: 1, ErrorRate: 0.75}, p)
+	if out.Action != ActionAlert || out.Reason != "error rate 75% of structured lines (limit 50%)" {
+		t.Fatalf("expected error rate alert, got %+v", out)
+	}
+	if w := NewWeightedScoreDecider().Evaluate(Telemetry{LogEntropy: 1, ErrorRate: 0.75}, p); w.Action != ActionAlert {
+		t.Fatalf("weighted: expected error rate alert, got %+v", w)
+	}
+	if out := NewThresholdDecider().Evaluate(Telemetry{LogEntropy: 1, ErrorRate: 0.5}, p); out.Action != ActionContinue {
+		t.Fatalf("error rate at the limit should continue, got %+v", out)
+	}
+}
+
+func TestEvaluatePauseOnBreach(t *testing.T) {
+	p := Policy{MaxChildProcesses: 10, PauseOnBreach: true}
+	sample := Telemetry{LogEntropy: 1, ChildProcesses: 50}
+	if out := NewThresholdDecider().Evaluate(sample, p); out.Action != ActionPause || out.ExitReason != ExitReasonForkBomb {
+		t.Fatalf("expected fork bomb pause, got %+v", out)
+	}
+	if w := NewWeightedScoreDecider().Evaluate(sample, p); w.Action != ActionPause {
+		t.Fatalf("weighted: expected pause, got %+v", w)
+	}
+	if r := NewRuleDecider().Evaluate(sample, p); r.Action != ActionPause {
+		t.Fatalf("rules: expected pause, got %+v", r)
+	}
+	if b := NewThresholdDecider().Evaluate(Telemetry{LogEntropy: 1, RunTokens: 10}, Policy{MaxTokens: 10, PauseOnBreach: true}); b.Action != ActionKill {
+		t.Fatalf("a budget breach should still kill, got %+v", b)
+	}
+
+	p.RestartOnBreach = true
+	if out := NewThresholdDecider().Evaluate(sample, p); out.Action != ActionRestart {
+		t.Fatalf("restart-on-breach should take precedence, got %+v", out)
+	}
+
+	p.RestartOnBreach, p.RolloutMode = false, RolloutShadow
+	out := NewThresholdDecider().Evaluate(sample, p)
+	if out.Action != ActionLogOnly || out.IntendedAction != ActionPause || RecordedOutcome(out.Action.String(), out.Reason) != OutcomeKill {
+		t.Fatalf("shadow mode should only log the pause, got %+v", out)
+	}
+	if a, err := ParseAction("pause"); err != nil || a != ActionPause {
+		t.Fatalf("ParseAction(pause) = %v, %v", a, err)
+	}
+}
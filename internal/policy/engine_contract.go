@@ -7,7 +7,7 @@ import (
 
 const (
 	DecisionEngineName      = "threshold-decider"
-	DecisionEngineVersion   = "1.1.0"
+	DecisionEngineVersion   = "1.2.0"
 	DecisionContractVersion = "decision-trace.v1"
 )
 
@@ -21,15 +21,25 @@ type EngineContract struct {
 }
 
 func CurrentEngineContract(rolloutMode RolloutMode) EngineContract {
+	return EngineContractFor(DecisionEngineName, DecisionEngineVersion, rolloutMode)
+}
+
+// EngineContractFor describes the trace contract for a specific engine, so
+// traces and replay digests carry whichever engine actually decided.
+func EngineContractFor(engineName, engineVersion string, rolloutMode RolloutMode) EngineContract {
 	mode := normalizeRolloutMode(rolloutMode, false)
 	return EngineContract{
-		EngineName:      DecisionEngineName,
-		EngineVersion:   DecisionEngineVersion,
+		EngineName:      engineName,
+		EngineVersion:   engineVersion,
 		ContractVersion: DecisionContractVersion,
 		RolloutMode:     string(mode),
 	}
 }
 
+func (r EngineRegistration) Contract(rolloutMode RolloutMode) EngineContract {
+	return EngineContractFor(r.Name, r.Version, rolloutMode)
+}
+
 func IsValidEngineVersion(version string) bool {
 	version = strings.TrimSpace(version)
 	return semverRe.MatchString(version)
@@ -0,0 +1,615 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// The rule language is a small boolean expression grammar over telemetry and
+// policy fields:
+//
+//	expr    := or
+//	or      := and { ("||" | "or") and }
+//	and     := unary { ("&&" | "and") unary }
+//	unary   := ("!" | "not") unary | compare
+//	compare := operand [ (">" | ">=" | "<" | "<=" | "==" | "!=") operand ]
+//	operand := number | duration | "true" | "false" | field | "(" expr ")"
+//
+// Durations (30s, 1m30s, 500ms) evaluate to seconds so they compare directly
+// against duration fields such as cpu_over_for.
+
+type exprKind int
+
+const (
+	exprNumber exprKind = iota
+	exprBool
+)
+
+func (k exprKind) String() string {
+	if k == exprBool {
+		return "bool"
+	}
+	return "number"
+}
+
+type exprValue struct {
+	kind exprKind
+	num  float64
+	b    bool
+}
+
+func (v exprValue) String() string {
+	if v.kind == exprBool {
+		return strconv.FormatBool(v.b)
+	}
+	return strconv.FormatFloat(v.num, 'f', -1, 64)
+}
+
+type exprField struct {
+	kind        exprKind
+	description string
+	get         func(t Telemetry, p Policy) exprValue
+	// logWindow fields are scored from the log window and are meaningless
+	// while Telemetry.LogWindowPending is set.
+	logWindow bool
+}
+
+func numberField(description string, get func(t Telemetry, p Policy) float64) exprField {
+	return exprField{kind: exprNumber, description: description, get: func(t Telemetry, p Policy) exprValue {
+		return exprValue{kind: exprNumber, num: get(t, p)}
+	}}
+}
+
+func boolField(description string, get func(t Telemetry, p Policy) bool) exprField {
+	return exprField{kind: exprBool, description: description, get: func(t Telemetry, p Policy) exprValue {
+		return exprValue{kind: exprBool, b: get(t, p)}
+	}}
+}
+
+func logWindowField(f exprField) exprField {
+	f.logWindow = true
+	return f
+}
+
+var exprFields = map[string]exprField{
+	"cpu_percent": numberField("process CPU usage percent", func(t Telemetry, _ Policy) float64 {
+		return t.CPUPercent
+	}),
+	"cpu_over_for": numberField("seconds CPU has stayed above max_cpu_percent", func(t Telemetry, _ Policy) float64 {
+		return t.CPUOverFor.Seconds()
+	}),
+	"memory_mb": numberField("resident memory in MB", func(t Telemetry, _ Policy) float64 {
+		return t.MemoryMB
+	}),
+	"log_repetition": logWindowField(numberField("0..1 repetition score of the log window", func(t Telemetry, _ Policy) float64 {
+		return t.LogRepetition
+	})),
+	"log_entropy": logWindowField(numberField("0..1 entropy score of the log window", func(t Telemetry, _ Policy) float64 {
+		return t.LogEntropy
+	})),
+	"raw_diversity": logWindowField(numberField("0..1 share of unique raw lines", func(t Telemetry, _ Policy) float64 {
+		return t.RawDiversity
+	})),
+	"progress_like": logWindowField(boolField("output looks like forward progress", func(t Telemetry, _ Policy) bool {
+		return t.ProgressLike
+	})),
+	"oom_kills": numberField("processes killed by the cgroup OOM killer (cgroup mode)", func(t Telemetry, _ Policy) float64 {
+		return float64(t.OOMKills)
+	}),
+	"cpu_throttled": numberField("0..1 share of time the cgroup was CPU-throttled (cgroup mode)", func(t Telemetry, _ Policy) float64 {
+		return t.CPUThrottled
+	}),
+	"output_idle_for": numberField("seconds since the process last wrote output", func(t Telemetry, _ Policy) float64 {
+		return t.OutputIdleFor.Seconds()
+	}),
+	"cpu_idle_for": numberField("seconds since CPU usage was last above the idle floor", func(t Telemetry, _ Policy) float64 {
+		return t.CPUIdleFor.Seconds()
+	}),
+	"heartbeat_age": numberField("seconds since the heartbeat file was modified (0 without one)", func(t Telemetry, _ Policy) float64 {
+		return t.HeartbeatAge.Seconds()
+	}),
+	"memory_growth_mb_per_min": numberField("RSS regression slope in MB per minute", func(t Telemetry, _ Policy) float64 {
+		return t.MemoryGrowthMBPerMin
+	}),
+	"memory_time_to_limit": numberField("seconds until memory is projected to reach memory_projection_mb (-1 when not growing)", func(t Telemetry, p Policy) float64 {
+		ttl, ok := ProjectedTimeToLimit(t, p)
+		if !ok {
+			return -1
+		}
+		return ttl.Seconds()
+	}),
+	"child_processes": numberField("processes in the tree besides the supervised command", func(t Telemetry, _ Policy) float64 {
+		return float64(t.ChildProcesses)
+	}),
+	"zombie_processes": numberField("exited but unreaped processes in the tree", func(t Telemetry, _ Policy) float64 {
+		return float64(t.ZombieProcesses)
+	}),
+	"open_fds": numberField("open file descriptors across the process tree", func(t Telemetry, _ Policy) float64 {
+		return float64(t.OpenFDs)
+	}),
+	"threads": numberField("threads across the process tree", func(t Telemetry, _ Policy) float64 {
+		return float64(t.Threads)
+	}),
+	"stderr_lines_per_sec": numberField("stderr lines per second over the recent window", func(t Telemetry, _ Policy) float64 {
+		return t.StderrLinesPerSec
+	}),
+	"stderr_repetition": numberField("0..1 repetition score of the stderr window", func(t Telemetry, _ Policy) float64 {
+		return t.StderrRepetition
+	}),
+	"tool_call_repetition": numberField("repeats of the most frequent recent tool call signature", func(t Telemetry, _ Policy) float64 {
+		return float64(t.ToolCallRepetition)
+	}),
+	"prompt_repetition": numberField("sends of the most frequent recent prompt through the LLM proxy", func(t Telemetry, _ Policy) float64 {
+		return float64(t.PromptRepetition)
+	}),
+	"run_cost_usd": numberField("estimated spend of this run in USD", func(t Telemetry, _ Policy) float64 {
+		return t.RunCostUSD
+	}),
+	"run_tokens": numberField("tokens used by this run", func(t Telemetry, _ Policy) float64 {
+		return float64(t.RunTokens)
+	}),
+	"workspace_cost_usd": numberField("estimated spend of the run's workspace today (UTC) in USD", func(t Telemetry, _ Policy) float64 {
+		return t.WorkspaceCostUSD
+	}),
+	"workspace_tokens": numberField("tokens used by the run's workspace today (UTC)", func(t Telemetry, _ Policy) float64 {
+		return float64(t.WorkspaceTokens)
+	}),
+	"error_rate": logWindowField(numberField("0..1 share of structured lines at error level or worse", func(t Telemetry, _ Policy) float64 {
+		return t.ErrorRate
+	})),
+	"repeated_exceptions": numberField("occurrences of the most frequent recent exception", func(t Telemetry, _ Policy) float64 {
+		return float64(t.RepeatedExceptions)
+	}),
+	"max_cpu_percent": numberField("policy CPU threshold", func(_ Telemetry, p Policy) float64 {
+		return p.MaxCPUPercent
+	}),
+	"cpu_window": numberField("policy CPU window in seconds", func(_ Telemetry, p Policy) float64 {
+		return p.CPUWindow.Seconds()
+	}),
+	"max_memory_mb": numberField("policy memory threshold in MB", func(_ Telemetry, p Policy) float64 {
+		return p.MaxMemoryMB
+	}),
+	"max_log_repetition": numberField("policy repetition threshold", func(_ Telemetry, p Policy) float64 {
+		return p.MaxLogRepetition
+	}),
+	"min_log_entropy": numberField("policy entropy floor", func(_ Telemetry, p Policy) float64 {
+		return p.MinLogEntropy
+	}),
+	"memory_projection_mb": numberField("memory level growth is projected against (defaults to max_memory_mb)", func(_ Telemetry, p Policy) float64 {
+		return MemoryProjectionLimit(p)
+	}),
+	"memory_projection_window": numberField("policy projection horizon in seconds (0 = off)", func(_ Telemetry, p Policy) float64 {
+		return p.MemoryProjectionWindow.Seconds()
+	}),
+	"max_child_processes": numberField("policy child process limit (0 = off)", func(_ Telemetry, p Policy) float64 {
+		return float64(p.MaxChildProcesses)
+	}),
+	"max_zombie_processes": numberField("policy zombie process limit (0 = off)", func(_ Telemetry, p Policy) float64 {
+		return float64(p.MaxZombieProcesses)
+	}),
+	"max_stderr_lines_per_sec": numberField("policy stderr rate limit (0 = off)", func(_ Telemetry, p Policy) float64 {
+		return p.MaxStderrLinesPerSec
+	}),
+	"max_stderr_repetition": numberField("policy stderr repetition limit (0 = off)", func(_ Telemetry, p Policy) float64 {
+		return p.MaxStderrRepetition
+	}),
+	"max_tool_call_repetition": numberField("policy tool call repetition limit (0 = off)", func(_ Telemetry, p Policy) float64 {
+		return float64(p.MaxToolCallRepetition)
+	}),
+	"max_prompt_repetition": numberField("policy identical prompt limit (0 = off)", func(_ Telemetry, p Policy) float64 {
+		return float64(p.MaxPromptRepetition)
+	}),
+	"max_cost_usd": numberField("policy per-run cost budget in USD (0 = off)", func(_ Telemetry, p Policy) float64 {
+		return p.MaxCostUSD
+	}),
+	"max_tokens": numberField("policy per-run token budget (0 = off)", func(_ Telemetry, p Policy) float64 {
+		return float64(p.MaxTokens)
+	}),
+	"workspace_daily_cost_usd": numberField("policy daily workspace cost budget in USD (0 = off)", func(_ Telemetry, p Policy) float64 {
+		return p.WorkspaceDailyCostUSD
+	}),
+	"workspace_daily_tokens": numberField("policy daily workspace token budget (0 = off)", func(_ Telemetry, p Policy) float64 {
+		return float64(p.WorkspaceDailyTokens)
+	}),
+	"max_error_rate": numberField("policy structured error rate limit (0 = off)", func(_ Telemetry, p Policy) float64 {
+		return p.MaxErrorRate
+	}),
+	"max_repeated_exceptions": numberField("policy repeated exception limit (0 = off)", func(_ Telemetry, p Policy) float64 {
+		return float64(p.MaxRepeatedExceptions)
+	}),
+	"max_output_idle": numberField("policy output idle limit in seconds (0 = off)", func(_ Telemetry, p Policy) float64 {
+		return p.MaxOutputIdle.Seconds()
+	}),
+	"max_cpu_idle": numberField("policy CPU idle limit in seconds (0 = off)", func(_ Telemetry, p Policy) float64 {
+		return p.MaxCPUIdle.Seconds()
+	}),
+	"max_heartbeat_age": numberField("policy heartbeat age limit in seconds (0 = off)", func(_ Telemetry, p Policy) float64 {
+		return p.MaxHeartbeatAge.Seconds()
+	}),
+}
+
+// ExpressionFields lists the field names usable in rule expressions together
+// with a short description, sorted by name.
+func ExpressionFields() [][2]string {
+	names := make([]string, 0, len(exprFields))
+	for name := range exprFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	out := make([][2]string, 0, len(names))
+	for _, name := range names {
+		out = append(out, [2]string{name, exprFields[name].description})
+	}
+	return out
+}
+
+type exprNode interface {
+	kind() exprKind
+	eval(t Telemetry, p Policy) exprValue
+}
+
+type literalNode struct{ value exprValue }
+
+func (n literalNode) kind() exprKind                   { return n.value.kind }
+func (n literalNode) eval(Telemetry, Policy) exprValue { return n.value }
+
+type fieldNode struct {
+	name  string
+	field exprField
+}
+
+func (n fieldNode) kind() exprKind                       { return n.field.kind }
+func (n fieldNode) eval(t Telemetry, p Policy) exprValue { return n.field.get(t, p) }
+
+type notNode struct{ operand exprNode }
+
+func (n notNode) kind() exprKind { return exprBool }
+func (n notNode) eval(t Telemetry, p Policy) exprValue {
+	return exprValue{kind: exprBool, b: !n.operand.eval(t, p).b}
+}
+
+type logicNode struct {
+	and         bool
+	left, right exprNode
+}
+
+func (n logicNode) kind() exprKind { return exprBool }
+func (n logicNode) eval(t Telemetry, p Policy) exprValue {
+	left := n.left.eval(t, p).b
+	if n.and && !left {
+		return exprValue{kind: exprBool}
+	}
+	if !n.and && left {
+		return exprValue{kind: exprBool, b: true}
+	}
+	return exprValue{kind: exprBool, b: n.right.eval(t, p).b}
+}
+
+type compareNode struct {
+	op          string
+	left, right exprNode
+}
+
+func (n compareNode) kind() exprKind { return exprBool }
+func (n compareNode) eval(t Telemetry, p Policy) exprValue {
+	l := n.left.eval(t, p)
+	r := n.right.eval(t, p)
+	var out bool
+	if l.kind == exprBool {
+		switch n.op {
+		case "==":
+			out = l.b == r.b
+		case "!=":
+			out = l.b != r.b
+		}
+		return exprValue{kind: exprBool, b: out}
+	}
+	switch n.op {
+	case ">":
+		out = l.num > r.num
+	case ">=":
+		out = l.num >= r.num
+	case "<":
+		out = l.num < r.num
+	case "<=":
+		out = l.num <= r.num
+	case "==":
+		out = l.num == r.num
+	case "!=":
+		out = l.num != r.num
+	}
+	return exprValue{kind: exprBool, b: out}
+}
+
+// Expression is a compiled rule condition.
+type Expression struct {
+	source string
+	root   exprNode
+	fields []string
+}
+
+func (e *Expression) String() string { return e.source }
+
+// Fields returns the distinct field names referenced by the expression in
+// order of first appearance.
+func (e *Expression) Fields() []string {
+	return append([]string(nil), e.fields...)
+}
+
+// UsesLogWindow reports whether the expression reads a log window score.
+func (e *Expression) UsesLogWindow() bool {
+	for _, name := range e.fields {
+		if exprFields[name].logWindow {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Expression) Eval(t Telemetry, p Policy) bool {
+	return e.root.eval(t, p).b
+}
+
+// FieldValues renders the referenced fields for explain output.
+func (e *Expression) FieldValues(t Telemetry, p Policy) map[string]string {
+	out := make(map[string]string, len(e.fields))
+	for _, name := range e.fields {
+		out[name] = exprFields[name].get(t, p).String()
+	}
+	return out
+}
+
+func CompileExpression(source string) (*Expression, error) {
+	tokens, err := lexExpression(source)
+	if err != nil {
+		return nil, err
+	}
+	ps := &exprParser{tokens: tokens, seen: make(map[string]bool)}
+	root, err := ps.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := ps.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at offset %d", tok.text, tok.pos)
+	}
+	if root.kind() != exprBool {
+		return nil, fmt.Errorf("expression must evaluate to a boolean, got %s", root.kind())
+	}
+	return &Expression{source: strings.TrimSpace(source), root: root, fields: ps.fields}, nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokOp
+	tokLParen
+	tokRParen
+)
+
+type exprToken struct {
+	kind tokenKind
+	text string
+	num  float64
+	pos  int
+}
+
+func lexExpression(src string) ([]exprToken, error) {
+	var tokens []exprToken
+	runes := []rune(src)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, exprToken{kind: tokLParen, text: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, exprToken{kind: tokRParen, text: ")", pos: i})
+			i++
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.' || unicode.IsLetter(runes[i])) {
+				i++
+			}
+			text := string(runes[start:i])
+			num, err := parseExprNumber(text)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at offset %d", text, start)
+			}
+			tokens = append(tokens, exprToken{kind: tokNumber, text: text, num: num, pos: start})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, exprToken{kind: tokIdent, text: strings.ToLower(string(runes[start:i])), pos: start})
+		default:
+			start := i
+			two := ""
+			if i+1 < len(runes) {
+				two = string(runes[i : i+2])
+			}
+			switch two {
+			case "&&", "||", ">=", "<=", "==", "!=":
+				tokens = append(tokens, exprToken{kind: tokOp, text: two, pos: start})
+				i += 2
+				continue
+			}
+			switch r {
+			case '>', '<', '!':
+				tokens = append(tokens, exprToken{kind: tokOp, text: string(r), pos: start})
+				i++
+			default:
+				return nil, fmt.Errorf("unexpected character %q at offset %d", r, start)
+			}
+		}
+	}
+	return append(tokens, exprToken{kind: tokEOF, text: "end of expression", pos: len(runes)}), nil
+}
+
+func parseExprNumber(text string) (float64, error) {
+	if strings.IndexFunc(text, unicode.IsLetter) >= 0 {
+		d, err := time.ParseDuration(text)
+		if err != nil {
+			return 0, err
+		}
+		return d.Seconds(), nil
+	}
+	return strconv.ParseFloat(text, 64)
+}
+
+type exprParser struct {
+	tokens []exprToken
+	pos    int
+	fields []string
+	seen   map[string]bool
+}
+
+func (ps *exprParser) peek() exprToken { return ps.tokens[ps.pos] }
+
+func (ps *exprParser) next() exprToken {
+	tok := ps.tokens[ps.pos]
+	if tok.kind != tokEOF {
+		ps.pos++
+	}
+	return tok
+}
+
+func (ps *exprParser) acceptLogic(symbol, word string) bool {
+	tok := ps.peek()
+	if (tok.kind == tokOp && tok.text == symbol) || (tok.kind == tokIdent && tok.text == word) {
+		ps.pos++
+		return true
+	}
+	return false
+}
+
+func (ps *exprParser) parseOr() (exprNode, error) {
+	left, err := ps.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for ps.acceptLogic("||", "or") {
+		right, err := ps.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if err := requireBool("or", left, right); err != nil {
+			return nil, err
+		}
+		left = logicNode{and: false, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (ps *exprParser) parseAnd() (exprNode, error) {
+	left, err := ps.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for ps.acceptLogic("&&", "and") {
+		right, err := ps.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if err := requireBool("and", left, right); err != nil {
+			return nil, err
+		}
+		left = logicNode{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (ps *exprParser) parseUnary() (exprNode, error) {
+	if ps.acceptLogic("!", "not") {
+		operand, err := ps.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if err := requireBool("not", operand); err != nil {
+			return nil, err
+		}
+		return notNode{operand: operand}, nil
+	}
+	return ps.parseCompare()
+}
+
+func (ps *exprParser) parseCompare() (exprNode, error) {
+	left, err := ps.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	tok := ps.peek()
+	if tok.kind != tokOp {
+		return left, nil
+	}
+	switch tok.text {
+	case ">", ">=", "<", "<=", "==", "!=":
+	default:
+		return left, nil
+	}
+	ps.next()
+	right, err := ps.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	if left.kind() != right.kind() {
+		return nil, fmt.Errorf("cannot compare %s with %s at offset %d", left.kind(), right.kind(), tok.pos)
+	}
+	if left.kind() == exprBool && tok.text != "==" && tok.text != "!=" {
+		return nil, fmt.Errorf("operator %q requires numbers at offset %d", tok.text, tok.pos)
+	}
+	return compareNode{op: tok.text, left: left, right: right}, nil
+}
+
+func (ps *exprParser) parseOperand() (exprNode, error) {
+	tok := ps.next()
+	switch tok.kind {
+	case tokNumber:
+		return literalNode{value: exprValue{kind: exprNumber, num: tok.num}}, nil
+	case tokLParen:
+		inner, err := ps.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := ps.next(); closing.kind != tokRParen {
+			return nil, fmt.Errorf("expected ')' at offset %d, got %q", closing.pos, closing.text)
+		}
+		return inner, nil
+	case tokIdent:
+		switch tok.text {
+		case "true", "false":
+			return literalNode{value: exprValue{kind: exprBool, b: tok.text == "true"}}, nil
+		case "and", "or", "not":
+			return nil, fmt.Errorf("unexpected %q at offset %d", tok.text, tok.pos)
+		}
+		field, ok := exprFields[tok.text]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q at offset %d", tok.text, tok.pos)
+		}
+		if !ps.seen[tok.text] {
+			ps.seen[tok.text] = true
+			ps.fields = append(ps.fields, tok.text)
+		}
+		return fieldNode{name: tok.text, field: field}, nil
+	default:
+		return nil, fmt.Errorf("unexpected %q at offset %d", tok.text, tok.pos)
+	}
+}
+
+func requireBool(op string, nodes ...exprNode) error {
+	for _, n := range nodes {
+		if n.kind() != exprBool {
+			return fmt.Errorf("operator %q requires boolean operands", op)
+		}
+	}
+	return nil
+}
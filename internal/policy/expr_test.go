@@ -0,0 +1,86 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompileExpressionEvaluates(t *testing.T) {
+	sample := Telemetry{
+		CPUPercent:    95,
+		CPUOverFor:    45 * time.Second,
+		MemoryMB:      512,
+		LogRepetition: 0.9,
+		LogEntropy:    0.1,
+		RawDiversity:  0.3,
+	}
+	p := Policy{MaxCPUPercent: 90, CPUWindow: 30 * time.Second, MaxLogRepetition: 0.8}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{"cpu_percent > 90", true},
+		{"cpu_percent > max_cpu_percent && cpu_over_for >= cpu_window", true},
+		{"cpu_over_for >= 1m", false},
+		{"cpu_over_for >= 45s and cpu_over_for < 1m30s", true},
+		{"log_repetition > max_log_repetition || log_entropy < 0.05", true},
+		{"not progress_like", true},
+		{"!(progress_like || raw_diversity >= 0.85)", true},
+		{"progress_like == false", true},
+		{"memory_mb != 512", false},
+		{"true && (false || cpu_percent <= .5)", false},
+	}
+	for _, tt := range tests {
+		expr, err := CompileExpression(tt.expr)
+		if err != nil {
+			t.Fatalf("CompileExpression(%q): %v", tt.expr, err)
+		}
+		if got := expr.Eval(sample, p); got != tt.want {
+			t.Fatalf("Eval(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestCompileExpressionErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"cpu_percent", "must evaluate to a boolean"},
+		{"gpu_percent > 10", "unknown field"},
+		{"cpu_percent > ", "unexpected"},
+		{"(cpu_percent > 10", "expected ')'"},
+		{"cpu_percent > 10 extra", "unexpected"},
+		{"progress_like > 1", "cannot compare"},
+		{"progress_like > true", "requires numbers"},
+		{"cpu_percent && progress_like", "requires boolean"},
+		{"cpu_over_for > 10parsecs", "invalid number"},
+		{"cpu_percent # 3", "unexpected character"},
+	}
+	for _, tt := range tests {
+		_, err := CompileExpression(tt.expr)
+		if err == nil {
+			t.Fatalf("expected error for %q", tt.expr)
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("error for %q = %q, want substring %q", tt.expr, err.Error(), tt.want)
+		}
+	}
+}
+
+func TestExpressionFieldsInOrder(t *testing.T) {
+	expr, err := CompileExpression("cpu_percent > 1 && memory_mb > 2 && cpu_percent < 100")
+	if err != nil {
+		t.Fatalf("CompileExpression: %v", err)
+	}
+	fields := expr.Fields()
+	if len(fields) != 2 || fields[0] != "cpu_percent" || fields[1] != "memory_mb" {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+	values := expr.FieldValues(Telemetry{CPUPercent: 12.5, MemoryMB: 64}, Policy{})
+	if values["cpu_percent"] != "12.5" || values["memory_mb"] != "64" {
+		t.Fatalf("unexpected field values %v", values)
+	}
+}
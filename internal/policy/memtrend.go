@@ -0,0 +1,49 @@
+package policy
+
+import (
+	"fmt"
+	"time"
+)
+
+// MemoryProjectionLimit is the memory level growth is projected against:
+// MemoryProjectionMB when set, otherwise MaxMemoryMB.
+func MemoryProjectionLimit(p Policy) float64 {
+	if p.MemoryProjectionMB > 0 {
+		return p.MemoryProjectionMB
+	}
+	return p.MaxMemoryMB
+}
+
+// ProjectedTimeToLimit extrapolates the current memory growth rate to the
+// projection limit. ok is false when there is no limit or memory is not
+// growing.
+func ProjectedTimeToLimit(t Telemetry, p Policy) (time.Duration, bool) {
+	limit := MemoryProjectionLimit(p)
+	if limit <= 0 || t.MemoryGrowthMBPerMin <= 0 {
+		return 0, false
+	}
+	remaining := limit - t.MemoryMB
+	if remaining <= 0 {
+		return 0, true
+	}
+	return time.Duration(remaining / t.MemoryGrowthMBPerMin * float64(time.Minute)), true
+}
+
+// MemoryTrendBreach reports whether memory is projected to reach the limit
+// within MemoryProjectionWindow.
+func MemoryTrendBreach(t Telemetry, p Policy) (bool, string) {
+	if p.MemoryProjectionWindow <= 0 {
+		return false, ""
+	}
+	ttl, ok := ProjectedTimeToLimit(t, p)
+	if !ok || ttl > p.MemoryProjectionWindow {
+		return false, ""
+	}
+	return true, fmt.Sprintf("memory projected to reach %.0fMB in %s (growing %.1fMB/min)",
+		MemoryProjectionLimit(p), roundSeconds(ttl), t.MemoryGrowthMBPerMin)
+}
+
+// MemoryTrendConfigured reports whether p acts on projected memory growth.
+func MemoryTrendConfigured(p Policy) bool {
+	return p.MemoryProjectionWindow > 0 && MemoryProjectionLimit(p) > 0
+}
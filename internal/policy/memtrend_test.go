@@ -0,0 +1,72 @@
+package policy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProjectedTimeToLimit(t *testing.T) {
+	p := Policy{MaxMemoryMB: 2048}
+
+	ttl, ok := ProjectedTimeToLimit(Telemetry{MemoryMB: 1024, MemoryGrowthMBPerMin: 256}, p)
+	if !ok || ttl != 4*time.Minute {
+		t.Fatalf("ttl = %s ok=%v, want 4m against max_memory_mb", ttl, ok)
+	}
+
+	p.MemoryProjectionMB = 4096
+	ttl, _ = ProjectedTimeToLimit(Telemetry{MemoryMB: 1024, MemoryGrowthMBPerMin: 256}, p)
+	if ttl != 12*time.Minute {
+		t.Fatalf("ttl = %s, want 12m against memory_projection_mb", ttl)
+	}
+
+	if _, ok := ProjectedTimeToLimit(Telemetry{MemoryMB: 1024, MemoryGrowthMBPerMin: -5}, p); ok {
+		t.Fatal("shrinking memory has no projection")
+	}
+	if _, ok := ProjectedTimeToLimit(Telemetry{MemoryMB: 1024, MemoryGrowthMBPerMin: 10}, Policy{}); ok {
+		t.Fatal("no limit means no projection")
+	}
+}
+
+func TestMemoryTrendBreachKillsBeforeCap(t *testing.T) {
+	p := Policy{MaxMemoryMB: 4096, MemoryProjectionWindow: 5 * time.Minute, MaxCPUPercent: 90, MinLogEntropy: 0.2, MaxLogRepetition: 0.8}
+	leaking := Telemetry{MemoryMB: 3000, MemoryGrowthMBPerMin: 300, LogEntropy: 0.9, ProgressLike: true, RawDiversity: 1}
+
+	out := NewThresholdDecider().Evaluate(leaking, p)
+	if out.Action != ActionKill || out.ExitReason != ExitReasonMemoryGrowth {
+		t.Fatalf("threshold: %+v", out)
+	}
+	if out.Reason != "memory projected to reach 4096MB in 3m39s (growing 300.0MB/min)" {
+		t.Fatalf("unexpected reason %q", out.Reason)
+	}
+	if out := NewWeightedScoreDecider().Evaluate(leaking, p); out.Action != ActionKill || out.ExitReason != ExitReasonMemoryGrowth {
+		t.Fatalf("weighted: %+v", out)
+	}
+
+	slow := leaking
+	slow.MemoryGrowthMBPerMin = 50
+	if out := NewThresholdDecider().Evaluate(slow, p); out.Action != ActionContinue {
+		t.Fatalf("slow growth should continue, got %+v", out)
+	}
+
+	if !MemoryTrendConfigured(p) || MemoryTrendConfigured(Policy{MaxMemoryMB: 4096}) {
+		t.Fatal("the trend check needs both a limit and a projection window")
+	}
+	// A leak needs no output to show; every engine acts on it before the
+	// log window fills, under the run's rollout mode.
+	quiet := Telemetry{LogWindowPending: true, MemoryMB: 3000, MemoryGrowthMBPerMin: 300}
+	quietSlow := quiet
+	quietSlow.MemoryGrowthMBPerMin = 50
+	shadow := p
+	shadow.RolloutMode = RolloutShadow
+	for name, d := range map[string]Decider{"threshold": NewThresholdDecider(), "weighted": NewWeightedScoreDecider(), "rules": NewRuleDecider()} {
+		if out := d.Evaluate(quiet, p); out.Action != ActionKill || out.ExitReason != ExitReasonMemoryGrowth {
+			t.Fatalf("%s: expected kill without log scores, got %+v", name, out)
+		}
+		if out := d.Evaluate(quietSlow, p); out.Action != ActionContinue {
+			t.Fatalf("%s: slow growth should continue, got %+v", name, out)
+		}
+		if out := d.Evaluate(quiet, shadow); out.Action != ActionLogOnly || out.IntendedAction != ActionKill {
+			t.Fatalf("%s: shadow mode should only log, got %+v", name, out)
+		}
+	}
+}
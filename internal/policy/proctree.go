@@ -0,0 +1,6 @@
+package policy
+
+// ProcessTreeConfigured reports whether p limits the supervised process tree.
+func ProcessTreeConfigured(p Policy) bool {
+	return p.MaxChildProcesses > 0 || p.MaxZombieProcesses > 0
+}
@@ -0,0 +1,18 @@
+package policy
+
+import "fmt"
+
+// PromptLoopBreach reports whether the same prompt has been sent to the model
+// more often than MaxPromptRepetition allows among the recent requests seen by
+// the LLM proxy. Each resend is paid for, so it is treated like a loop.
+func PromptLoopBreach(t Telemetry, p Policy) (bool, string) {
+	if p.MaxPromptRepetition <= 0 || t.PromptRepetition <= p.MaxPromptRepetition {
+		return false, ""
+	}
+	return true, fmt.Sprintf("identical prompt sent %d times (limit %d)", t.PromptRepetition, p.MaxPromptRepetition)
+}
+
+// PromptLoopConfigured reports whether p limits prompt repetition.
+func PromptLoopConfigured(p Policy) bool {
+	return p.MaxPromptRepetition > 0
+}
@@ -0,0 +1,123 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultDecisionEngine is used when no engine is selected for a run.
+const DefaultDecisionEngine = DecisionEngineName
+
+type EngineFactory func() Decider
+
+type EngineRegistration struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+	factory     EngineFactory
+}
+
+// New builds a fresh Decider for the registered engine.
+func (r EngineRegistration) New() Decider {
+	return r.factory()
+}
+
+var engineRegistry = struct {
+	mu      sync.RWMutex
+	engines map[string]EngineRegistration
+}{engines: make(map[string]EngineRegistration)}
+
+func init() {
+	MustRegisterEngine(DecisionEngineName, DecisionEngineVersion,
+		"CPU, memory, repetition and entropy thresholds with a progress guard",
+		NewThresholdDecider)
+	MustRegisterEngine(WeightedScoreEngineName, WeightedScoreEngineVersion,
+		"weighted blend of CPU, repetition and entropy pressure with score cut-offs",
+		NewWeightedScoreDecider)
+	MustRegisterEngine(RuleEngineName, RuleEngineVersion,
+		"declarative YAML rules over telemetry fields (built-in rules unless --policy-file is set)",
+		NewRuleDecider)
+}
+
+func normalizeEngineName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// RegisterEngine makes a decision engine selectable by name. Names are
+// case-insensitive and versions must be semver so trace contracts stay valid.
+func RegisterEngine(name, version, description string, factory EngineFactory) error {
+	key := normalizeEngineName(name)
+	if key == "" {
+		return fmt.Errorf("decision engine name is required")
+	}
+	if !IsValidEngineVersion(version) {
+		return fmt.Errorf("decision engine %q has invalid version %q", key, version)
+	}
+	if factory == nil {
+		return fmt.Errorf("decision engine %q has no factory", key)
+	}
+
+	engineRegistry.mu.Lock()
+	defer engineRegistry.mu.Unlock()
+	if _, exists := engineRegistry.engines[key]; exists {
+		return fmt.Errorf("decision engine %q already registered", key)
+	}
+	engineRegistry.engines[key] = EngineRegistration{
+		Name:        key,
+		Version:     strings.TrimSpace(version),
+		Description: strings.TrimSpace(description),
+		factory:     factory,
+	}
+	return nil
+}
+
+func MustRegisterEngine(name, version, description string, factory EngineFactory) {
+	if err := RegisterEngine(name, version, description, factory); err != nil {
+		panic(err)
+	}
+}
+
+func LookupEngine(name string) (EngineRegistration, bool) {
+	key := normalizeEngineName(name)
+	if key == "" {
+		key = DefaultDecisionEngine
+	}
+	engineRegistry.mu.RLock()
+	defer engineRegistry.mu.RUnlock()
+	reg, ok := engineRegistry.engines[key]
+	return reg, ok
+}
+
+// ResolveEngine returns the registration for name, falling back to the
+// default engine when name is empty.
+func ResolveEngine(name string) (EngineRegistration, error) {
+	reg, ok := LookupEngine(name)
+	if !ok {
+		return EngineRegistration{}, fmt.Errorf("unknown decision engine %q (available: %s)",
+			normalizeEngineName(name), strings.Join(RegisteredEngineNames(), ", "))
+	}
+	return reg, nil
+}
+
+func RegisteredEngines() []EngineRegistration {
+	engineRegistry.mu.RLock()
+	out := make([]EngineRegistration, 0, len(engineRegistry.engines))
+	for _, reg := range engineRegistry.engines {
+		out = append(out, reg)
+	}
+	engineRegistry.mu.RUnlock()
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func RegisteredEngineNames() []string {
+	engines := RegisteredEngines()
+	names := make([]string, 0, len(engines))
+	for _, reg := range engines {
+		names = append(names, reg.Name)
+	}
+	return names
+}
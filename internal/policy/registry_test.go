@@ -0,0 +1,138 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegisteredEnginesIncludeBuiltins(t *testing.T) {
+	names := RegisteredEngineNames()
+	want := map[string]bool{DecisionEngineName: false, WeightedScoreEngineName: false}
+	for _, name := range names {
+		if _, ok := want[name]; ok {
+			want[name] = true
+		}
+	}
+	for name, found := range want {
+		if !found {
+			t.Fatalf("expected builtin engine %q in %v", name, names)
+		}
+	}
+}
+
+func TestResolveEngineDefaultsAndNormalizes(t *testing.T) {
+	reg, err := ResolveEngine("")
+	if err != nil {
+		t.Fatalf("ResolveEngine(\"\"): %v", err)
+	}
+	if reg.Name != DefaultDecisionEngine || reg.Version != DecisionEngineVersion {
+		t.Fatalf("expected default engine %s@%s, got %s@%s", DefaultDecisionEngine, DecisionEngineVersion, reg.Name, reg.Version)
+	}
+
+	reg, err = ResolveEngine("  Weighted-Score-Decider ")
+	if err != nil {
+		t.Fatalf("ResolveEngine(weighted): %v", err)
+	}
+	if reg.Name != WeightedScoreEngineName {
+		t.Fatalf("expected %q, got %q", WeightedScoreEngineName, reg.Name)
+	}
+	if _, ok := reg.New().(WeightedScoreDecider); !ok {
+		t.Fatalf("expected WeightedScoreDecider, got %T", reg.New())
+	}
+}
+
+func TestResolveEngineUnknown(t *testing.T) {
+	_, err := ResolveEngine("does-not-exist")
+	if err == nil {
+		t.Fatal("expected error for unknown engine")
+	}
+	if !strings.Contains(err.Error(), DecisionEngineName) {
+		t.Fatalf("expected error to list available engines, got %q", err.Error())
+	}
+}
+
+func TestRegisterEngineRejectsInvalidInput(t *testing.T) {
+	if err := RegisterEngine("", "1.0.0", "", NewThresholdDecider); err == nil {
+		t.Fatal("expected error for empty name")
+	}
+	if err := RegisterEngine("bad-version-engine", "1.0", "", NewThresholdDecider); err == nil {
+		t.Fatal("expected error for invalid version")
+	}
+	if err := RegisterEngine("nil-factory-engine", "1.0.0", "", nil); err == nil {
+		t.Fatal("expected error for nil factory")
+	}
+	if err := RegisterEngine(DecisionEngineName, "9.9.9", "", NewThresholdDecider); err == nil {
+		t.Fatal("expected error for duplicate registration")
+	}
+}
+
+func TestEngineRegistrationContract(t *testing.T) {
+	reg, err := ResolveEngine(WeightedScoreEngineName)
+	if err != nil {
+		t.Fatalf("ResolveEngine: %v", err)
+	}
+	contract := reg.Contract(RolloutShadow)
+	if contract.EngineName != WeightedScoreEngineName || contract.EngineVersion != WeightedScoreEngineVersion {
+		t.Fatalf("unexpected contract engine %s@%s", contract.EngineName, contract.EngineVersion)
+	}
+	if contract.ContractVersion != DecisionContractVersion {
+		t.Fatalf("expected contract version %q, got %q", DecisionContractVersion, contract.ContractVersion)
+	}
+	if contract.RolloutMode != string(RolloutShadow) {
+		t.Fatalf("expected rollout mode shadow, got %q", contract.RolloutMode)
+	}
+}
+
+func TestWeightedScoreDeciderActions(t *testing.T) {
+	d := NewWeightedScoreDecider()
+	p := Policy{
+		MaxCPUPercent:    90,
+		CPUWindow:        30 * time.Second,
+		MinLogEntropy:    0.20,
+		MaxLogRepetition: 0.80,
+	}
+
+	calm := d.Evaluate(Telemetry{CPUPercent: 20, LogEntropy: 0.9, LogRepetition: 0.1}, p)
+	if calm.Action != ActionContinue {
+		t.Fatalf("expected CONTINUE for calm telemetry, got %s (%s)", calm.Action, calm.Reason)
+	}
+
+	runaway := d.Evaluate(Telemetry{
+		CPUPercent:    99,
+		CPUOverFor:    40 * time.Second,
+		LogEntropy:    0.05,
+		LogRepetition: 0.95,
+	}, p)
+	if runaway.Action != ActionKill {
+		t.Fatalf("expected KILL for runaway telemetry, got %s (%s)", runaway.Action, runaway.Reason)
+	}
+
+	guarded := d.Evaluate(Telemetry{
+		CPUPercent:    99,
+		CPUOverFor:    40 * time.Second,
+		LogEntropy:    0.05,
+		LogRepetition: 0.95,
+		ProgressLike:  true,
+		RawDiversity:  0.95,
+	}, p)
+	if guarded.Action != ActionContinue {
+		t.Fatalf("expected progress guard to suppress action, got %s (%s)", guarded.Action, guarded.Reason)
+	}
+}
+
+func TestWeightedScoreDeciderHonorsRollout(t *testing.T) {
+	d := NewWeightedScoreDecider()
+	p := Policy{MaxMemoryMB: 100, RolloutMode: RolloutShadow}
+
+	out := d.Evaluate(Telemetry{MemoryMB: 512}, p)
+	if out.Action != ActionLogOnly {
+		t.Fatalf("expected LOG_ONLY in shadow mode, got %s", out.Action)
+	}
+	if out.IntendedAction != ActionKill {
+		t.Fatalf("expected intended KILL, got %s", out.IntendedAction)
+	}
+	if !strings.HasPrefix(out.Reason, "Shadow mode: would KILL.") {
+		t.Fatalf("unexpected reason %q", out.Reason)
+	}
+}
@@ -0,0 +1,497 @@
+package policy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	RuleEngineName    = "rule-decider"
+	RuleEngineVersion = "1.1.0"
+
+	// PolicyFileVersion is the only policy file schema version understood today.
+	PolicyFileVersion = 1
+)
+
+// PolicyFile is the on-disk YAML shape of a declarative policy.
+type PolicyFile struct {
+	Version     int              `yaml:"version"`
+	Name        string           `yaml:"name"`
+	Description string           `yaml:"description,omitempty"`
+	Thresholds  PolicyFileLimit  `yaml:"thresholds,omitempty"`
+	Rules       []PolicyFileRule `yaml:"rules"`
+}
+
+// PolicyFileLimit overrides the thresholds a run would otherwise use. Unset
+// values keep the CLI/config defaults.
+type PolicyFileLimit struct {
+	MaxCPUPercent    *float64 `yaml:"max_cpu_percent,omitempty"`
+	CPUWindow        string   `yaml:"cpu_window,omitempty"`
+	MaxMemoryMB      *float64 `yaml:"max_memory_mb,omitempty"`
+	MaxLogRepetition *float64 `yaml:"max_log_repetition,omitempty"`
+	MinLogEntropy    *float64 `yaml:"min_log_entropy,omitempty"`
+	MaxOutputIdle    string   `yaml:"max_output_idle,omitempty"`
+	MaxCPUIdle       string   `yaml:"max_cpu_idle,omitempty"`
+	MaxHeartbeatAge  string   `yaml:"max_heartbeat_age,omitempty"`
+
+	MemoryProjectionMB     *float64 `yaml:"memory_projection_mb,omitempty"`
+	MemoryProjectionWindow string   `yaml:"memory_projection_window,omitempty"`
+
+	MaxChildProcesses  *int `yaml:"max_child_processes,omitempty"`
+	MaxZombieProcesses *int `yaml:"max_zombie_processes,omitempty"`
+
+	MaxStderrLinesPerSec  *float64 `yaml:"max_stderr_lines_per_sec,omitempty"`
+	MaxStderrRepetition   *float64 `yaml:"max_stderr_repetition,omitempty"`
+	MaxRepeatedExceptions *int     `yaml:"max_repeated_exceptions,omitempty"`
+
+	MaxErrorRate          *float64 `yaml:"max_error_rate,omitempty"`
+	MaxToolCallRepetition *int     `yaml:"max_tool_call_repetition,omitempty"`
+	MaxPromptRepetition   *int     `yaml:"max_prompt_repetition,omitempty"`
+
+	MaxCostUSD            *float64 `yaml:"max_cost_usd,omitempty"`
+	MaxTokens             *int     `yaml:"max_tokens,omitempty"`
+	WorkspaceDailyCostUSD *float64 `yaml:"workspace_daily_cost_usd,omitempty"`
+	WorkspaceDailyTokens  *int     `yaml:"workspace_daily_tokens,omitempty"`
+}
+
+type PolicyFileRule struct {
+	Name   string `yaml:"name"`
+	When   string `yaml:"when"`
+	Action string `yaml:"action"`
+	Reason string `yaml:"reason,omitempty"`
+	// ExitReason overrides the incident exit reason when the rule kills or
+	// restarts, e.g. STALL_DETECTED. Defaults to LOOP_DETECTED.
+	ExitReason string `yaml:"exit_reason,omitempty"`
+}
+
+type Rule struct {
+	Name       string
+	When       *Expression
+	Action     Action
+	Reason     string
+	ExitReason string
+}
+
+// RuleSet is a compiled policy file.
+type RuleSet struct {
+	Name        string
+	Version     int
+	Description string
+	Rules       []Rule
+
+	limits          PolicyFileLimit
+	cpuWindow       time.Duration
+	maxOutputIdle   time.Duration
+	maxCPUIdle      time.Duration
+	maxHeartbeatAge time.Duration
+	memoryWindow    time.Duration
+}
+
+func ParseAction(raw string) (Action, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "continue":
+		return ActionContinue, nil
+	case "alert":
+		return ActionAlert, nil
+	case "kill":
+		return ActionKill, nil
+	case "restart":
+		return ActionRestart, nil
+	case "log_only", "log-only":
+		return ActionLogOnly, nil
+	case "pause":
+		return ActionPause, nil
+	default:
+		return ActionContinue, fmt.Errorf("unknown action %q (expected continue|alert|kill|restart|pause|log_only)", raw)
+	}
+}
+
+func actionSeverity(a Action) int {
+	switch a {
+	case ActionKill, ActionRestart:
+		return 4
+	case ActionPause:
+		return 3
+	case ActionAlert:
+		return 2
+	case ActionLogOnly:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func LoadRuleSetFile(path string) (*RuleSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read policy file: %w", err)
+	}
+	rs, err := ParseRuleSet(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rs, nil
+}
+
+func ParseRuleSet(data []byte) (*RuleSet, error) {
+	var file PolicyFile
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("policy file is empty")
+		}
+		return nil, fmt.Errorf("parse policy file: %w", err)
+	}
+	return CompileRuleSet(file)
+}
+
+func CompileRuleSet(file PolicyFile) (*RuleSet, error) {
+	if file.Version != PolicyFileVersion {
+		return nil, fmt.Errorf("unsupported policy file version %d (expected %d)", file.Version, PolicyFileVersion)
+	}
+	if len(file.Rules) == 0 {
+		return nil, fmt.Errorf("policy file must define at least one rule")
+	}
+
+	rs := &RuleSet{
+		Name:        strings.TrimSpace(file.Name),
+		Version:     file.Version,
+		Description: strings.TrimSpace(file.Description),
+		limits:      file.Thresholds,
+	}
+	if rs.Name == "" {
+		rs.Name = "unnamed"
+	}
+	durations := []struct {
+		key string
+		raw string
+		dst *time.Duration
+	}{
+		{"cpu_window", file.Thresholds.CPUWindow, &rs.cpuWindow},
+		{"max_output_idle", file.Thresholds.MaxOutputIdle, &rs.maxOutputIdle},
+		{"max_cpu_idle", file.Thresholds.MaxCPUIdle, &rs.maxCPUIdle},
+		{"max_heartbeat_age", file.Thresholds.MaxHeartbeatAge, &rs.maxHeartbeatAge},
+		{"memory_projection_window", file.Thresholds.MemoryProjectionWindow, &rs.memoryWindow},
+	}
+	for _, d := range durations {
+		raw := strings.TrimSpace(d.raw)
+		if raw == "" {
+			continue
+		}
+		v, err := time.ParseDuration(raw)
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("thresholds.%s: invalid duration %q", d.key, raw)
+		}
+		*d.dst = v
+	}
+
+	seen := make(map[string]bool, len(file.Rules))
+	for i, spec := range file.Rules {
+		name := strings.TrimSpace(spec.Name)
+		if name == "" {
+			return nil, fmt.Errorf("rules[%d]: name is required", i)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("rules[%d]: duplicate rule name %q", i, name)
+		}
+		seen[name] = true
+		if strings.TrimSpace(spec.When) == "" {
+			return nil, fmt.Errorf("rule %q: when is required", name)
+		}
+		expr, err := CompileExpression(spec.When)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", name, err)
+		}
+		action, err := ParseAction(spec.Action)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", name, err)
+		}
+		rs.Rules = append(rs.Rules, Rule{
+			Name:       name,
+			When:       expr,
+			Action:     action,
+			Reason:     strings.TrimSpace(spec.Reason),
+			ExitReason: strings.ToUpper(strings.TrimSpace(spec.ExitReason)),
+		})
+	}
+	return rs, nil
+}
+
+// ApplyThresholds returns p with any thresholds set in the policy file.
+func (rs *RuleSet) ApplyThresholds(p Policy) Policy {
+	if rs.limits.MaxCPUPercent != nil {
+		p.MaxCPUPercent = *rs.limits.MaxCPUPercent
+	}
+	if rs.cpuWindow > 0 {
+		p.CPUWindow = rs.cpuWindow
+	}
+	if rs.limits.MaxMemoryMB != nil {
+		p.MaxMemoryMB = *rs.limits.MaxMemoryMB
+	}
+	if rs.limits.MaxLogRepetition != nil {
+		p.MaxLogRepetition = *rs.limits.MaxLogRepetition
+	}
+	if rs.limits.MinLogEntropy != nil {
+		p.MinLogEntropy = *rs.limits.MinLogEntropy
+	}
+	if rs.maxOutputIdle > 0 {
+		p.MaxOutputIdle = rs.maxOutputIdle
+	}
+	if rs.maxCPUIdle > 0 {
+		p.MaxCPUIdle = rs.maxCPUIdle
+	}
+	if rs.maxHeartbeatAge > 0 {
+		p.MaxHeartbeatAge = rs.maxHeartbeatAge
+	}
+	if rs.limits.MemoryProjectionMB != nil {
+		p.MemoryProjectionMB = *rs.limits.MemoryProjectionMB
+	}
+	if rs.memoryWindow > 0 {
+		p.MemoryProjectionWindow = rs.memoryWindow
+	}
+	if rs.limits.MaxChildProcesses != nil {
+		p.MaxChildProcesses = *rs.limits.MaxChildProcesses
+	}
+	if rs.limits.MaxZombieProcesses != nil {
+		p.MaxZombieProcesses = *rs.limits.MaxZombieProcesses
+	}
+	if rs.limits.MaxStderrLinesPerSec != nil {
+		p.MaxStderrLinesPerSec = *rs.limits.MaxStderrLinesPerSec
+	}
+	if rs.limits.MaxStderrRepetition != nil {
+		p.MaxStderrRepetition = *rs.limits.MaxStderrRepetition
+	}
+	if rs.limits.MaxRepeatedExceptions != nil {
+		p.MaxRepeatedExceptions = *rs.limits.MaxRepeatedExceptions
+	}
+	if rs.limits.MaxErrorRate != nil {
+		p.MaxErrorRate = *rs.limits.MaxErrorRate
+	}
+	if rs.limits.MaxToolCallRepetition != nil {
+		p.MaxToolCallRepetition = *rs.limits.MaxToolCallRepetition
+	}
+	if rs.limits.MaxPromptRepetition != nil {
+		p.MaxPromptRepetition = *rs.limits.MaxPromptRepetition
+	}
+	if rs.limits.MaxCostUSD != nil {
+		p.MaxCostUSD = *rs.limits.MaxCostUSD
+	}
+	if rs.limits.MaxTokens != nil {
+		p.MaxTokens = *rs.limits.MaxTokens
+	}
+	if rs.limits.WorkspaceDailyCostUSD != nil {
+		p.WorkspaceDailyCostUSD = *rs.limits.WorkspaceDailyCostUSD
+	}
+	if rs.limits.WorkspaceDailyTokens != nil {
+		p.WorkspaceDailyTokens = *rs.limits.WorkspaceDailyTokens
+	}
+	return p
+}
+
+type RuleMatch struct {
+	Name    string            `json:"name"`
+	When    string            `json:"when"`
+	Action  string            `json:"action"`
+	Matched bool              `json:"matched"`
+	Fields  map[string]string `json:"fields"`
+	// Pending is set when the rule reads log window scores and the sample
+	// was taken before the window filled; the rule is not evaluated.
+	Pending bool `json:"pending,omitempty"`
+}
+
+type RuleExplanation struct {
+	Policy   string      `json:"policy"`
+	Rules    []RuleMatch `json:"rules"`
+	Fired    string      `json:"fired,omitempty"`
+	Action   string      `json:"action"`
+	Intended string      `json:"intended_action"`
+	Reason   string      `json:"reason"`
+	// ExitReason is set when the fired rule names one.
+	ExitReason string   `json:"exit_reason,omitempty"`
+	Decision   Decision `json:"-"`
+}
+
+// Explain evaluates every rule against the sample. The most severe matching
+// action wins; ties go to the rule listed first in the file. Rules that read
+// log window scores are skipped until the window has filled.
+func (rs *RuleSet) Explain(t Telemetry, p Policy) RuleExplanation {
+	out := RuleExplanation{Policy: rs.Name, Rules: make([]RuleMatch, 0, len(rs.Rules))}
+	var fired *Rule
+	for i := range rs.Rules {
+		rule := &rs.Rules[i]
+		pending := t.LogWindowPending && rule.When.UsesLogWindow()
+		matched := !pending && rule.When.Eval(t, p)
+		out.Rules = append(out.Rules, RuleMatch{
+			Name:    rule.Name,
+			When:    rule.When.String(),
+			Action:  rule.Action.String(),
+			Matched: matched,
+			Fields:  rule.When.FieldValues(t, p),
+			Pending: pending,
+		})
+		if matched && (fired == nil || actionSeverity(rule.Action) > actionSeverity(fired.Action)) {
+			fired = rule
+		}
+	}
+
+	if fired == nil || fired.Action == ActionContinue {
+		reason := "No rules matched"
+		if fired != nil {
+			out.Fired = fired.Name
+			reason = ruleReason(fired)
+		}
+		out.Decision = Decision{Action: ActionContinue, IntendedAction: ActionContinue, Reason: reason}
+	} else {
+		out.Fired = fired.Name
+		action := fired.Action
+		// A restarted or resumed run would draw on the same exhausted budget.
+		if action == ActionKill && fired.ExitReason != ExitReasonBudget {
+			action = breachAction(p)
+		}
+		out.Decision = applyRollout(action, ruleReason(fired), t, p)
+		out.Decision.ExitReason = fired.ExitReason
+	}
+
+	out.Action = out.Decision.Action.String()
+	out.Intended = out.Decision.IntendedAction.String()
+	out.Reason = out.Decision.Reason
+	out.ExitReason = out.Decision.ExitReason
+	return out
+}
+
+func ruleReason(rule *Rule) string {
+	if rule.Reason != "" {
+		return fmt.Sprintf("rule %s: %s", rule.Name, rule.Reason)
+	}
+	return fmt.Sprintf("rule %s: %s", rule.Name, rule.When.String())
+}
+
+// RuleDecider evaluates a compiled RuleSet.
+type RuleDecider struct {
+	RuleSet *RuleSet
+}
+
+// NewRuleDecider returns a rule engine running the built-in rule set, which
+// mirrors threshold-decider.
+func NewRuleDecider() Decider {
+	return NewRuleDeciderFor(DefaultRuleSet())
+}
+
+func NewRuleDeciderFor(rs *RuleSet) Decider {
+	return RuleDecider{RuleSet: rs}
+}
+
+func (d RuleDecider) Evaluate(t Telemetry, p Policy) Decision {
+	return d.RuleSet.Explain(t, p).Decision
+}
+
+const defaultRuleSetYAML = `version: 1
+name: builtin
+description: Rule-language equivalent of threshold-decider.
+rules:
+  - name: budget
+    when: >-
+      (max_cost_usd > 0 && run_cost_usd >= max_cost_usd)
+      || (max_tokens > 0 && run_tokens >= max_tokens)
+      || (workspace_daily_cost_usd > 0 && workspace_cost_usd >= workspace_daily_cost_usd)
+      || (workspace_daily_tokens > 0 && workspace_tokens >= workspace_daily_tokens)
+    action: kill
+    reason: spend budget reached
+    exit_reason: BUDGET_EXCEEDED
+  - name: memory-limit
+    when: max_memory_mb > 0 && memory_mb > max_memory_mb
+    action: kill
+    reason: memory exceeded max_memory_mb
+  - name: memory-growth
+    when: >-
+      memory_projection_window > 0 && memory_time_to_limit >= 0
+      && memory_time_to_limit <= memory_projection_window
+    action: kill
+    reason: memory projected to reach memory_projection_mb
+    exit_reason: MEMORY_GROWTH_DETECTED
+  - name: fork-bomb
+    when: max_child_processes > 0 && child_processes > max_child_processes
+    action: kill
+    reason: too many child processes
+    exit_reason: FORK_BOMB_DETECTED
+  - name: repeated-exception
+    when: max_repeated_exceptions > 0 && repeated_exceptions > max_repeated_exceptions
+    action: kill
+    reason: same exception repeated
+    exit_reason: REPEATED_EXCEPTION_DETECTED
+  - name: tool-call-loop
+    when: max_tool_call_repetition > 0 && tool_call_repetition > max_tool_call_repetition
+    action: kill
+    reason: same tool call repeated
+    exit_reason: TOOL_CALL_LOOP_DETECTED
+  - name: prompt-loop
+    when: max_prompt_repetition > 0 && prompt_repetition > max_prompt_repetition
+    action: kill
+    reason: identical prompt resent
+    exit_reason: PROMPT_LOOP_DETECTED
+  - name: stall
+    when: >-
+      (max_output_idle > 0 || max_cpu_idle > 0)
+      && (max_output_idle == 0 || output_idle_for >= max_output_idle)
+      && (max_cpu_idle == 0 || cpu_idle_for >= max_cpu_idle)
+    action: kill
+    reason: no output and no CPU activity
+    exit_reason: STALL_DETECTED
+  - name: heartbeat-stale
+    when: max_heartbeat_age > 0 && heartbeat_age > max_heartbeat_age
+    action: kill
+    reason: heartbeat file stopped updating
+    exit_reason: STALL_DETECTED
+  - name: runaway-loop
+    when: >-
+      max_cpu_percent > 0 && cpu_percent > max_cpu_percent && cpu_over_for >= cpu_window
+      && ((max_log_repetition > 0 && log_repetition > max_log_repetition)
+        || (min_log_entropy > 0 && log_entropy < min_log_entropy))
+      && !(progress_like && raw_diversity >= 0.85)
+    action: kill
+    reason: sustained CPU with looping output
+  - name: cpu-hot
+    when: max_cpu_percent > 0 && cpu_percent > max_cpu_percent && cpu_over_for >= cpu_window
+    action: alert
+    reason: sustained CPU above max_cpu_percent
+  - name: log-loop
+    when: >-
+      (max_log_repetition > 0 && log_repetition > max_log_repetition)
+      || (min_log_entropy > 0 && log_entropy < min_log_entropy)
+    action: alert
+    reason: repetitive low-entropy output
+  - name: zombie-accumulation
+    when: max_zombie_processes > 0 && zombie_processes > max_zombie_processes
+    action: alert
+    reason: unreaped zombie processes accumulating
+  - name: stderr-flood
+    when: max_stderr_lines_per_sec > 0 && stderr_lines_per_sec > max_stderr_lines_per_sec
+    action: alert
+    reason: stderr rate above max_stderr_lines_per_sec
+  - name: stderr-loop
+    when: max_stderr_repetition > 0 && stderr_repetition > max_stderr_repetition
+    action: alert
+    reason: repetitive stderr output
+  - name: error-rate
+    when: max_error_rate > 0 && error_rate > max_error_rate
+    action: alert
+    reason: structured error rate above max_error_rate
+`
+
+// DefaultRuleSet returns the built-in policy used when rule-decider runs
+// without a policy file.
+func DefaultRuleSet() *RuleSet {
+	rs, err := ParseRuleSet([]byte(defaultRuleSetYAML))
+	if err != nil {
+		panic(fmt.Sprintf("builtin rule set: %v", err))
+	}
+	return rs
+}
@@ -0,0 +1,248 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testPolicyYAML = `version: 1
+name: strict
+thresholds:
+  max_cpu_percent: 80
+  cpu_window: 20s
+  min_log_entropy: 0.3
+rules:
+  - name: hot
+    when: cpu_percent > max_cpu_percent
+    action: alert
+  - name: runaway-loop
+    when: cpu_percent > max_cpu_percent && cpu_over_for >= cpu_window && log_entropy < min_log_entropy
+    action: kill
+    reason: looping under load
+`
+
+func TestParseRuleSetAppliesThresholds(t *testing.T) {
+	rs, err := ParseRuleSet([]byte(testPolicyYAML))
+	if err != nil {
+		t.Fatalf("ParseRuleSet: %v", err)
+	}
+	if rs.Name != "strict" || len(rs.Rules) != 2 {
+		t.Fatalf("unexpected rule set %s with %d rules", rs.Name, len(rs.Rules))
+	}
+	p := rs.ApplyThresholds(Policy{MaxCPUPercent: 60, MaxLogRepetition: 0.8, MinLogEntropy: 0.2})
+	if p.MaxCPUPercent != 80 || p.CPUWindow != 20*time.Second || p.MinLogEntropy != 0.3 {
+		t.Fatalf("thresholds not applied: %+v", p)
+	}
+	if p.MaxLogRepetition != 0.8 {
+		t.Fatalf("expected unset threshold to be preserved, got %.2f", p.MaxLogRepetition)
+	}
+}
+
+func TestRuleSetExplainMostSevereRuleWins(t *testing.T) {
+	rs, err := ParseRuleSet([]byte(testPolicyYAML))
+	if err != nil {
+		t.Fatalf("ParseRuleSet: %v", err)
+	}
+	p := rs.ApplyThresholds(Policy{})
+
+	out := rs.Explain(Telemetry{CPUPercent: 95, CPUOverFor: 25 * time.Second, LogEntropy: 0.1}, p)
+	if out.Fired != "runaway-loop" {
+		t.Fatalf("expected runaway-loop to fire, got %q", out.Fired)
+	}
+	if out.Decision.Action != ActionKill {
+		t.Fatalf("expected KILL, got %s", out.Decision.Action)
+	}
+	if out.Reason != "rule runaway-loop: looping under load" {
+		t.Fatalf("unexpected reason %q", out.Reason)
+	}
+	if !out.Rules[0].Matched || !out.Rules[1].Matched {
+		t.Fatalf("expected both rules to match: %+v", out.Rules)
+	}
+
+	out = rs.Explain(Telemetry{CPUPercent: 95, CPUOverFor: 5 * time.Second, LogEntropy: 0.1}, p)
+	if out.Fired != "hot" || out.Decision.Action != ActionAlert {
+		t.Fatalf("expected hot ALERT, got %q %s", out.Fired, out.Decision.Action)
+	}
+	if out.Rules[0].Fields["cpu_percent"] != "95" {
+		t.Fatalf("expected field values in explanation, got %v", out.Rules[0].Fields)
+	}
+
+	out = rs.Explain(Telemetry{CPUPercent: 10}, p)
+	if out.Fired != "" || out.Decision.Action != ActionContinue {
+		t.Fatalf("expected no rule to fire, got %q %s", out.Fired, out.Decision.Action)
+	}
+}
+
+func TestRuleDeciderHonorsRolloutAndRestart(t *testing.T) {
+	rs, err := ParseRuleSet([]byte(testPolicyYAML))
+	if err != nil {
+		t.Fatalf("ParseRuleSet: %v", err)
+	}
+	d := NewRuleDeciderFor(rs)
+	sample := Telemetry{CPUPercent: 95, CPUOverFor: 25 * time.Second, LogEntropy: 0.1}
+
+	p := rs.ApplyThresholds(Policy{RolloutMode: RolloutShadow})
+	out := d.Evaluate(sample, p)
+	if out.Action != ActionLogOnly || out.IntendedAction != ActionKill {
+		t.Fatalf("expected shadow LOG_ONLY/KILL, got %s/%s", out.Action, out.IntendedAction)
+	}
+
+	p = rs.ApplyThresholds(Policy{RestartOnBreach: true})
+	out = d.Evaluate(sample, p)
+	if out.Action != ActionRestart {
+		t.Fatalf("expected RESTART with RestartOnBreach, got %s", out.Action)
+	}
+}
+
+func TestParseRuleSetErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{"empty", "", "empty"},
+		{"version", "version: 2\nrules:\n  - name: a\n    when: true\n    action: alert\n", "unsupported policy file version"},
+		{"no rules", "version: 1\n", "at least one rule"},
+		{"unknown key", "version: 1\nrulez: []\n", "parse policy file"},
+		{"missing name", "version: 1\nrules:\n  - when: true\n    action: alert\n", "name is required"},
+		{"duplicate", "version: 1\nrules:\n  - name: a\n    when: true\n    action: alert\n  - name: a\n    when: true\n    action: kill\n", "duplicate rule name"},
+		{"bad action", "version: 1\nrules:\n  - name: a\n    when: true\n    action: explode\n", "unknown action"},
+		{"bad expr", "version: 1\nrules:\n  - name: a\n    when: cpu_percent >\n    action: kill\n", `rule "a"`},
+		{"bad window", "version: 1\nthresholds:\n  cpu_window: soon\nrules:\n  - name: a\n    when: true\n    action: kill\n", "cpu_window"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRuleSet([]byte(tt.yaml))
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error %q does not contain %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadRuleSetFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte(testPolicyYAML), 0o600); err != nil {
+		t.Fatalf("write policy: %v", err)
+	}
+	rs, err := LoadRuleSetFile(path)
+	if err != nil {
+		t.Fatalf("LoadRuleSetFile: %v", err)
+	}
+	if rs.Name != "strict" {
+		t.Fatalf("expected strict, got %q", rs.Name)
+	}
+	if _, err := LoadRuleSetFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+}
+
+// The built-in rule set is meant to mirror threshold-decider; keep the two in
+// step on representative samples.
+func TestDefaultRuleSetMatchesThresholdDecider(t *testing.T) {
+	p := Policy{
+		MaxCPUPercent:    90,
+		CPUWindow:        30 * time.Second,
+		MaxMemoryMB:      1024,
+		MinLogEntropy:    0.20,
+		MaxLogRepetition: 0.80,
+		MaxOutputIdle:    2 * time.Minute,
+		MaxCPUIdle:       2 * time.Minute,
+		MaxHeartbeatAge:  time.Minute,
+
+		MemoryProjectionMB:     4096,
+		MemoryProjectionWindow: 5 * time.Minute,
+		MaxChildProcesses:      64,
+		MaxZombieProcesses:     10,
+		MaxStderrLinesPerSec:   50,
+		MaxStderrRepetition:    0.9,
+		MaxRepeatedExceptions:  5,
+		MaxErrorRate:           0.5,
+		MaxToolCallRepetition:  6,
+		MaxPromptRepetition:    4,
+		MaxCostUSD:             2.5,
+		MaxTokens:              100000,
+		WorkspaceDailyCostUSD:  20,
+		WorkspaceDailyTokens:   1000000,
+	}
+	samples := []Telemetry{
+		{CPUPercent: 10, LogEntropy: 0.9, LogRepetition: 0.1},
+		{CPUPercent: 95, CPUOverFor: 45 * time.Second, LogEntropy: 0.1, LogRepetition: 0.95},
+		{CPUPercent: 95, CPUOverFor: 45 * time.Second, LogEntropy: 0.9, LogRepetition: 0.1},
+		{CPUPercent: 95, CPUOverFor: 45 * time.Second, LogEntropy: 0.1, LogRepetition: 0.95, ProgressLike: true, RawDiversity: 0.9},
+		{CPUPercent: 20, LogEntropy: 0.1, LogRepetition: 0.95},
+		{CPUPercent: 20, MemoryMB: 2048, LogEntropy: 0.9},
+		{LogEntropy: 0.9, OutputIdleFor: 3 * time.Minute, CPUIdleFor: 3 * time.Minute},
+		{CPUPercent: 50, LogEntropy: 0.9, OutputIdleFor: 3 * time.Minute},
+		{LogEntropy: 0.9, HeartbeatAge: 90 * time.Second},
+		{LogEntropy: 0.9, MemoryMB: 900, MemoryGrowthMBPerMin: 1000},
+		{LogEntropy: 0.9, MemoryMB: 900, MemoryGrowthMBPerMin: 100},
+		{LogEntropy: 0.9, ChildProcesses: 500},
+		{LogEntropy: 0.9, ChildProcesses: 12, ZombieProcesses: 11},
+		{LogEntropy: 0.9, RepeatedExceptions: 8, ExceptionSignature: "ValueError: bad input <NUM>"},
+		{LogEntropy: 0.9, RepeatedExceptions: 5, StderrLinesPerSec: 200},
+		{LogEntropy: 0.9, StderrRepetition: 0.95},
+		{LogEntropy: 0.9, ErrorRate: 0.8},
+		{LogEntropy: 0.9, ErrorRate: 0.8, StderrLinesPerSec: 200},
+		{LogEntropy: 0.9, ToolCallRepetition: 9, ToolCallSignature: `search {"q":"weather"}`},
+		{LogEntropy: 0.9, ToolCallRepetition: 6, ToolCallSignature: `search {"q":"weather"}`},
+		{LogEntropy: 0.9, ToolCallRepetition: 9, RepeatedExceptions: 8},
+		{LogEntropy: 0.9, PromptRepetition: 5, PromptSignature: "3fa9c2d1e0b4: What is the weather?"},
+		{LogEntropy: 0.9, PromptRepetition: 4},
+		{LogEntropy: 0.9, RunCostUSD: 2.5, RunTokens: 40000},
+		{LogEntropy: 0.9, RunCostUSD: 1, RunTokens: 100000},
+		{LogEntropy: 0.9, RunCostUSD: 1, WorkspaceCostUSD: 21, WorkspaceTokens: 900000},
+		{LogEntropy: 0.9, RunCostUSD: 2.4, RunTokens: 99999, WorkspaceCostUSD: 19.9, WorkspaceTokens: 999999},
+		{LogEntropy: 0.9, RunTokens: 120000, ChildProcesses: 500},
+	}
+	threshold := NewThresholdDecider()
+	rules := NewRuleDecider()
+	for i, sample := range samples {
+		want := threshold.Evaluate(sample, p)
+		got := rules.Evaluate(sample, p)
+		if got.Action != want.Action {
+			t.Fatalf("sample %d: rule-decider %s, threshold-decider %s", i, got.Action, want.Action)
+		}
+		if got.Action == ActionKill && got.ExitReason != want.ExitReason && !(got.ExitReason == "" && want.ExitReason == ExitReasonLoop) {
+			t.Fatalf("sample %d: rule-decider exit reason %q, threshold-decider %q", i, got.ExitReason, want.ExitReason)
+		}
+	}
+}
+
+func TestDefaultRuleSetMatchesThresholdDeciderWithZeroThresholds(t *testing.T) {
+	// A zero threshold disables its check in threshold-decider; the built-in
+	// rules must not read it as "any value breaches".
+	policies := []Policy{
+		{},
+		{MaxCPUPercent: 90},
+		{MaxLogRepetition: 0.8},
+		{MinLogEntropy: 0.2},
+		{MaxCPUPercent: 90, MaxLogRepetition: 0.8},
+		{MaxCPUPercent: 90, MinLogEntropy: 0.2},
+	}
+	samples := []Telemetry{
+		{},
+		{CPUPercent: 95, CPUOverFor: time.Minute},
+		{LogEntropy: 0.1, LogRepetition: 0.95},
+		{CPUPercent: 95, CPUOverFor: time.Minute, LogEntropy: 0.1, LogRepetition: 0.95},
+		{CPUPercent: 95, CPUOverFor: time.Minute, LogEntropy: 0.9, LogRepetition: 0.95},
+		{CPUPercent: 95, CPUOverFor: time.Minute, LogEntropy: 0.1, LogRepetition: 0.1},
+	}
+	threshold := NewThresholdDecider()
+	rules := NewRuleDecider()
+	for i, p := range policies {
+		for j, sample := range samples {
+			want := threshold.Evaluate(sample, p)
+			got := rules.Evaluate(sample, p)
+			if got.Action != want.Action {
+				t.Fatalf("policy %d sample %d: rule-decider %s, threshold-decider %s", i, j, got.Action, want.Action)
+			}
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StallConfigured reports whether p sets any stall threshold.
+func StallConfigured(p Policy) bool {
+	return p.MaxOutputIdle > 0 || p.MaxCPUIdle > 0 || p.MaxHeartbeatAge > 0
+}
+
+// StallBreach reports whether the sample describes a silent hang: every
+// configured idle limit (output, CPU) exceeded at once, or a stale heartbeat
+// file. Idle limits are combined with AND so a quiet but busy process, or a
+// chatty process waiting on I/O, is not treated as stalled.
+func StallBreach(t Telemetry, p Policy) (bool, string) {
+	if p.MaxHeartbeatAge > 0 && t.HeartbeatAge > p.MaxHeartbeatAge {
+		return true, fmt.Sprintf("heartbeat file not updated for %s (limit %s)", roundSeconds(t.HeartbeatAge), p.MaxHeartbeatAge)
+	}
+	if p.MaxOutputIdle <= 0 && p.MaxCPUIdle <= 0 {
+		return false, ""
+	}
+	reasons := make([]string, 0, 2)
+	if p.MaxOutputIdle > 0 {
+		if t.OutputIdleFor < p.MaxOutputIdle {
+			return false, ""
+		}
+		reasons = append(reasons, fmt.Sprintf("no output for %s", roundSeconds(t.OutputIdleFor)))
+	}
+	if p.MaxCPUIdle > 0 {
+		if t.CPUIdleFor < p.MaxCPUIdle {
+			return false, ""
+		}
+		reasons = append(reasons, fmt.Sprintf("no CPU activity for %s", roundSeconds(t.CPUIdleFor)))
+	}
+	return true, "stall: " + strings.Join(reasons, " AND ")
+}
+
+func stallDecision(reason string, t Telemetry, p Policy) Decision {
+	d := applyRollout(breachAction(p), reason, t, p)
+	d.ExitReason = ExitReasonStall
+	return d
+}
+
+// roundSeconds keeps sub-second precision only for short durations so a
+// breach just past a small limit does not read as "2s (limit 2s)".
+func roundSeconds(d time.Duration) time.Duration {
+	if d < time.Minute {
+		return d.Round(100 * time.Millisecond)
+	}
+	return d.Round(time.Second)
+}
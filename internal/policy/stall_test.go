@@ -0,0 +1,101 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStallBreachRequiresEveryIdleLimit(t *testing.T) {
+	p := Policy{MaxOutputIdle: time.Minute, MaxCPUIdle: time.Minute}
+
+	cases := []struct {
+		name string
+		t    Telemetry
+		want bool
+	}{
+		{"active", Telemetry{OutputIdleFor: time.Second, CPUIdleFor: time.Second}, false},
+		{"quiet but busy", Telemetry{OutputIdleFor: 2 * time.Minute, CPUIdleFor: time.Second}, false},
+		{"chatty but idle", Telemetry{OutputIdleFor: time.Second, CPUIdleFor: 2 * time.Minute}, false},
+		{"silent hang", Telemetry{OutputIdleFor: 2 * time.Minute, CPUIdleFor: 90 * time.Second}, true},
+	}
+	for _, tc := range cases {
+		got, reason := StallBreach(tc.t, p)
+		if got != tc.want {
+			t.Fatalf("%s: stalled=%v, want %v (%s)", tc.name, got, tc.want, reason)
+		}
+	}
+
+	_, reason := StallBreach(Telemetry{OutputIdleFor: 2 * time.Minute, CPUIdleFor: 90 * time.Second}, p)
+	if reason != "stall: no output for 2m0s AND no CPU activity for 1m30s" {
+		t.Fatalf("unexpected reason %q", reason)
+	}
+
+	if got, _ := StallBreach(Telemetry{OutputIdleFor: time.Hour, CPUIdleFor: time.Hour}, Policy{}); got {
+		t.Fatal("stall must be off without thresholds")
+	}
+}
+
+func TestStallBreachHeartbeat(t *testing.T) {
+	p := Policy{MaxHeartbeatAge: 30 * time.Second}
+	if got, _ := StallBreach(Telemetry{HeartbeatAge: 10 * time.Second}, p); got {
+		t.Fatal("fresh heartbeat must not stall")
+	}
+	got, reason := StallBreach(Telemetry{HeartbeatAge: 45 * time.Second}, p)
+	if !got || !strings.Contains(reason, "heartbeat file not updated for 45s") {
+		t.Fatalf("stalled=%v reason=%q", got, reason)
+	}
+}
+
+func TestEnginesReportStallExitReason(t *testing.T) {
+	p := Policy{MaxOutputIdle: time.Minute, MaxCPUIdle: time.Minute, MaxCPUPercent: 90, MinLogEntropy: 0.2, MaxLogRepetition: 0.8}
+	sample := Telemetry{LogEntropy: 0.9, OutputIdleFor: 5 * time.Minute, CPUIdleFor: 5 * time.Minute}
+
+	engines := map[string]Decider{
+		"threshold": NewThresholdDecider(),
+		"weighted":  NewWeightedScoreDecider(),
+		"rules":     NewRuleDecider(),
+	}
+	for name, d := range engines {
+		out := d.Evaluate(sample, p)
+		if out.Action != ActionKill || out.ExitReason != ExitReasonStall {
+			t.Fatalf("%s: action=%s exit=%q", name, out.Action, out.ExitReason)
+		}
+	}
+	// Before the log window fills the scores are unset; the stall still
+	// goes through each engine and its rollout handling.
+	pending := Telemetry{LogWindowPending: true, OutputIdleFor: 5 * time.Minute, CPUIdleFor: 5 * time.Minute}
+	p.RestartOnBreach = true
+	p.RolloutMode = RolloutShadow
+	for name, d := range engines {
+		out := d.Evaluate(pending, p)
+		if out.Action != ActionLogOnly || out.IntendedAction != ActionRestart || out.ExitReason != ExitReasonStall {
+			t.Fatalf("%s: shadow restart before the window fills: %+v", name, out)
+		}
+	}
+}
+
+func TestEnginesIgnoreLogScoresBeforeTheWindowFills(t *testing.T) {
+	p := Policy{MaxCPUPercent: 90, MaxLogRepetition: 0.8, MinLogEntropy: 0.2, MaxErrorRate: 0.5}
+	// Unscored: zero entropy would otherwise read as a dead-flat loop.
+	pending := Telemetry{LogWindowPending: true, CPUPercent: 99, CPUOverFor: time.Minute}
+
+	engines := map[string]Decider{
+		"threshold": NewThresholdDecider(),
+		"weighted":  NewWeightedScoreDecider(),
+		"rules":     NewRuleDecider(),
+	}
+	for name, d := range engines {
+		out := d.Evaluate(pending, p)
+		if out.Action == ActionKill || strings.Contains(out.Reason, "entropy") {
+			t.Fatalf("%s: pending log scores must not count, got %+v", name, out)
+		}
+	}
+
+	explained := DefaultRuleSet().Explain(pending, p)
+	for _, rule := range explained.Rules {
+		if want := rule.Name == "runaway-loop" || rule.Name == "log-loop" || rule.Name == "error-rate"; rule.Pending != want {
+			t.Fatalf("rule %s: pending=%v, want %v", rule.Name, rule.Pending, want)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package policy
+
+import "fmt"
+
+// RepeatedExceptionBreach reports whether one exception signature has been
+// seen more often than MaxRepeatedExceptions allows. This is treated like a
+// loop: a worker retrying the same failing call forever.
+func RepeatedExceptionBreach(t Telemetry, p Policy) (bool, string) {
+	if p.MaxRepeatedExceptions <= 0 || t.RepeatedExceptions <= p.MaxRepeatedExceptions {
+		return false, ""
+	}
+	return true, fmt.Sprintf("exception %q repeated %d times (limit %d)", t.ExceptionSignature, t.RepeatedExceptions, p.MaxRepeatedExceptions)
+}
+
+// outputAlertReasons lists the stderr volume, stderr repetition and error
+// rate breaches. They only alert: noisy or failing output is not by itself
+// evidence the run is stuck.
+func outputAlertReasons(t Telemetry, p Policy) []string {
+	var reasons []string
+	if p.MaxStderrLinesPerSec > 0 && t.StderrLinesPerSec > p.MaxStderrLinesPerSec {
+		reasons = append(reasons, fmt.Sprintf("stderr rate %.1f lines/s (limit %.1f)", t.StderrLinesPerSec, p.MaxStderrLinesPerSec))
+	}
+	if p.MaxStderrRepetition > 0 && t.StderrRepetition > p.MaxStderrRepetition {
+		reasons = append(reasons, fmt.Sprintf("stderr repetition exceeded %.2f", p.MaxStderrRepetition))
+	}
+	if !t.LogWindowPending && p.MaxErrorRate > 0 && t.ErrorRate > p.MaxErrorRate {
+		reasons = append(reasons, fmt.Sprintf("error rate %.0f%% of structured lines (limit %.0f%%)", t.ErrorRate*100, p.MaxErrorRate*100))
+	}
+	return reasons
+}
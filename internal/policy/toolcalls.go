@@ -0,0 +1,14 @@
+package policy
+
+import "fmt"
+
+// ToolCallLoopBreach reports whether an agent has made the same tool call,
+// with the same normalized arguments, more often than MaxToolCallRepetition
+// allows within its recent calls. The surrounding text can vary enough to
+// pass the log repetition checks while the agent makes no progress.
+func ToolCallLoopBreach(t Telemetry, p Policy) (bool, string) {
+	if p.MaxToolCallRepetition <= 0 || t.ToolCallRepetition <= p.MaxToolCallRepetition {
+		return false, ""
+	}
+	return true, fmt.Sprintf("tool call %s repeated %d times (limit %d)", t.ToolCallSignature, t.ToolCallRepetition, p.MaxToolCallRepetition)
+}
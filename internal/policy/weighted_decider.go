@@ -0,0 +1,139 @@
+package policy
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	WeightedScoreEngineName    = "weighted-score-decider"
+	WeightedScoreEngineVersion = "1.1.0"
+)
+
+// WeightedScoreDecider blends CPU, repetition and entropy pressure into a
+// single 0..1 score instead of requiring individual thresholds to trip
+// together. Memory, memory growth, fork bombs, repeated exceptions, tool call
+// and prompt loops, stalls and budgets remain hard limits.
+type WeightedScoreDecider struct {
+	CPUWeight        float64
+	RepetitionWeight float64
+	EntropyWeight    float64
+	AlertScore       float64
+	KillScore        float64
+}
+
+func NewWeightedScoreDecider() Decider {
+	return WeightedScoreDecider{
+		CPUWeight:        0.40,
+		RepetitionWeight: 0.35,
+		EntropyWeight:    0.25,
+		AlertScore:       0.75,
+		KillScore:        0.95,
+	}
+}
+
+func (d WeightedScoreDecider) Evaluate(t Telemetry, p Policy) Decision {
+	if over, reason := BudgetBreach(t, p); over {
+		return budgetDecision(reason, t, p)
+	}
+	if p.MaxMemoryMB > 0 && t.MemoryMB > p.MaxMemoryMB {
+		return applyRollout(breachAction(p), fmt.Sprintf("memory exceeded %.0fMB", p.MaxMemoryMB), t, p)
+	}
+	if growing, reason := MemoryTrendBreach(t, p); growing {
+		d := applyRollout(breachAction(p), reason, t, p)
+		d.ExitReason = ExitReasonMemoryGrowth
+		return d
+	}
+	if p.MaxChildProcesses > 0 && t.ChildProcesses > p.MaxChildProcesses {
+		d := applyRollout(breachAction(p), forkBombReason(t, p), t, p)
+		d.ExitReason = ExitReasonForkBomb
+		return d
+	}
+	if repeated, reason := RepeatedExceptionBreach(t, p); repeated {
+		d := applyRollout(breachAction(p), reason, t, p)
+		d.ExitReason = ExitReasonException
+		return d
+	}
+	if looping, reason := ToolCallLoopBreach(t, p); looping {
+		d := applyRollout(breachAction(p), reason, t, p)
+		d.ExitReason = ExitReasonToolLoop
+		return d
+	}
+	if looping, reason := PromptLoopBreach(t, p); looping {
+		d := applyRollout(breachAction(p), reason, t, p)
+		d.ExitReason = ExitReasonPromptLoop
+		return d
+	}
+	if stalled, reason := StallBreach(t, p); stalled {
+		return stallDecision(reason, t, p)
+	}
+
+	cpuPressure := 0.0
+	if p.MaxCPUPercent > 0 {
+		cpuPressure = clampUnit(t.CPUPercent / p.MaxCPUPercent)
+		if p.CPUWindow > 0 && t.CPUOverFor < p.CPUWindow {
+			cpuPressure *= 0.5
+		}
+	}
+	repetitionPressure := 0.0
+	if p.MaxLogRepetition > 0 && !t.LogWindowPending {
+		repetitionPressure = clampUnit(t.LogRepetition / p.MaxLogRepetition)
+	}
+	entropyPressure := 0.0
+	if p.MinLogEntropy > 0 && p.MinLogEntropy < 1 && !t.LogWindowPending {
+		entropyPressure = clampUnit((1 - t.LogEntropy) / (1 - p.MinLogEntropy))
+	}
+
+	totalWeight := d.CPUWeight + d.RepetitionWeight + d.EntropyWeight
+	if totalWeight <= 0 {
+		return Decision{
+			Action:         ActionContinue,
+			IntendedAction: ActionContinue,
+			Reason:         "No weights configured",
+		}
+	}
+	score := (d.CPUWeight*cpuPressure + d.RepetitionWeight*repetitionPressure + d.EntropyWeight*entropyPressure) / totalWeight
+
+	progressGuard := t.ProgressLike && t.RawDiversity >= 0.85
+	if progressGuard {
+		score *= 0.5
+	}
+
+	if score < d.AlertScore {
+		alerts := outputAlertReasons(t, p)
+		if p.MaxZombieProcesses > 0 && t.ZombieProcesses > p.MaxZombieProcesses {
+			alerts = append([]string{zombieReason(t, p)}, alerts...)
+		}
+		if len(alerts) > 0 {
+			return applyRollout(ActionAlert, strings.Join(alerts, " AND "), t, p)
+		}
+		return Decision{
+			Action:         ActionContinue,
+			IntendedAction: ActionContinue,
+			Reason:         fmt.Sprintf("Weighted score %.2f below alert threshold %.2f", score, d.AlertScore),
+		}
+	}
+
+	reasons := []string{fmt.Sprintf("weighted score %.2f (cpu=%.2f repetition=%.2f entropy=%.2f)",
+		score, cpuPressure, repetitionPressure, entropyPressure)}
+	if progressGuard {
+		reasons = append(reasons, "progressing output pattern detected; score halved")
+	}
+
+	action := ActionAlert
+	if score >= d.KillScore {
+		action = breachAction(p)
+	}
+	return applyRollout(action, strings.Join(reasons, " AND "), t, p)
+}
+
+func clampUnit(v float64) float64 {
+	if math.IsNaN(v) || v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
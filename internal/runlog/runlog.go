@@ -0,0 +1,398 @@
+// Package runlog persists the redacted stdout/stderr of supervised runs under
+// the daemon runtime directory, one directory per run ID.
+package runlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"flowforge/internal/daemon"
+	"flowforge/internal/redact"
+)
+
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+
+	fileName      = "output.log"
+	stateFileName = "state.json"
+	runsDir       = "runs"
+
+	// DefaultMaxBytes bounds the capture of one run across all rotated files.
+	DefaultMaxBytes = 10 << 20
+	// DefaultMaxFiles is the number of files kept per run, including the
+	// active one.
+	DefaultMaxFiles = 3
+	// DefaultKeepRuns is the number of run directories kept under runs/;
+	// older captures are removed when a new run opens.
+	DefaultKeepRuns = 100
+	// maxLineBytes caps a single captured line; longer output is split.
+	maxLineBytes = 16 << 10
+)
+
+var (
+	ErrInvalidRunID = errors.New("runlog: invalid run id")
+	ErrNotFound     = errors.New("runlog: no captured output for run")
+	runIDPattern    = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,127}$`)
+)
+
+// Line is one captured line of output.
+type Line struct {
+	Time   time.Time `json:"ts"`
+	Stream string    `json:"stream"`
+	Text   string    `json:"line"`
+}
+
+// Options bounds the on-disk size of a capture. MaxBytes is split evenly
+// across MaxFiles, so the oldest output is dropped first. KeepRuns bounds
+// how many runs' captures are kept at all.
+type Options struct {
+	MaxBytes int64
+	MaxFiles int
+	KeepRuns int
+}
+
+// RunDir returns the capture directory for runID without creating it.
+func RunDir(runID string) (string, error) {
+	if !runIDPattern.MatchString(runID) {
+		return "", ErrInvalidRunID
+	}
+	root, err := daemon.ResolveRuntimeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, runsDir, runID), nil
+}
+
+// StatePath returns where a run launched by the daemon publishes its live
+// state (see state.Store.WriteFile), without creating the run directory.
+func StatePath(runID string) (string, error) {
+	dir, err := RunDir(runID)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, stateFileName), nil
+}
+
+// Writer appends redacted lines to a run's capture and rotates it once the
+// active file reaches its share of MaxBytes. It is safe for concurrent use.
+type Writer struct {
+	mu      sync.Mutex
+	dir     string
+	f       *os.File
+	size    int64
+	limit   int64
+	files   int
+	streams []*streamWriter
+	now     func() time.Time
+}
+
+// Open creates (or appends to) the capture for runID, then removes the
+// captures of all but the KeepRuns most recently written runs.
+func Open(runID string, opts Options) (*Writer, error) {
+	dir, err := RunDir(runID)
+	if err != nil {
+		return nil, err
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultMaxBytes
+	}
+	if opts.MaxFiles <= 0 {
+		opts.MaxFiles = DefaultMaxFiles
+	}
+	if opts.KeepRuns <= 0 {
+		opts.KeepRuns = DefaultKeepRuns
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("runlog: create run dir: %w", err)
+	}
+	// Pruning is best effort: a capture that cannot be removed now is
+	// retried when the next run opens.
+	_ = prune(filepath.Dir(dir), filepath.Base(dir), opts.KeepRuns)
+	w := &Writer{
+		dir:   dir,
+		limit: opts.MaxBytes / int64(opts.MaxFiles),
+		files: opts.MaxFiles,
+		now:   time.Now,
+	}
+	if err := w.openActive(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// prune removes the oldest run directories under root until keep remain.
+// Runs are ordered by when their active capture file was last written, so a
+// long-running run is not pruned for having started early; current is never
+// removed.
+func prune(root, current string, keep int) error {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return err
+	}
+	type run struct {
+		name    string
+		written time.Time
+	}
+	runs := make([]run, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() || e.Name() == current || !runIDPattern.MatchString(e.Name()) {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(root, e.Name(), fileName))
+		if err != nil {
+			if info, err = e.Info(); err != nil {
+				continue
+			}
+		}
+		runs = append(runs, run{name: e.Name(), written: info.ModTime()})
+	}
+	// current counts toward keep.
+	if len(runs) < keep {
+		return nil
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].written.After(runs[j].written) })
+	var errs []error
+	for _, r := range runs[keep-1:] {
+		if err := os.RemoveAll(filepath.Join(root, r.name)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (w *Writer) openActive() error {
+	f, err := os.OpenFile(filepath.Join(w.dir, fileName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("runlog: open capture: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("runlog: stat capture: %w", err)
+	}
+	w.f = f
+	w.size = info.Size()
+	return nil
+}
+
+// rotate shifts output.log -> output.log.1 -> ... and drops the oldest file.
+func (w *Writer) rotate() error {
+	if err := w.f.Close(); err != nil {
+		return err
+	}
+	base := filepath.Join(w.dir, fileName)
+	if w.files <= 1 {
+		if err := os.Remove(base); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return w.openActive()
+	}
+	_ = os.Remove(base + "." + strconv.Itoa(w.files-1))
+	for i := w.files - 2; i >= 1; i-- {
+		_ = os.Rename(base+"."+strconv.Itoa(i), base+"."+strconv.Itoa(i+1))
+	}
+	if err := os.Rename(base, base+".1"); err != nil {
+		return err
+	}
+	return w.openActive()
+}
+
+// Dir is the directory holding the capture files.
+func (w *Writer) Dir() string { return w.dir }
+
+// WriteLine records one line of output after redacting it.
+func (w *Writer) WriteLine(stream, text string) error {
+	rec, err := json.Marshal(Line{Time: w.now().UTC(), Stream: stream, Text: redact.Line(text)})
+	if err != nil {
+		return err
+	}
+	rec = append(rec, '\n')
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		return os.ErrClosed
+	}
+	if w.size > 0 && w.size+int64(len(rec)) > w.limit {
+		if err := w.rotate(); err != nil {
+			return fmt.Errorf("runlog: rotate: %w", err)
+		}
+	}
+	n, err := w.f.Write(rec)
+	w.size += int64(n)
+	return err
+}
+
+// Stream returns an io.Writer that splits its input into lines tagged with
+// name. A trailing partial line is held until the next newline or Close.
+func (w *Writer) Stream(name string) io.Writer {
+	s := &streamWriter{w: w, name: name}
+	w.mu.Lock()
+	w.streams = append(w.streams, s)
+	w.mu.Unlock()
+	return s
+}
+
+// Close flushes partial lines from every stream and closes the file.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	streams := w.streams
+	w.mu.Unlock()
+	for _, s := range streams {
+		s.flush()
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		return nil
+	}
+	err := w.f.Close()
+	w.f = nil
+	return err
+}
+
+type streamWriter struct {
+	w    *Writer
+	name string
+	mu   sync.Mutex
+	buf  []byte
+}
+
+func (s *streamWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf = append(s.buf, p...)
+	for {
+		i := bytes.IndexByte(s.buf, '\n')
+		if i < 0 {
+			if len(s.buf) < maxLineBytes {
+				break
+			}
+			i = maxLineBytes
+		}
+		line := string(bytes.TrimRight(s.buf[:i], "\r"))
+		if i < len(s.buf) && s.buf[i] == '\n' {
+			i++
+		}
+		s.buf = s.buf[i:]
+		// Capture is best effort: a full disk must not stall the child's pipe.
+		_ = s.w.WriteLine(s.name, line)
+	}
+	return len(p), nil
+}
+
+func (s *streamWriter) flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.buf) > 0 {
+		_ = s.w.WriteLine(s.name, string(s.buf))
+		s.buf = nil
+	}
+}
+
+// ReadOptions filters captured lines. Zero values disable each filter.
+type ReadOptions struct {
+	Since time.Time // lines at or after Since
+	Until time.Time // lines at or before Until
+	Tail  int       // only the last Tail lines
+	// MaxBytes keeps only the newest lines whose text fits in MaxBytes.
+	MaxBytes int
+}
+
+// ParseSince accepts an RFC3339 timestamp or a duration relative to now
+// ("15m" means the last fifteen minutes).
+func ParseSince(raw string, now time.Time) (time.Time, error) {
+	if ts, err := time.Parse(time.RFC3339, raw); err == nil {
+		return ts, nil
+	}
+	if d, err := time.ParseDuration(raw); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("since must be an RFC3339 timestamp or a duration such as 15m")
+}
+
+// Read returns the captured lines of runID, oldest first.
+func Read(runID string, opts ReadOptions) ([]Line, error) {
+	dir, err := RunDir(runID)
+	if err != nil {
+		return nil, err
+	}
+	return readDir(dir, opts)
+}
+
+func readDir(dir string, opts ReadOptions) ([]Line, error) {
+	base := filepath.Join(dir, fileName)
+	paths := []string{base}
+	for i := 1; ; i++ {
+		p := base + "." + strconv.Itoa(i)
+		if _, err := os.Stat(p); err != nil {
+			break
+		}
+		paths = append(paths, p)
+	}
+
+	var out []Line
+	found := false
+	for i := len(paths) - 1; i >= 0; i-- {
+		f, err := os.Open(paths[i])
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("runlog: open capture: %w", err)
+		}
+		found = true
+		sc := bufio.NewScanner(f)
+		sc.Buffer(make([]byte, 0, 64<<10), 8*maxLineBytes) // room for JSON escaping
+		for sc.Scan() {
+			var l Line
+			if json.Unmarshal(sc.Bytes(), &l) != nil {
+				continue // torn write from a crash
+			}
+			if !opts.Since.IsZero() && l.Time.Before(opts.Since) {
+				continue
+			}
+			if !opts.Until.IsZero() && l.Time.After(opts.Until) {
+				continue
+			}
+			out = append(out, l)
+		}
+		err = sc.Err()
+		_ = f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("runlog: read capture: %w", err)
+		}
+	}
+	if !found {
+		return nil, ErrNotFound
+	}
+
+	if opts.Tail > 0 && len(out) > opts.Tail {
+		out = out[len(out)-opts.Tail:]
+	}
+	if opts.MaxBytes > 0 {
+		total, start := 0, len(out)
+		for start > 0 && total+len(out[start-1].Text)+1 <= opts.MaxBytes {
+			start--
+			total += len(out[start].Text) + 1
+		}
+		out = out[start:]
+	}
+	if out == nil {
+		out = []Line{}
+	}
+	return out, nil
+}
@@ -0,0 +1,152 @@
+package runlog
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriterSplitsStreamsAndRedacts(t *testing.T) {
+	t.Setenv("FLOWFORGE_DAEMON_DIR", t.TempDir())
+	w, err := Open("run-1", Options{})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	stdout := w.Stream(StreamStdout)
+	stderr := w.Stream(StreamStderr)
+	fmt.Fprint(stdout, "step 1\nstep")
+	fmt.Fprint(stderr, "Authorization: Bearer abc.def\n")
+	fmt.Fprint(stdout, " 2\r\npartial")
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	lines, err := Read("run-1", ReadOptions{})
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	got := make([]string, 0, len(lines))
+	for _, l := range lines {
+		got = append(got, l.Stream+":"+l.Text)
+	}
+	want := []string{"stdout:step 1", "stderr:Authorization: Bearer <REDACTED>", "stdout:step 2", "stdout:partial"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("lines = %q, want %q", got, want)
+	}
+}
+
+func TestWriterRotatesWithinBudget(t *testing.T) {
+	t.Setenv("FLOWFORGE_DAEMON_DIR", t.TempDir())
+	w, err := Open("run-rotate", Options{MaxBytes: 3000, MaxFiles: 3})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	for i := 0; i < 200; i++ {
+		if err := w.WriteLine(StreamStdout, fmt.Sprintf("line %03d", i)); err != nil {
+			t.Fatalf("WriteLine() error = %v", err)
+		}
+	}
+	_ = w.Close()
+
+	dir, _ := RunDir("run-rotate")
+	var total int64
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 capture files, got %d", len(entries))
+	}
+	for _, e := range entries {
+		info, _ := os.Stat(filepath.Join(dir, e.Name()))
+		total += info.Size()
+	}
+	if total > 3000 {
+		t.Fatalf("capture uses %d bytes, budget 3000", total)
+	}
+
+	lines, err := Read("run-rotate", ReadOptions{Tail: 2})
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(lines) != 2 || lines[0].Text != "line 198" || lines[1].Text != "line 199" {
+		t.Fatalf("tail = %+v", lines)
+	}
+	all, _ := Read("run-rotate", ReadOptions{})
+	for i := 1; i < len(all); i++ {
+		if all[i].Text <= all[i-1].Text {
+			t.Fatalf("lines out of order at %d: %q after %q", i, all[i].Text, all[i-1].Text)
+		}
+	}
+}
+
+func TestOpenPrunesLeastRecentlyWrittenRuns(t *testing.T) {
+	t.Setenv("FLOWFORGE_DAEMON_DIR", t.TempDir())
+	base := time.Now().Add(-time.Hour)
+	for i, runID := range []string{"run-a", "run-b", "run-c"} {
+		w, err := Open(runID, Options{KeepRuns: 10})
+		if err != nil {
+			t.Fatalf("Open(%s) error = %v", runID, err)
+		}
+		_ = w.WriteLine(StreamStdout, runID)
+		_ = w.Close()
+		dir, _ := RunDir(runID)
+		written := base.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(filepath.Join(dir, fileName), written, written); err != nil {
+			t.Fatalf("Chtimes() error = %v", err)
+		}
+	}
+	// run-a started first but is still writing, so run-b is the oldest.
+	dirA, _ := RunDir("run-a")
+	now := time.Now()
+	_ = os.Chtimes(filepath.Join(dirA, fileName), now, now)
+
+	w, err := Open("run-d", Options{KeepRuns: 3})
+	if err != nil {
+		t.Fatalf("Open(run-d) error = %v", err)
+	}
+	_ = w.Close()
+
+	for runID, kept := range map[string]bool{"run-a": true, "run-b": false, "run-c": true, "run-d": true} {
+		dir, _ := RunDir(runID)
+		_, err := os.Stat(dir)
+		if kept && err != nil {
+			t.Fatalf("expected %s to be kept: %v", runID, err)
+		}
+		if !kept && !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be pruned, stat error = %v", runID, err)
+		}
+	}
+}
+
+func TestReadFilters(t *testing.T) {
+	t.Setenv("FLOWFORGE_DAEMON_DIR", t.TempDir())
+	w, err := Open("run-filter", Options{})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	base := time.Date(2026, 1, 2, 3, 4, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		at := base.Add(time.Duration(i) * time.Minute)
+		w.now = func() time.Time { return at }
+		_ = w.WriteLine(StreamStdout, fmt.Sprintf("m%d", i))
+	}
+	_ = w.Close()
+
+	lines, _ := Read("run-filter", ReadOptions{Since: base.Add(2 * time.Minute), Until: base.Add(3 * time.Minute)})
+	if len(lines) != 2 || lines[0].Text != "m2" || lines[1].Text != "m3" {
+		t.Fatalf("since/until = %+v", lines)
+	}
+	lines, _ = Read("run-filter", ReadOptions{MaxBytes: 6})
+	if len(lines) != 2 || lines[0].Text != "m3" {
+		t.Fatalf("max bytes = %+v", lines)
+	}
+
+	if _, err := Read("missing", ReadOptions{}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := Read("../etc", ReadOptions{}); !errors.Is(err, ErrInvalidRunID) {
+		t.Fatalf("expected ErrInvalidRunID, got %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package state
 
 import (
 	"encoding/json"
+	"os"
 	"strings"
 	"sync"
 	"time"
@@ -9,35 +10,78 @@ import (
 
 // ProcessState holds the runtime state of the supervised process
 type ProcessState struct {
-	CPU        float64  `json:"cpu"`
-	LastLine   string   `json:"last_line"`
-	Status     string   `json:"status"` // RUNNING, STOPPED, LOOP_DETECTED, WATCHDOG_ALERT
-	Command    string   `json:"command"`
-	Args       []string `json:"args"` // Secure: Exact arguments for restart
-	Dir        string   `json:"dir"`  // Working directory
-	PID        int      `json:"pid"`
-	Reason     string   `json:"reason"`
-	CPUScore   float64  `json:"cpu_score"`
-	Entropy    float64  `json:"entropy_score"`
-	Confidence float64  `json:"confidence_score"`
-	Lifecycle  string   `json:"lifecycle"`
-	Timestamp  int64    `json:"timestamp"`
-}
-
-var (
-	currentState      ProcessState
-	lifecycleOverride string
+	// RunID identifies the worker in multi-worker streams; the default
+	// store leaves it empty until a run registers.
+	RunID    string  `json:"run_id,omitempty"`
+	CPU      float64 `json:"cpu"`
+	LastLine string  `json:"last_line"`
+	// LastLineStream is "stdout" or "stderr" when LastLine is process
+	// output, and empty for FlowForge status text.
+	LastLineStream string   `json:"last_line_stream,omitempty"`
+	Status         string   `json:"status"` // RUNNING, PAUSED, STOPPED, LOOP_DETECTED, WATCHDOG_ALERT
+	Command        string   `json:"command"`
+	Args           []string `json:"args"` // Secure: Exact arguments for restart
+	Dir            string   `json:"dir"`  // Working directory
+	PID            int      `json:"pid"`
+	Reason         string   `json:"reason"`
+	CPUScore       float64  `json:"cpu_score"`
+	Entropy        float64  `json:"entropy_score"`
+	Confidence     float64  `json:"confidence_score"`
+	Lifecycle      string   `json:"lifecycle"`
+	Timestamp      int64    `json:"timestamp"`
+}
+
+// Store holds the state of one supervised process. The package-level
+// functions act on the default store, which `flowforge run` updates; the
+// daemon keeps one store per worker.
+type Store struct {
 	mu                sync.RWMutex
-)
+	current           ProcessState
+	lifecycleOverride string
+	runID             string
+}
+
+var defaultStore = &Store{}
+
+// NewStore returns an empty store whose states carry runID.
+func NewStore(runID string) *Store {
+	return &Store{runID: runID, current: ProcessState{RunID: runID}}
+}
+
+// Default returns the store behind the package-level functions.
+func Default() *Store {
+	return defaultStore
+}
+
+// SetRunID tags the store's states with runID.
+func (s *Store) SetRunID(runID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runID = runID
+	s.current.RunID = runID
+}
+
+// RunID returns the run ID the store's states carry.
+func (s *Store) RunID() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.runID
+}
 
 // UpdateState safely updates the global process state
 func UpdateState(cpu float64, lastLine, status, command string, args []string, dir string, pid int) {
-	mu.Lock()
-	defer mu.Unlock()
+	defaultStore.UpdateState(cpu, lastLine, status, command, args, dir, pid)
+}
+
+// UpdateState replaces the store's process state.
+func (s *Store) UpdateState(cpu float64, lastLine, status, command string, args []string, dir string, pid int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
 	argsCopy := append([]string(nil), args...)
 
-	currentState = ProcessState{
+	s.current = ProcessState{
+		RunID:     s.runID,
 		CPU:       cpu,
 		LastLine:  lastLine,
 		Status:    status,
@@ -48,71 +92,149 @@ func UpdateState(cpu float64, lastLine, status, command string, args []string, d
 		Lifecycle: deriveLifecycle(status, pid),
 		Timestamp: time.Now().UnixMilli(),
 	}
-	if lifecycleOverride != "" {
-		currentState.Lifecycle = lifecycleOverride
+	if s.lifecycleOverride != "" {
+		s.current.Lifecycle = s.lifecycleOverride
 	}
 }
 
+// SetLastLineStream records which stream the current LastLine came from.
+func SetLastLineStream(stream string) {
+	defaultStore.SetLastLineStream(stream)
+}
+
+// SetLastLineStream records which stream the current LastLine came from.
+func (s *Store) SetLastLineStream(stream string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current.LastLineStream = stream
+}
+
 // UpdateDecision updates decision diagnostics while preserving current process identity.
 func UpdateDecision(reason string, cpuScore, entropy, confidence float64) {
-	mu.Lock()
-	defer mu.Unlock()
-	currentState.Reason = reason
-	currentState.CPUScore = cpuScore
-	currentState.Entropy = entropy
-	currentState.Confidence = confidence
-	currentState.Timestamp = time.Now().UnixMilli()
+	defaultStore.UpdateDecision(reason, cpuScore, entropy, confidence)
+}
+
+// UpdateDecision updates decision diagnostics while preserving current process identity.
+func (s *Store) UpdateDecision(reason string, cpuScore, entropy, confidence float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current.Reason = reason
+	s.current.CPUScore = cpuScore
+	s.current.Entropy = entropy
+	s.current.Confidence = confidence
+	s.current.Timestamp = time.Now().UnixMilli()
 }
 
 // GetState safely returns a copy of the current state
 func GetState() ProcessState {
-	mu.RLock()
-	defer mu.RUnlock()
-	return currentState
+	return defaultStore.GetState()
+}
+
+// GetState returns a copy of the store's state.
+func (s *Store) GetState() ProcessState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current
 }
 
 // JSON returns the state as a JSON byte slice (for API)
 func JSON() ([]byte, error) {
-	mu.RLock()
-	defer mu.RUnlock()
-	return json.Marshal(currentState)
+	return defaultStore.JSON()
+}
+
+// JSON returns the store's state as a JSON byte slice (for API)
+func (s *Store) JSON() ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return json.Marshal(s.current)
+}
+
+// WriteFile replaces path with the store's state, atomically, so another
+// process can follow this one with ReadFile.
+func (s *Store) WriteFile(path string) error {
+	data, err := s.JSON()
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// ReadFile returns the state last written to path by WriteFile.
+func ReadFile(path string) (ProcessState, error) {
+	var st ProcessState
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return st, err
+	}
+	err = json.Unmarshal(data, &st)
+	return st, err
+}
+
+// MergeTelemetry copies the sampled fields of t (CPU, last line and
+// decision scores) into the store. Run ID, command, status, lifecycle and
+// PID stay the store's own, so a daemon can fold a child run's telemetry
+// into the worker it tracks. Older telemetry than the store's is ignored.
+func (s *Store) MergeTelemetry(t ProcessState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t.Timestamp < s.current.Timestamp {
+		return
+	}
+	s.current.CPU = t.CPU
+	s.current.LastLine = t.LastLine
+	s.current.LastLineStream = t.LastLineStream
+	s.current.Reason = t.Reason
+	s.current.CPUScore = t.CPUScore
+	s.current.Entropy = t.Entropy
+	s.current.Confidence = t.Confidence
+	s.current.Timestamp = t.Timestamp
 }
 
 // UpdateLifecycle updates lifecycle metadata while preserving telemetry fields.
 // pid < 0 preserves the existing PID.
 func UpdateLifecycle(lifecycle, status string, pid int) {
-	mu.Lock()
-	defer mu.Unlock()
+	defaultStore.UpdateLifecycle(lifecycle, status, pid)
+}
+
+// UpdateLifecycle updates lifecycle metadata while preserving telemetry fields.
+// pid < 0 preserves the existing PID.
+func (s *Store) UpdateLifecycle(lifecycle, status string, pid int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
 	lifecycle = strings.ToUpper(strings.TrimSpace(lifecycle))
 	switch lifecycle {
-	case "STARTING", "STOPPING", "FAILED":
-		lifecycleOverride = lifecycle
+	case "STARTING", "STOPPING", "PAUSED", "FAILED":
+		s.lifecycleOverride = lifecycle
 	case "RUNNING", "STOPPED", "":
-		lifecycleOverride = ""
+		s.lifecycleOverride = ""
 	default:
 		// Keep custom lifecycle values as explicit override.
-		lifecycleOverride = lifecycle
+		s.lifecycleOverride = lifecycle
 	}
 
 	if lifecycle != "" {
-		currentState.Lifecycle = lifecycle
-	} else if lifecycleOverride != "" {
-		currentState.Lifecycle = lifecycleOverride
+		s.current.Lifecycle = lifecycle
+	} else if s.lifecycleOverride != "" {
+		s.current.Lifecycle = s.lifecycleOverride
 	}
 	if status != "" {
-		currentState.Status = status
+		s.current.Status = status
 	}
 	if pid >= 0 {
-		currentState.PID = pid
+		s.current.PID = pid
 	}
-	currentState.Timestamp = time.Now().UnixMilli()
+	s.current.Timestamp = time.Now().UnixMilli()
 }
 
 func deriveLifecycle(status string, pid int) string {
 	status = strings.ToUpper(strings.TrimSpace(status))
 	switch status {
-	case "STARTING", "RUNNING", "STOPPING", "STOPPED", "FAILED":
+	case "STARTING", "RUNNING", "PAUSED", "STOPPING", "STOPPED", "FAILED":
 		return status
 	case "WATCHDOG_ALERT", "WATCHDOG_WARN", "WATCHDOG_CRITICAL", "PROBING_DETECTED":
 		return "RUNNING"
@@ -0,0 +1,161 @@
+package supervisor
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"syscall"
+	"time"
+
+	"flowforge/internal/sysmon"
+)
+
+const adoptPollInterval = 250 * time.Millisecond
+
+// Adopted controls a process group this process did not start, such as a
+// worker a restarted daemon finds still running. It is not the group's
+// parent, so it polls for exit instead of waiting, and it checks the
+// leader's identity before every signal so a recycled pid is never hit.
+type Adopted struct {
+	id sysmon.ProcessIdentity
+
+	mu       sync.Mutex
+	paused   bool
+	done     chan struct{}
+	doneOnce sync.Once
+	stopOnce sync.Once
+	stopErr  error
+}
+
+// Adopt takes over the process group led by id.PID, provided that process
+// is still the one id describes.
+func Adopt(id sysmon.ProcessIdentity) (*Adopted, error) {
+	current, err := sysmon.IdentifyProcess(id.PID)
+	if err != nil {
+		return nil, fmt.Errorf("supervisor: adopt pid %d: %w", id.PID, err)
+	}
+	if !current.Same(id) {
+		return nil, fmt.Errorf("supervisor: pid %d now belongs to another process", id.PID)
+	}
+	if current.PGID != current.PID {
+		return nil, fmt.Errorf("supervisor: pid %d no longer leads its process group", id.PID)
+	}
+	return &Adopted{id: current, done: make(chan struct{})}, nil
+}
+
+// Identity returns the identity the group was adopted under.
+func (a *Adopted) Identity() sysmon.ProcessIdentity {
+	return a.id
+}
+
+func (a *Adopted) PID() int {
+	return a.id.PID
+}
+
+func (a *Adopted) Exited() bool {
+	select {
+	case <-a.done:
+		return true
+	default:
+	}
+	if current, err := sysmon.IdentifyProcess(a.id.PID); err == nil && current.Same(a.id) {
+		return false
+	}
+	a.doneOnce.Do(func() { close(a.done) })
+	return true
+}
+
+// Wait blocks until the group leader exits. Its exit status went to
+// whichever process reaped it, so Wait always returns nil.
+func (a *Adopted) Wait() error {
+	ticker := time.NewTicker(adoptPollInterval)
+	defer ticker.Stop()
+	for !a.Exited() {
+		<-ticker.C
+	}
+	return nil
+}
+
+// signal sends sig to the group while its leader is still the adopted
+// process; a leader that is gone is not an error.
+func (a *Adopted) signal(sig syscall.Signal) error {
+	if a.Exited() {
+		return nil
+	}
+	return signalGroup(a.id.PID, sig)
+}
+
+// Pause sends SIGSTOP to the process group.
+func (a *Adopted) Pause() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.Exited() {
+		return errors.New("supervisor: process is not running")
+	}
+	if a.paused {
+		return nil
+	}
+	if err := a.signal(syscall.SIGSTOP); err != nil {
+		return fmt.Errorf("supervisor: SIGSTOP failed: %w", err)
+	}
+	a.paused = true
+	return nil
+}
+
+// Resume sends SIGCONT to a paused process group.
+func (a *Adopted) Resume() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.paused {
+		return nil
+	}
+	if err := a.signal(syscall.SIGCONT); err != nil {
+		return fmt.Errorf("supervisor: SIGCONT failed: %w", err)
+	}
+	a.paused = false
+	return nil
+}
+
+// Stop sends SIGTERM to the process group, waits for grace duration, then
+// escalates to SIGKILL for the process group if needed.
+func (a *Adopted) Stop(grace time.Duration) error {
+	a.stopOnce.Do(func() {
+		a.stopErr = a.stopInternal(grace)
+	})
+	return a.stopErr
+}
+
+func (a *Adopted) stopInternal(grace time.Duration) error {
+	if grace <= 0 {
+		grace = defaultGrace
+	}
+	if a.Exited() {
+		return nil
+	}
+	pid := a.id.PID
+	termErr := a.signal(syscall.SIGTERM)
+	a.mu.Lock()
+	if a.paused {
+		_ = a.signal(syscall.SIGCONT)
+		a.paused = false
+	}
+	a.mu.Unlock()
+	if waitForGroupExit(pid, grace) {
+		return nil
+	}
+
+	// The leader was ours when the stop began and may since have exited
+	// while the rest of its group lingers. The kernel does not hand out a
+	// pid that still names a live group, so the group is still ours to kill.
+	killErr := signalGroup(pid, syscall.SIGKILL)
+	if !waitForGroupExit(pid, 2*time.Second) {
+		return fmt.Errorf("supervisor: process group %d did not exit after SIGKILL", pid)
+	}
+	if termErr != nil && killErr != nil {
+		return fmt.Errorf("supervisor: SIGTERM failed: %v; SIGKILL failed: %v", termErr, killErr)
+	}
+	if killErr != nil {
+		return fmt.Errorf("supervisor: SIGKILL failed: %v", killErr)
+	}
+	return nil
+}
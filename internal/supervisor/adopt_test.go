@@ -0,0 +1,69 @@
+package supervisor
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"flowforge/internal/sysmon"
+)
+
+// startGroupLeader starts sleep as the leader of its own group, the way a
+// previous daemon would have left it, and reaps it in the background.
+func startGroupLeader(t *testing.T) (int, <-chan struct{}) {
+	t.Helper()
+	cmd := exec.Command("sleep", "30")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	pid := cmd.Process.Pid
+	reaped := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(reaped)
+	}()
+	t.Cleanup(func() { _ = syscall.Kill(-pid, syscall.SIGKILL) })
+	return pid, reaped
+}
+
+func TestAdoptVerifiesIdentityBeforeSignalling(t *testing.T) {
+	pid, reaped := startGroupLeader(t)
+	id, err := sysmon.IdentifyProcess(pid)
+	if errors.Is(err, sysmon.ErrTreeUnsupported) {
+		t.Skip("process identity is not available on this platform")
+	}
+	if err != nil {
+		t.Fatalf("identify: %v", err)
+	}
+
+	recycled := id
+	recycled.StartTicks++
+	if _, err := Adopt(recycled); err == nil {
+		t.Fatal("expected adopt to refuse a pid with a different start time")
+	}
+	stale := &Adopted{id: recycled, done: make(chan struct{})}
+	if err := stale.Stop(100 * time.Millisecond); err != nil || !processExists(pid) {
+		t.Fatalf("expected a stale identity to leave pid %d alone (err %v)", pid, err)
+	}
+
+	a, err := Adopt(id)
+	if err != nil {
+		t.Fatalf("adopt: %v", err)
+	}
+	if a.PID() != pid || a.Exited() {
+		t.Fatalf("expected adopted pid %d to be running", pid)
+	}
+	if err := a.Pause(); err != nil || !waitForProcState(pid, 'T', 2*time.Second) {
+		t.Fatalf("expected pause to stop pid %d (err %v)", pid, err)
+	}
+	if err := a.Stop(2 * time.Second); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	<-reaped
+	if !a.Exited() || a.Wait() != nil {
+		t.Fatal("expected the adopted group to be reported as exited")
+	}
+}
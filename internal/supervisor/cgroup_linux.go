@@ -0,0 +1,19 @@
+package supervisor
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// startInCgroup points clone3 at the cgroup directory (CLONE_INTO_CGROUP).
+// The returned func closes the directory fd once the child has started.
+func startInCgroup(attr *syscall.SysProcAttr, path string) (func(), error) {
+	dir, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("supervisor: open cgroup %s: %w", path, err)
+	}
+	attr.UseCgroupFD = true
+	attr.CgroupFD = int(dir.Fd())
+	return func() { _ = dir.Close() }, nil
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package supervisor
+
+import (
+	"errors"
+	"syscall"
+)
+
+func startInCgroup(_ *syscall.SysProcAttr, _ string) (func(), error) {
+	return nil, errors.New("supervisor: cgroup placement is only supported on Linux")
+}
@@ -0,0 +1,222 @@
+package supervisor
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// Resource names accepted in RLimit.Resource.
+const (
+	RLimitAS     = "as"     // address space, bytes
+	RLimitNOFILE = "nofile" // open file descriptors
+	RLimitNPROC  = "nproc"  // processes for the real user ID, not just this tree
+	RLimitCPU    = "cpu"    // CPU seconds
+	RLimitFSIZE  = "fsize"  // largest file the process may write, bytes
+)
+
+var rlimitResources = map[string]int{
+	RLimitAS:     unix.RLIMIT_AS,
+	RLimitNOFILE: unix.RLIMIT_NOFILE,
+	RLimitNPROC:  unix.RLIMIT_NPROC,
+	RLimitCPU:    unix.RLIMIT_CPU,
+	RLimitFSIZE:  unix.RLIMIT_FSIZE,
+}
+
+// execShimArg marks a re-exec of the current binary whose only job is to set
+// rlimits on itself and exec the real command. Go's os/exec has no hook
+// between fork and exec, so this is how limits land before the target runs.
+const execShimArg = "__flowforge_exec_shim"
+
+// RLimit is one resource limit applied to the child before exec.
+type RLimit struct {
+	Resource string `json:"resource"`
+	Soft     uint64 `json:"soft"`
+	Hard     uint64 `json:"hard"`
+}
+
+// EnvPolicy filters and extends the environment the child inherits. Allow and
+// Deny entries are exact names or path.Match globs such as "AWS_*". An empty
+// Allow keeps everything; Deny is applied after Allow; Set always wins.
+type EnvPolicy struct {
+	Allow []string          `json:"allow,omitempty"`
+	Deny  []string          `json:"deny,omitempty"`
+	Set   map[string]string `json:"-"`
+}
+
+// Limits are the process controls applied by Start.
+type Limits struct {
+	RLimits []RLimit
+	Env     EnvPolicy
+}
+
+// IsZero reports whether l changes nothing about the child.
+func (l Limits) IsZero() bool {
+	return len(l.RLimits) == 0 && len(l.Env.Allow) == 0 && len(l.Env.Deny) == 0 && len(l.Env.Set) == 0
+}
+
+// AppliedLimits records what Start actually did. Environment values are never
+// included, only variable names.
+type AppliedLimits struct {
+	RLimits     []RLimit `json:"rlimits,omitempty"`
+	EnvAllow    []string `json:"env_allow,omitempty"`
+	EnvDeny     []string `json:"env_deny,omitempty"`
+	EnvInjected []string `json:"env_injected,omitempty"`
+	EnvDropped  []string `json:"env_dropped,omitempty"`
+}
+
+// ValidateRLimit checks that a resource name is supported and soft <= hard.
+func ValidateRLimit(l RLimit) error {
+	if _, ok := rlimitResources[l.Resource]; !ok {
+		return fmt.Errorf("unknown rlimit %q (expected as|nofile|nproc|cpu|fsize)", l.Resource)
+	}
+	if l.Soft > l.Hard {
+		return fmt.Errorf("rlimit %s: soft limit %d exceeds hard limit %d", l.Resource, l.Soft, l.Hard)
+	}
+	return nil
+}
+
+// FilterEnv applies p to environ ("KEY=VALUE" entries). It returns the new
+// environment plus the names that were dropped and injected.
+func FilterEnv(environ []string, p EnvPolicy) (out []string, dropped []string, injected []string) {
+	out = make([]string, 0, len(environ)+len(p.Set))
+	for _, kv := range environ {
+		key, _, _ := strings.Cut(kv, "=")
+		if _, overridden := p.Set[key]; overridden {
+			continue
+		}
+		if len(p.Allow) > 0 && !matchEnvName(p.Allow, key) {
+			dropped = append(dropped, key)
+			continue
+		}
+		if matchEnvName(p.Deny, key) {
+			dropped = append(dropped, key)
+			continue
+		}
+		out = append(out, kv)
+	}
+	for key := range p.Set {
+		injected = append(injected, key)
+	}
+	sort.Strings(injected)
+	for _, key := range injected {
+		out = append(out, key+"="+p.Set[key])
+	}
+	sort.Strings(dropped)
+	return out, dropped, injected
+}
+
+func matchEnvName(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if pattern == name {
+			return true
+		}
+		if ok, err := path.Match(pattern, name); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+func encodeRLimits(limits []RLimit) string {
+	parts := make([]string, 0, len(limits))
+	for _, l := range limits {
+		parts = append(parts, fmt.Sprintf("%s=%d:%d", l.Resource, l.Soft, l.Hard))
+	}
+	return strings.Join(parts, ",")
+}
+
+func decodeRLimits(raw string) ([]RLimit, error) {
+	var limits []RLimit
+	for _, part := range strings.Split(raw, ",") {
+		if part == "" {
+			continue
+		}
+		name, values, ok := strings.Cut(part, "=")
+		softRaw, hardRaw, ok2 := strings.Cut(values, ":")
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("malformed rlimit %q", part)
+		}
+		soft, err := strconv.ParseUint(softRaw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed rlimit %q: %w", part, err)
+		}
+		hard, err := strconv.ParseUint(hardRaw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed rlimit %q: %w", part, err)
+		}
+		limits = append(limits, RLimit{Resource: name, Soft: soft, Hard: hard})
+	}
+	return limits, nil
+}
+
+// RunExecShimIfRequested must be called first thing in main. When the binary
+// was started as the rlimit shim it applies the limits and execs the target;
+// it only returns for normal invocations.
+func RunExecShimIfRequested() {
+	if len(os.Args) < 4 || os.Args[1] != execShimArg {
+		return
+	}
+	// argv: self, shimArg, encoded limits, target path, target argv...
+	limits, err := decodeRLimits(os.Args[2])
+	if err == nil {
+		for _, l := range limits {
+			if err = ValidateRLimit(l); err != nil {
+				break
+			}
+			if err = unix.Setrlimit(rlimitResources[l.Resource], &unix.Rlimit{Cur: l.Soft, Max: l.Hard}); err != nil {
+				err = fmt.Errorf("setrlimit %s: %w", l.Resource, err)
+				break
+			}
+		}
+	}
+	if err == nil {
+		err = unix.Exec(os.Args[3], os.Args[4:], os.Environ())
+	}
+	fmt.Fprintf(os.Stderr, "flowforge: exec shim: %v\n", err)
+	os.Exit(127)
+}
+
+// applyLimits rewrites s.cmd for the configured limits and returns what was
+// applied. Called from Start with s.mu held.
+func (s *Supervisor) applyLimits() (AppliedLimits, error) {
+	applied := AppliedLimits{
+		EnvAllow: append([]string(nil), s.limits.Env.Allow...),
+		EnvDeny:  append([]string(nil), s.limits.Env.Deny...),
+	}
+
+	if len(s.limits.Env.Allow) > 0 || len(s.limits.Env.Deny) > 0 || len(s.limits.Env.Set) > 0 {
+		environ := s.cmd.Env
+		if environ == nil {
+			environ = os.Environ()
+		}
+		s.cmd.Env, applied.EnvDropped, applied.EnvInjected = FilterEnv(environ, s.limits.Env)
+	}
+
+	if len(s.limits.RLimits) == 0 {
+		return applied, nil
+	}
+	for _, l := range s.limits.RLimits {
+		if err := ValidateRLimit(l); err != nil {
+			return AppliedLimits{}, fmt.Errorf("supervisor: %w", err)
+		}
+	}
+	if s.cmd.Err != nil {
+		return AppliedLimits{}, s.cmd.Err
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return AppliedLimits{}, fmt.Errorf("supervisor: locate executable for rlimit shim: %w", err)
+	}
+	target := s.cmd.Path
+	argv := append([]string{self, execShimArg, encodeRLimits(s.limits.RLimits), target}, s.cmd.Args...)
+	s.cmd.Path = self
+	s.cmd.Args = argv
+	applied.RLimits = append([]RLimit(nil), s.limits.RLimits...)
+	return applied, nil
+}
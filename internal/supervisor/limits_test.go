@@ -0,0 +1,75 @@
+package supervisor
+
+import (
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// Supervisors under test re-exec the test binary as the rlimit shim.
+	RunExecShimIfRequested()
+	os.Exit(m.Run())
+}
+
+func TestFilterEnvAllowDenySet(t *testing.T) {
+	environ := []string{"PATH=/bin", "HOME=/root", "AWS_SECRET_ACCESS_KEY=x", "AWS_REGION=us-east-1", "OPENAI_API_KEY=y", "DEBUG=0"}
+	out, dropped, injected := FilterEnv(environ, EnvPolicy{
+		Allow: []string{"PATH", "HOME", "AWS_*", "DEBUG"},
+		Deny:  []string{"AWS_SECRET_*"},
+		Set:   map[string]string{"DEBUG": "1", "FLOWFORGE": "1"},
+	})
+
+	want := []string{"PATH=/bin", "HOME=/root", "AWS_REGION=us-east-1", "DEBUG=1", "FLOWFORGE=1"}
+	if !reflect.DeepEqual(out, want) {
+		t.Fatalf("env = %v, want %v", out, want)
+	}
+	if !reflect.DeepEqual(dropped, []string{"AWS_SECRET_ACCESS_KEY", "OPENAI_API_KEY"}) {
+		t.Fatalf("dropped = %v", dropped)
+	}
+	if !reflect.DeepEqual(injected, []string{"DEBUG", "FLOWFORGE"}) {
+		t.Fatalf("injected = %v", injected)
+	}
+}
+
+func TestValidateRLimit(t *testing.T) {
+	if err := ValidateRLimit(RLimit{Resource: "stack", Soft: 1, Hard: 1}); err == nil {
+		t.Fatal("expected unknown resource error")
+	}
+	if err := ValidateRLimit(RLimit{Resource: RLimitNOFILE, Soft: 10, Hard: 5}); err == nil {
+		t.Fatal("expected soft > hard error")
+	}
+}
+
+func TestStartAppliesRLimitsAndEnv(t *testing.T) {
+	cmd := exec.Command("/bin/sh", "-c", `echo "$(ulimit -n) $(ulimit -f) ${KEEP:-unset} ${DROP:-unset} ${INJECT:-unset}"`)
+	cmd.Env = []string{"PATH=" + os.Getenv("PATH"), "KEEP=kept", "DROP=secret"}
+	var out strings.Builder
+	cmd.Stdout = &out
+
+	s := New(cmd)
+	s.SetLimits(Limits{
+		RLimits: []RLimit{
+			{Resource: RLimitNOFILE, Soft: 64, Hard: 64},
+			{Resource: RLimitFSIZE, Soft: 1024 * 512, Hard: 1024 * 512},
+		},
+		Env: EnvPolicy{Deny: []string{"DROP"}, Set: map[string]string{"INJECT": "yes"}},
+	})
+	if err := s.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if err := s.Wait(); err != nil {
+		t.Fatalf("wait: %v", err)
+	}
+
+	// sh reports fsize in 512-byte blocks.
+	if got := strings.TrimSpace(out.String()); got != "64 1024 kept unset yes" {
+		t.Fatalf("child saw %q", got)
+	}
+	applied := s.AppliedLimits()
+	if len(applied.RLimits) != 2 || !reflect.DeepEqual(applied.EnvDropped, []string{"DROP"}) || !reflect.DeepEqual(applied.EnvInjected, []string{"INJECT"}) {
+		t.Fatalf("unexpected applied limits: %+v", applied)
+	}
+}
@@ -26,6 +26,11 @@ type Supervisor struct {
 	started  bool
 	stopOnce sync.Once
 	stopErr  error
+	paused   bool
+
+	cgroupPath string
+	limits     Limits
+	applied    AppliedLimits
 }
 
 func New(cmd *exec.Cmd) *Supervisor {
@@ -50,7 +55,24 @@ func (s *Supervisor) Start() error {
 	if s.cmd.SysProcAttr == nil {
 		s.cmd.SysProcAttr = &syscall.SysProcAttr{}
 	}
-	s.cmd.SysProcAttr.Setpgid = true
+	// A new session also leads a new process group of the same id.
+	if !s.cmd.SysProcAttr.Setsid {
+		s.cmd.SysProcAttr.Setpgid = true
+	}
+
+	applied, err := s.applyLimits()
+	if err != nil {
+		return err
+	}
+	s.applied = applied
+
+	if s.cgroupPath != "" {
+		closeCgroup, err := startInCgroup(s.cmd.SysProcAttr, s.cgroupPath)
+		if err != nil {
+			return err
+		}
+		defer closeCgroup()
+	}
 
 	if err := s.cmd.Start(); err != nil {
 		return err
@@ -70,6 +92,37 @@ func (s *Supervisor) Start() error {
 	return nil
 }
 
+// PlaceInCgroup makes Start launch the command directly inside the cgroup v2
+// directory at path, so no child can fork before the limits apply. It must
+// be called before Start.
+func (s *Supervisor) PlaceInCgroup(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cgroupPath = path
+}
+
+// SetLimits configures rlimits and environment filtering for Start. It must be
+// called before Start.
+func (s *Supervisor) SetLimits(l Limits) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limits = l
+}
+
+// Limits returns the configured limits, for relaunching the same command.
+func (s *Supervisor) Limits() Limits {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.limits
+}
+
+// AppliedLimits reports the rlimits and environment changes Start applied.
+func (s *Supervisor) AppliedLimits() AppliedLimits {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.applied
+}
+
 func (s *Supervisor) PID() int {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -92,6 +145,45 @@ func (s *Supervisor) Wait() error {
 	return s.waitErr
 }
 
+// Pause sends SIGSTOP to the process group. Pausing a paused group is a
+// no-op.
+func (s *Supervisor) Pause() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.started || s.Exited() {
+		return errors.New("supervisor: process is not running")
+	}
+	if s.paused {
+		return nil
+	}
+	if err := signalGroup(s.pid, syscall.SIGSTOP); err != nil {
+		return fmt.Errorf("supervisor: SIGSTOP failed: %w", err)
+	}
+	s.paused = true
+	return nil
+}
+
+// Resume sends SIGCONT to a paused process group.
+func (s *Supervisor) Resume() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.paused {
+		return nil
+	}
+	if err := signalGroup(s.pid, syscall.SIGCONT); err != nil {
+		return fmt.Errorf("supervisor: SIGCONT failed: %w", err)
+	}
+	s.paused = false
+	return nil
+}
+
+// Paused reports whether the group is stopped by Pause.
+func (s *Supervisor) Paused() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.paused && !s.Exited()
+}
+
 // Stop sends SIGTERM to the process group, waits for grace duration, then
 // escalates to SIGKILL for the process group if needed.
 func (s *Supervisor) Stop(grace time.Duration) error {
@@ -141,6 +233,13 @@ func (s *Supervisor) stopInternal(grace time.Duration) error {
 	}
 
 	termErr := signalGroup(pid, syscall.SIGTERM)
+	// A stopped group only acts on SIGTERM once it is continued.
+	s.mu.Lock()
+	if s.paused {
+		_ = signalGroup(pid, syscall.SIGCONT)
+		s.paused = false
+	}
+	s.mu.Unlock()
 	if waitForGroupExit(pid, grace) {
 		return nil
 	}
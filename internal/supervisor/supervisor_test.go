@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"syscall"
@@ -121,6 +122,73 @@ func TestTrapSignalsStopsProcess(t *testing.T) {
 	}
 }
 
+func TestPauseResumeAndStopWhilePaused(t *testing.T) {
+	cmd := exec.Command("python3", "-c", "import time; time.sleep(120)")
+	s := New(cmd)
+	if err := s.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	pid := s.PID()
+
+	if err := s.Pause(); err != nil {
+		t.Fatalf("pause: %v", err)
+	}
+	if err := s.Pause(); err != nil {
+		t.Fatalf("second pause should be a no-op: %v", err)
+	}
+	if !s.Paused() || !waitForProcState(pid, 'T', 2*time.Second) {
+		t.Fatalf("expected process %d to be stopped after pause", pid)
+	}
+	if err := s.Resume(); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if s.Paused() || waitForProcState(pid, 'T', 200*time.Millisecond) {
+		t.Fatalf("expected process %d to run after resume", pid)
+	}
+
+	if err := s.Pause(); err != nil {
+		t.Fatalf("pause before stop: %v", err)
+	}
+	start := time.Now()
+	if err := s.Stop(2 * time.Second); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected SIGTERM to reach the paused group, stop took %s", elapsed)
+	}
+	_ = s.Wait()
+	if err := s.Pause(); err == nil {
+		t.Fatal("expected pause of an exited process to fail")
+	}
+}
+
+// waitForProcState waits until /proc reports pid in state.
+func waitForProcState(pid int, state byte, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		raw, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+		if err == nil {
+			// The state follows the parenthesised command name.
+			if i := strings.LastIndexByte(string(raw), ')'); i >= 0 && i+2 < len(raw) && raw[i+2] == state {
+				return true
+			}
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestStartFailsWhenCgroupIsMissing(t *testing.T) {
+	s := New(exec.Command("sleep", "5"))
+	s.PlaceInCgroup(filepath.Join(t.TempDir(), "missing"))
+	if err := s.Start(); err == nil {
+		_ = s.Stop(time.Second)
+		t.Fatal("expected start to fail for a missing cgroup directory")
+	}
+}
+
 func processExists(pid int) bool {
 	if pid <= 0 {
 		return false
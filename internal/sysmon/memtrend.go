@@ -0,0 +1,75 @@
+package sysmon
+
+import "time"
+
+// minTrendSamples is the fewest points a slope is fitted on.
+const minTrendSamples = 5
+
+// MemorySample is one RSS reading.
+type MemorySample struct {
+	At time.Time
+	MB float64
+}
+
+// MemoryTrend keeps a sliding window of RSS samples for one process and fits
+// a least-squares line through them. It is not safe for concurrent use; the
+// monitor loop owns it.
+type MemoryTrend struct {
+	window  time.Duration
+	samples []MemorySample
+}
+
+// NewMemoryTrend returns a trend over the last window of samples.
+func NewMemoryTrend(window time.Duration) *MemoryTrend {
+	if window <= 0 {
+		window = 2 * time.Minute
+	}
+	return &MemoryTrend{window: window}
+}
+
+// Add records a sample and drops the ones that fell out of the window.
+func (m *MemoryTrend) Add(at time.Time, mb float64) {
+	m.samples = append(m.samples, MemorySample{At: at, MB: mb})
+	cutoff := at.Add(-m.window)
+	drop := 0
+	for drop < len(m.samples) && m.samples[drop].At.Before(cutoff) {
+		drop++
+	}
+	if drop > 0 {
+		m.samples = append(m.samples[:0], m.samples[drop:]...)
+	}
+}
+
+// Len returns the number of samples in the window.
+func (m *MemoryTrend) Len() int {
+	return len(m.samples)
+}
+
+// SlopeMBPerMin returns the regression slope of RSS over the window in MB
+// per minute. ok is false until the samples cover at least a quarter of the
+// window, so start-up allocation is not mistaken for a leak.
+func (m *MemoryTrend) SlopeMBPerMin() (slope float64, ok bool) {
+	n := len(m.samples)
+	if n < minTrendSamples {
+		return 0, false
+	}
+	first := m.samples[0].At
+	if m.samples[n-1].At.Sub(first) < m.window/4 {
+		return 0, false
+	}
+
+	var sumX, sumY, sumXY, sumXX float64
+	for _, s := range m.samples {
+		x := s.At.Sub(first).Minutes()
+		sumX += x
+		sumY += s.MB
+		sumXY += x * s.MB
+		sumXX += x * x
+	}
+	fn := float64(n)
+	denom := fn*sumXX - sumX*sumX
+	if denom == 0 {
+		return 0, false
+	}
+	return (fn*sumXY - sumX*sumY) / denom, true
+}
@@ -0,0 +1,47 @@
+package sysmon
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMemoryTrendSlope(t *testing.T) {
+	trend := NewMemoryTrend(time.Minute)
+	start := time.Unix(1700000000, 0)
+
+	for i := 0; i < 4; i++ {
+		trend.Add(start.Add(time.Duration(i)*time.Second), 100+float64(i))
+	}
+	if _, ok := trend.SlopeMBPerMin(); ok {
+		t.Fatal("slope must not be reported before the window is a quarter full")
+	}
+
+	// 2MB every 5s = 24MB/min, with some jitter.
+	for i := 4; i <= 24; i++ {
+		jitter := 0.5
+		if i%2 == 0 {
+			jitter = -0.5
+		}
+		trend.Add(start.Add(time.Duration(i*5)*time.Second), 100+float64(i*2)+jitter)
+	}
+	slope, ok := trend.SlopeMBPerMin()
+	if !ok || math.Abs(slope-24) > 1 {
+		t.Fatalf("slope = %.2f ok=%v, want ~24MB/min", slope, ok)
+	}
+	if trend.Len() > 13 {
+		t.Fatalf("expected samples older than the window to be dropped, have %d", trend.Len())
+	}
+}
+
+func TestMemoryTrendFlat(t *testing.T) {
+	trend := NewMemoryTrend(20 * time.Second)
+	start := time.Unix(1700000000, 0)
+	for i := 0; i < 20; i++ {
+		trend.Add(start.Add(time.Duration(i)*time.Second), 512)
+	}
+	slope, ok := trend.SlopeMBPerMin()
+	if !ok || slope != 0 {
+		t.Fatalf("slope = %.2f ok=%v, want 0", slope, ok)
+	}
+}
@@ -0,0 +1,59 @@
+package sysmon
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrTreeUnsupported is returned by TreeSampler.Sample on platforms without a
+// process tree reader; callers fall back to sampling the leader only.
+var ErrTreeUnsupported = errors.New("sysmon: process tree sampling is not supported on this platform")
+
+// TreeStats aggregates one sample over a supervised process, its descendants
+// and anything else left in its process group (e.g. orphans whose parent
+// crashed).
+type TreeStats struct {
+	Processes  int     // members including the root and zombies
+	Children   int     // members other than the root
+	Zombies    int     // members that exited but were not reaped
+	CPUPercent float64 // summed over members since the previous sample; 100 = one core
+	RSSBytes   uint64
+	OpenFDs    int
+	Threads    int
+}
+
+// procTimes is what the sampler remembers per pid between ticks.
+type procTimes struct {
+	start uint64  // start time in clock ticks, guards against pid reuse
+	cpu   float64 // utime+stime in seconds
+}
+
+// TreeSampler walks the process tree rooted at one pid. CPU is computed from
+// the difference between consecutive samples, so the first sample reports
+// zero CPU. It is not safe for concurrent use.
+type TreeSampler struct {
+	root     int
+	procRoot string
+	last     map[int]procTimes
+	lastAt   time.Time
+}
+
+// NewTreeSampler returns a sampler for the tree rooted at pid.
+func NewTreeSampler(pid int) *TreeSampler {
+	return &TreeSampler{root: pid, procRoot: "/proc", last: make(map[int]procTimes)}
+}
+
+// ProcessIdentity pins a pid to one process. Pids are recycled, so a process
+// found under a remembered pid is only the same one if it started at the same
+// clock tick of the same boot.
+type ProcessIdentity struct {
+	PID        int    `json:"pid"`
+	PGID       int    `json:"pgid"`
+	StartTicks uint64 `json:"start_ticks"`
+	BootID     string `json:"boot_id,omitempty"`
+}
+
+// Same reports whether id and other describe the same process.
+func (id ProcessIdentity) Same(other ProcessIdentity) bool {
+	return id.PID > 0 && id.PID == other.PID && id.StartTicks == other.StartTicks && id.BootID == other.BootID
+}
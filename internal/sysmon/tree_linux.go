@@ -0,0 +1,218 @@
+//go:build linux
+
+package sysmon
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+)
+
+// procStat is the subset of /proc/<pid>/stat the sampler needs.
+type procStat struct {
+	pid     int
+	state   byte
+	ppid    int
+	pgrp    int
+	cpu     float64 // utime+stime in seconds
+	threads int
+	start   uint64
+	rss     uint64 // bytes
+}
+
+// Sample reads every /proc/<pid>/stat once, selects the members of the tree
+// (descendants of the root by ppid, plus anything sharing the root's process
+// group) and aggregates them.
+func (s *TreeSampler) Sample() (TreeStats, error) {
+	now := time.Now()
+	all, err := readProcStats(s.procRoot)
+	if err != nil {
+		return TreeStats{}, err
+	}
+
+	root, ok := all[s.root]
+	if !ok {
+		return TreeStats{}, fmt.Errorf("sysmon: process %d not found", s.root)
+	}
+	members := treeMembers(all, root)
+
+	elapsed := 0.0
+	if !s.lastAt.IsZero() {
+		elapsed = now.Sub(s.lastAt).Seconds()
+	}
+	next := make(map[int]procTimes, len(members))
+	var out TreeStats
+	for _, st := range members {
+		out.Processes++
+		if st.pid != s.root {
+			out.Children++
+		}
+		if st.state == 'Z' {
+			out.Zombies++
+			continue
+		}
+		out.RSSBytes += st.rss
+		out.Threads += st.threads
+		if fds, err := os.ReadDir(filepath.Join(s.procRoot, strconv.Itoa(st.pid), "fd")); err == nil {
+			out.OpenFDs += len(fds)
+		}
+		if prev, ok := s.last[st.pid]; ok && prev.start == st.start && elapsed > 0 {
+			if delta := st.cpu - prev.cpu; delta > 0 {
+				out.CPUPercent += delta / elapsed * 100
+			}
+		}
+		next[st.pid] = procTimes{start: st.start, cpu: st.cpu}
+	}
+	s.last = next
+	s.lastAt = now
+	return out, nil
+}
+
+// ProcessGroups returns the process groups of the tree rooted at pid, the
+// root's own group first. A group signal to the root misses descendants that
+// lead groups of their own, such as the command a nested supervisor runs.
+func ProcessGroups(pid int) ([]int, error) {
+	return processGroups("/proc", pid)
+}
+
+func processGroups(procRoot string, pid int) ([]int, error) {
+	all, err := readProcStats(procRoot)
+	if err != nil {
+		return nil, err
+	}
+	root, ok := all[pid]
+	if !ok {
+		return nil, fmt.Errorf("sysmon: process %d not found", pid)
+	}
+	groups := []int{root.pgrp}
+	seen := map[int]bool{root.pgrp: true}
+	for _, st := range treeMembers(all, root) {
+		if !seen[st.pgrp] {
+			seen[st.pgrp] = true
+			groups = append(groups, st.pgrp)
+		}
+	}
+	return groups, nil
+}
+
+// IdentifyProcess returns the identity of the running process pid. Zombies
+// are reported as not found: they can no longer be signalled or adopted.
+func IdentifyProcess(pid int) (ProcessIdentity, error) {
+	return identifyProcess("/proc", pid)
+}
+
+func identifyProcess(procRoot string, pid int) (ProcessIdentity, error) {
+	data, err := os.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return ProcessIdentity{}, fmt.Errorf("sysmon: process %d not found", pid)
+	}
+	st, err := parseProcStat(pid, string(data), 1)
+	if err != nil {
+		return ProcessIdentity{}, err
+	}
+	if st.state == 'Z' || st.state == 'X' {
+		return ProcessIdentity{}, fmt.Errorf("sysmon: process %d not found", pid)
+	}
+	bootID, err := os.ReadFile(filepath.Join(procRoot, "sys", "kernel", "random", "boot_id"))
+	if err != nil {
+		return ProcessIdentity{}, fmt.Errorf("sysmon: read boot id: %w", err)
+	}
+	return ProcessIdentity{
+		PID:        pid,
+		PGID:       st.pgrp,
+		StartTicks: st.start,
+		BootID:     strings.TrimSpace(string(bootID)),
+	}, nil
+}
+
+func readProcStats(procRoot string) (map[int]procStat, error) {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, fmt.Errorf("sysmon: read %s: %w", procRoot, err)
+	}
+
+	pageSize := uint64(os.Getpagesize())
+	all := make(map[int]procStat, len(entries))
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(procRoot, e.Name(), "stat"))
+		if err != nil {
+			continue // exited while scanning
+		}
+		st, err := parseProcStat(pid, string(data), pageSize)
+		if err != nil {
+			continue
+		}
+		all[pid] = st
+	}
+	return all, nil
+}
+
+// treeMembers returns root, its descendants, and any other process in the
+// root's process group.
+func treeMembers(all map[int]procStat, root procStat) []procStat {
+	children := make(map[int][]int, len(all))
+	for pid, st := range all {
+		children[st.ppid] = append(children[st.ppid], pid)
+	}
+	seen := map[int]bool{root.pid: true}
+	queue := []int{root.pid}
+	for i := 0; i < len(queue); i++ {
+		for _, child := range children[queue[i]] {
+			if !seen[child] {
+				seen[child] = true
+				queue = append(queue, child)
+			}
+		}
+	}
+	for pid, st := range all {
+		if st.pgrp == root.pgrp && root.pgrp == root.pid && !seen[pid] {
+			seen[pid] = true
+			queue = append(queue, pid)
+		}
+	}
+	out := make([]procStat, 0, len(queue))
+	for _, pid := range queue {
+		out = append(out, all[pid])
+	}
+	return out
+}
+
+// parseProcStat parses /proc/<pid>/stat. The command name is wrapped in
+// parentheses and may itself contain spaces or ')', so fields are counted
+// from the last ')'.
+func parseProcStat(pid int, data string, pageSize uint64) (procStat, error) {
+	end := strings.LastIndexByte(data, ')')
+	if end < 0 {
+		return procStat{}, fmt.Errorf("malformed stat for pid %d", pid)
+	}
+	fields := strings.Fields(data[end+1:])
+	// fields[0] is field 3 (state) in proc(5) numbering.
+	if len(fields) < 22 || len(fields[0]) != 1 {
+		return procStat{}, fmt.Errorf("malformed stat for pid %d", pid)
+	}
+	num := func(i int) uint64 {
+		v, _ := strconv.ParseUint(fields[i], 10, 64)
+		return v
+	}
+	ppid, _ := strconv.Atoi(fields[1])
+	pgrp, _ := strconv.Atoi(fields[2])
+	return procStat{
+		pid:     pid,
+		state:   fields[0][0],
+		ppid:    ppid,
+		pgrp:    pgrp,
+		cpu:     float64(num(11)+num(12)) / cpu.ClocksPerSec,
+		threads: int(num(17)),
+		start:   num(19),
+		rss:     num(21) * pageSize,
+	}, nil
+}
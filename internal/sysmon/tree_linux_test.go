@@ -0,0 +1,115 @@
+//go:build linux
+
+package sysmon
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeProcStat(t *testing.T, root string, pid, ppid, pgrp int, state string, utime, threads, rssPages int) {
+	t.Helper()
+	dir := filepath.Join(root, fmt.Sprint(pid))
+	if err := os.MkdirAll(filepath.Join(dir, "fd"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// pid (comm) state ppid pgrp session tty tpgid flags minflt cminflt majflt cmajflt
+	// utime stime cutime cstime priority nice num_threads itreal starttime vsize rss
+	stat := fmt.Sprintf("%d (a (weird) name) %s %d %d 0 0 0 0 0 0 0 0 %d 0 0 0 20 0 %d 0 %d 0 %d 0 0\n",
+		pid, state, ppid, pgrp, utime, threads, 1000+pid, rssPages)
+	if err := os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTreeSamplerAggregatesGroupAndDescendants(t *testing.T) {
+	proc := t.TempDir()
+	writeProcStat(t, proc, 100, 1, 100, "S", 10, 2, 10)   // root, group leader
+	writeProcStat(t, proc, 101, 100, 100, "R", 20, 4, 20) // child
+	writeProcStat(t, proc, 102, 101, 102, "S", 0, 1, 5)   // grandchild in its own group
+	writeProcStat(t, proc, 103, 100, 100, "Z", 0, 1, 0)   // unreaped child
+	writeProcStat(t, proc, 104, 1, 100, "S", 0, 1, 1)     // orphan still in the group
+	writeProcStat(t, proc, 200, 1, 200, "R", 50, 8, 99)   // unrelated
+	if err := os.WriteFile(filepath.Join(proc, "101", "fd", "0"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &TreeSampler{root: 100, procRoot: proc, last: make(map[int]procTimes)}
+	stats, err := s.Sample()
+	if err != nil {
+		t.Fatalf("sample: %v", err)
+	}
+	page := uint64(os.Getpagesize())
+	want := TreeStats{Processes: 5, Children: 4, Zombies: 1, RSSBytes: 36 * page, OpenFDs: 1, Threads: 8}
+	if stats != want {
+		t.Fatalf("stats = %+v, want %+v", stats, want)
+	}
+	if _, tracked := s.last[200]; tracked {
+		t.Fatal("unrelated process must not be sampled")
+	}
+
+	groups, err := processGroups(proc, 100)
+	if err != nil {
+		t.Fatalf("process groups: %v", err)
+	}
+	if !reflect.DeepEqual(groups, []int{100, 102}) {
+		t.Fatalf("groups = %v, want the root's group then the grandchild's", groups)
+	}
+}
+
+func TestTreeSamplerLiveProcess(t *testing.T) {
+	s := NewTreeSampler(os.Getpid())
+	if _, err := s.Sample(); err != nil {
+		t.Fatalf("sample self: %v", err)
+	}
+	stats, err := s.Sample()
+	if err != nil {
+		t.Fatalf("sample self: %v", err)
+	}
+	if stats.Processes < 1 || stats.RSSBytes == 0 || stats.Threads < 1 {
+		t.Fatalf("unexpected self sample: %+v", stats)
+	}
+
+	if _, err := NewTreeSampler(1 << 30).Sample(); err == nil {
+		t.Fatal("expected error for a missing root")
+	}
+}
+
+func TestIdentifyProcessPinsStartTimeAndBoot(t *testing.T) {
+	proc := t.TempDir()
+	writeProcStat(t, proc, 100, 1, 100, "S", 0, 1, 1)
+	writeProcStat(t, proc, 101, 100, 100, "Z", 0, 1, 0)
+	if err := os.MkdirAll(filepath.Join(proc, "sys", "kernel", "random"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(proc, "sys", "kernel", "random", "boot_id"), []byte("boot-a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := identifyProcess(proc, 100)
+	if err != nil {
+		t.Fatalf("identify: %v", err)
+	}
+	want := ProcessIdentity{PID: 100, PGID: 100, StartTicks: 1100, BootID: "boot-a"}
+	if id != want {
+		t.Fatalf("identity = %+v, want %+v", id, want)
+	}
+	if _, err := identifyProcess(proc, 101); err == nil {
+		t.Fatal("expected a zombie not to be identified")
+	}
+
+	// The same pid started later is a different process.
+	recycled := want
+	recycled.StartTicks++
+	if want.Same(recycled) || !want.Same(id) {
+		t.Fatal("expected identities to compare by start time")
+	}
+
+	self, err := IdentifyProcess(os.Getpid())
+	if err != nil || self.BootID == "" || self.StartTicks == 0 {
+		t.Fatalf("identify self: %+v, %v", self, err)
+	}
+}
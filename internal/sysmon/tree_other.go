@@ -0,0 +1,18 @@
+//go:build !linux
+
+package sysmon
+
+// Sample is only implemented on Linux.
+func (s *TreeSampler) Sample() (TreeStats, error) {
+	return TreeStats{}, ErrTreeUnsupported
+}
+
+// ProcessGroups is only implemented on Linux.
+func ProcessGroups(pid int) ([]int, error) {
+	return nil, ErrTreeUnsupported
+}
+
+// IdentifyProcess is only implemented on Linux.
+func IdentifyProcess(pid int) (ProcessIdentity, error) {
+	return ProcessIdentity{}, ErrTreeUnsupported
+}
@@ -0,0 +1,253 @@
+package tokens
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkoukk/tiktoken-go"
+)
+
+// DefaultCurrency is assumed for catalog entries that do not name one.
+const DefaultCurrency = "USD"
+
+const effectiveDateLayout = "2006-01-02"
+
+// Price is one catalog entry: what a model costs per 1K input and output
+// tokens from EffectiveDate on. A model may have several entries; the latest
+// one in effect wins.
+type Price struct {
+	Model         string  `json:"model" mapstructure:"model" yaml:"model"`
+	InputPer1K    float64 `json:"input_per_1k" mapstructure:"input_per_1k" yaml:"input_per_1k"`
+	OutputPer1K   float64 `json:"output_per_1k" mapstructure:"output_per_1k" yaml:"output_per_1k"`
+	Currency      string  `json:"currency" mapstructure:"currency" yaml:"currency"`
+	EffectiveDate string  `json:"effective_date,omitempty" mapstructure:"effective_date" yaml:"effective_date,omitempty"`
+	// Encoding is the tiktoken encoding used to count the model's tokens, or
+	// "approximate".
+	Encoding string `json:"encoding,omitempty" mapstructure:"encoding" yaml:"encoding,omitempty"`
+}
+
+// defaultPrices are list prices in USD. Anthropic publishes no tokenizer, so
+// its models are counted with cl100k_base as an approximation.
+var defaultPrices = []Price{
+	{Model: "gpt-4", InputPer1K: 0.03, OutputPer1K: 0.06, EffectiveDate: "2023-03-14", Encoding: "cl100k_base"},
+	{Model: "gpt-4-turbo", InputPer1K: 0.01, OutputPer1K: 0.03, EffectiveDate: "2024-04-09", Encoding: "cl100k_base"},
+	{Model: "gpt-4o", InputPer1K: 0.0025, OutputPer1K: 0.01, EffectiveDate: "2024-10-01", Encoding: "o200k_base"},
+	{Model: "gpt-4o-mini", InputPer1K: 0.00015, OutputPer1K: 0.0006, EffectiveDate: "2024-07-18", Encoding: "o200k_base"},
+	{Model: "gpt-3.5-turbo", InputPer1K: 0.0005, OutputPer1K: 0.0015, EffectiveDate: "2024-01-25", Encoding: "cl100k_base"},
+	{Model: "claude-3-opus", InputPer1K: 0.015, OutputPer1K: 0.075, EffectiveDate: "2024-03-04", Encoding: "cl100k_base"},
+	{Model: "claude-3-sonnet", InputPer1K: 0.003, OutputPer1K: 0.015, EffectiveDate: "2024-03-04", Encoding: "cl100k_base"},
+	{Model: "claude-3-haiku", InputPer1K: 0.00025, OutputPer1K: 0.00125, EffectiveDate: "2024-03-13", Encoding: "cl100k_base"},
+	{Model: "claude-3-5-sonnet", InputPer1K: 0.003, OutputPer1K: 0.015, EffectiveDate: "2024-06-20", Encoding: "cl100k_base"},
+}
+
+// Catalog maps model names to prices. Lookups match the model exactly or by
+// its longest catalog prefix followed by "-", so "gpt-4o-2024-08-06" is
+// priced as gpt-4o and never as gpt-4.
+type Catalog struct {
+	byModel map[string][]Price // newest first
+}
+
+// NewCatalog validates prices and builds a catalog. A later entry for the
+// same model and effective date replaces an earlier one.
+func NewCatalog(prices []Price) (*Catalog, error) {
+	c := &Catalog{byModel: make(map[string][]Price)}
+	for i, p := range prices {
+		p, err := normalizePrice(p)
+		if err != nil {
+			return nil, fmt.Errorf("pricing[%d]: %w", i, err)
+		}
+		entries := c.byModel[p.Model]
+		replaced := false
+		for j := range entries {
+			if entries[j].EffectiveDate == p.EffectiveDate {
+				entries[j], replaced = p, true
+			}
+		}
+		if !replaced {
+			entries = append(entries, p)
+		}
+		c.byModel[p.Model] = entries
+	}
+	for _, entries := range c.byModel {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].EffectiveDate > entries[j].EffectiveDate })
+	}
+	return c, nil
+}
+
+// DefaultCatalog returns the built-in prices.
+func DefaultCatalog() *Catalog {
+	c, err := NewCatalog(defaultPrices)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// WithDefaults returns the built-in prices followed by prices, so configured
+// entries add models and override built-in ones.
+func WithDefaults(prices []Price) (*Catalog, error) {
+	// Validate on their own first so errors index the configured list.
+	if _, err := NewCatalog(prices); err != nil {
+		return nil, err
+	}
+	return NewCatalog(append(append([]Price{}, defaultPrices...), prices...))
+}
+
+func normalizePrice(p Price) (Price, error) {
+	p.Model = strings.ToLower(strings.TrimSpace(p.Model))
+	if p.Model == "" {
+		return p, fmt.Errorf("model is required")
+	}
+	if p.InputPer1K < 0 || p.OutputPer1K < 0 {
+		return p, fmt.Errorf("%s: prices must be >= 0", p.Model)
+	}
+	if p.InputPer1K == 0 && p.OutputPer1K == 0 {
+		return p, fmt.Errorf("%s: set input_per_1k or output_per_1k", p.Model)
+	}
+	p.Currency = strings.ToUpper(strings.TrimSpace(p.Currency))
+	if p.Currency == "" {
+		p.Currency = DefaultCurrency
+	}
+	if len(p.Currency) != 3 {
+		return p, fmt.Errorf("%s: currency %q is not a 3-letter code", p.Model, p.Currency)
+	}
+	p.EffectiveDate = strings.TrimSpace(p.EffectiveDate)
+	if p.EffectiveDate != "" {
+		// Unquoted YAML dates arrive as RFC 3339 timestamps.
+		if ts, err := time.Parse(time.RFC3339, p.EffectiveDate); err == nil {
+			p.EffectiveDate = ts.UTC().Format(effectiveDateLayout)
+		}
+		if _, err := time.Parse(effectiveDateLayout, p.EffectiveDate); err != nil {
+			return p, fmt.Errorf("%s: effective_date %q must be YYYY-MM-DD", p.Model, p.EffectiveDate)
+		}
+	}
+	p.Encoding = strings.TrimSpace(p.Encoding)
+	if p.Encoding != "" && !knownEncodings[p.Encoding] {
+		return p, fmt.Errorf("%s: unknown encoding %q", p.Model, p.Encoding)
+	}
+	return p, nil
+}
+
+var knownEncodings = map[string]bool{
+	tiktoken.MODEL_O200K_BASE:  true,
+	tiktoken.MODEL_CL100K_BASE: true,
+	tiktoken.MODEL_P50K_BASE:   true,
+	tiktoken.MODEL_P50K_EDIT:   true,
+	tiktoken.MODEL_R50K_BASE:   true,
+	ApproximateEncoding:        true,
+}
+
+// Prices returns every entry, sorted by model and newest first.
+func (c *Catalog) Prices() []Price {
+	out := []Price{}
+	for _, entries := range c.byModel {
+		out = append(out, entries...)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Model != out[j].Model {
+			return out[i].Model < out[j].Model
+		}
+		return out[i].EffectiveDate > out[j].EffectiveDate
+	})
+	return out
+}
+
+// Lookup returns the price in effect for model at t.
+func (c *Catalog) Lookup(model string, at time.Time) (Price, bool) {
+	model = strings.ToLower(strings.TrimSpace(model))
+	if model == "" {
+		return Price{}, false
+	}
+	best := longestModelKey(model, c.byModel)
+	if best == "" {
+		return Price{}, false
+	}
+	day := at.UTC().Format(effectiveDateLayout)
+	for _, p := range c.byModel[best] {
+		if p.EffectiveDate <= day {
+			return p, true
+		}
+	}
+	return Price{}, false
+}
+
+// longestModelKey returns the key of m that equals model or is its longest
+// prefix followed by "-", or "".
+func longestModelKey[T any](model string, m map[string]T) string {
+	best := ""
+	for key := range m {
+		if (model == key || strings.HasPrefix(model, key+"-")) && len(key) > len(best) {
+			best = key
+		}
+	}
+	return best
+}
+
+// Cost is a priced usage breakdown. Known is false when the model has no
+// catalog entry, in which case every cost is zero rather than a guess.
+type Cost struct {
+	Model         string  `json:"model"`
+	Known         bool    `json:"known"`
+	PricedAs      string  `json:"priced_as,omitempty"`
+	Currency      string  `json:"currency,omitempty"`
+	EffectiveDate string  `json:"effective_date,omitempty"`
+	InputTokens   int     `json:"input_tokens"`
+	OutputTokens  int     `json:"output_tokens"`
+	InputCost     float64 `json:"input_cost"`
+	OutputCost    float64 `json:"output_cost"`
+	Total         float64 `json:"total"`
+}
+
+func (c Cost) Tokens() int {
+	return c.InputTokens + c.OutputTokens
+}
+
+// Cost prices input and output tokens for model at t.
+func (c *Catalog) Cost(model string, inputTokens, outputTokens int, at time.Time) Cost {
+	out := Cost{Model: model, InputTokens: inputTokens, OutputTokens: outputTokens}
+	p, ok := c.Lookup(model, at)
+	if !ok {
+		return out
+	}
+	out.Known = true
+	out.PricedAs = p.Model
+	out.Currency = p.Currency
+	out.EffectiveDate = p.EffectiveDate
+	out.InputCost = float64(inputTokens) / 1000.0 * p.InputPer1K
+	out.OutputCost = float64(outputTokens) / 1000.0 * p.OutputPer1K
+	out.Total = out.InputCost + out.OutputCost
+	return out
+}
+
+var (
+	catalogMu sync.RWMutex
+	catalog   = DefaultCatalog()
+)
+
+// SetCatalog replaces the catalog used by EstimateCost, PriceUsage and
+// ForModel.
+func SetCatalog(c *Catalog) {
+	if c == nil {
+		c = DefaultCatalog()
+	}
+	catalogMu.Lock()
+	catalog = c
+	catalogMu.Unlock()
+	resetModelTokenizers()
+}
+
+// ActiveCatalog returns the catalog in use.
+func ActiveCatalog() *Catalog {
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+	return catalog
+}
+
+// PriceUsage prices usage for model with the active catalog at the current
+// date.
+func PriceUsage(model string, inputTokens, outputTokens int) Cost {
+	return ActiveCatalog().Cost(model, inputTokens, outputTokens, time.Now())
+}
@@ -0,0 +1,71 @@
+package tokens
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCatalogLookupMatchesLongestPrefix(t *testing.T) {
+	c := DefaultCatalog()
+	at := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := map[string]string{
+		"gpt-4o":                 "gpt-4o",
+		"GPT-4o-2024-08-06":      "gpt-4o",
+		"gpt-4o-mini-2024-07-18": "gpt-4o-mini",
+		"gpt-4-0613":             "gpt-4",
+		"gpt-4-turbo-preview":    "gpt-4-turbo",
+	}
+	for model, want := range cases {
+		p, ok := c.Lookup(model, at)
+		if !ok || p.Model != want {
+			t.Fatalf("Lookup(%q) = %q ok=%v, want %q", model, p.Model, ok, want)
+		}
+	}
+	if _, ok := c.Lookup("gpt-4omni", at); ok {
+		t.Fatal("expected a prefix without a dash separator not to match")
+	}
+}
+
+func TestCatalogPicksPriceInEffect(t *testing.T) {
+	c, err := WithDefaults([]Price{{Model: "gpt-4o", InputPer1K: 0.002, OutputPer1K: 0.008, EffectiveDate: "2026-01-01T00:00:00Z"}})
+	if err != nil {
+		t.Fatalf("WithDefaults: %v", err)
+	}
+	before := c.Cost("gpt-4o", 1000, 1000, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC))
+	if before.EffectiveDate != "2024-10-01" || before.Total != 0.0125 {
+		t.Fatalf("expected the built-in price before the override, got %+v", before)
+	}
+	after := c.Cost("gpt-4o", 1000, 1000, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC))
+	if after.EffectiveDate != "2026-01-01" || after.InputCost != 0.002 || after.OutputCost != 0.008 {
+		t.Fatalf("expected the override once effective, got %+v", after)
+	}
+	if _, ok := c.Lookup("claude-3-opus", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)); ok {
+		t.Fatal("expected no price before the first effective date")
+	}
+}
+
+func TestCatalogUnknownModelIsNotPriced(t *testing.T) {
+	cost := DefaultCatalog().Cost("my-local-llm", 500, 500, time.Now())
+	if cost.Known || cost.Total != 0 || cost.Tokens() != 1000 {
+		t.Fatalf("expected an unpriced breakdown with token counts, got %+v", cost)
+	}
+}
+
+func TestNewCatalogRejectsInvalidEntries(t *testing.T) {
+	cases := map[string]Price{
+		"model is required":  {InputPer1K: 1},
+		"must be >= 0":       {Model: "m", InputPer1K: -1},
+		"set input_per_1k":   {Model: "m"},
+		"3-letter code":      {Model: "m", InputPer1K: 1, Currency: "euro"},
+		"must be YYYY-MM-DD": {Model: "m", InputPer1K: 1, EffectiveDate: "01/02/2026"},
+		"unknown encoding":   {Model: "m", InputPer1K: 1, Encoding: "gpt2"},
+	}
+	for want, p := range cases {
+		_, err := WithDefaults([]Price{{Model: "ok", OutputPer1K: 1}, p})
+		if err == nil || !strings.Contains(err.Error(), want) || !strings.HasPrefix(err.Error(), "pricing[1]") {
+			t.Fatalf("expected %q error at pricing[1], got %v", want, err)
+		}
+	}
+}
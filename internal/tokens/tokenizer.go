@@ -0,0 +1,153 @@
+package tokens
+
+import (
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkoukk/tiktoken-go"
+)
+
+// ApproximateEncoding names the approximate counter. Catalog entries may use
+// it as their encoding for models without a tiktoken vocabulary.
+const ApproximateEncoding = "approximate"
+
+// defaultEncoding counts models that neither the catalog nor tiktoken know.
+const defaultEncoding = tiktoken.MODEL_CL100K_BASE
+
+// Tokenizer counts the tokens of a piece of text. Implementations must be
+// safe for concurrent use.
+type Tokenizer interface {
+	Count(text string) int
+	// Encoding names the vocabulary, e.g. "o200k_base" or "approximate".
+	Encoding() string
+}
+
+type tiktokenTokenizer struct {
+	enc      *tiktoken.Tiktoken
+	encoding string
+}
+
+func (t tiktokenTokenizer) Count(text string) int {
+	return len(t.enc.Encode(text, nil, nil))
+}
+
+func (t tiktokenTokenizer) Encoding() string { return t.encoding }
+
+type approximateTokenizer struct{}
+
+// Count assumes about four characters per token, which holds for English
+// prose and code within a few tens of percent.
+func (approximateTokenizer) Count(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+func (approximateTokenizer) Encoding() string { return ApproximateEncoding }
+
+// Approximate returns a tokenizer that estimates counts from text length.
+func Approximate() Tokenizer { return approximateTokenizer{} }
+
+var (
+	tokenizerMu sync.RWMutex
+	// encoders holds one tokenizer per encoding; building a tiktoken BPE is
+	// far more expensive than using it.
+	encoders = map[string]Tokenizer{}
+	// byModel caches ForModel. It is cleared when the catalog or the
+	// registered tokenizers change.
+	byModel    = map[string]Tokenizer{}
+	registered = map[string]Tokenizer{}
+)
+
+// RegisterTokenizer makes t count model and the models it prefixes (the same
+// matching as the pricing catalog). A nil t removes the registration.
+func RegisterTokenizer(model string, t Tokenizer) {
+	model = strings.ToLower(strings.TrimSpace(model))
+	tokenizerMu.Lock()
+	defer tokenizerMu.Unlock()
+	if t == nil {
+		delete(registered, model)
+	} else {
+		registered[model] = t
+	}
+	clear(byModel)
+}
+
+// ForModel returns the tokenizer for model: a registered one, else the
+// encoding of its pricing catalog entry, else tiktoken's encoding for the
+// model name, else cl100k_base. An encoding that cannot be loaded falls back
+// to the approximate counter.
+func ForModel(model string) Tokenizer {
+	key := strings.ToLower(strings.TrimSpace(model))
+	tokenizerMu.RLock()
+	t, ok := byModel[key]
+	tokenizerMu.RUnlock()
+	if ok {
+		return t
+	}
+
+	tokenizerMu.RLock()
+	t = registered[longestModelKey(key, registered)]
+	tokenizerMu.RUnlock()
+	if t == nil {
+		t = ForEncoding(encodingForModel(key))
+	}
+
+	tokenizerMu.Lock()
+	byModel[key] = t
+	tokenizerMu.Unlock()
+	return t
+}
+
+func encodingForModel(model string) string {
+	if p, ok := ActiveCatalog().Lookup(model, time.Now()); ok && p.Encoding != "" {
+		return p.Encoding
+	}
+	if encoding, ok := tiktoken.MODEL_TO_ENCODING[model]; ok {
+		return encoding
+	}
+	best := ""
+	for prefix := range tiktoken.MODEL_PREFIX_TO_ENCODING {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best != "" {
+		return tiktoken.MODEL_PREFIX_TO_ENCODING[best]
+	}
+	return defaultEncoding
+}
+
+// ForEncoding returns the cached tokenizer for a tiktoken encoding name or
+// ApproximateEncoding, loading it on first use.
+func ForEncoding(encoding string) Tokenizer {
+	if encoding == ApproximateEncoding {
+		return Approximate()
+	}
+	tokenizerMu.RLock()
+	t, ok := encoders[encoding]
+	tokenizerMu.RUnlock()
+	if ok {
+		return t
+	}
+
+	tokenizerMu.Lock()
+	defer tokenizerMu.Unlock()
+	if t, ok := encoders[encoding]; ok {
+		return t
+	}
+	// A failed load is cached too, so an offline host does not retry the
+	// vocabulary download on every line.
+	t = Approximate()
+	if enc, err := tiktoken.GetEncoding(encoding); err == nil {
+		t = tiktokenTokenizer{enc: enc, encoding: encoding}
+	}
+	encoders[encoding] = t
+	return t
+}
+
+func resetModelTokenizers() {
+	tokenizerMu.Lock()
+	clear(byModel)
+	tokenizerMu.Unlock()
+}
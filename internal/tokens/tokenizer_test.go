@@ -0,0 +1,55 @@
+package tokens
+
+import "testing"
+
+func TestEncodingForModelIsPerModel(t *testing.T) {
+	defer SetCatalog(nil)
+
+	cases := map[string]string{
+		"gpt-4o-2024-08-06": "o200k_base",  // catalog entry
+		"gpt-4":             "cl100k_base", // catalog entry
+		"text-davinci-003":  "p50k_base",   // tiktoken's model table
+		"my-local-llm":      defaultEncoding,
+	}
+	for model, want := range cases {
+		if got := encodingForModel(model); got != want {
+			t.Fatalf("encodingForModel(%q) = %q, want %q", model, got, want)
+		}
+	}
+
+	c, err := WithDefaults([]Price{{Model: "my-local-llm", OutputPer1K: 0.001, Encoding: ApproximateEncoding}})
+	if err != nil {
+		t.Fatalf("WithDefaults: %v", err)
+	}
+	_ = ForModel("my-local-llm")
+	SetCatalog(c)
+	if got := ForModel("my-local-llm").Encoding(); got != ApproximateEncoding {
+		t.Fatalf("expected a catalog change to reselect the tokenizer, got %q", got)
+	}
+}
+
+type fixedTokenizer int
+
+func (f fixedTokenizer) Count(string) int { return int(f) }
+func (fixedTokenizer) Encoding() string   { return "fixed" }
+
+func TestRegisteredTokenizerWinsByPrefix(t *testing.T) {
+	RegisterTokenizer("local", fixedTokenizer(7))
+	defer RegisterTokenizer("local", nil)
+
+	if got := Count("anything at all", "local-13b"); got != 7 {
+		t.Fatalf("expected the registered tokenizer for a prefixed model, got %d", got)
+	}
+	if got := ForModel("localhost").Encoding(); got == "fixed" {
+		t.Fatal("expected a prefix without a dash separator not to match")
+	}
+}
+
+func TestApproximateCountsRunes(t *testing.T) {
+	cases := map[string]int{"": 0, "abc": 1, "abcd": 1, "abcde": 2, "héllo wörld!": 3}
+	for text, want := range cases {
+		if got := Approximate().Count(text); got != want {
+			t.Fatalf("Approximate().Count(%q) = %d, want %d", text, got, want)
+		}
+	}
+}
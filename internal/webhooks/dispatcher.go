@@ -0,0 +1,235 @@
+package webhooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"flowforge/internal/database"
+)
+
+const (
+	scanBatchSize    = 200
+	deliverBatchSize = 20
+	maxErrorBodySize = 256
+)
+
+// Envelope is the JSON body of every webhook request.
+type Envelope struct {
+	Kind  string                `json:"kind"`
+	Event database.UnifiedEvent `json:"event"`
+}
+
+// Dispatcher queues and sends webhook deliveries. The exported durations may
+// be changed before Start.
+type Dispatcher struct {
+	PollInterval time.Duration
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+
+	cfg    Config
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewDispatcher(cfg Config) *Dispatcher {
+	return &Dispatcher{
+		PollInterval: time.Second,
+		BaseBackoff:  5 * time.Second,
+		MaxBackoff:   10 * time.Minute,
+		cfg:          cfg,
+		client:       &http.Client{Timeout: cfg.Timeout},
+		now:          time.Now,
+	}
+}
+
+// Start runs the dispatcher in the background until the returned stop
+// function is called. An attempt in flight when stop is called finishes
+// first.
+func (d *Dispatcher) Start() func() {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(d.PollInterval)
+		defer ticker.Stop()
+		for {
+			if err := d.RunOnce(); err != nil {
+				log.Printf("[webhooks] %v", err)
+			}
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+		wg.Wait()
+	}
+}
+
+// RunOnce queues deliveries for events written since the last scan and
+// attempts every delivery that is due.
+func (d *Dispatcher) RunOnce() error {
+	if err := d.scan(); err != nil {
+		return fmt.Errorf("scan events: %w", err)
+	}
+	if err := d.deliverDue(); err != nil {
+		return fmt.Errorf("deliver: %w", err)
+	}
+	return nil
+}
+
+// scan moves the cursor over new events. The first scan on a database only
+// places the cursor at the newest event, so enabling webhooks does not
+// replay history.
+func (d *Dispatcher) scan() error {
+	cursor, ok, err := database.GetWebhookCursor()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		latest, err := database.MaxEventRowID()
+		if err != nil {
+			return err
+		}
+		return database.AdvanceWebhookCursor(latest)
+	}
+	for {
+		events, err := database.GetUnifiedEventsAfter(cursor, scanBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+		for _, e := range events {
+			if err := d.enqueue(e); err != nil {
+				return err
+			}
+			cursor = int64(e.ID)
+		}
+		if err := database.AdvanceWebhookCursor(cursor); err != nil {
+			return err
+		}
+		if len(events) < scanBatchSize {
+			return nil
+		}
+	}
+}
+
+func (d *Dispatcher) enqueue(e database.UnifiedEvent) error {
+	kind, ok := Classify(e)
+	if !ok || !d.cfg.Kinds[kind] {
+		return nil
+	}
+	body, err := json.Marshal(Envelope{Kind: kind, Event: e})
+	if err != nil {
+		return err
+	}
+	for _, target := range d.cfg.URLs {
+		if _, err := database.EnqueueWebhookDelivery(database.WebhookDelivery{
+			EventID:    e.EventID,
+			EventRowID: int64(e.ID),
+			Kind:       kind,
+			URL:        target,
+			Body:       string(body),
+		}, d.now()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Dispatcher) deliverDue() error {
+	// The lease outlasts one attempt, so a slow receiver is not sent the
+	// same delivery twice.
+	lease := d.cfg.Timeout + 30*time.Second
+	for {
+		due, err := database.ClaimDueWebhookDeliveries(d.now(), lease, deliverBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, delivery := range due {
+			if err := d.attempt(delivery); err != nil {
+				return err
+			}
+		}
+		if len(due) < deliverBatchSize {
+			return nil
+		}
+	}
+}
+
+func (d *Dispatcher) attempt(delivery database.WebhookDelivery) error {
+	statusCode, sendErr := d.send(delivery)
+	now := d.now()
+	if sendErr == nil {
+		return database.MarkWebhookDelivered(delivery.ID, statusCode, now)
+	}
+	attempts := delivery.Attempts + 1
+	giveUp := attempts >= d.cfg.MaxAttempts
+	return database.MarkWebhookAttemptFailed(delivery.ID, statusCode, sendErr.Error(), now.Add(d.backoff(attempts)), giveUp)
+}
+
+func (d *Dispatcher) send(delivery database.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Body)
+	req, err := http.NewRequest(http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(d.now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "FlowForge-Webhooks/1")
+	req.Header.Set(HeaderEvent, delivery.Kind)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(d.cfg.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		// url.Error quotes the full URL, and the error is stored and served.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = fmt.Errorf("%s: %w", strings.ToLower(urlErr.Op), urlErr.Err)
+		}
+		return 0, err
+	}
+	defer resp.Body.Close()
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg := fmt.Sprintf("receiver answered %d", resp.StatusCode)
+		if text := strings.TrimSpace(string(snippet)); text != "" {
+			msg += ": " + text
+		}
+		return resp.StatusCode, errors.New(msg)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff is the wait after the given number of failed attempts: the base
+// doubled per earlier failure, capped at MaxBackoff.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	wait := d.BaseBackoff
+	for i := 1; i < attempts && wait < d.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > d.MaxBackoff {
+		wait = d.MaxBackoff
+	}
+	return wait
+}
@@ -0,0 +1,160 @@
+// Package webhooks delivers selected timeline events to HTTP endpoints.
+//
+// A dispatcher follows the events table by id, queues a delivery in the
+// SQLite outbox for every matching event and URL, and POSTs each one as
+// signed JSON until the receiver answers 2xx or attempts run out. Because
+// the outbox and its cursor live in the database, events written by any
+// FlowForge process are delivered, and nothing is lost across restarts.
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"flowforge/internal/database"
+)
+
+// Event kinds a webhook can subscribe to.
+const (
+	KindIncident       = "incident"
+	KindDecision       = "decision"
+	KindLifecycle      = "lifecycle"
+	KindSignalBaseline = "signal_baseline"
+)
+
+// AllKinds is the default subscription.
+var AllKinds = []string{KindIncident, KindDecision, KindLifecycle, KindSignalBaseline}
+
+// Request headers. The signature is "sha256=" followed by the hex HMAC-SHA256
+// of "<timestamp>.<body>" keyed with the shared secret.
+const (
+	HeaderSignature = "X-FlowForge-Signature"
+	HeaderTimestamp = "X-FlowForge-Timestamp"
+	HeaderEvent     = "X-FlowForge-Event"
+	HeaderDelivery  = "X-FlowForge-Delivery"
+)
+
+type Config struct {
+	URLs        []string
+	Secret      []byte
+	Kinds       map[string]bool
+	MaxAttempts int
+	Timeout     time.Duration
+}
+
+// Enabled reports whether any webhook URL is configured.
+func (c Config) Enabled() bool {
+	return len(c.URLs) > 0
+}
+
+// LoadFromEnv reads the webhook configuration. No FLOWFORGE_WEBHOOK_URLS
+// means webhooks are off; URLs without a secret are an error, since
+// receivers could not tell our requests from anyone else's.
+func LoadFromEnv() (Config, error) {
+	cfg := Config{
+		Kinds:       map[string]bool{},
+		MaxAttempts: envInt("FLOWFORGE_WEBHOOK_MAX_ATTEMPTS", 8),
+		Timeout:     time.Duration(envInt("FLOWFORGE_WEBHOOK_TIMEOUT_MS", 5000)) * time.Millisecond,
+	}
+	for _, raw := range splitList(os.Getenv("FLOWFORGE_WEBHOOK_URLS")) {
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return Config{}, fmt.Errorf("FLOWFORGE_WEBHOOK_URLS: %q is not an http(s) URL", raw)
+		}
+		cfg.URLs = append(cfg.URLs, raw)
+	}
+	if !cfg.Enabled() {
+		return cfg, nil
+	}
+	secret := strings.TrimSpace(os.Getenv("FLOWFORGE_WEBHOOK_SECRET"))
+	if secret == "" {
+		return Config{}, fmt.Errorf("FLOWFORGE_WEBHOOK_SECRET is required when FLOWFORGE_WEBHOOK_URLS is set")
+	}
+	cfg.Secret = []byte(secret)
+
+	kinds := splitList(os.Getenv("FLOWFORGE_WEBHOOK_EVENTS"))
+	if len(kinds) == 0 {
+		kinds = AllKinds
+	}
+	for _, kind := range kinds {
+		switch kind {
+		case KindIncident, KindDecision, KindLifecycle, KindSignalBaseline:
+			cfg.Kinds[kind] = true
+		default:
+			return Config{}, fmt.Errorf("FLOWFORGE_WEBHOOK_EVENTS: unknown event %q (want %s)", kind, strings.Join(AllKinds, ", "))
+		}
+	}
+	if cfg.MaxAttempts < 1 {
+		cfg.MaxAttempts = 1
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	return cfg, nil
+}
+
+// Classify returns the webhook kind of a timeline event. Decisions to let a
+// process continue, and audit events other than signal-baseline
+// transitions, have no kind.
+func Classify(e database.UnifiedEvent) (string, bool) {
+	switch e.EventType {
+	case "incident":
+		return KindIncident, true
+	case "decision":
+		if strings.EqualFold(strings.TrimSpace(e.Title), "CONTINUE") {
+			return "", false
+		}
+		return KindDecision, true
+	case "lifecycle":
+		return KindLifecycle, true
+	case "audit":
+		if strings.HasPrefix(e.Title, "SIGNAL_BASELINE_") {
+			return KindSignalBaseline, true
+		}
+	}
+	return "", false
+}
+
+// Sign returns the X-FlowForge-Signature value for body sent at timestamp
+// (Unix seconds, as in X-FlowForge-Timestamp).
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write([]byte(timestamp))
+	_, _ = mac.Write([]byte("."))
+	_, _ = mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature made by Sign in constant time.
+func Verify(secret []byte, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+func splitList(raw string) []string {
+	out := []string{}
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+func envInt(key string, fallback int) int {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return fallback
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return fallback
+	}
+	return v
+}
@@ -0,0 +1,224 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"flowforge/internal/database"
+)
+
+func setupWebhookDB(t *testing.T) {
+	t.Helper()
+	t.Setenv("FLOWFORGE_MASTER_KEY", "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef")
+	t.Setenv("FLOWFORGE_DB_PATH", filepath.Join(t.TempDir(), "flowforge-webhooks-test.db"))
+	database.CloseDB()
+	if err := database.InitDB(); err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+	t.Cleanup(database.CloseDB)
+}
+
+type receivedHook struct {
+	kind     string
+	delivery string
+	envelope Envelope
+}
+
+func TestDispatcherDeliversSignedEventsWithRetries(t *testing.T) {
+	setupWebhookDB(t)
+	secret := []byte("hook-secret")
+
+	var mu sync.Mutex
+	var received []receivedHook
+	calls := 0
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !Verify(secret, r.Header.Get(HeaderTimestamp), body, r.Header.Get(HeaderSignature)) {
+			http.Error(w, "bad signature", http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls == 1 {
+			http.Error(w, "warming up", http.StatusServiceUnavailable)
+			return
+		}
+		var env Envelope
+		if err := json.Unmarshal(body, &env); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		received = append(received, receivedHook{kind: r.Header.Get(HeaderEvent), delivery: r.Header.Get(HeaderDelivery), envelope: env})
+	}))
+	defer receiver.Close()
+
+	cfg := Config{
+		URLs:        []string{receiver.URL + "/hook"},
+		Secret:      secret,
+		Kinds:       map[string]bool{KindIncident: true, KindDecision: true, KindLifecycle: true, KindSignalBaseline: true},
+		MaxAttempts: 3,
+		Timeout:     2 * time.Second,
+	}
+	d := NewDispatcher(cfg)
+	d.BaseBackoff = time.Millisecond
+	d.MaxBackoff = 5 * time.Millisecond
+
+	if _, err := database.InsertEvent("incident", "system", "before webhooks", "run-old", "inc-old", "LOOP_DETECTED", "", 0, 0, 0, 0); err != nil {
+		t.Fatalf("InsertEvent: %v", err)
+	}
+	if err := d.RunOnce(); err != nil {
+		t.Fatalf("first RunOnce: %v", err)
+	}
+
+	insert := func(eventType, title string) {
+		t.Helper()
+		if _, err := database.InsertEvent(eventType, "system", "reason", "run-1", "inc-1", title, "summary", 42, 90, 10, 80); err != nil {
+			t.Fatalf("InsertEvent(%s, %s): %v", eventType, title, err)
+		}
+	}
+	insert("incident", "LOOP_DETECTED")
+	insert("decision", "CONTINUE")
+	insert("decision", "KILL")
+	insert("lifecycle", "LIFECYCLE_STOPPED")
+	insert("audit", "SIGNAL_BASELINE_AT_RISK")
+	insert("audit", "PROCESS_KILL")
+
+	for i := 0; i < 4; i++ {
+		if err := d.RunOnce(); err != nil {
+			t.Fatalf("RunOnce: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 4 {
+		t.Fatalf("expected 4 deliveries, got %d: %+v", len(received), received)
+	}
+	wantKinds := map[string]string{KindIncident: "LOOP_DETECTED", KindDecision: "KILL", KindLifecycle: "LIFECYCLE_STOPPED", KindSignalBaseline: "SIGNAL_BASELINE_AT_RISK"}
+	for _, hook := range received {
+		title, ok := wantKinds[hook.kind]
+		if !ok || hook.envelope.Kind != hook.kind || hook.envelope.Event.Title != title || hook.envelope.Event.RunID != "run-1" {
+			t.Fatalf("unexpected delivery %+v", hook)
+		}
+		if hook.delivery == "" {
+			t.Fatalf("expected %s header on %+v", HeaderDelivery, hook)
+		}
+		delete(wantKinds, hook.kind)
+	}
+
+	delivered, err := database.ListWebhookDeliveries(database.WebhookDeliveryDelivered, 10)
+	if err != nil || len(delivered) != 4 {
+		t.Fatalf("expected 4 delivered rows, got %+v err=%v", delivered, err)
+	}
+	retried := 0
+	for _, row := range delivered {
+		if row.Attempts == 2 {
+			retried++
+		}
+	}
+	if retried != 1 {
+		t.Fatalf("expected exactly one delivery to need a retry, got %+v", delivered)
+	}
+}
+
+func TestDispatcherGivesUpAfterMaxAttempts(t *testing.T) {
+	setupWebhookDB(t)
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down for maintenance", http.StatusInternalServerError)
+	}))
+	defer broken.Close()
+
+	d := NewDispatcher(Config{
+		URLs:        []string{broken.URL},
+		Secret:      []byte("hook-secret"),
+		Kinds:       map[string]bool{KindIncident: true},
+		MaxAttempts: 2,
+		Timeout:     2 * time.Second,
+	})
+	d.BaseBackoff = time.Millisecond
+	d.MaxBackoff = time.Millisecond
+	if err := d.RunOnce(); err != nil {
+		t.Fatalf("first RunOnce: %v", err)
+	}
+	if _, err := database.InsertEvent("incident", "system", "reason", "run-1", "inc-1", "LOOP_DETECTED", "", 0, 0, 0, 0); err != nil {
+		t.Fatalf("InsertEvent: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		if err := d.RunOnce(); err != nil {
+			t.Fatalf("RunOnce: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	failed, err := database.ListWebhookDeliveries(database.WebhookDeliveryFailed, 10)
+	if err != nil || len(failed) != 1 {
+		t.Fatalf("expected one failed delivery, got %+v err=%v", failed, err)
+	}
+	if failed[0].Attempts != 2 || failed[0].LastStatusCode != http.StatusInternalServerError || failed[0].LastError != "receiver answered 500: down for maintenance" {
+		t.Fatalf("unexpected failed delivery %+v", failed[0])
+	}
+}
+
+func TestDispatcherErrorsDoNotRecordTheURL(t *testing.T) {
+	setupWebhookDB(t)
+	closed := httptest.NewServer(http.NotFoundHandler())
+	target := closed.URL + "/services/T000/B000/secret-path"
+	closed.Close()
+
+	d := NewDispatcher(Config{
+		URLs:        []string{target},
+		Secret:      []byte("hook-secret"),
+		Kinds:       map[string]bool{KindIncident: true},
+		MaxAttempts: 1,
+		Timeout:     2 * time.Second,
+	})
+	if err := d.RunOnce(); err != nil {
+		t.Fatalf("first RunOnce: %v", err)
+	}
+	if _, err := database.InsertEvent("incident", "system", "reason", "run-1", "inc-1", "LOOP_DETECTED", "", 0, 0, 0, 0); err != nil {
+		t.Fatalf("InsertEvent: %v", err)
+	}
+	if err := d.RunOnce(); err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+
+	failed, err := database.ListWebhookDeliveries(database.WebhookDeliveryFailed, 10)
+	if err != nil || len(failed) != 1 {
+		t.Fatalf("expected one failed delivery, got %+v err=%v", failed, err)
+	}
+	if failed[0].LastError == "" || strings.Contains(failed[0].LastError, "secret-path") {
+		t.Fatalf("expected a connection error without the URL, got %q", failed[0].LastError)
+	}
+}
+
+func TestLoadFromEnvRequiresSecret(t *testing.T) {
+	t.Setenv("FLOWFORGE_WEBHOOK_URLS", "https://hooks.example.com/flowforge")
+	t.Setenv("FLOWFORGE_WEBHOOK_SECRET", "")
+	if _, err := LoadFromEnv(); err == nil {
+		t.Fatal("expected webhook URLs without a secret to be rejected")
+	}
+
+	t.Setenv("FLOWFORGE_WEBHOOK_SECRET", "s3cret")
+	t.Setenv("FLOWFORGE_WEBHOOK_EVENTS", "incident, lifecycle")
+	cfg, err := LoadFromEnv()
+	if err != nil {
+		t.Fatalf("LoadFromEnv: %v", err)
+	}
+	if !cfg.Enabled() || !cfg.Kinds[KindIncident] || !cfg.Kinds[KindLifecycle] || cfg.Kinds[KindDecision] || cfg.MaxAttempts != 8 {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+
+	t.Setenv("FLOWFORGE_WEBHOOK_EVENTS", "incidents")
+	if _, err := LoadFromEnv(); err == nil {
+		t.Fatal("expected an unknown event kind to be rejected")
+	}
+}
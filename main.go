@@ -4,9 +4,14 @@ import (
 	"log"
 
 	"flowforge/cmd"
+	"flowforge/internal/supervisor"
 )
 
 func main() {
+	// Must run before anything else: supervised commands with rlimits are
+	// launched through a re-exec of this binary.
+	supervisor.RunExecShimIfRequested()
+
 	// keep main tiny; cmd.Execute implements CLI and server bootstrap
 	if err := cmd.Execute(); err != nil {
 		log.Fatalf("flowforge: %v", err)
@@ -0,0 +1,41 @@
+package test
+
+import (
+	"flowforge/cmd"
+	"flowforge/internal/tokens"
+	"strings"
+	"testing"
+)
+
+// BenchmarkObserverThroughput feeds a chatty process's output through the
+// log observer, token counting included, and reports lines and tokens per
+// second for each tokenizer.
+func BenchmarkObserverThroughput(b *testing.B) {
+	fixture := loadFixture(b, "healthy.txt")
+	chunk := []byte(strings.Join(fixture.lines, "\n") + "\n")
+
+	tokens.RegisterTokenizer("bench-approximate", tokens.Approximate())
+	b.Cleanup(func() { tokens.RegisterTokenizer("bench-approximate", nil) })
+
+	for _, model := range []string{"gpt-4o", "gpt-3.5-turbo", "bench-approximate"} {
+		b.Run(model, func(b *testing.B) {
+			observer := cmd.NewLogObserver(20, model)
+			_, _ = observer.Write(chunk) // load the encoder outside the timer
+			start := observer.TotalTokens()
+
+			b.SetBytes(int64(len(chunk)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, _ = observer.Write(chunk)
+			}
+			b.StopTimer()
+
+			seconds := b.Elapsed().Seconds()
+			if seconds > 0 {
+				b.ReportMetric(float64(observer.TotalTokens()-start)/seconds, "tokens/s")
+				b.ReportMetric(float64(b.N*len(fixture.lines))/seconds, "lines/s")
+			}
+		})
+	}
+}